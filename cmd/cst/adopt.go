@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/hook"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+	"github.com/imyousuf/claude-session-tracker/internal/transcript"
+)
+
+// --- Adopt Command ---
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt",
+	Short: "Add sessions from before cst was installed, read from Claude Code's transcripts",
+	Long: `Scan the transcripts under ~/.claude/projects for sessions cst has never
+seen, such as those from before its hooks were installed, and add them as
+the hooks would have: project, model, branch and ticket tags, start and
+last activity, prompts and the files they changed. Adopted sessions can be
+listed, searched and resumed like any other.
+
+Transcripts are read in parallel. Subagent transcripts, sessions without
+prompts, ignored projects and prompts matching ignore_prompt_patterns are
+skipped. Running it again only adds sessions that are still unknown.`,
+	Example: `  cst adopt --dry-run
+  cst adopt`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			return err
+		}
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		found, err := transcript.All(transcript.DefaultDir())
+		if err != nil {
+			return err
+		}
+		var unknown []string
+		for _, id := range slices.Sorted(maps.Keys(found)) {
+			exists, err := s.SessionExists(id)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				unknown = append(unknown, found[id])
+			}
+		}
+
+		adopted, skipped := 0, 0
+		for r := range readTranscripts(unknown) {
+			if r.err != nil {
+				slog.Warn("reading transcript failed", "transcript", r.path, "err", r.err)
+				fmt.Fprintf(os.Stderr, "Skipped %s: %v\n", r.path, r.err)
+				skipped++
+				continue
+			}
+			if !hook.Adoptable(cfg, r.sess) {
+				skipped++
+				continue
+			}
+			if !flagDryRun {
+				ok, err := hook.Adopt(s, cfg, r.sess, r.path)
+				if err != nil {
+					return fmt.Errorf("adopt %s: %w", textutil.ShortID(r.sess.ID), err)
+				}
+				if !ok {
+					continue // started since the scan
+				}
+			}
+			adopted++
+			fmt.Printf("%s  %s  %s  (prompts: %d)\n", textutil.ShortID(r.sess.ID),
+				r.sess.Start.Local().Format("2006-01-02 15:04"), r.sess.CWD, len(r.sess.Prompts))
+		}
+
+		verb := "Adopted"
+		if flagDryRun {
+			verb = "Would adopt"
+		}
+		fmt.Printf("%s %d of %d unknown sessions (%d skipped: subagents, no prompts, ignored or unreadable).\n",
+			verb, adopted, len(unknown), skipped)
+		return nil
+	},
+}
+
+// readTranscript is a transcript read by readTranscripts.
+type readTranscript struct {
+	path string
+	sess transcript.Session
+	err  error
+}
+
+// readTranscripts reads the transcripts at paths on one goroutine per CPU,
+// sending them in no particular order. Only the reading is parallel: the
+// results are written to the store one at a time by the receiver.
+func readTranscripts(paths []string) <-chan readTranscript {
+	jobs := make(chan string)
+	results := make(chan readTranscript)
+	var wg sync.WaitGroup
+	for range min(runtime.NumCPU(), max(len(paths), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				sess, err := transcript.ReadSession(path)
+				results <- readTranscript{path: path, sess: sess, err: err}
+			}
+		}()
+	}
+	go func() {
+		for _, path := range paths {
+			jobs <- path
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/bundle"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+	"github.com/imyousuf/claude-session-tracker/internal/transcript"
+)
+
+// --- Bundle Command ---
+
+var (
+	flagBundleOutput string
+	flagBundleNote   string
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle <session-id>",
+	Short: "Export a session with its prompts and transcript to a shareable archive",
+	Long: `Write a single .tar.gz archive holding the session record, its prompt history,
+working directory trail, an optional note, and a copy of the Claude transcript.
+A colleague can register it with ` + "`cst bundle import`" + `. The session ID may be a unique prefix.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		sess, err := lookupSession(s, args[0])
+		if err != nil {
+			return err
+		}
+		prompts, err := s.GetPrompts(sess.ID, store.DefaultMaxPrompt)
+		if err != nil {
+			return err
+		}
+		cwds, err := s.GetCWDHistory(sess.ID)
+		if err != nil {
+			return err
+		}
+
+		m := bundle.Manifest{
+			CreatedAt: time.Now().UnixMilli(),
+			CreatedBy: "cst " + Version,
+			Session: bundle.Session{
+				ID:           sess.ID,
+				Project:      sess.Project,
+				CWD:          sess.CWD,
+				Model:        sess.Model,
+				StartedAt:    sess.StartedAt,
+				LastActivity: sess.LastActivity,
+				PromptCount:  sess.PromptCount,
+				FirstPrompt:  sess.FirstPrompt,
+				Title:        sess.Title,
+			},
+			Notes: sess.Notes,
+		}
+		if flagBundleNote != "" {
+			m.Notes = flagBundleNote
+		}
+		// Prompts are stored newest first; bundles keep chronological order
+		for i := len(prompts) - 1; i >= 0; i-- {
+			m.Prompts = append(m.Prompts, bundle.Prompt{Text: prompts[i].Text, Timestamp: prompts[i].Timestamp,
+				Occurrences: prompts[i].Occurrences, Agent: prompts[i].Agent})
+		}
+		for _, c := range cwds {
+			m.CWDHistory = append(m.CWDHistory, bundle.CWDEntry{CWD: c.CWD, Timestamp: c.Timestamp})
+		}
+
+		var tr io.Reader
+		var trSize int64
+		if path := sessionTranscript(sess); path != "" {
+			f, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("open transcript: %w", err)
+			}
+			defer func() { _ = f.Close() }()
+			info, err := f.Stat()
+			if err != nil {
+				return fmt.Errorf("stat transcript: %w", err)
+			}
+			tr, trSize = f, info.Size()
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: no transcript found for session %s; bundling metadata only\n", textutil.ShortID(sess.ID))
+		}
+
+		out := flagBundleOutput
+		if out == "" {
+			out = fmt.Sprintf("cst-%s.tar.gz", textutil.ShortID(sess.ID))
+		}
+		if _, err := os.Stat(out); err == nil {
+			return fmt.Errorf("%s already exists", out)
+		}
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		if err := bundle.Write(f, m, tr, trSize); err != nil {
+			_ = f.Close()
+			_ = os.Remove(out)
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		fmt.Printf("Wrote %s (%d prompts, transcript: %v).\n", out, len(m.Prompts), tr != nil)
+		return nil
+	},
+}
+
+var bundleImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Register a session bundle as a read-only session",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		// Refuse duplicates before touching an existing session's transcript copy
+		checkNew := func(id string) error {
+			if existing, err := s.GetSession(id); err == nil && existing.ID == id {
+				return fmt.Errorf("session %s is already tracked", textutil.ShortID(id))
+			}
+			return nil
+		}
+
+		var transcriptPath string
+		m, err := bundle.Read(f, func(m bundle.Manifest, r io.Reader) error {
+			if err := checkNew(m.Session.ID); err != nil {
+				return err
+			}
+			dir := filepath.Join(filepath.Dir(dbPath()), "bundles")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			transcriptPath = filepath.Join(dir, filepath.Base(m.Session.ID)+".jsonl")
+			out, err := os.Create(transcriptPath)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, r); err != nil {
+				_ = out.Close()
+				return err
+			}
+			return out.Close()
+		})
+		if err != nil {
+			if transcriptPath != "" {
+				_ = os.Remove(transcriptPath)
+			}
+			return err
+		}
+		if err := checkNew(m.Session.ID); err != nil {
+			return err
+		}
+
+		sess := store.Session{
+			ID:             m.Session.ID,
+			Project:        m.Session.Project,
+			CWD:            m.Session.CWD,
+			StartedAt:      m.Session.StartedAt,
+			LastActivity:   m.Session.LastActivity,
+			Model:          m.Session.Model,
+			Notes:          m.Notes,
+			TranscriptPath: transcriptPath,
+			PromptCount:    m.Session.PromptCount,
+			FirstPrompt:    m.Session.FirstPrompt,
+			Title:          m.Session.Title,
+		}
+		var prompts []store.Prompt
+		for _, p := range m.Prompts {
+			prompts = append(prompts, store.Prompt{Text: p.Text, Timestamp: p.Timestamp, Occurrences: p.Occurrences, Agent: p.Agent})
+		}
+		var cwds []store.CWDEntry
+		for _, c := range m.CWDHistory {
+			cwds = append(cwds, store.CWDEntry{CWD: c.CWD, Timestamp: c.Timestamp})
+		}
+		if err := s.ImportSession(sess, prompts, cwds); err != nil {
+			if transcriptPath != "" {
+				_ = os.Remove(transcriptPath)
+			}
+			return fmt.Errorf("import session %s: %w", textutil.ShortID(sess.ID), err)
+		}
+
+		fmt.Printf("Imported session %s from %s (read-only).\n", textutil.ShortID(sess.ID), m.Session.Project)
+		if transcriptPath != "" {
+			fmt.Printf("Transcript: %s\n", transcriptPath)
+		}
+		return nil
+	},
+}
+
+func init() {
+	bundleCmd.AddCommand(bundleImportCmd)
+	bundleCmd.Flags().StringVarP(&flagBundleOutput, "output", "o", "", "Archive path (default cst-<id>.tar.gz)")
+	bundleCmd.Flags().StringVar(&flagBundleNote, "note", "", "Note for the recipient, stored with the bundle")
+}
+
+// lookupSession resolves a full or prefix session ID with a friendly error.
+func lookupSession(s *store.Store, id string) (store.Session, error) {
+	sess, err := s.GetSession(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sess, fmt.Errorf("no session matches %q", id)
+	}
+	return sess, err
+}
+
+// sessionTranscript returns the transcript path for a session, or "" if none exists.
+func sessionTranscript(sess store.Session) string {
+	if sess.TranscriptPath != "" {
+		if _, err := os.Stat(sess.TranscriptPath); err == nil {
+			return sess.TranscriptPath
+		}
+	}
+	path, err := transcript.Find(transcript.DefaultDir(), sess.ID)
+	if err != nil {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+)
+
+// --- Config Edit Command ---
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the config in a full-screen form",
+	Long: `Open a form with every key cst config set accepts. Booleans and keys with a
+fixed set of values are toggled or cycled, numbers and text are typed, and
+lists are edited item by item, so that items containing commas, such as
+regular expressions, need no escaping. Values are checked as cst config set
+checks them, and every change is saved to ~/.cst/config.json at once.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		m := launcher.NewConfigEditor(cfgPath, cfg)
+		if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+			return fmt.Errorf("run TUI: %w", err)
+		}
+		return nil
+	},
+}
+
+// setConfigValue sets key to value as given to cst config set, where lists
+// are comma-separated, lists of regexes are one pattern or a JSON array, and
+// "" or "[]" clears them.
+func setConfigValue(cfg *config.Config, key, value string) error {
+	k, ok := config.LookupKey(key)
+	if !ok {
+		return fmt.Errorf("unknown config key: %q\nAvailable: %s (see cst config list-keys)", key, strings.Join(configKeys(), ", "))
+	}
+	v, err := k.Parse(value)
+	if err != nil {
+		return err
+	}
+	return cfg.Set(key, v)
+}
+
+// configKeys returns the keys accepted by `cst config set`.
+func configKeys() []string {
+	var names []string
+	for _, k := range config.Keys() {
+		names = append(names, k.Name)
+	}
+	return names
+}
+
+// warnUnknownKeys tells the user about keys in the config file that cst
+// does not know, which saving the config drops.
+func warnUnknownKeys(cfg config.Config) {
+	for _, name := range cfg.UnknownKeys {
+		fmt.Fprintf(os.Stderr, "Warning: unknown config key %q is ignored and dropped when the config is saved\n", name)
+	}
+}
+
+// --- Config List-Keys Command ---
+
+var configListKeysCmd = &cobra.Command{
+	Use:   "list-keys",
+	Short: "List the keys cst config set accepts",
+	Long: `Print every key cst config set and cst config edit accept, with its type,
+its default and what it does. A type of a|b|c means one of those values.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys := config.Keys()
+		types := make([]string, len(keys))
+		nameWidth, typeWidth, defaultWidth := len("KEY"), len("TYPE"), len("DEFAULT")
+		for i, k := range keys {
+			types[i] = string(k.Type)
+			if k.Regexps {
+				types[i] = "regexes"
+			}
+			if len(k.Choices) > 0 {
+				types[i] = strings.Join(k.Choices, "|")
+			}
+			nameWidth = max(nameWidth, len(k.Name))
+			typeWidth = max(typeWidth, len(types[i]))
+			defaultWidth = max(defaultWidth, len(cmp.Or(k.Default, "-")))
+		}
+		fmt.Printf("%-*s  %-*s  %-*s  %s\n", nameWidth, "KEY", typeWidth, "TYPE", defaultWidth, "DEFAULT", "DESCRIPTION")
+		for i, k := range keys {
+			fmt.Printf("%-*s  %-*s  %-*s  %s\n", nameWidth, k.Name, typeWidth, types[i], defaultWidth, cmp.Or(k.Default, "-"), k.Description)
+		}
+		return nil
+	},
+}
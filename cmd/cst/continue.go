@@ -0,0 +1,89 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/procutil"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// --- Continue Command ---
+
+var continueCmd = &cobra.Command{
+	Use:   "continue [-- claude args...]",
+	Short: "Resume the latest session of this project without the picker",
+	Long: `Resume the session of the current project, or of --project, that was active
+most recently, skipping the picker. Running and imported sessions are
+passed over, as are subagent and claude -p runs. The session's own claude
+arguments (see cst set-args) and those from the config apply, and any given
+after -- override them. A session that ended with /clear is resumed with a
+warning, as its conversation was cleared.
+
+If cst has no session to resume for the project, claude --continue runs
+instead and picks up claude's own latest conversation in the directory.`,
+	Example: `  cst continue
+  cst continue -p ~/src/api -- --model opus`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := filepath.Abs(cmp.Or(flagProject, "."))
+		if err != nil {
+			return fmt.Errorf("resolve project: %w", err)
+		}
+		project = store.ResolvePath(project)
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		sess, found, err := latestResumable(s, project)
+		_ = s.Close()
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not load config: %v\n", err)
+		}
+		if !found {
+			fmt.Printf("No session of %s to resume, running claude --continue...\n", project)
+			claudeArgs := config.MergeArgs(cfg.ResumeArgs(project, nil), args)
+			return runClaude(project, project, slices.Concat([]string{"--continue"}, claudeArgs))
+		}
+		if sess.EndReason == store.EndReasonClear {
+			fmt.Fprintf(os.Stderr, "Warning: session %s ended with /clear; claude resumes an empty conversation\n", textutil.ShortID(sess.ID))
+		}
+		return resumeSession(sess.ID, sess.Project, cfg.ResumeDir(sess.Project, sess.CWD), config.MergeArgs(cfg.ResumeArgs(sess.Project, sess.ResumeArgs), args))
+	},
+}
+
+// latestResumable returns the most recently active session of project that
+// can be resumed here: not running and not imported from a bundle.
+func latestResumable(s *store.Store, project string) (store.Session, bool, error) {
+	// Sessions whose claude died without a SessionEnd are not running
+	if err := s.RefreshActive(procutil.IsProcessAlive); err != nil {
+		return store.Session{}, false, err
+	}
+	sessions, err := s.ListSessions(store.ListOptions{Project: project, ExcludeHeadless: true})
+	if err != nil {
+		return store.Session{}, false, err
+	}
+	for _, sess := range sessions {
+		if !sess.Active && !sess.ReadOnly {
+			return sess, true, nil
+		}
+	}
+	return store.Session{}, false, nil
+}
+
+func init() {
+	continueCmd.Flags().StringVarP(&flagProject, "project", "p", "", "Continue the latest session of this project instead of the current directory's")
+	continueCmd.Flags().StringSliceVar(&flagClaudeBin, "claude-bin", nil, "Command that runs claude on resume, e.g. ~/bin/claude or ssh,devbox,claude (overrides claude_bin)")
+}
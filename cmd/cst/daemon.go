@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/hook"
+	"github.com/imyousuf/claude-session-tracker/internal/procutil"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/tailer"
+	"github.com/imyousuf/claude-session-tracker/internal/transcript"
+)
+
+// --- Daemon Command ---
+
+// flagDaemonInterval is its own variable: a flag variable shared between
+// commands starts out at the default of whichever registered it last.
+var flagDaemonInterval time.Duration
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Follow running sessions' transcripts for tokens, tool calls and activity",
+	Long: `Follow the transcripts of running sessions as Claude Code writes them and
+record, in near real time, the tokens used, the tool calls made and the
+session's last activity. Hooks only fire on prompts, tool uses and a few
+other events; the transcript shows a long autonomous run at work between
+them. Tokens and tool calls show in the launcher preview, in cst list
+--json and as query fields (input_tokens, output_tokens, tool_uses).
+
+It is optional and runs in the foreground until interrupted, so start it
+from your shell profile, a tmux window, systemd or launchd. Every --interval
+it picks up sessions that started, lets go of those that ended and rereads
+every transcript in case a change went unnoticed. Sessions are read from
+where the daemon left off, or from the start the first time.
+
+With hook_daemon on (cst config set hook_daemon true), it also takes the
+events of cst hooks over ~/.cst/daemon.sock and records them one at a time
+on its own open database, sparing every prompt and tool call a database
+open and keeping hooks from contending with each other for the database.
+Hooks handle their events themselves while no daemon runs.`,
+	Example: `  cst daemon
+  cst daemon --interval 1m --verbose`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if flagDaemonInterval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		t, err := tailer.New(s, transcript.DefaultDir(), procutil.IsProcessAlive)
+		if err != nil {
+			return fmt.Errorf("watch transcripts: %w", err)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		cfg, _ := config.Load(config.DefaultConfigPath())
+		switch {
+		case cfg.HookDaemon && flagDB != "":
+			fmt.Fprintf(os.Stderr, "Not taking hook events: hooks only forward them to a daemon on the default database.\n")
+		case cfg.HookDaemon:
+			stopHooks, err := serveHooks(cfg)
+			if err != nil {
+				return err
+			}
+			defer stopHooks()
+			fmt.Fprintf(os.Stderr, "Taking hook events on %s.\n", config.DefaultSocketPath())
+		}
+
+		fmt.Fprintf(os.Stderr, "Following the transcripts of running sessions (Ctrl+C to stop).\n")
+		return t.Run(ctx, flagDaemonInterval)
+	},
+}
+
+// serveHooks records the hook events forwarded to ~/.cst/daemon.sock on a
+// store of their own, until the returned function stops it.
+func serveHooks(cfg config.Config) (stop func(), err error) {
+	s, err := openStoreWithConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ln, err := hook.Listen(config.DefaultSocketPath())
+	if err != nil {
+		_ = s.Close()
+		return nil, fmt.Errorf("take hook events: %w", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := hook.Serve(ln, func(req hook.Request) error { return recordRequest(s, req) }); err != nil {
+			slog.Error("hook events no longer taken", "err", err)
+		}
+	}()
+	return func() {
+		_ = ln.Close()
+		<-done
+		_ = s.Close()
+	}, nil
+}
+
+// recordRequest records a hook event forwarded by a hook, as the hook would
+// have itself.
+func recordRequest(s *store.Store, req hook.Request) error {
+	handler := hookHandlers[req.Event]
+	if handler == nil {
+		return fmt.Errorf("unknown event %q", req.Event)
+	}
+	input, err := hook.ReadInput(strings.NewReader(req.Payload))
+	if err != nil {
+		return err
+	}
+	input.PID, input.Terminal = req.PID, req.Terminal
+	// Loaded per event, as by hooks, so that config changes apply at once
+	cfg, _ := config.Load(config.DefaultConfigPath())
+	configureStore(s, cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), hookDeadline)
+	defer cancel()
+	return recordInput(s.WithContext(ctx), req.Event, input, handler, cfg, req.Strict)
+}
+
+func init() {
+	daemonCmd.Flags().DurationVar(&flagDaemonInterval, "interval", 30*time.Second, "How often to look for started and ended sessions")
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+// --- DB Command ---
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Database maintenance commands",
+}
+
+func init() {
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbVersionCmd)
+	dbCmd.AddCommand(dbPathCmd)
+	dbCmd.AddCommand(dbVacuumCmd)
+	dbCmd.AddCommand(dbShrinkCmd)
+	dbCmd.AddCommand(dbBackupCmd)
+	dbCmd.AddCommand(dbRestoreCmd)
+	dbCmd.AddCommand(dbIntegrityCheckCmd)
+
+	dbRestoreCmd.Flags().BoolVarP(&flagYes, "yes", "y", false, "Skip the confirmation prompt")
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the database schema to the latest version",
+	Long: `Apply pending schema migrations. Migrations also run automatically whenever
+cst opens the database; this command makes the upgrade explicit.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := store.Open(dbPath())
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		version, err := s.SchemaVersion()
+		if err != nil {
+			return err
+		}
+		if from := s.MigratedFrom(); from < version {
+			fmt.Printf("Migrated schema from version %d to %d.\n", from, version)
+		} else {
+			fmt.Printf("Schema is up to date (version %d).\n", version)
+		}
+		return nil
+	},
+}
+
+var dbVersionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the database schema version",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := store.Open(dbPath())
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		version, err := s.SchemaVersion()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Schema version: %d (latest supported: %d)\n", version, store.LatestSchemaVersion)
+		return nil
+	},
+}
+
+var dbPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the database path",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(dbPath())
+	},
+}
+
+var dbVacuumCmd = &cobra.Command{
+	Use:   "vacuum",
+	Short: "Compact the database file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := dbPath()
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		before := dbFileSize(path)
+		if err := s.Vacuum(); err != nil {
+			return err
+		}
+		after := dbFileSize(path)
+		fmt.Printf("Vacuumed %s: %s -> %s\n", path, formatBytes(before), formatBytes(after))
+		return nil
+	},
+}
+
+var dbShrinkCmd = &cobra.Command{
+	Use:   "shrink",
+	Short: "Trim the prompt history of ended sessions until the database fits max_db_size_mb",
+	Long: `Bring the database under max_db_size_mb: trim the prompt history of ended
+sessions to the newest 5 prompts, then 1, then none, vacuuming after each
+step, until it fits. Sessions keep their prompt count and first and last
+prompts. A session's end starts this in the background when the database
+is over the cap; cst cleanup runs it too.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			return err
+		}
+		if cfg.MaxDBSizeMB <= 0 {
+			return fmt.Errorf("no cap to shrink to; set one with cst config set max_db_size_mb <MB>")
+		}
+		s, err := openStoreWithConfig(cfg)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		report, err := s.Shrink(int64(cfg.MaxDBSizeMB) << 20)
+		if err != nil {
+			return fmt.Errorf("shrink database: %w", err)
+		}
+		if report.KeptPrompts < 0 {
+			fmt.Printf("Database is under max_db_size_mb (%d MB): %s.\n", cfg.MaxDBSizeMB, formatBytes(report.Before.Total()))
+			return nil
+		}
+		printShrink(report, cfg.MaxDBSizeMB)
+		return nil
+	},
+}
+
+// printShrink reports what Shrink did to bring the database under maxMB.
+func printShrink(report store.ShrinkReport, maxMB int) {
+	fmt.Printf("Database was over max_db_size_mb (%d MB): trimmed inactive sessions to their newest %d prompts, removing %d; %s -> %s.\n",
+		maxMB, report.KeptPrompts, report.PromptsRemoved, formatBytes(report.Before.Total()), formatBytes(report.After.Total()))
+	if report.After.Total() > int64(maxMB)<<20 {
+		fmt.Println("It is still over; remove sessions (cst cleanup --days, cst delete) or raise max_db_size_mb.")
+	}
+}
+
+var dbBackupCmd = &cobra.Command{
+	Use:   "backup <path>",
+	Short: "Write an online backup of the database to path",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		if err := s.Backup(args[0]); err != nil {
+			return fmt.Errorf("backup: %w", err)
+		}
+		fmt.Printf("Backed up database to %s (%s).\n", args[0], formatBytes(dbFileSize(args[0])))
+		return nil
+	},
+}
+
+var dbRestoreCmd = &cobra.Command{
+	Use:   "restore <path>",
+	Short: "Replace the database with a backup",
+	Long: `Replace all tracked sessions with the contents of a backup created by
+` + "`cst db backup`" + `. The backup is migrated to the current schema after restoring.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !flagYes && !confirm(fmt.Sprintf("Replace %s with %s? Current sessions will be lost. (y/N) ", dbPath(), args[0])) {
+			fmt.Println("Aborted.")
+			return nil
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		if err := s.Restore(args[0]); err != nil {
+			return fmt.Errorf("restore: %w", err)
+		}
+		fmt.Printf("Restored database from %s.\n", args[0])
+		return nil
+	},
+}
+
+var dbIntegrityCheckCmd = &cobra.Command{
+	Use:   "integrity-check",
+	Short: "Check the database for corruption",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		problems, err := s.IntegrityCheck()
+		if err != nil {
+			return err
+		}
+		if len(problems) == 0 {
+			fmt.Println("ok")
+			return nil
+		}
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		return fmt.Errorf("integrity check found %d problems", len(problems))
+	},
+}
+
+func dbFileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/procutil"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// --- Delete Command ---
+
+var (
+	flagDeleteOlderThan string
+	flagForce           bool
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete [<session>...]",
+	Short: "Delete sessions and their prompts",
+	Long: `Delete sessions from the database, given by full or prefix ID, or every
+session of --project and/or inactive for longer than --older-than. The
+sessions to delete are listed and confirmed first unless --yes.
+
+Running sessions are refused unless --force: they would be recreated, without
+their history, by their next prompt. Transcripts in ~/.claude/projects are
+left alone; see cst prune-transcripts.`,
+	Example: `  cst delete 3f2a91c0
+  cst delete --project . --older-than 30d --yes`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filtered := flagProject != "" || flagDeleteOlderThan != ""
+		switch {
+		case len(args) > 0 && filtered:
+			return errors.New("give sessions or --project/--older-than, not both")
+		case len(args) == 0 && !filtered:
+			return errors.New("give sessions to delete, or --project/--older-than")
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		// Sessions whose process died without a SessionEnd are not running
+		if err := s.RefreshActive(procutil.IsProcessAlive); err != nil {
+			return err
+		}
+
+		var sessions []store.Session
+		if filtered {
+			if sessions, err = filterSessions(s); err != nil {
+				return err
+			}
+		} else {
+			seen := make(map[string]bool)
+			for _, id := range args {
+				sess, err := lookupSession(s, id)
+				if err != nil {
+					return err
+				}
+				if !seen[sess.ID] {
+					seen[sess.ID] = true
+					sessions = append(sessions, sess)
+				}
+			}
+		}
+
+		var running []store.Session
+		if !flagForce {
+			var rest []store.Session
+			for _, sess := range sessions {
+				if sess.Active {
+					running = append(running, sess)
+				} else {
+					rest = append(rest, sess)
+				}
+			}
+			if len(running) > 0 && !filtered {
+				return fmt.Errorf("session %s is still running; pass --force to delete it anyway", textutil.ShortID(running[0].ID))
+			}
+			sessions = rest
+		}
+
+		if len(sessions) == 0 {
+			fmt.Println("No sessions to delete.")
+		} else {
+			cols, err := launcher.ParseColumns(cleanupColumns)
+			if err != nil {
+				return err
+			}
+			printSessionsTable(sessions, cols)
+		}
+		if len(running) > 0 {
+			fmt.Printf("\nSkipping %d running sessions; pass --force to delete them too.\n", len(running))
+		}
+		if len(sessions) == 0 {
+			return nil
+		}
+
+		if !flagYes && !confirm(fmt.Sprintf("\nDelete %d sessions and their prompts? (y/N) ", len(sessions))) {
+			fmt.Println("Aborted.")
+			return nil
+		}
+		for _, sess := range sessions {
+			if err := s.DeleteSession(sess.ID); err != nil {
+				return fmt.Errorf("delete session %s: %w", textutil.ShortID(sess.ID), err)
+			}
+		}
+		fmt.Printf("Deleted %d sessions.\n", len(sessions))
+		return nil
+	},
+}
+
+// filterSessions returns the sessions selected by --project and --older-than.
+func filterSessions(s *store.Store) ([]store.Session, error) {
+	var opts store.ListOptions
+	if flagProject != "" {
+		project, err := filepath.Abs(flagProject)
+		if err != nil {
+			return nil, err
+		}
+		opts.Project = project
+	}
+	if flagDeleteOlderThan != "" {
+		age, err := parseAge(flagDeleteOlderThan)
+		if err != nil {
+			return nil, err
+		}
+		opts.Until = time.Now().Add(-age).UnixMilli()
+	}
+	return s.ListSessions(opts)
+}
+
+func init() {
+	deleteCmd.Flags().StringVarP(&flagProject, "project", "p", "", "Delete the sessions of this project")
+	deleteCmd.Flags().StringVar(&flagDeleteOlderThan, "older-than", "", "Delete sessions inactive for longer than this (e.g. 30d, 12h)")
+	deleteCmd.Flags().BoolVarP(&flagYes, "yes", "y", false, "Skip the confirmation prompt")
+	deleteCmd.Flags().BoolVar(&flagForce, "force", false, "Also delete sessions that are still running")
+}
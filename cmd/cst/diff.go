@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// --- Diff Command ---
+
+// diffColumnWidth is the width of each session's column in cst diff.
+const diffColumnWidth = 40
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <session> <session>",
+	Short: "Compare two sessions side by side",
+	Long: `Compare two sessions, to pick which of two similar ones to resume: their
+fields side by side, with the ones that differ marked *, their stored prompts,
+oldest first, with those sent in both marked =, and the files their
+transcripts say they changed, split into those both changed and those only
+one did.
+
+In the launcher, press = on one session and = again on another to compare
+them there.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		a, err := lookupSession(s, args[0])
+		if err != nil {
+			return err
+		}
+		b, err := lookupSession(s, args[1])
+		if err != nil {
+			return err
+		}
+		if a.ID == b.ID {
+			return fmt.Errorf("%s and %s are the same session", args[0], args[1])
+		}
+		c, err := launcher.LoadComparison(s, s, a, b)
+		if err != nil {
+			return err
+		}
+		printComparison(c)
+		return nil
+	},
+}
+
+// diffCell cuts s to a column of cst diff and pads it.
+func diffCell(s string) string {
+	s = textutil.Truncate(s, diffColumnWidth-2, "...")
+	return s + strings.Repeat(" ", diffColumnWidth-textutil.Width(s))
+}
+
+// oneLine collapses the whitespace in s, line breaks included, to single
+// spaces.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func printComparison(c launcher.Comparison) {
+	fmt.Printf("  %-12s%s%s\n", "", diffCell(textutil.ShortID(c.A.ID)), textutil.ShortID(c.B.ID))
+	for _, f := range c.Fields() {
+		if f.A == "" && f.B == "" {
+			continue
+		}
+		mark := " "
+		if f.A != f.B {
+			mark = "*"
+		}
+		fmt.Printf("%s %-12s%s%s\n", mark, f.Name, diffCell(oneLine(f.A)), oneLine(f.B))
+	}
+
+	shared := c.SharedPrompts()
+	fmt.Printf("\nPrompts, oldest first (%d sent in both):\n", len(shared))
+	if len(c.PromptsA) == 0 && len(c.PromptsB) == 0 {
+		fmt.Println("  none recorded")
+	}
+	prompt := func(ps []store.Prompt, i int) string {
+		if i >= len(ps) {
+			return ""
+		}
+		if shared[launcher.PromptKey(ps[i])] {
+			return "= " + oneLine(ps[i].Text)
+		}
+		return "  " + oneLine(ps[i].Text)
+	}
+	for i := range max(len(c.PromptsA), len(c.PromptsB)) {
+		fmt.Printf("  %-12s%s%s\n", "", diffCell(prompt(c.PromptsA, i)), prompt(c.PromptsB, i))
+	}
+
+	fmt.Printf("\nFiles changed: %d and %d\n", len(c.FilesA), len(c.FilesB))
+	both := c.SharedFiles()
+	rel := func(path string) string { return strings.TrimPrefix(path, c.A.Project+"/") }
+	for _, f := range c.FilesA {
+		if both[f.Path] {
+			fmt.Printf("  %-12s%s\n", "both", rel(f.Path))
+		}
+	}
+	for _, f := range c.FilesA {
+		if !both[f.Path] {
+			fmt.Printf("  %-12s%s\n", textutil.ShortID(c.A.ID), rel(f.Path))
+		}
+	}
+	for _, f := range c.FilesB {
+		if !both[f.Path] {
+			fmt.Printf("  %-12s%s\n", textutil.ShortID(c.B.ID), rel(f.Path))
+		}
+	}
+}
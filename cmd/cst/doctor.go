@@ -0,0 +1,195 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/hook"
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// --- Doctor Command ---
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Look for problems in the session database",
+	Long: `Check the session database for likely problems and suggest fixes.
+
+Currently reported:
+  duplicates     sessions of the same project whose activity overlaps, on
+                 the same terminal where recorded, which usually means Claude
+                 Code issued a new session ID for the same work (fix: cst merge)
+  hook failures  hook events that failed to be recorded, from
+                 ~/.cst/hook-errors.log (see hook_fail_mode in cst config set)
+  orphans        projects recorded on this machine whose directory is gone,
+                 as after a repository moved or the home directory changed
+                 (fix: cst project move, spelled out when the project is
+                 found under the current home directory)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		groups, err := s.FindDuplicates()
+		if err != nil {
+			return err
+		}
+		failures, err := hook.ReadFailures(config.DefaultHookErrorsPath())
+		if err != nil {
+			return err
+		}
+		sessions, err := s.ListSessions(store.ListOptions{})
+		if err != nil {
+			return err
+		}
+		orphans := orphanedProjects(sessions)
+		if len(groups) == 0 && len(failures) == 0 && len(orphans) == 0 {
+			fmt.Println("No problems found.")
+			return nil
+		}
+		if len(failures) > 0 {
+			printHookFailures(failures)
+			if len(groups) == 0 && len(orphans) == 0 {
+				return nil
+			}
+			fmt.Println()
+		}
+		if len(orphans) > 0 {
+			printOrphans(orphans)
+			if len(groups) == 0 {
+				return nil
+			}
+			fmt.Println()
+		}
+
+		prompt := func(text string) string { return textutil.Truncate(text, 60, "...") }
+		fmt.Printf("%d likely duplicate session group(s):\n", len(groups))
+		for _, g := range groups {
+			fmt.Printf("\n%s\n", g.Keep.Project)
+			fmt.Printf("  keep   %-8s  %-10s  %s\n", textutil.ShortID(g.Keep.ID),
+				launcher.FormatRelativeTime(g.Keep.LastActivity), prompt(g.Keep.LastPrompt))
+			for _, dup := range g.Duplicates {
+				fmt.Printf("  merge  %-8s  %-10s  %s\n", textutil.ShortID(dup.ID),
+					launcher.FormatRelativeTime(dup.LastActivity), prompt(dup.LastPrompt))
+			}
+			for _, dup := range g.Duplicates {
+				fmt.Printf("  cst merge %s %s\n", textutil.ShortID(g.Keep.ID), textutil.ShortID(dup.ID))
+			}
+		}
+		return nil
+	},
+}
+
+// doctorFailures is how many of the latest hook failures cst doctor lists.
+const doctorFailures = 5
+
+// printHookFailures reports the count of failed hook events and the latest
+// of them, given oldest first.
+func printHookFailures(failures []hook.Failure) {
+	first, last := failures[0], failures[len(failures)-1]
+	fmt.Printf("%d failed hook event(s) since %s, the last %s:\n", len(failures),
+		first.Time.Local().Format("2006-01-02 15:04"), launcher.FormatRelativeTime(last.Time.UnixMilli()))
+	for _, f := range slices.Backward(failures[max(len(failures)-doctorFailures, 0):]) {
+		fmt.Printf("  %-10s  %-16s  %s\n", launcher.FormatRelativeTime(f.Time.UnixMilli()), f.Event, f.Err)
+	}
+	fmt.Printf("Details are in %s; remove it to reset the count.\n", config.DefaultHookErrorsPath())
+}
+
+// orphan is a project whose directory is gone.
+type orphan struct {
+	project  string
+	sessions int
+	last     int64 // last activity
+}
+
+// orphanedProjects returns the projects of the sessions recorded on this
+// machine whose directory no longer exists, most recently active first.
+func orphanedProjects(sessions []store.Session) []orphan {
+	var orphans []orphan
+	index := make(map[string]int)
+	for _, sess := range sessions {
+		if !sess.IsLocal() || sess.ReadOnly || sess.Project == "" {
+			continue
+		}
+		i, ok := index[sess.Project]
+		if !ok {
+			if _, err := os.Stat(sess.Project); !errors.Is(err, fs.ErrNotExist) {
+				index[sess.Project] = -1
+				continue
+			}
+			i = len(orphans)
+			index[sess.Project] = i
+			orphans = append(orphans, orphan{project: sess.Project})
+		}
+		if i < 0 {
+			continue
+		}
+		orphans[i].sessions++
+		orphans[i].last = max(orphans[i].last, sess.LastActivity)
+	}
+	slices.SortFunc(orphans, func(a, b orphan) int { return cmp.Compare(b.last, a.last) })
+	return orphans
+}
+
+// movedHome guesses that project is gone because the home directory it was
+// in changed, as on a new machine, and returns the old home directory if
+// the project is found under the current one.
+func movedHome(project string) (string, bool) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false
+	}
+	for _, parent := range []string{"/home/", "/Users/"} {
+		rest, ok := strings.CutPrefix(project, parent)
+		if !ok {
+			continue
+		}
+		user, rest, ok := strings.Cut(rest, "/")
+		if !ok || parent+user == home {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(home, rest)); err == nil && info.IsDir() {
+			return parent + user, true
+		}
+	}
+	return "", false
+}
+
+// printOrphans lists the projects whose directory is gone and the moves
+// that find them again.
+func printOrphans(orphans []orphan) {
+	home, _ := os.UserHomeDir()
+	var moves []string
+	unknown := 0
+	fmt.Printf("%d project(s) whose directory is gone:\n", len(orphans))
+	for _, o := range orphans {
+		fmt.Printf("  %-10s  %3d session(s)  %s\n", launcher.FormatRelativeTime(o.last), o.sessions, o.project)
+		if old, ok := movedHome(o.project); ok {
+			if move := "cst project move " + old + " " + home; !slices.Contains(moves, move) {
+				moves = append(moves, move)
+			}
+		} else {
+			unknown++
+		}
+	}
+	for _, move := range moves {
+		fmt.Printf("  %s\n", move)
+	}
+	if unknown > 0 {
+		fmt.Println("  cst project move <old-path> <new-path> for where the others are now")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// templateFuncs are the functions available to cst list --format
+// go-template, besides text/template's own.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join":     func(sep string, elems []string) string { return strings.Join(elems, sep) },
+	"lower":    strings.ToLower,
+	"upper":    strings.ToUpper,
+	"truncate": func(width int, s string) string { return textutil.Truncate(s, width, "...") },
+	"time": func(ms int64) string {
+		if ms == 0 {
+			return ""
+		}
+		return time.UnixMilli(ms).Format(time.RFC3339)
+	},
+	"ago":      launcher.FormatRelativeTime,
+	"duration": launcher.FormatDuration,
+}
+
+// listTemplate returns the template of cst list --format go-template=<text>
+// or go-template-file=<path>, or nil for other formats.
+func listTemplate(format string) (*template.Template, error) {
+	text, ok := strings.CutPrefix(format, "go-template=")
+	if path, isFile := strings.CutPrefix(format, "go-template-file="); isFile {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("--format: %w", err)
+		}
+		// Each session's output already ends with a line break
+		text, ok = strings.TrimSuffix(string(data), "\n"), true
+	}
+	if !ok {
+		return nil, nil
+	}
+	t, err := template.New("format").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("--format: %w", err)
+	}
+	return t, nil
+}
+
+// templateSession is what the template of cst list --format go-template is
+// executed with for each session: its fields, and its prompts on request.
+type templateSession struct {
+	store.Session
+	store *store.Store
+}
+
+// Prompts returns the session's stored prompts, newest first. They are only
+// read from the store when a template uses them.
+func (ts templateSession) Prompts() ([]store.Prompt, error) {
+	return ts.store.GetPrompts(ts.ID, store.DefaultMaxPrompt)
+}
+
+// printSessionsTemplate prints each session through t, followed by a line
+// break. Prompts of sessions from extra stores are read from those stores.
+func printSessionsTemplate(t *template.Template, s *store.Store, secondaries []store.Secondary, sessions []store.Session) error {
+	stores := map[string]*store.Store{"": s}
+	for _, sec := range secondaries {
+		stores[sec.Name] = sec.Store
+	}
+	var b strings.Builder
+	for _, sess := range sessions {
+		if err := t.Execute(&b, templateSession{sess, stores[sess.Source]}); err != nil {
+			return err
+		}
+		b.WriteByte('\n')
+	}
+	_, err := os.Stdout.WriteString(b.String())
+	return err
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/journal"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+// --- Journal Command ---
+
+var (
+	flagJournalDir   string
+	flagJournalPer   string
+	flagJournalSince string
+	flagJournalFull  bool
+)
+
+var journalCmd = &cobra.Command{
+	Use:   "journal",
+	Short: "Write sessions to Markdown notes, one per day or per session",
+	Long: `Write the sessions active in the last --since to Markdown notes in --dir:
+one note per day, named like Obsidian daily notes (2026-10-15.md), or with
+--per session one per session (2026-10-15 3f2a91c0.md). Each lists the
+sessions' titles, projects, branches, time worked, tags, links and the
+prompts sent, with their times. A session resumed on a later day is on the
+notes of both days.
+
+cst only rewrites the part of a note between its two marker comments, so
+notes of your own around it are kept, and it adds that part to the end of
+a note that has none. It records what it wrote in --dir/.cst-journal.json
+and later runs only rewrite the notes of sessions active since; run it from
+cron or after work. --full rewrites every note. Notes only hold the prompts
+each session keeps, so days whose prompts a session no longer keeps are
+left as they were written.`,
+	Example: `  cst journal --dir ~/notes/claude
+  cst journal --dir ~/notes/claude --per session --since 90d -p .`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !slices.Contains(journal.Modes, flagJournalPer) {
+			return fmt.Errorf("unknown --per %q (want %s)", flagJournalPer, strings.Join(journal.Modes, " or "))
+		}
+		age, err := parseAge(flagJournalSince)
+		if err != nil {
+			return err
+		}
+		project := flagProject
+		if project != "" {
+			if project, err = filepath.Abs(project); err != nil {
+				return err
+			}
+			project = store.ResolvePath(project)
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		since := time.Now().Add(-age)
+		if flagJournalPer == journal.ByDay {
+			// Days are written whole, so the first one is covered from its start
+			since = journal.DayStart(since)
+		}
+		sessions, err := s.ListSessions(store.ListOptions{Project: project, Since: since.UnixMilli(), ExcludeHeadless: true})
+		if err != nil {
+			return err
+		}
+		entries := make([]journal.Entry, len(sessions))
+		for i, sess := range sessions {
+			prompts, err := s.GetPrompts(sess.ID, store.DefaultMaxPrompt)
+			if err != nil {
+				return err
+			}
+			slices.Reverse(prompts) // oldest first
+			entries[i] = journal.Entry{Session: sess, Prompts: prompts}
+		}
+
+		res, err := journal.Write(entries, journal.Options{Dir: flagJournalDir, Mode: flagJournalPer, Since: since, Full: flagJournalFull})
+		if err != nil {
+			return err
+		}
+		for _, path := range res.Written {
+			fmt.Println(path)
+		}
+		fmt.Fprintf(os.Stderr, "Notes written to %s: %d; sessions unchanged: %d.\n", flagJournalDir, len(res.Written), res.Unchanged)
+		return nil
+	},
+}
+
+func init() {
+	journalCmd.Flags().StringVar(&flagJournalDir, "dir", "", "Directory of the notes, e.g. in an Obsidian vault")
+	_ = journalCmd.MarkFlagRequired("dir")
+	journalCmd.Flags().StringVar(&flagJournalPer, "per", journal.ByDay, "One note per "+strings.Join(journal.Modes, " or "))
+	journalCmd.Flags().StringVar(&flagJournalSince, "since", "30d", "Cover sessions active within this long (e.g. 7d, 12h)")
+	journalCmd.Flags().StringVarP(&flagProject, "project", "p", "", "Only journal this project")
+	journalCmd.Flags().BoolVar(&flagJournalFull, "full", false, "Rewrite every note, not only those of sessions active since the last run")
+}
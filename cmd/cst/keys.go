@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+)
+
+// --- Keybindings ---
+
+var configKeysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "List launcher keys, with remapped ones marked",
+	Long: `Manage keybindings: the keys of launcher actions. Keys set for an action
+replace its defaults, and no key may do two things on the same screen.
+
+Actions: ` + strings.Join(launcher.KeyActions, ", ") + `
+Keys are single characters, space, enter, esc, tab, shift+tab, backspace,
+delete, insert, up, down, left, right, home, end, pgup, pgdown, ctrl+<letter>,
+alt+<key> or f1-f20.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			return err
+		}
+		for _, action := range launcher.KeyActions {
+			ks, custom := cfg.Keybindings[action]
+			if !custom {
+				ks = launcher.DefaultKeys[action]
+			}
+			line := fmt.Sprintf("%-13s %s", action, strings.Join(keyLabels(ks), " "))
+			if custom {
+				line += "  (remapped)"
+			}
+			fmt.Println(line)
+		}
+		if err := launcher.ValidateKeybindings(cfg.Keybindings); err != nil {
+			fmt.Printf("\nNot in use, the launcher keeps the defaults: %v\n", err)
+		}
+		return nil
+	},
+}
+
+var configKeysSetCmd = &cobra.Command{
+	Use:   "set <action> <key>...",
+	Short: "Set the keys of a launcher action",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		bindings := maps.Clone(cfg.Keybindings)
+		if bindings == nil {
+			bindings = make(map[string][]string)
+		}
+		bindings[args[0]] = args[1:]
+		if err := launcher.ValidateKeybindings(bindings); err != nil {
+			return err
+		}
+		cfg.Keybindings = bindings
+		if err := config.Save(cfgPath, cfg); err != nil {
+			return err
+		}
+		fmt.Printf("Set %s = %s\n", args[0], strings.Join(keyLabels(args[1:]), " "))
+		return nil
+	},
+}
+
+var configKeysUnsetCmd = &cobra.Command{
+	Use:   "unset <action>...",
+	Short: "Restore the default keys of launcher actions",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		for _, action := range args {
+			if _, ok := cfg.Keybindings[action]; !ok {
+				return fmt.Errorf("%s is not remapped", action)
+			}
+			delete(cfg.Keybindings, action)
+		}
+		// Restoring defaults can clash with what is still remapped
+		if err := launcher.ValidateKeybindings(cfg.Keybindings); err != nil {
+			return err
+		}
+		if err := config.Save(cfgPath, cfg); err != nil {
+			return err
+		}
+		for _, action := range args {
+			fmt.Printf("Unset %s\n", action)
+		}
+		return nil
+	},
+}
+
+// keyLabels returns keys as typed in the config: the space bar as "space".
+func keyLabels(ks []string) []string {
+	labels := make([]string, len(ks))
+	for i, k := range ks {
+		if k == " " {
+			k = "space"
+		}
+		labels[i] = k
+	}
+	return labels
+}
+
+func init() {
+	configCmd.AddCommand(configKeysCmd)
+	configKeysCmd.AddCommand(configKeysSetCmd)
+	configKeysCmd.AddCommand(configKeysUnsetCmd)
+}
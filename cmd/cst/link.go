@@ -0,0 +1,81 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// --- Link Command ---
+
+var flagLinkRemove bool
+
+var linkCmd = &cobra.Command{
+	Use:   "link <session> [url]",
+	Short: "Link a session to an issue, ticket or pull request",
+	Long: `Attach the URL of an issue, ticket or pull request to a session. Links
+show in the launcher's preview pane, where o opens the latest one in the
+browser, and launcher search matches them. A session can have several.
+
+Without a URL, list the session's links. --remove detaches one.`,
+	Example: `  cst link 3f2a91c0 https://github.com/acme/api/issues/42
+  cst link 3f2a91c0 https://acme.atlassian.net/browse/API-123
+  cst link 3f2a91c0 --remove https://github.com/acme/api/issues/42`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if flagLinkRemove && len(args) < 2 {
+			return errors.New("--remove needs the URL to remove")
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		sess, err := lookupSession(s, args[0])
+		if err != nil {
+			return err
+		}
+		if len(args) == 1 {
+			if len(sess.Links) == 0 {
+				fmt.Printf("Session %s has no links\n", textutil.ShortID(sess.ID))
+			}
+			for _, link := range sess.Links {
+				fmt.Println(link)
+			}
+			return nil
+		}
+
+		link := args[1]
+		if flagLinkRemove {
+			err := s.RemoveLink(sess.ID, link)
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("session %s has no link %s", textutil.ShortID(sess.ID), link)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Removed %s from session %s\n", link, textutil.ShortID(sess.ID))
+			return nil
+		}
+		if u, err := url.Parse(link); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid URL %q, expected e.g. https://github.com/owner/repo/issues/1", link)
+		}
+		if err := s.AddLink(sess.ID, link, time.Now().UnixMilli()); err != nil {
+			return err
+		}
+		fmt.Printf("Linked session %s to %s\n", textutil.ShortID(sess.ID), link)
+		return nil
+	},
+}
+
+func init() {
+	linkCmd.Flags().BoolVar(&flagLinkRemove, "remove", false, "Remove the link instead of adding it")
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/procutil"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+// --- List Command ---
+
+var (
+	flagOffset  int
+	flagSince   string
+	flagUntil   string
+	flagTag     string
+	flagTicket  string
+	flagHost    string
+	flagModel   string
+	flagColumns string
+	flagFormat  string
+)
+
+// listFormats are the values of cst list --format.
+var listFormats = []string{"table", "json", "csv", "tsv", "claude-context"}
+
+// Defaults of cst list --format claude-context: the number of sessions
+// when --limit is not given, and the prompts shown per session.
+const (
+	contextSessions = 10
+	contextPrompts  = 5
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List sessions (non-interactive)",
+	Long: `List sessions, most recently active first.
+
+--since and --until filter on last activity and accept an age ("90m", "7d"),
+a date (2006-01-02), an RFC 3339 timestamp or milliseconds since the epoch.
+Use --limit and --offset to page through long lists:
+
+  cst list --all --json --limit 50 --offset 100
+  cst list --since 7d --until 1d
+
+--ticket lists the sessions that worked on a ticket, whose ID was taken
+from the git branch (feature/JIRA-123-x or 1234-fix-x) and stored as a tag;
+see ticket_patterns in cst config set. --tag is the same.
+
+--workspace lists the sessions of every project in a workspace, a named
+group of projects; see cst config workspace.
+
+--host lists sessions last started on one machine, for databases shared
+between machines; "." means this machine.
+
+--model lists sessions whose last model contains the text given, ignoring
+case, so --model opus matches claude-opus-4-1.
+
+--columns picks the table columns and their order, overriding the columns
+config; available columns are listed under cst config set:
+
+  cst list --columns status,branch,time,prompt
+
+--format csv and --format tsv print the columns with a header row of their
+names, for spreadsheets and awk. Values are whole: full IDs and paths, times
+in RFC 3339, worked time in seconds and token counts as numbers. CSV is
+quoted per RFC 4180; TSV is not quoted, so tabs and line breaks in prompts
+become spaces.
+
+  cst list --all --format tsv --columns id,started,worked,project | sort -t$'\t' -k3 -n
+
+--format go-template=<template> prints each session through a Go
+text/template, as docker and kubectl do, and go-template-file=<path> reads
+the template from a file. Templates see the fields of the session, such as
+.ID, .Project, .Title, .Branch, .Model, .StartedAt and .LastActivity (ms
+since the epoch), .WorkedMS, .PromptCount and .Tags, and .Prompts, its
+stored prompts newest first (.Text, .Timestamp), read only when used. The
+functions json, join, lower, upper, truncate, time (RFC 3339), ago and
+duration are available:
+
+  cst list --all --format 'go-template={{.ID}} {{.Project}}'
+  cst list --format 'go-template={{time .StartedAt}} {{join "," .Tags}}{{range .Prompts}}
+    {{truncate 60 .Text}}{{end}}'
+
+--format claude-context prints a compact Markdown digest of the latest
+sessions (10 unless --limit is given) and their recent prompts, for seeding
+a new claude session with what earlier ones did, e.g. from a custom slash
+command (.claude/commands/recent.md containing !` + "`cst list --format claude-context`" + `)
+or by appending it to CLAUDE.md.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if flagLimit < 0 || flagOffset < 0 {
+			return fmt.Errorf("--limit and --offset must not be negative")
+		}
+		format := cmp.Or(flagFormat, "table")
+		tmpl, err := listTemplate(format)
+		if err != nil {
+			return err
+		}
+		if tmpl != nil {
+			format = "go-template"
+		}
+		switch {
+		case tmpl == nil && !slices.Contains(listFormats, format):
+			return fmt.Errorf("unknown --format %q, expected one of %s, go-template=<template> or go-template-file=<path>",
+				format, strings.Join(listFormats, ", "))
+		case flagJSON && format != "table" && format != "json":
+			return fmt.Errorf("--json cannot be combined with --format %s", format)
+		case flagJSON:
+			format = "json"
+		}
+		if format == "claude-context" && flagLimit == 0 {
+			flagLimit = contextSessions
+		}
+		start := time.Now()
+		project := flagProject
+		if !flagAll && project == "" {
+			project, err = os.Getwd()
+			if err != nil {
+				return err
+			}
+		}
+		project = store.ResolvePath(project)
+
+		cfg, _ := config.Load(config.DefaultConfigPath())
+		var workspace []string
+		if flagWorkspace != "" {
+			if flagAll {
+				return fmt.Errorf("--workspace and --all cannot both be given")
+			}
+			var err error
+			if workspace, err = workspaceProjects(cfg, flagWorkspace); err != nil {
+				return err
+			}
+		}
+		cols, err := tableColumns(cfg)
+		if err != nil {
+			return err
+		}
+		s, err := openStoreWithConfig(cfg)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+		if flagTimings {
+			defer printTimings(s, start)
+		}
+		secondaries := openSecondaries(cfg)
+		defer closeSecondaries(secondaries)
+
+		if flagWatch {
+			if format != "table" {
+				return fmt.Errorf("--watch only shows the table, not --format %s", format)
+			}
+			return watchSessions(s, secondaries, project, workspace, cols)
+		}
+
+		sessions, err := listSessions(s, secondaries, project, workspace)
+		if err != nil {
+			return err
+		}
+
+		switch format {
+		case "json":
+			return printSessionsJSON(sessions)
+		case "csv", "tsv":
+			return printSessionsDelimited(format, sessions, cols)
+		case "go-template":
+			return printSessionsTemplate(tmpl, s, secondaries, sessions)
+		case "claude-context":
+			return printClaudeContext(s, secondaries, project, sessions)
+		}
+
+		if len(sessions) == 0 {
+			fmt.Println("No sessions found.")
+			return nil
+		}
+
+		printSessionsTable(sessions, cols)
+		return nil
+	},
+}
+
+// listSessions applies the scope, time and paging flags across the local
+// store and any extra stores. The projects of a workspace, if given, replace
+// project. Relative times are resolved on every call, so --watch keeps a
+// moving window.
+func listSessions(s *store.Store, secondaries []store.Secondary, project string, workspace []string) ([]store.Session, error) {
+	if flagTag != "" && flagTicket != "" && !strings.EqualFold(flagTag, flagTicket) {
+		return nil, errors.New("--tag and --ticket cannot both be given")
+	}
+	opts := store.ListOptions{Limit: flagLimit, Offset: flagOffset, Tag: cmp.Or(flagTicket, flagTag), Host: flagHost, Model: flagModel, ExcludeHeadless: !flagHeadless}
+	if opts.Host == "." {
+		opts.Host = store.LocalHost()
+	}
+	switch {
+	case len(workspace) > 0:
+		opts.Projects = workspace
+	case !flagAll:
+		opts.Project = project
+	}
+	now := time.Now()
+	var err error
+	if flagSince != "" {
+		if opts.Since, err = store.ParseTime(flagSince, now); err != nil {
+			return nil, fmt.Errorf("--since: %w", err)
+		}
+	}
+	if flagUntil != "" {
+		if opts.Until, err = store.ParseTime(flagUntil, now); err != nil {
+			return nil, fmt.Errorf("--until: %w", err)
+		}
+	}
+	return store.ListMerged(s, secondaries, opts)
+}
+
+// printClaudeContext prints sessions with their recent prompts as the
+// digest of --format claude-context. Prompts of sessions from extra stores
+// are read from those stores.
+func printClaudeContext(s *store.Store, secondaries []store.Secondary, project string, sessions []store.Session) error {
+	bySource := make(map[string][]string)
+	for _, sess := range sessions {
+		bySource[sess.Source] = append(bySource[sess.Source], sess.ID)
+	}
+	history, err := s.PromptHistory(bySource[""], contextPrompts)
+	if err != nil {
+		return err
+	}
+	for _, sec := range secondaries {
+		h, err := sec.Store.PromptHistory(bySource[sec.Name], contextPrompts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", sec.Name, err)
+		}
+		maps.Copy(history, h)
+	}
+
+	fmt.Print(launcher.ContextMarkdown(listScope(project), sessions, history))
+	return nil
+}
+
+// listScope describes the sessions cst list shows, for headings.
+func listScope(project string) string {
+	switch {
+	case flagWorkspace != "":
+		return "workspace " + flagWorkspace
+	case flagAll || project == "":
+		return "all projects"
+	}
+	return project
+}
+
+// tableColumns resolves the columns of cst list from --columns, then the
+// columns config, then the default.
+func tableColumns(cfg config.Config) ([]launcher.Column, error) {
+	names := cfg.Columns
+	if flagColumns != "" {
+		names = splitArgs(flagColumns)
+	}
+	if len(names) == 0 {
+		names = launcher.DefaultListColumns
+	}
+	cols, err := launcher.ParseColumns(names)
+	if err != nil {
+		if flagColumns != "" {
+			return nil, fmt.Errorf("--columns: %w", err)
+		}
+		return nil, fmt.Errorf("columns config: %w", err)
+	}
+	return cols, nil
+}
+
+// listPromptWidth is the widest the flexible column of cst list gets.
+const listPromptWidth = 60
+
+func printSessionsTable(sessions []store.Session, cols []launcher.Column) {
+	header, rule := launcher.RenderHeader(cols, "  ", listPromptWidth)
+	fmt.Println(header)
+	fmt.Println(rule)
+	for _, sess := range sessions {
+		fmt.Println(launcher.RenderRow(cols, sess, "  ", listPromptWidth, false))
+	}
+}
+
+// watchSessions clears the terminal and re-renders the session table every
+// --interval, refreshing active state each time, until interrupted.
+func watchSessions(s *store.Store, secondaries []store.Secondary, project string, workspace []string, cols []launcher.Column) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(flagInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RefreshActive(procutil.IsProcessAlive); err != nil {
+			return err
+		}
+		sessions, err := listSessions(s, secondaries, project, workspace)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print("\033[H\033[2J") // move cursor home and clear screen
+		fmt.Printf("cst list --watch  %s  (every %s, updated %s, Ctrl+C to exit)\n\n",
+			listScope(project), flagInterval, time.Now().Format("15:04:05"))
+		if waiting := awaitingFirst(sessions); waiting > 0 {
+			fmt.Printf("%d waiting on you, longest for %s\n\n", waiting, waitingFor(sessions[0]))
+		}
+		if len(sessions) == 0 {
+			fmt.Println("No sessions found.")
+		} else {
+			printSessionsTable(sessions, cols)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// awaitingFirst moves active sessions waiting on the user to the front,
+// longest wait first, keeping the order of the rest. Returns how many wait.
+func awaitingFirst(sessions []store.Session) int {
+	waiting := func(sess store.Session) bool { return sess.Active && sess.AwaitingSince > 0 }
+	slices.SortStableFunc(sessions, func(a, b store.Session) int {
+		switch {
+		case waiting(a) && waiting(b):
+			return cmp.Compare(a.AwaitingSince, b.AwaitingSince)
+		case waiting(a):
+			return -1
+		case waiting(b):
+			return 1
+		}
+		return 0
+	})
+	n := 0
+	for n < len(sessions) && waiting(sessions[n]) {
+		n++
+	}
+	return n
+}
+
+// waitingFor formats how long a session has been waiting on the user, e.g. "12m".
+func waitingFor(sess store.Session) string {
+	return strings.TrimSuffix(launcher.FormatRelativeTime(sess.AwaitingSince), " ago")
+}
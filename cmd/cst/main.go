@@ -1,12 +1,26 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
@@ -14,7 +28,12 @@ import (
 	"github.com/imyousuf/claude-session-tracker/internal/config"
 	"github.com/imyousuf/claude-session-tracker/internal/hook"
 	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/logging"
+	"github.com/imyousuf/claude-session-tracker/internal/procutil"
 	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+	"github.com/imyousuf/claude-session-tracker/internal/title"
+	"github.com/imyousuf/claude-session-tracker/internal/transcript"
 )
 
 // Build-time variables set via ldflags.
@@ -31,10 +50,26 @@ func main() {
 }
 
 var (
-	flagAll     bool
-	flagProject string
-	flagDays    int
-	flagJSON    bool
+	flagAll       bool
+	flagProject   string
+	flagDays      int
+	flagCleared   int
+	flagJSON      bool
+	flagOlderThan string
+	flagDryRun    bool
+	flagYes       bool
+	flagTimings   bool
+	flagProfile   string
+	flagWatch     bool
+	flagInterval  time.Duration
+	flagVerbose   bool
+	flagDB        string
+	flagReadOnly  bool
+	flagClaudeBin []string
+	flagStrict    bool
+	flagHeadless  bool
+	flagWorkspace string
+	flagNoColor   bool
 )
 
 var rootCmd = &cobra.Command{
@@ -43,6 +78,8 @@ var rootCmd = &cobra.Command{
 	Long:  "A tool that tracks Claude Code sessions via lifecycle hooks and provides a TUI launcher to browse and resume previous sessions.\n\nAny arguments after -- are passed through to the claude CLI on resume.",
 	RunE:  launchTUI,
 	Args:  cobra.ArbitraryArgs,
+
+	PersistentPreRunE: setup,
 }
 
 func init() {
@@ -52,19 +89,88 @@ func init() {
 	rootCmd.AddCommand(cleanupCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(pruneTranscriptsCmd)
+	rootCmd.AddCommand(dbCmd)
+	rootCmd.AddCommand(bundleCmd)
+	rootCmd.AddCommand(queryCmd)
+	rootCmd.AddCommand(promptsCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(outcomesCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(topCmd)
+	rootCmd.AddCommand(mergeCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(titleCmd)
+	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(reportCmd)
+	rootCmd.AddCommand(journalCmd)
+	rootCmd.AddCommand(continueCmd)
+	rootCmd.AddCommand(setArgsCmd)
+	rootCmd.AddCommand(linkCmd)
+	rootCmd.AddCommand(whichCmd)
+	rootCmd.AddCommand(shareCmd)
+	rootCmd.AddCommand(adoptCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(projectCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(profileCmd)
+
+	rootCmd.PersistentFlags().BoolVarP(&flagVerbose, "verbose", "v", false, "Log what cst does to stderr")
+	rootCmd.PersistentFlags().BoolVar(&flagNoColor, "no-color", false, "Print without colors or text styles (also when NO_COLOR is set)")
+	rootCmd.PersistentFlags().StringVar(&flagProfile, "profile", "", "Profile whose config and database to use (see cst profile; default $CST_PROFILE or the one in use)")
+	rootCmd.PersistentFlags().StringVar(&flagDB, "db", "", "Session database to open instead of the profile's sessions.db")
 
 	// Launch flags (also on root)
 	rootCmd.Flags().BoolVarP(&flagAll, "all", "a", false, "Show sessions from all projects")
 	rootCmd.Flags().StringVarP(&flagProject, "project", "p", "", "Filter by project path")
+	rootCmd.Flags().StringVarP(&flagWorkspace, "workspace", "w", "", "Show sessions from the projects of this workspace (see cst config workspace)")
+	rootCmd.Flags().BoolVar(&flagTimings, "timings", false, "Print a query timing summary on exit")
+	rootCmd.Flags().StringVar(&flagColumns, "columns", "", "Comma-separated session list columns, in order (overrides the columns config)")
+	rootCmd.Flags().BoolVar(&flagHeadless, "include-headless", false, "Also show subagent and non-interactive (claude -p) sessions")
+	rootCmd.Flags().BoolVar(&flagReadOnly, "read-only", false, "Open the database without writing to it or checking which sessions still run")
+	rootCmd.Flags().StringSliceVar(&flagClaudeBin, "claude-bin", nil, "Command that runs claude on resume, e.g. ~/bin/claude or ssh,devbox,claude (overrides claude_bin)")
 
 	launchCmd.Flags().BoolVarP(&flagAll, "all", "a", false, "Show sessions from all projects")
 	launchCmd.Flags().StringVarP(&flagProject, "project", "p", "", "Filter by project path")
+	launchCmd.Flags().StringVarP(&flagWorkspace, "workspace", "w", "", "Show sessions from the projects of this workspace (see cst config workspace)")
+	launchCmd.Flags().BoolVar(&flagTimings, "timings", false, "Print a query timing summary on exit")
+	launchCmd.Flags().StringVar(&flagColumns, "columns", "", "Comma-separated session list columns, in order (overrides the columns config)")
+	launchCmd.Flags().BoolVar(&flagHeadless, "include-headless", false, "Also show subagent and non-interactive (claude -p) sessions")
+	launchCmd.Flags().BoolVar(&flagReadOnly, "read-only", false, "Open the database without writing to it or checking which sessions still run")
+	launchCmd.Flags().StringSliceVar(&flagClaudeBin, "claude-bin", nil, "Command that runs claude on resume, e.g. ~/bin/claude or ssh,devbox,claude (overrides claude_bin)")
 
 	listCmd.Flags().BoolVarP(&flagAll, "all", "a", false, "Show sessions from all projects")
 	listCmd.Flags().StringVarP(&flagProject, "project", "p", "", "Filter by project path")
+	listCmd.Flags().StringVar(&flagWorkspace, "workspace", "", "List sessions from the projects of this workspace (see cst config workspace)")
 	listCmd.Flags().BoolVar(&flagJSON, "json", false, "Output as JSON")
+	listCmd.Flags().StringVar(&flagFormat, "format", "", "Output format: "+strings.Join(listFormats, ", ")+", go-template=<template> or go-template-file=<path> (default table)")
+	listCmd.Flags().BoolVar(&flagTimings, "timings", false, "Print a query timing summary on exit")
+	listCmd.Flags().BoolVarP(&flagWatch, "watch", "w", false, "Re-render the table periodically until interrupted")
+	listCmd.Flags().DurationVar(&flagInterval, "interval", 2*time.Second, "Refresh interval for --watch")
+	listCmd.Flags().IntVar(&flagLimit, "limit", 0, "Maximum number of sessions (0 for all)")
+	listCmd.Flags().IntVar(&flagOffset, "offset", 0, "Skip this many sessions first")
+	listCmd.Flags().StringVar(&flagSince, "since", "", "Only sessions active at or after this time (e.g. 7d, 2006-01-02)")
+	listCmd.Flags().StringVar(&flagUntil, "until", "", "Only sessions last active before this time")
+	listCmd.Flags().StringVar(&flagTag, "tag", "", "Only sessions with this tag, e.g. a ticket ID from the branch name")
+	listCmd.Flags().StringVar(&flagTicket, "ticket", "", "Only sessions that worked on this ticket, e.g. PROJ-123 from the branch name")
+	listCmd.Flags().StringVar(&flagHost, "host", "", "Only sessions last started on this machine (. for this one)")
+	listCmd.Flags().StringVar(&flagModel, "model", "", "Only sessions whose model contains this, e.g. opus")
+	listCmd.Flags().StringVar(&flagColumns, "columns", "", "Comma-separated columns to show, in order (overrides the columns config)")
+	listCmd.Flags().BoolVar(&flagHeadless, "include-headless", false, "Also list subagent and non-interactive (claude -p) sessions")
+	listCmd.Flags().BoolVar(&flagReadOnly, "read-only", false, "Open the database without writing to it or checking which sessions still run")
+	versionCmd.Flags().BoolVar(&flagJSON, "json", false, "Output as JSON")
 
-	cleanupCmd.Flags().IntVar(&flagDays, "days", 30, "Remove inactive sessions older than N days")
+	cleanupCmd.Flags().IntVar(&flagDays, "days", config.DefaultRetentionDays, "Remove inactive sessions older than N days (overrides retention_days in config)")
+	cleanupCmd.Flags().IntVar(&flagCleared, "cleared-days", 0, "Remove sessions ended with /clear after N days (overrides cleared_retention_days in config)")
+	cleanupCmd.Flags().StringVarP(&flagProject, "project", "p", "", "Only clean up sessions of this project")
+	cleanupCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "List the sessions that would be removed without removing them")
+	cleanupCmd.Flags().BoolVar(&flagJSON, "json", false, "Print the report as JSON")
+
+	pruneTranscriptsCmd.Flags().StringVar(&flagOlderThan, "older-than", "60d", "Only prune sessions inactive for longer than this (e.g. 60d, 12h)")
+	pruneTranscriptsCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "List transcripts that would be removed without deleting them")
+	adoptCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "List the sessions that would be adopted without adding them")
+	pruneTranscriptsCmd.Flags().BoolVarP(&flagYes, "yes", "y", false, "Skip the confirmation prompt")
 }
 
 // --- Hook Commands ---
@@ -75,16 +181,22 @@ var hookCmd = &cobra.Command{
 }
 
 func init() {
+	hookCmd.PersistentFlags().BoolVar(&flagStrict, "strict", false, "Reject payloads with unknown or mistyped fields, and exit 1 when handling fails")
 	hookCmd.AddCommand(hookSessionStartCmd)
 	hookCmd.AddCommand(hookPromptCmd)
 	hookCmd.AddCommand(hookSessionEndCmd)
+	hookCmd.AddCommand(hookPreToolCmd)
+	hookCmd.AddCommand(hookPostToolCmd)
+	hookCmd.AddCommand(hookToolFailureCmd)
+	hookCmd.AddCommand(hookNotificationCmd)
+	hookCmd.AddCommand(hookStatsCmd)
 }
 
 var hookSessionStartCmd = &cobra.Command{
 	Use:   "session-start",
 	Short: "Handle SessionStart hook event",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runHook(hook.HandleSessionStart)
+		return runHook("SessionStart", hook.HandleSessionStart)
 	},
 }
 
@@ -92,7 +204,7 @@ var hookPromptCmd = &cobra.Command{
 	Use:   "prompt",
 	Short: "Handle UserPromptSubmit hook event",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runHook(hook.HandlePrompt)
+		return runHook("UserPromptSubmit", hook.HandlePrompt)
 	},
 }
 
@@ -100,23 +212,384 @@ var hookSessionEndCmd = &cobra.Command{
 	Use:   "session-end",
 	Short: "Handle SessionEnd hook event",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runHook(hook.HandleSessionEnd)
+		return runHook("SessionEnd", hook.HandleSessionEnd)
+	},
+}
+
+var hookPreToolCmd = &cobra.Command{
+	Use:   "pre-tool",
+	Short: "Handle PreToolUse hook event",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHook("PreToolUse", hook.HandlePreTool)
 	},
 }
 
-func runHook(handler func(*store.Store, hook.HookInput) error) error {
-	input, err := hook.ReadInput(os.Stdin)
+var hookPostToolCmd = &cobra.Command{
+	Use:     "post-tool",
+	Aliases: []string{"tool"},
+	Short:   "Handle PostToolUse hook event",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHook("PostToolUse", hook.HandleTool)
+	},
+}
+
+var hookToolFailureCmd = &cobra.Command{
+	Use:   "tool-failure",
+	Short: "Handle PostToolUseFailure hook event",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHook("PostToolUseFailure", hook.HandleToolFailure)
+	},
+}
+
+var hookNotificationCmd = &cobra.Command{
+	Use:   "notification",
+	Short: "Handle Notification hook event",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHook("Notification", hook.HandleNotification)
+	},
+}
+
+// hookHandler handles one kind of hook event.
+type hookHandler func(*store.Store, config.Config, hook.HookInput) error
+
+// runHook handles a hook event read from stdin, then runs the user's scripts
+// for it from ~/.cst/hooks.d/<event>/. Script failures are reported but never
+// fail the hook. With record_hook_payloads on, the payload is saved first.
+// Failures are handled as hook_fail_mode says.
+func runHook(event string, handler hookHandler) error {
+	payload, err := io.ReadAll(os.Stdin)
+
+	// Sessions started by cst title to summarize prompts are not tracked
+	if os.Getenv(title.HeadlessEnv) != "" {
+		slog.Debug("hook skipped: titling run", "event", event)
+		return nil
+	}
+
+	// A broken config must not stop sessions from being tracked
+	cfg, _ := config.Load(config.DefaultConfigPath())
 	if err != nil {
+		return hookFailed(event, payload, cfg, fmt.Errorf("read hook input: %w", err))
+	}
+	if err := handleRecovered(event, payload, handler, cfg); err != nil {
+		return hookFailed(event, payload, cfg, err)
+	}
+	return nil
+}
+
+// handleRecovered handles a hook payload, turning a panic into an error so
+// that it fails the hook like any other error.
+func handleRecovered(event string, payload []byte, handler hookHandler, cfg config.Config) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handlePayload(event, payload, handler, cfg, cfg.RecordHookPayloads, true)
+}
+
+// hookFailed logs a failed hook event to ~/.cst/hook-errors.log, where cst
+// doctor counts them, and returns the error only with hook_fail_mode strict
+// or --strict. Otherwise the hook exits 0, so that a database hiccup does not
+// put an error in front of the user in the middle of their Claude session.
+func hookFailed(event string, payload []byte, cfg config.Config, err error) error {
+	var input struct {
+		SessionID string `json:"session_id"`
+	}
+	_ = json.Unmarshal(payload, &input)
+	slog.Error("hook failed", "event", event, "session", input.SessionID, "err", err)
+
+	path := config.DefaultHookErrorsPath()
+	f := hook.Failure{Time: time.Now(), Event: event, SessionID: input.SessionID, Err: err.Error()}
+	if lerr := hook.LogFailure(path, f); lerr != nil {
+		slog.Warn("hook failure not logged", "path", path, "err", lerr)
+	}
+	switch {
+	case flagStrict || cfg.HookFailMode == "strict":
+		return err
+	case cfg.HookFailMode != "silent":
+		fmt.Fprintf(os.Stderr, "cst: %s hook failed: %v (logged to %s)\n", event, err, path)
+	}
+	return nil
+}
+
+// handlePayload handles a raw hook payload for event, saving it to
+// ~/.cst/payloads if record and running the user's scripts if scripts.
+func handlePayload(event string, payload []byte, handler hookHandler, cfg config.Config, record, scripts bool) error {
+	start := time.Now()
+	input, err := hook.ReadInput(bytes.NewReader(payload))
+	// Payloads that fail to decode are recorded too: they are the ones to debug
+	if record && !cfg.IsProjectIgnored(input.CWD) {
+		path, err := hook.RecordPayload(config.DefaultPayloadsPath(), event, input.SessionID, payload, time.Now(), hook.DefaultMaxPayloads)
+		if err != nil {
+			slog.Warn("hook payload not recorded", "event", event, "err", err)
+		} else {
+			slog.Debug("hook payload recorded", "event", event, "path", path)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	slog.Debug("hook received", "event", event, "session", input.SessionID, "cwd", input.CWD, "bytes", len(payload))
+
+	// Silently skip sessions in directories the user asked us not to track
+	if cfg.IsProjectIgnored(input.CWD) {
+		slog.Debug("hook skipped: project ignored", "event", event, "cwd", input.CWD)
+		return nil
+	}
+
+	// The database work gets hookDeadline, after which the hook stops
+	// waiting on it, so that a lock held elsewhere, as on a network
+	// filesystem, never stalls Claude Code; exiting rolls back whatever it
+	// left unfinished
+	ctx, cancel := context.WithTimeout(context.Background(), hookDeadline)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		// Forwarded with hook_daemon, unless a --db other than the daemon's is given
+		if cfg.HookDaemon && flagDB == "" {
+			req := hook.Request{Event: event, Payload: string(payload), Strict: flagStrict,
+				PID: os.Getppid(), Terminal: procutil.TerminalFromEnv(os.Getenv)}
+			err := hook.Forward(ctx, config.DefaultSocketPath(), req)
+			if !errors.Is(err, hook.ErrNoDaemon) {
+				done <- err
+				return
+			}
+			slog.Debug("hook not forwarded", "event", event, "err", err)
+		}
+		done <- handleInput(ctx, event, input, handler, cfg)
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("gave up on the database after %s", hookDeadline)
+	}
+
+	if !scripts {
 		return err
 	}
+	timeout := scriptTimeout(cfg, time.Since(start))
+	if timeout <= 0 {
+		slog.Warn("hook scripts skipped: no time left", "event", event)
+		return err
+	}
+	for _, r := range hook.RunScripts(config.DefaultScriptsDir(), event, input.SessionID, payload, timeout) {
+		slog.Warn("hook script failed", "event", event, "script", r.Path, "err", r.Err)
+		fmt.Fprintf(os.Stderr, "cst: hook script %s: %v\n", r.Path, r.Err)
+	}
+	return err
+}
+
+// hookDeadline bounds the database work of a hook, leaving the rest of
+// hookBudget to the scripts in ~/.cst/hooks.d.
+const hookDeadline = 2 * time.Second
+
+// hookBudget is the part of the 5s timeout in hooks.json a hook takes, the
+// rest being left for starting cst and exiting.
+const hookBudget = 4 * time.Second
+
+// scriptTimeout returns the time limit of hook scripts: script_timeout_seconds,
+// cut to what is left of hookBudget after elapsed, less the wait for the
+// pipes of a killed script. It is not positive when nothing is left.
+func scriptTimeout(cfg config.Config, elapsed time.Duration) time.Duration {
+	timeout := time.Duration(cfg.ScriptTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = hook.DefaultScriptTimeout
+	}
+	return min(timeout, hookBudget-elapsed-hook.ScriptWaitDelay)
+}
 
-	s, err := store.Open(store.DefaultDBPath())
+// handleInput records a validated hook event in the store, whose operations
+// are canceled once ctx is done.
+func handleInput(ctx context.Context, event string, input hook.HookInput, handler hookHandler, cfg config.Config) error {
+	s, err := openStoreContext(ctx, cfg)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = s.Close() }()
+	return recordInput(s, event, input, handler, cfg, flagStrict)
+}
+
+// recordInput validates a hook event and records it in s, rejecting input
+// that drifted from the known payload fields if strict.
+func recordInput(s *store.Store, event string, input hook.HookInput, handler hookHandler, cfg config.Config, strict bool) error {
+	if drift := input.Drift(); len(drift) > 0 {
+		now := time.Now().UnixMilli()
+		for _, reason := range drift {
+			_ = s.RecordHookAnomaly(event, reason, now)
+		}
+		slog.Warn("hook input drift", "event", event, "session", input.SessionID, "drift", drift, "unknown", rawFields(input.Unknown))
+		if strict {
+			return fmt.Errorf("rejected %s event: %s", event, strings.Join(drift, "; "))
+		}
+	}
+
+	if err := hook.Validate(event, input); err != nil {
+		reason := err.Error()
+		if errors.Is(err, hook.ErrInvalidSessionID) {
+			reason = hook.ErrInvalidSessionID.Error() // don't split counters per bad ID
+		}
+		_ = s.RecordHookAnomaly(event, reason, time.Now().UnixMilli())
+		slog.Warn("hook rejected", "event", event, "session", input.SessionID, "reason", err)
+		return fmt.Errorf("rejected %s event: %w", event, err)
+	}
+
+	start := time.Now()
+	err := handler(s, cfg, input)
+	slog.Debug("hook handled", "event", event, "session", input.SessionID, "duration", time.Since(start), "err", err)
+	return err
+}
+
+// maxLoggedField caps the width of an unknown field's value that is logged.
+const maxLoggedField = 200
+
+// rawFields returns the unknown fields of a payload for logging, each value
+// as sent but cut to maxLoggedField characters.
+func rawFields(unknown map[string]json.RawMessage) map[string]string {
+	fields := make(map[string]string, len(unknown))
+	for name, raw := range unknown {
+		fields[name] = textutil.Truncate(string(raw), maxLoggedField, "...")
+	}
+	return fields
+}
+
+var hookStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show counts of rejected or repaired hook events",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		anomalies, err := s.HookAnomalies()
+		if err != nil {
+			return err
+		}
+		if len(anomalies) == 0 {
+			fmt.Println("No rejected or repaired hook events.")
+			return nil
+		}
+
+		fmt.Printf("%-18s  %6s  %-10s  %s\n", "EVENT", "COUNT", "LAST SEEN", "REASON")
+		for _, a := range anomalies {
+			fmt.Printf("%-18s  %6d  %-10s  %s\n", a.Event, a.Count, launcher.FormatRelativeTime(a.LastSeen), a.Reason)
+		}
+		return nil
+	},
+}
+
+// openStore opens the database, logging slow queries when the log file or --verbose is on.
+func openStore() (*store.Store, error) {
+	cfg, _ := config.Load(config.DefaultConfigPath())
+	return openStoreWithConfig(cfg)
+}
+
+// openStoreWithConfig opens the database given by --db, or the profile's,
+// read-only with --read-only.
+func openStoreWithConfig(cfg config.Config) (*store.Store, error) {
+	return openStoreContext(context.Background(), cfg)
+}
+
+// openStoreContext is openStoreWithConfig with opening the database,
+// migrations included, and every operation of the store canceled once ctx
+// is done.
+func openStoreContext(ctx context.Context, cfg config.Config) (*store.Store, error) {
+	var s *store.Store
+	var err error
+	if flagReadOnly {
+		// Nothing to migrate, so only the operations need ctx
+		if s, err = store.OpenReadOnly(dbPath()); err == nil {
+			s = s.WithContext(ctx)
+		}
+	} else {
+		s, err = store.OpenContext(ctx, dbPath())
+	}
+	if err != nil {
+		return nil, err
+	}
+	configureStore(s, cfg)
+	return s, nil
+}
 
-	return handler(s, input)
+// configureStore applies the store settings in cfg to s.
+func configureStore(s *store.Store, cfg config.Config) {
+	if cfg.DebugLog || flagVerbose {
+		threshold := store.DefaultSlowQueryThreshold
+		if cfg.SlowQueryMS > 0 {
+			threshold = time.Duration(cfg.SlowQueryMS) * time.Millisecond
+		}
+		s.SetQueryLogger(slog.Default(), threshold)
+	}
+	s.SetIdleGap(time.Duration(cfg.IdleGapMinutes) * time.Minute)
+	if cfg.PIDGraceSeconds != 0 {
+		s.SetPIDGrace(time.Duration(cfg.PIDGraceSeconds) * time.Second)
+	}
+	s.SetPromptStorage(store.PromptStorage(cfg.PromptStorage), cfg.PromptMaxLen)
+}
+
+// dbPath returns the database given by --db, or else the sessions.db of the
+// profile in use.
+func dbPath() string {
+	return cmp.Or(flagDB, filepath.Join(config.Dir(), store.DefaultDBName))
+}
+
+// setup runs before every command to select the profile and set up logging
+// and how output is drawn: without colors for --no-color or NO_COLOR
+// (https://no-color.org), and with ASCII only per the ascii config.
+func setup(cmd *cobra.Command, args []string) error {
+	name, err := config.ResolveProfile(flagProfile)
+	if err != nil {
+		return err
+	}
+	// cst profile still runs when the profile in use is gone, to pick another
+	if err := config.SetProfile(name); err != nil && cmd != profileCmd && cmd.Parent() != profileCmd {
+		return err
+	}
+	// Sessions resumed from here record into this profile too
+	if flagProfile != "" {
+		_ = os.Setenv(config.ProfileEnv, name)
+	}
+
+	// Records from loading the config, such as unknown keys, are logged
+	// again by the command's own Load once the logger is in place.
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	cfg, _ := config.Load(config.DefaultConfigPath())
+	setupLogging(cfg)
+	launcher.SetNoColor(flagNoColor || os.Getenv("NO_COLOR") != "")
+	launcher.SetASCII(cfg.ASCII)
+	launcher.SetRoughErrors(cfg.RoughErrors())
+	if err := launcher.SetKeys(cfg.Keybindings); err != nil {
+		slog.Warn("keybindings not applied", "err", err)
+	}
+	return nil
+}
+
+// setupLogging installs the default logger: to stderr with --verbose and to
+// ~/.cst/cst.log when debug_log is set. Otherwise records are dropped, which
+// keeps hook output clean.
+func setupLogging(cfg config.Config) {
+	opts := logging.Options{Verbose: flagVerbose}
+	if cfg.DebugLog {
+		opts.File = config.DefaultLogPath()
+	}
+	logger, err := logging.New(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not open log file: %v\n", err)
+	}
+	slog.SetDefault(logger)
+}
+
+// printTimings writes a timing summary of the command and its store queries to stderr.
+func printTimings(s *store.Store, start time.Time) {
+	fmt.Fprintf(os.Stderr, "\nTimings: total %s\n", time.Since(start).Round(time.Microsecond))
+	fmt.Fprintf(os.Stderr, "  %-20s  %5s  %12s  %12s  %6s\n", "OPERATION", "CALLS", "TOTAL", "MAX", "ROWS")
+	for _, st := range s.QueryStats() {
+		fmt.Fprintf(os.Stderr, "  %-20s  %5d  %12s  %12s  %6d\n",
+			st.Op, st.Calls, st.Total.Round(time.Microsecond), st.Max.Round(time.Microsecond), st.Rows)
+	}
 }
 
 // --- Launch Command ---
@@ -128,6 +601,7 @@ var launchCmd = &cobra.Command{
 }
 
 func launchTUI(cmd *cobra.Command, args []string) error {
+	start := time.Now()
 	project := flagProject
 	if !flagAll && project == "" {
 		var err error
@@ -138,13 +612,59 @@ func launchTUI(cmd *cobra.Command, args []string) error {
 	}
 	project = store.ResolvePath(project)
 
-	s, err := store.Open(store.DefaultDBPath())
+	s, err := openStore()
 	if err != nil {
 		return fmt.Errorf("open store: %w", err)
 	}
 	defer func() { _ = s.Close() }()
 
-	m := launcher.New(s, project, flagAll)
+	cfg, err := config.Load(config.DefaultConfigPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load config: %v\n", err)
+	}
+
+	if flagWorkspace != "" && flagAll {
+		return fmt.Errorf("--workspace and --all cannot both be given")
+	}
+	showAll := flagAll
+	if !cmd.Flags().Changed("all") && flagWorkspace == "" && cfg.DefaultScope == "all" {
+		showAll = true
+	}
+	workspace := flagWorkspace
+	if workspace == "" && project != "" {
+		workspace = cfg.WorkspaceOf(project)
+	}
+	var workspaceDirs []string
+	if workspace != "" {
+		if workspaceDirs, err = workspaceProjects(cfg, workspace); err != nil {
+			return err
+		}
+	}
+	inWorkspace := flagWorkspace != "" || (workspace != "" && !showAll && cfg.DefaultScope == "workspace")
+
+	secondaries := openSecondaries(cfg)
+	defer closeSecondaries(secondaries)
+
+	m := launcher.New(s, project, showAll).
+		WithConfig(config.DefaultConfigPath(), cfg).
+		WithSecondaries(secondaries).
+		WithResume(flagClaudeBin, args).
+		WithHeadless(flagHeadless).
+		WithWorkspace(workspace, workspaceDirs, inWorkspace)
+	if flagColumns != "" {
+		cols, err := launcher.ParseColumns(splitArgs(flagColumns))
+		if err != nil {
+			return fmt.Errorf("--columns: %w", err)
+		}
+		m = m.WithColumns(cols)
+	}
+	ctx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	if changes, err := s.WatchChanges(ctx); err != nil {
+		slog.Warn("watching the database failed; the list refreshes on refresh_seconds only", "err", err)
+	} else {
+		m = m.WithChanges(changes)
+	}
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	finalModel, err := p.Run()
@@ -152,174 +672,374 @@ func launchTUI(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("run TUI: %w", err)
 	}
 
+	if flagTimings {
+		printTimings(s, start)
+	}
+
 	result := finalModel.(launcher.Model).GetResult()
 	if result == nil {
 		return nil // User quit without selecting
 	}
 
-	return resumeSession(result.SessionID, result.Project, args)
+	return resumeSession(result.SessionID, result.Project, result.Dir, result.Args)
+}
+
+// resumeSession runs claude --resume on the session in dir, its project
+// directory or where it last worked (see config.ResumeDir), followed by
+// args: the config's claude arguments and any given after --, as confirmed
+// in the launcher.
+func resumeSession(sessionID, project, dir string, args []string) error {
+	if _, err := os.Stat(dir); err != nil {
+		moved, err := moveSession(sessionID, dir)
+		if err != nil {
+			return err
+		}
+		project, dir = moved, moved
+	}
+	fmt.Printf("Resuming session %s...\n", textutil.ShortID(sessionID))
+	return runClaude(project, dir, slices.Concat([]string{"--resume", sessionID}, args))
+}
+
+// moveSession asks where the missing directory of a session to resume is
+// now and moves the session there in the store. Resuming elsewhere would
+// start claude without the project's settings, in the wrong files.
+func moveSession(sessionID, missing string) (string, error) {
+	fmt.Printf("%s is missing. Where is the project of session %s now? (empty to abort) ", missing, textutil.ShortID(sessionID))
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return "", fmt.Errorf("not resuming: %s is missing", missing)
+	}
+	dir, err := filepath.Abs(config.ExpandHome(answer))
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(dir); err != nil {
+		return "", err
+	} else if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = s.Close() }()
+	if err := s.SetProject(sessionID, dir); err != nil {
+		return "", fmt.Errorf("move session %s: %w", textutil.ShortID(sessionID), err)
+	}
+	fmt.Printf("Moved session %s to %s\n", textutil.ShortID(sessionID), dir)
+	return store.ResolvePath(dir), nil
 }
 
-func resumeSession(sessionID, project string, extraArgs []string) error {
-	// Load config for additional claude args
+// runClaude replaces cst with claude, given args, in dir with the
+// environment of project, which dir is or is inside of. A claude wrapper
+// runs as a child instead.
+func runClaude(project, dir string, args []string) error {
 	cfg, err := config.Load(config.DefaultConfigPath())
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: could not load config: %v\n", err)
 	}
 
-	// Build claude command: claude --resume <id> [config args] [-- extra args]
-	claudeArgs := []string{"claude", "--resume", sessionID}
-	claudeArgs = append(claudeArgs, cfg.ClaudeArgs()...)
-	claudeArgs = append(claudeArgs, extraArgs...)
+	if len(flagClaudeBin) > 0 {
+		cfg.ClaudeBin = flagClaudeBin
+	}
 
-	fmt.Printf("Resuming session %s...\n", sessionID[:8])
+	claude := cfg.ClaudeCommand()
+	claudeArgs := slices.Concat(claude, args)
 
-	// Change to the project directory
-	if err := os.Chdir(project); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: could not cd to %s: %v\n", project, err)
+	// Never run claude anywhere but dir, see moveSession
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("cannot start claude in %s: %w", dir, err)
 	}
+	env := cfg.Environ(project, os.Environ())
 
-	claudeBin, err := exec.LookPath("claude")
+	if len(claude) > 1 {
+		return runWrapper(claudeArgs, env)
+	}
+	claudeBin, err := exec.LookPath(claude[0])
 	if err != nil {
-		return fmt.Errorf("claude not found in PATH: %w", err)
+		return fmt.Errorf("claude not found, set claude_bin or --claude-bin: %w", err)
 	}
+	return syscall.Exec(claudeBin, claudeArgs, env)
+}
+
+// runWrapper runs a claude wrapper such as ssh or docker exec as a child on
+// this terminal and exits with its status. Interrupts are left to the child.
+func runWrapper(argv, env []string) error {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = env
 
-	return syscall.Exec(claudeBin, claudeArgs, os.Environ())
+	// Catching rather than ignoring SIGINT keeps it at its default in the child
+	signal.Notify(make(chan os.Signal, 1), os.Interrupt)
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("run %s: %w", argv[0], err)
+	}
+	return nil
 }
 
-// --- List Command ---
+// --- Cleanup Command ---
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Remove old inactive sessions",
+	Long: `Remove inactive sessions older than retention_days (default 30) or --days.
+
+Projects can have their own policy, set with cst config retention: a
+different age, no age limit, and a cap on the number of sessions kept,
+beyond which the oldest inactive sessions are removed. --project cleans up
+a single project.
 
-var listCmd = &cobra.Command{
-	Use:   "list",
-	Short: "List sessions (non-interactive)",
+Sessions ended with /clear are usually abandoned; cleared_retention_days
+or --cleared-days removes them sooner than the project's age limit.
+
+Every removed session is listed with its project, age and last prompt.
+--dry-run prints the same report without removing anything, and --json
+prints it as JSON.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("days") {
+			if flagDays <= 0 {
+				return fmt.Errorf("--days must be positive")
+			}
+			cfg.RetentionDays = flagDays
+		}
+		if cmd.Flags().Changed("cleared-days") {
+			if flagCleared <= 0 {
+				return fmt.Errorf("--cleared-days must be positive")
+			}
+			cfg.ClearedRetentionDays = flagCleared
+		}
 		project := flagProject
-		if !flagAll && project == "" {
-			var err error
-			project, err = os.Getwd()
-			if err != nil {
+		if project != "" {
+			if project, err = filepath.Abs(project); err != nil {
 				return err
 			}
+			project = store.ResolvePath(project)
 		}
-		project = store.ResolvePath(project)
 
-		s, err := store.Open(store.DefaultDBPath())
+		s, err := openStore()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = s.Close() }()
 
 		var sessions []store.Session
-		if flagAll || project == "" {
-			sessions, err = s.ListAll()
+		if flagDryRun {
+			sessions, err = s.ExpiredSessions(cfg.RetentionFor, cfg.ClearedRetentionDays, project)
 		} else {
-			sessions, err = s.ListByProject(project)
+			sessions, err = s.Cleanup(cfg.RetentionFor, cfg.ClearedRetentionDays, project)
 		}
 		if err != nil {
 			return err
 		}
 
-		if len(sessions) == 0 {
-			fmt.Println("No sessions found.")
-			return nil
+		// Then bring the database under max_db_size_mb
+		var shrink *store.ShrinkReport
+		if cfg.MaxDBSizeMB > 0 && !flagDryRun {
+			report, err := s.Shrink(int64(cfg.MaxDBSizeMB) << 20)
+			if err != nil {
+				return fmt.Errorf("shrink database: %w", err)
+			}
+			if report.KeptPrompts >= 0 {
+				shrink = &report
+			}
 		}
 
 		if flagJSON {
-			return printSessionsJSON(sessions)
-		}
-
-		// Table output
-		fmt.Printf("%-8s  %-8s  %-10s  %-14s  %s\n", "STATUS", "ID", "LAST SEEN", "MODEL", "LAST PROMPT")
-		fmt.Println("--------  --------  ----------  --------------  -----------")
-		for _, sess := range sessions {
-			status := "inactive"
-			if sess.Active {
-				status = "ACTIVE"
-			}
-			idShort := sess.ID
-			if len(idShort) > 8 {
-				idShort = idShort[:8]
-			}
-			relTime := launcher.FormatRelativeTime(sess.LastActivity)
-			model := sess.Model
-			if len(model) > 14 {
-				model = model[:14]
+			out := cleanupReport{SchemaVersion: JSONSchemaVersion, DryRun: flagDryRun, Sessions: make([]sessionRecord, 0, len(sessions))}
+			for _, sess := range sessions {
+				out.Sessions = append(out.Sessions, newSessionRecord(sess))
 			}
-			prompt := sess.LastPrompt
-			if prompt == "" {
-				prompt = "(none)"
+			if shrink != nil {
+				out.Shrink = &shrinkRecord{
+					SizeBefore:     shrink.Before.Total(),
+					SizeAfter:      shrink.After.Total(),
+					PromptsRemoved: shrink.PromptsRemoved,
+					PromptsKept:    shrink.KeptPrompts,
+				}
 			}
-			if len(prompt) > 60 {
-				prompt = prompt[:57] + "..."
+			return writeJSON(out)
+		}
+
+		if len(sessions) > 0 {
+			cols, err := launcher.ParseColumns(cleanupColumns)
+			if err != nil {
+				return err
 			}
-			fmt.Printf("%-8s  %-8s  %-10s  %-14s  %s\n", status, idShort, relTime, model, prompt)
+			printSessionsTable(sessions, cols)
+			fmt.Println()
+		}
+		verb := "Removed"
+		if flagDryRun {
+			verb = "Would remove"
+		}
+		days, _ := cfg.RetentionFor("")
+		if len(cfg.ProjectRetention) > 0 {
+			fmt.Printf("%s %d inactive sessions older than %d days or beyond their project's retention policy.\n", verb, len(sessions), days)
+		} else {
+			fmt.Printf("%s %d inactive sessions older than %d days.\n", verb, len(sessions), days)
+		}
+		if cfg.ClearedRetentionDays > 0 {
+			fmt.Printf("Sessions ended with /clear expire after %d days.\n", cfg.ClearedRetentionDays)
+		}
+		if shrink != nil {
+			printShrink(*shrink, cfg.MaxDBSizeMB)
 		}
 		return nil
 	},
 }
 
-func printSessionsJSON(sessions []store.Session) error {
-	fmt.Println("[")
-	for i, sess := range sessions {
-		active := "false"
-		if sess.Active {
-			active = "true"
-		}
-		fmt.Printf(`  {"id":"%s","project":"%s","active":%s,"model":"%s","last_prompt":"%s","last_activity":%d}`,
-			sess.ID, sess.Project, active, sess.Model, escapeJSON(sess.LastPrompt), sess.LastActivity)
-		if i < len(sessions)-1 {
-			fmt.Println(",")
-		} else {
-			fmt.Println()
-		}
-	}
-	fmt.Println("]")
-	return nil
+// cleanupColumns are the columns of the cst cleanup report.
+var cleanupColumns = []string{"id", "project", "time", "prompt"}
+
+// cleanupReport is the JSON object printed by cst cleanup --json.
+type cleanupReport struct {
+	SchemaVersion int             `json:"schema_version"`
+	DryRun        bool            `json:"dry_run"`
+	Sessions      []sessionRecord `json:"sessions"` // removed, or to be removed
+	Shrink        *shrinkRecord   `json:"shrink,omitempty"`
 }
 
-func escapeJSON(s string) string {
-	var result []byte
-	for _, c := range s {
-		switch c {
-		case '"':
-			result = append(result, '\\', '"')
-		case '\\':
-			result = append(result, '\\', '\\')
-		case '\n':
-			result = append(result, '\\', 'n')
-		case '\r':
-			result = append(result, '\\', 'r')
-		case '\t':
-			result = append(result, '\\', 't')
-		default:
-			result = append(result, byte(c))
-		}
-	}
-	return string(result)
+// shrinkRecord describes the prompt trimming cst cleanup did to bring the
+// database under max_db_size_mb. Sizes are in bytes.
+type shrinkRecord struct {
+	SizeBefore     int64 `json:"size_before"`
+	SizeAfter      int64 `json:"size_after"`
+	PromptsRemoved int64 `json:"prompts_removed"`
+	PromptsKept    int   `json:"prompts_kept"`
 }
 
-// --- Cleanup Command ---
+// --- Prune Transcripts Command ---
 
-var cleanupCmd = &cobra.Command{
-	Use:   "cleanup",
-	Short: "Remove old inactive sessions",
+var pruneTranscriptsCmd = &cobra.Command{
+	Use:   "prune-transcripts",
+	Short: "Delete Claude transcripts of old inactive sessions",
+	Long: `Delete transcript files in ~/.claude/projects for sessions that cst has
+recorded as inactive for longer than --older-than. Pruned sessions can no
+longer be resumed. Use --dry-run to see what would be removed.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		s, err := store.Open(store.DefaultDBPath())
+		age, err := parseAge(flagOlderThan)
+		if err != nil {
+			return err
+		}
+
+		s, err := openStore()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = s.Close() }()
 
-		removed, err := s.Cleanup(flagDays)
+		cutoff := time.Now().Add(-age).UnixMilli()
+		sessions, err := s.ListInactiveBefore(cutoff)
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("Removed %d inactive sessions older than %d days.\n", removed, flagDays)
+		type candidate struct {
+			path string
+			size int64
+		}
+		var candidates []candidate
+		var total int64
+		dir := transcript.DefaultDir()
+		for _, sess := range sessions {
+			path, err := transcript.Find(dir, sess.ID)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return err
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			candidates = append(candidates, candidate{path: path, size: info.Size()})
+			total += info.Size()
+		}
+
+		if len(candidates) == 0 {
+			fmt.Println("No transcripts to prune.")
+			return nil
+		}
+
+		for _, c := range candidates {
+			fmt.Printf("%10s  %s\n", formatBytes(c.size), c.path)
+		}
+
+		if flagDryRun {
+			fmt.Printf("\nWould remove %d transcripts (%s).\n", len(candidates), formatBytes(total))
+			return nil
+		}
+
+		if !flagYes && !confirm(fmt.Sprintf("\nDelete %d transcripts (%s)? These sessions can no longer be resumed. (y/N) ", len(candidates), formatBytes(total))) {
+			fmt.Println("Aborted.")
+			return nil
+		}
+
+		removed := 0
+		var reclaimed int64
+		for _, c := range candidates {
+			if err := os.Remove(c.path); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not remove %s: %v\n", c.path, err)
+				continue
+			}
+			removed++
+			reclaimed += c.size
+		}
+
+		fmt.Printf("Removed %d transcripts, reclaimed %s.\n", removed, formatBytes(reclaimed))
 		return nil
 	},
 }
 
+// parseAge parses a duration that additionally accepts a day suffix ("60d").
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// confirm prints the question and reports whether the user answered yes.
+func confirm(question string) bool {
+	fmt.Print(question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
 // --- Config Command ---
 
 var configCmd = &cobra.Command{
@@ -331,6 +1051,7 @@ var configCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		warnUnknownKeys(cfg)
 		data, err := json.MarshalIndent(cfg, "", "  ")
 		if err != nil {
 			return err
@@ -344,9 +1065,15 @@ var configCmd = &cobra.Command{
 var configSetCmd = &cobra.Command{
 	Use:   "set <key> <value>",
 	Short: "Set a config value",
-	Long: `Set a configuration value. Available keys:
-  dangerously_skip_permissions  (true/false) - Always pass --dangerously-skip-permissions to claude
-  extra_args                    (comma-separated) - Additional args to pass to claude on resume`,
+	Long: `Set a configuration value. The value is checked against the key's type
+and allowed values before the config is saved. Lists are comma-separated, and
+"" or "[]" clears them. Lists of regexes, whose patterns may hold commas, take
+a JSON array of them, '["^(?i)ok$", "^y{1,3}$"]', or else one pattern, such as
+'[A-Z]+-[0-9]+'. cst config list-keys shows every key with its type, default
+and description.
+
+Most of these can also be changed from the launcher's settings screen (,),
+and all of them with cst config edit.`,
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfgPath := config.DefaultConfigPath()
@@ -355,25 +1082,10 @@ var configSetCmd = &cobra.Command{
 			return err
 		}
 
+		warnUnknownKeys(cfg)
 		key, value := args[0], args[1]
-		switch key {
-		case "dangerously_skip_permissions":
-			switch value {
-			case "true":
-				cfg.DangerouslySkipPermissions = true
-			case "false":
-				cfg.DangerouslySkipPermissions = false
-			default:
-				return fmt.Errorf("invalid value %q for %s, expected true or false", value, key)
-			}
-		case "extra_args":
-			if value == "" || value == "[]" {
-				cfg.ExtraArgs = nil
-			} else {
-				cfg.ExtraArgs = splitArgs(value)
-			}
-		default:
-			return fmt.Errorf("unknown config key: %q\nAvailable: dangerously_skip_permissions, extra_args", key)
+		if err := setConfigValue(&cfg, key, value); err != nil {
+			return err
 		}
 
 		if err := config.Save(cfgPath, cfg); err != nil {
@@ -395,8 +1107,285 @@ func splitArgs(s string) []string {
 	return args
 }
 
+var configIgnoreCmd = &cobra.Command{
+	Use:   "ignore",
+	Short: "List glob patterns of projects that are never tracked",
+	Long: `Manage the ignored_projects list. Sessions started in a directory matching
+one of these glob patterns (or below one) are silently not recorded.
+A leading ~/ is expanded to the home directory.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			return err
+		}
+		if len(cfg.IgnoredProjects) == 0 {
+			fmt.Println("No ignored projects.")
+			return nil
+		}
+		for _, pattern := range cfg.IgnoredProjects {
+			fmt.Println(pattern)
+		}
+		return nil
+	},
+}
+
+var configIgnoreAddCmd = &cobra.Command{
+	Use:   "add <pattern>",
+	Short: "Stop tracking sessions in directories matching pattern",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		added, err := cfg.AddIgnoredProject(args[0])
+		if err != nil {
+			return err
+		}
+		if !added {
+			fmt.Printf("Already ignored: %s\n", args[0])
+			return nil
+		}
+		if err := config.Save(cfgPath, cfg); err != nil {
+			return err
+		}
+		fmt.Printf("Ignoring %s\n", args[0])
+		return nil
+	},
+}
+
+var configIgnoreRemoveCmd = &cobra.Command{
+	Use:   "remove <pattern>",
+	Short: "Resume tracking sessions in directories matching pattern",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		if !cfg.RemoveIgnoredProject(args[0]) {
+			return fmt.Errorf("pattern %q is not in the ignore list", args[0])
+		}
+		if err := config.Save(cfgPath, cfg); err != nil {
+			return err
+		}
+		fmt.Printf("No longer ignoring %s\n", args[0])
+		return nil
+	},
+}
+
+var configEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "List environment variables injected into claude on resume",
+	Long: `Manage environment variables passed to claude when resuming a session.
+Global variables apply to every session; variables set with --project apply
+only to sessions whose project matches the glob pattern (or is below it) and
+override global ones. A leading ~/ is expanded to the home directory.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			return err
+		}
+		if len(cfg.Env) == 0 && len(cfg.ProjectEnv) == 0 {
+			fmt.Println("No environment variables configured.")
+			return nil
+		}
+		for _, name := range slices.Sorted(maps.Keys(cfg.Env)) {
+			fmt.Printf("%s=%s\n", name, cfg.Env[name])
+		}
+		for _, pattern := range slices.Sorted(maps.Keys(cfg.ProjectEnv)) {
+			fmt.Printf("\n[%s]\n", pattern)
+			env := cfg.ProjectEnv[pattern]
+			for _, name := range slices.Sorted(maps.Keys(env)) {
+				fmt.Printf("%s=%s\n", name, env[name])
+			}
+		}
+		return nil
+	},
+}
+
+var configEnvSetCmd = &cobra.Command{
+	Use:   "set <NAME=VALUE>...",
+	Short: "Set environment variables for resumed sessions",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		for _, arg := range args {
+			name, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				return fmt.Errorf("invalid assignment %q, expected NAME=VALUE", arg)
+			}
+			if err := cfg.SetEnv(flagProject, name, value); err != nil {
+				return err
+			}
+		}
+		if err := config.Save(cfgPath, cfg); err != nil {
+			return err
+		}
+		for _, arg := range args {
+			fmt.Printf("Set %s%s\n", arg, envScope(flagProject))
+		}
+		return nil
+	},
+}
+
+var configEnvUnsetCmd = &cobra.Command{
+	Use:   "unset <NAME>...",
+	Short: "Remove environment variables for resumed sessions",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		for _, name := range args {
+			if !cfg.UnsetEnv(flagProject, name) {
+				return fmt.Errorf("%s is not set%s", name, envScope(flagProject))
+			}
+		}
+		if err := config.Save(cfgPath, cfg); err != nil {
+			return err
+		}
+		for _, name := range args {
+			fmt.Printf("Unset %s%s\n", name, envScope(flagProject))
+		}
+		return nil
+	},
+}
+
+func envScope(project string) string {
+	if project == "" {
+		return ""
+	}
+	return " for " + project
+}
+
+var (
+	flagRetentionDays int
+	flagMaxSessions   int
+)
+
+var configRetentionCmd = &cobra.Command{
+	Use:   "retention",
+	Short: "List per-project session retention policies",
+	Long: `Manage per-project retention policies, applied by cst cleanup and, for
+session caps, whenever a session starts. A policy applies to projects
+matching its glob pattern (or below it); the longest matching pattern wins.
+A leading ~/ is expanded to the home directory.
+
+  cst config retention set '~/scratch/*' --max-sessions 20
+  cst config retention set ~/src/main-repo --days -1   # never remove by age`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			return err
+		}
+		days, _ := cfg.RetentionFor("")
+		fmt.Printf("Default: inactive sessions older than %d days are removed\n", days)
+		for _, pattern := range slices.Sorted(maps.Keys(cfg.ProjectRetention)) {
+			fmt.Printf("%s: %s\n", pattern, formatRetention(cfg.ProjectRetention[pattern]))
+		}
+		return nil
+	},
+}
+
+var configRetentionSetCmd = &cobra.Command{
+	Use:   "set <pattern>",
+	Short: "Set the retention policy of projects matching a pattern",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("days") && !cmd.Flags().Changed("max-sessions") {
+			return fmt.Errorf("give --days, --max-sessions or both")
+		}
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		rule := cfg.ProjectRetention[args[0]]
+		if cmd.Flags().Changed("days") {
+			rule.Days = flagRetentionDays
+		}
+		if cmd.Flags().Changed("max-sessions") {
+			rule.MaxSessions = flagMaxSessions
+		}
+		if err := cfg.SetRetention(args[0], rule); err != nil {
+			return err
+		}
+		if err := config.Save(cfgPath, cfg); err != nil {
+			return err
+		}
+		fmt.Printf("%s: %s\n", args[0], formatRetention(rule))
+		return nil
+	},
+}
+
+var configRetentionUnsetCmd = &cobra.Command{
+	Use:   "unset <pattern>",
+	Short: "Remove the retention policy of a pattern",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		if !cfg.UnsetRetention(args[0]) {
+			return fmt.Errorf("no retention policy for %s", args[0])
+		}
+		if err := config.Save(cfgPath, cfg); err != nil {
+			return err
+		}
+		fmt.Printf("Removed the retention policy for %s\n", args[0])
+		return nil
+	},
+}
+
+// formatRetention describes a retention rule, e.g. "kept 90 days, at most 20 sessions".
+func formatRetention(rule config.RetentionRule) string {
+	var age string
+	switch {
+	case rule.Days < 0:
+		age = "kept regardless of age"
+	case rule.Days == 0:
+		age = "default age"
+	default:
+		age = fmt.Sprintf("kept %d days", rule.Days)
+	}
+	if rule.MaxSessions > 0 {
+		return fmt.Sprintf("%s, at most %d sessions", age, rule.MaxSessions)
+	}
+	return age
+}
+
 func init() {
 	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configListKeysCmd)
+	configCmd.AddCommand(configIgnoreCmd)
+	configIgnoreCmd.AddCommand(configIgnoreAddCmd)
+	configIgnoreCmd.AddCommand(configIgnoreRemoveCmd)
+	configCmd.AddCommand(configEnvCmd)
+	configEnvCmd.AddCommand(configEnvSetCmd)
+	configEnvCmd.AddCommand(configEnvUnsetCmd)
+	configCmd.AddCommand(configRetentionCmd)
+	configRetentionCmd.AddCommand(configRetentionSetCmd)
+	configRetentionCmd.AddCommand(configRetentionUnsetCmd)
+	configRetentionSetCmd.Flags().IntVar(&flagRetentionDays, "days", 0, "Remove inactive sessions older than N days (0 for retention_days, negative for never)")
+	configRetentionSetCmd.Flags().IntVar(&flagMaxSessions, "max-sessions", 0, "Keep at most N sessions, removing the oldest inactive ones (0 for no cap)")
+	for _, c := range []*cobra.Command{configEnvSetCmd, configEnvUnsetCmd} {
+		c.Flags().StringVar(&flagProject, "project", "", "Glob pattern of projects the variable applies to")
+	}
 }
 
 // --- Version Command ---
@@ -404,9 +1393,58 @@ func init() {
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print version information",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("cst %s\n", Version)
-		fmt.Printf("  commit: %s\n", Commit)
-		fmt.Printf("  built:  %s\n", BuildDate)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := versionInfo{
+			SchemaVersion:  JSONSchemaVersion,
+			Version:        Version,
+			Commit:         Commit,
+			BuildDate:      BuildDate,
+			GoVersion:      runtime.Version(),
+			Platform:       runtime.GOOS + "/" + runtime.GOARCH,
+			Profile:        config.Profile(),
+			DBPath:         dbPath(),
+			ConfigPath:     config.DefaultConfigPath(),
+			LatestDBSchema: store.LatestSchemaVersion,
+		}
+		version, err := store.ReadSchemaVersion(info.DBPath)
+		if err != nil {
+			info.DBSchemaError = err.Error()
+		} else {
+			info.DBSchemaVersion = version
+		}
+
+		if flagJSON {
+			return writeJSON(info)
+		}
+
+		fmt.Printf("cst %s\n", info.Version)
+		fmt.Printf("  commit:  %s\n", info.Commit)
+		fmt.Printf("  built:   %s\n", info.BuildDate)
+		fmt.Printf("  go:      %s (%s)\n", info.GoVersion, info.Platform)
+		if info.DBSchemaError != "" {
+			fmt.Printf("  schema:  unknown (%s)\n", info.DBSchemaError)
+		} else {
+			fmt.Printf("  schema:  %d (latest %d)\n", info.DBSchemaVersion, info.LatestDBSchema)
+		}
+		fmt.Printf("  profile: %s\n", info.Profile)
+		fmt.Printf("  db:      %s\n", info.DBPath)
+		fmt.Printf("  config:  %s\n", info.ConfigPath)
+		return nil
 	},
 }
+
+// versionInfo is the machine-readable output of `cst version --json`.
+type versionInfo struct {
+	SchemaVersion   int    `json:"schema_version"`
+	Version         string `json:"version"`
+	Commit          string `json:"commit"`
+	BuildDate       string `json:"build_date"`
+	GoVersion       string `json:"go_version"`
+	Platform        string `json:"platform"`
+	DBSchemaVersion int    `json:"db_schema_version"`
+	LatestDBSchema  int    `json:"latest_db_schema_version"`
+	DBSchemaError   string `json:"db_schema_error,omitempty"`
+	Profile         string `json:"profile"`
+	DBPath          string `json:"db_path"`
+	ConfigPath      string `json:"config_path"`
+}
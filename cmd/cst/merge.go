@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// --- Merge Command ---
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge <keep> <duplicate>",
+	Short: "Fold a duplicate session into another and delete it",
+	Long: `Merge two sessions that are really one piece of work, for example after
+Claude Code issued a new session ID. The duplicate's prompts, working
+directory history and tags move to <keep>, which takes the earliest start and
+latest activity of the two; the duplicate is then deleted.
+
+Keep the session you would resume: its transcript is the one claude continues.
+cst doctor lists likely duplicates with a suggested merge command.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		keep, err := lookupSession(s, args[0])
+		if err != nil {
+			return err
+		}
+		dup, err := lookupSession(s, args[1])
+		if err != nil {
+			return err
+		}
+		if keep.ID == dup.ID {
+			return fmt.Errorf("%s and %s are the same session", args[0], args[1])
+		}
+		if dup.Active {
+			return fmt.Errorf("session %s is still running; merge it after it ends", textutil.ShortID(dup.ID))
+		}
+		if err := s.MergeSessions(keep.ID, dup.ID); err != nil {
+			return err
+		}
+		fmt.Printf("Merged session %s into %s\n", textutil.ShortID(dup.ID), textutil.ShortID(keep.ID))
+		return nil
+	},
+}
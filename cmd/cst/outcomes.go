@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// --- Outcomes Command ---
+
+var outcomesCmd = &cobra.Command{
+	Use:   "outcomes",
+	Short: "List ended sessions waiting for an outcome label",
+	Long: `List sessions that ended while outcome_survey was enabled and have not
+been labelled yet. Label one with:
+
+  cst outcomes set <session> <label> [note...]
+
+Labels are free-form; a small fixed set such as shipped, partial, abandoned
+and exploration makes them easy to count later, e.g. with
+cst query 'outcome = shipped'.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		sessions, err := s.ListUnlabeled()
+		if err != nil {
+			return err
+		}
+		if len(sessions) == 0 {
+			fmt.Println("No sessions waiting for an outcome.")
+			return nil
+		}
+
+		fmt.Printf("%-8s  %-10s  %-20s  %s\n", "ID", "ENDED", "PROJECT", "LAST PROMPT")
+		for _, sess := range sessions {
+			project := textutil.Truncate(filepath.Base(sess.Project), 20, "...")
+			prompt := sess.LastPrompt
+			if prompt == "" {
+				prompt = "(none)"
+			}
+			prompt = textutil.Truncate(prompt, 60, "...")
+			fmt.Printf("%-8s  %-10s  %-20s  %s\n", textutil.ShortID(sess.ID), launcher.FormatRelativeTime(sess.LastActivity), project, prompt)
+		}
+		return nil
+	},
+}
+
+var outcomesSetCmd = &cobra.Command{
+	Use:   "set <session> <label> [note...]",
+	Short: "Label how a session went, e.g. shipped or abandoned",
+	Long: `Label how a session went, with an optional note. An empty label ("")
+clears the outcome. Any session can be labelled, not only those listed by
+cst outcomes.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		sess, err := lookupSession(s, args[0])
+		if err != nil {
+			return err
+		}
+		label := strings.TrimSpace(args[1])
+		note := strings.TrimSpace(strings.Join(args[2:], " "))
+		if err := s.SetOutcome(sess.ID, label, note, time.Now().UnixMilli()); err != nil {
+			return err
+		}
+		if label == "" {
+			fmt.Printf("Cleared outcome of session %s\n", textutil.ShortID(sess.ID))
+		} else {
+			fmt.Printf("Session %s: %s\n", textutil.ShortID(sess.ID), label)
+		}
+		return nil
+	},
+}
+
+func init() {
+	outcomesCmd.AddCommand(outcomesSetCmd)
+}
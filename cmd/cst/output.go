@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+// JSONSchemaVersion is the version of cst's machine-readable output
+// (list --json, query, version --json). Within a version, fields may be
+// added but are never removed, renamed or changed in meaning; doing any of
+// those requires bumping it.
+const JSONSchemaVersion = 1
+
+// sessionList is the top-level JSON object printed by list --json and query.
+type sessionList struct {
+	SchemaVersion int             `json:"schema_version"`
+	Sessions      []sessionRecord `json:"sessions"`
+}
+
+// sessionRecord is the JSON shape of a session in JSON output.
+// Timestamps are milliseconds since the epoch; zero means never.
+type sessionRecord struct {
+	ID             string   `json:"id"`
+	Project        string   `json:"project"`
+	CWD            string   `json:"cwd"`
+	Model          string   `json:"model"`
+	Active         bool     `json:"active"`
+	ReadOnly       bool     `json:"read_only"`
+	Headless       bool     `json:"headless"`
+	ResumeArgs     []string `json:"resume_args,omitempty"`
+	PID            *int     `json:"pid"`
+	StartedAt      int64    `json:"started_at"`
+	LastActivity   int64    `json:"last_activity"`
+	LastPromptAt   int64    `json:"last_prompt_at"`
+	LastToolAt     int64    `json:"last_tool_at"`
+	LastResumeAt   int64    `json:"last_resume_at"`
+	LastPrompt     string   `json:"last_prompt"`
+	FirstPrompt    string   `json:"first_prompt"`
+	PromptCount    int      `json:"prompt_count"`
+	Title          string   `json:"title,omitempty"`
+	Notes          string   `json:"notes,omitempty"`
+	TranscriptPath string   `json:"transcript_path,omitempty"`
+	// Set while Claude is waiting on the user; 0 otherwise.
+	AwaitingSince   int64  `json:"awaiting_since"`
+	AwaitingMessage string `json:"awaiting_message,omitempty"`
+	TTY             string `json:"tty,omitempty"`
+	Terminal        string `json:"terminal,omitempty"`
+	WorkedMS        int64  `json:"worked_ms"`
+	// Read from the transcript by cst daemon; 0 if it never followed the session.
+	InputTokens     int64    `json:"input_tokens"`
+	OutputTokens    int64    `json:"output_tokens"`
+	CacheReadTokens int64    `json:"cache_read_tokens"`
+	ToolUses        int      `json:"tool_uses"`
+	ToolErrors      int      `json:"tool_errors"`
+	EndReason       string   `json:"end_reason,omitempty"`
+	ConcurrentWith  []string `json:"concurrent_with,omitempty"`
+	Branch          string   `json:"branch,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	Links           []string `json:"links,omitempty"`
+	Outcome         string   `json:"outcome,omitempty"`
+	OutcomeNote     string   `json:"outcome_note,omitempty"`
+	Host            string   `json:"host,omitempty"`
+	User            string   `json:"user,omitempty"`
+	PermissionMode  string   `json:"permission_mode,omitempty"`
+	// Name of the extra store the session was read from; omitted for local sessions.
+	Source string `json:"source,omitempty"`
+}
+
+func newSessionRecord(sess store.Session) sessionRecord {
+	return sessionRecord{
+		ID:             sess.ID,
+		Project:        sess.Project,
+		CWD:            sess.CWD,
+		Model:          sess.Model,
+		Active:         sess.Active,
+		ReadOnly:       sess.ReadOnly,
+		PID:            sess.PID,
+		StartedAt:      sess.StartedAt,
+		LastActivity:   sess.LastActivity,
+		LastPromptAt:   sess.LastPromptAt,
+		LastToolAt:     sess.LastToolAt,
+		LastResumeAt:   sess.LastResumeAt,
+		LastPrompt:     sess.LastPrompt,
+		FirstPrompt:    sess.FirstPrompt,
+		PromptCount:    sess.PromptCount,
+		Title:          sess.Title,
+		Headless:       sess.Headless,
+		ResumeArgs:     sess.ResumeArgs,
+		Notes:          sess.Notes,
+		TranscriptPath: sess.TranscriptPath,
+
+		AwaitingSince:   sess.AwaitingSince,
+		AwaitingMessage: sess.AwaitingMessage,
+		TTY:             sess.TTY,
+		Terminal:        sess.Terminal,
+		WorkedMS:        sess.WorkedMS,
+		InputTokens:     sess.InputTokens,
+		OutputTokens:    sess.OutputTokens,
+		CacheReadTokens: sess.CacheReadTokens,
+		ToolUses:        sess.ToolUses,
+		ToolErrors:      sess.ToolErrors,
+		EndReason:       sess.EndReason,
+		ConcurrentWith:  sess.ConcurrentWith,
+		Branch:          sess.Branch,
+		Tags:            sess.Tags,
+		Links:           sess.Links,
+		Outcome:         sess.Outcome,
+		OutcomeNote:     sess.OutcomeNote,
+		Host:            sess.Host,
+		User:            sess.User,
+		PermissionMode:  sess.PermissionMode,
+		Source:          sess.Source,
+	}
+}
+
+func printSessionsJSON(sessions []store.Session) error {
+	out := sessionList{SchemaVersion: JSONSchemaVersion, Sessions: make([]sessionRecord, 0, len(sessions))}
+	for _, sess := range sessions {
+		out.Sessions = append(out.Sessions, newSessionRecord(sess))
+	}
+	return writeJSON(out)
+}
+
+// writeJSON prints v to stdout as indented JSON.
+func writeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeDelimited prints a header row and rows to stdout as CSV, quoted per
+// RFC 4180, or as TSV, where tabs and line breaks in fields become spaces
+// instead, so that every line is one row for awk and cut.
+func writeDelimited(format string, header []string, rows [][]string) error {
+	if format == "tsv" {
+		clean := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+		var b strings.Builder
+		for _, row := range slices.Concat([][]string{header}, rows) {
+			for i, field := range row {
+				if i > 0 {
+					b.WriteByte('\t')
+				}
+				b.WriteString(clean.Replace(field))
+			}
+			b.WriteByte('\n')
+		}
+		_, err := os.Stdout.WriteString(b.String())
+		return err
+	}
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+func printSessionsDelimited(format string, sessions []store.Session, cols []launcher.Column) error {
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.Name
+	}
+	rows := make([][]string, len(sessions))
+	for i, sess := range sessions {
+		rows[i] = make([]string, len(cols))
+		for j, c := range cols {
+			rows[i][j] = c.Value(sess)
+		}
+	}
+	return writeDelimited(format, header, rows)
+}
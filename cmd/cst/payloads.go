@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/hook"
+)
+
+// hookHandlers maps hook event names, as in hook_event_name, to handlers.
+var hookHandlers = map[string]hookHandler{
+	"SessionStart":       hook.HandleSessionStart,
+	"UserPromptSubmit":   hook.HandlePrompt,
+	"PreToolUse":         hook.HandlePreTool,
+	"PostToolUse":        hook.HandleTool,
+	"PostToolUseFailure": hook.HandleToolFailure,
+	"Notification":       hook.HandleNotification,
+	"SessionEnd":         hook.HandleSessionEnd,
+}
+
+var (
+	flagReplayEvent   string
+	flagReplayScripts bool
+)
+
+var hookRecordCmd = &cobra.Command{
+	Use:   "record [on|off]",
+	Short: "Save every raw hook payload to ~/.cst/payloads",
+	Long: `Turn recording of raw hook payloads on or off, or show whether it is on.
+While on, every payload Claude Code sends to a cst hook is saved as
+~/.cst/payloads/<time>-<event>-<session>.json before it is handled, including
+payloads cst cannot decode. The newest 1000 are kept. Payloads hold your
+prompts; the files are readable only by you.
+
+Replay a recording with cst hook replay.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"on", "off"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		dir := config.DefaultPayloadsPath()
+
+		if len(args) == 1 {
+			switch args[0] {
+			case "on":
+				cfg.RecordHookPayloads = true
+			case "off":
+				cfg.RecordHookPayloads = false
+			default:
+				return fmt.Errorf("invalid argument %q, expected on or off", args[0])
+			}
+			if err := config.Save(cfgPath, cfg); err != nil {
+				return err
+			}
+		}
+
+		recorded, err := hook.ListPayloads(dir)
+		if err != nil {
+			return err
+		}
+		state := "off"
+		if cfg.RecordHookPayloads {
+			state = "on"
+		}
+		fmt.Printf("Recording is %s; %d payloads in %s\n", state, len(recorded), dir)
+		if len(recorded) > 0 {
+			fmt.Printf("Latest: %s\n", recorded[len(recorded)-1])
+		}
+		return nil
+	},
+}
+
+var hookReplayCmd = &cobra.Command{
+	Use:   "replay <file>...",
+	Short: "Feed recorded hook payloads back through the handlers",
+	Long: `Handle recorded hook payloads again, in the order given, as if Claude Code
+had just sent them. The handler is chosen by --event, the payload's
+hook_event_name, or the event in the name of the recording. Timestamps are
+those of the replay, not of the recording.
+
+Replays write to the session database like the live hooks do; pass --db with
+a scratch copy to keep them out of your history. Scripts in ~/.cst/hooks.d
+only run with --scripts.`,
+	Example: `  cst hook replay --db /tmp/scratch.db ~/.cst/payloads/*-3f2a91c0.json`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if flagReplayEvent != "" && hookHandlers[flagReplayEvent] == nil {
+			return fmt.Errorf("unknown event %q, expected one of %s", flagReplayEvent, strings.Join(hookEvents(), ", "))
+		}
+		cfg, _ := config.Load(config.DefaultConfigPath())
+
+		failed := 0
+		for _, path := range args {
+			if err := replayPayload(path, cfg); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", filepath.Base(path), err)
+				failed++
+				continue
+			}
+			fmt.Printf("%s: ok\n", filepath.Base(path))
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d payloads failed", failed, len(args))
+		}
+		return nil
+	},
+}
+
+// replayPayload handles the payload recorded at path.
+func replayPayload(path string, cfg config.Config) error {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	event := flagReplayEvent
+	if event == "" {
+		var header struct {
+			HookEventName string `json:"hook_event_name"`
+		}
+		_ = json.NewDecoder(bytes.NewReader(payload)).Decode(&header)
+		event = cmp.Or(header.HookEventName, recordedEvent(path))
+	}
+	if event == "" {
+		return errors.New("payload has no hook_event_name; pass --event")
+	}
+	handler := hookHandlers[event]
+	if handler == nil {
+		return fmt.Errorf("unknown event %q; pass --event", event)
+	}
+	return handlePayload(event, payload, handler, cfg, false, flagReplayScripts)
+}
+
+// recordedEvent returns the event in the name of a file written by
+// hook.RecordPayload, or "" if the name has another form.
+func recordedEvent(path string) string {
+	parts := strings.Split(strings.TrimSuffix(filepath.Base(path), ".json"), "-")
+	if len(parts) != 3 {
+		return ""
+	}
+	return parts[1]
+}
+
+// hookEvents returns the hook event names cst handles, sorted.
+func hookEvents() []string {
+	return slices.Sorted(maps.Keys(hookHandlers))
+}
+
+func init() {
+	hookReplayCmd.Flags().StringVar(&flagReplayEvent, "event", "", "Handle every payload as this event, e.g. UserPromptSubmit")
+	hookReplayCmd.Flags().BoolVar(&flagReplayScripts, "scripts", false, "Also run the scripts in ~/.cst/hooks.d")
+
+	hookCmd.AddCommand(hookRecordCmd)
+	hookCmd.AddCommand(hookReplayCmd)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+)
+
+// --- Profile Commands ---
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "List, create and switch profiles: separate session histories",
+	Long: `Profiles keep session histories apart, such as personal and client work.
+Each has its own config and database under ~/.cst/profiles/<name>; the
+default profile is ~/.cst itself.
+
+The profile in use is the one given with --profile, else $CST_PROFILE, else
+the one chosen with cst profile use. Hooks follow the same rule, so sessions
+record into the profile in use when they send their events, and sessions
+resumed from cst --profile <name> record into that profile.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return profileListCmd.RunE(cmd, args)
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles, marking the one in use",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := config.Profiles()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			mark := " "
+			if name == config.Profile() {
+				mark = "*"
+			}
+			fmt.Printf("%s %-12s  %s\n", mark, name, config.ProfileDir(name))
+		}
+		return nil
+	},
+}
+
+var profileCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.CreateProfile(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Created profile %s in %s. Use it with cst --profile %s or cst profile use %s.\n",
+			args[0], config.ProfileDir(args[0]), args[0], args[0])
+		return nil
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Use a profile from now on, default for ~/.cst",
+	Long: `Use a profile from now on: for cst without --profile, and for the hooks of
+every Claude Code session, unless $CST_PROFILE names another.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.UseProfile(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Using profile %s (%s).\n", args[0], config.ProfileDir(args[0]))
+		return nil
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileCreateCmd)
+	profileCmd.AddCommand(profileUseCmd)
+}
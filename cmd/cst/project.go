@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+// --- Project Command ---
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Manage the project directories sessions are recorded under",
+}
+
+var projectMoveCmd = &cobra.Command{
+	Use:   "move <old-path> <new-path>",
+	Short: "Move sessions to the new directory of their project",
+	Long: `Rewrite the paths recorded under old-path to the same paths under new-path,
+for a repository that was moved or renamed, or a home directory that changed:
+the project and working directory of every session and the directories it
+moved through. Projects inside old-path move along, so moving an old home
+directory moves all of its projects.
+
+cst doctor lists the projects whose directory is gone.`,
+	Example: `  cst project move ~/src/api ~/code/api
+  cst project move /home/olduser /home/newuser`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+		to, err := filepath.Abs(args[1])
+		if err != nil {
+			return err
+		}
+		if from == string(filepath.Separator) {
+			return errors.New("cannot move the root directory")
+		}
+		if info, err := os.Stat(to); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a directory; move the project there first", to)
+		}
+		to = store.ResolvePath(to)
+		if from == to {
+			return fmt.Errorf("%s is already where sessions are recorded", to)
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+		moved, err := s.MoveProject(from, to)
+		if err != nil {
+			return err
+		}
+		if moved == 0 {
+			fmt.Printf("No session has its project in %s\n", from)
+			return nil
+		}
+		fmt.Printf("Moved %d session(s) from %s to %s\n", moved, from, to)
+		return nil
+	},
+}
+
+func init() {
+	projectCmd.AddCommand(projectMoveCmd)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// --- Prompts Command ---
+
+var flagMarkdown bool
+
+var promptsCmd = &cobra.Command{
+	Use:   "prompts <session>",
+	Short: "List a session's recorded prompts with their IDs",
+	Long: `List a session's recorded prompts with their IDs, newest first.
+
+--json and --markdown export the session's notes and all its stored prompts
+in the order they were sent, for example to keep a record of a session:
+
+  cst prompts 3f2a91c0 --markdown > session.md
+
+The launcher copies the same Markdown to the clipboard with y.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if flagJSON && flagMarkdown {
+			return fmt.Errorf("--json and --markdown cannot be combined")
+		}
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		sess, err := lookupSession(s, args[0])
+		if err != nil {
+			return err
+		}
+		prompts, err := s.GetPrompts(sess.ID, store.DefaultMaxPrompt)
+		if err != nil {
+			return err
+		}
+		switch {
+		case flagJSON:
+			return printPromptsJSON(sess, prompts)
+		case flagMarkdown:
+			fmt.Print(launcher.PromptsMarkdown(sess, prompts))
+			return nil
+		}
+		if len(prompts) == 0 {
+			fmt.Println("No prompts recorded.")
+			return nil
+		}
+
+		fmt.Printf("%-8s  %-10s  %s\n", "ID", "WHEN", "PROMPT")
+		for _, p := range prompts {
+			text := p.Text
+			if p.Occurrences > 1 {
+				text += fmt.Sprintf(" (%s%d)", launcher.CurrentGlyphs().Times, p.Occurrences)
+			}
+			fmt.Printf("%-8d  %-10s  %s\n", p.ID, launcher.FormatRelativeTime(p.Timestamp), text)
+		}
+		return nil
+	},
+}
+
+var promptsRmCmd = &cobra.Command{
+	Use:   "rm <session> <prompt-id>...",
+	Short: "Delete individual prompts from a session's history",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		sess, err := lookupSession(s, args[0])
+		if err != nil {
+			return err
+		}
+		prompts, err := s.GetPrompts(sess.ID, store.DefaultMaxPrompt)
+		if err != nil {
+			return err
+		}
+
+		// Validate every ID before deleting anything.
+		var ids []int64
+		for _, arg := range args[1:] {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid prompt ID %q", arg)
+			}
+			if !slices.ContainsFunc(prompts, func(p store.Prompt) bool { return p.ID == id }) {
+				return fmt.Errorf("session %s has no prompt %d (see cst prompts %s)", textutil.ShortID(sess.ID), id, textutil.ShortID(sess.ID))
+			}
+			ids = append(ids, id)
+		}
+
+		for _, id := range ids {
+			if err := s.DeletePrompt(id); err != nil {
+				return fmt.Errorf("delete prompt %d: %w", id, err)
+			}
+			fmt.Printf("Deleted prompt %d from session %s\n", id, textutil.ShortID(sess.ID))
+		}
+		return nil
+	},
+}
+
+// promptExport is the JSON object printed by cst prompts --json.
+type promptExport struct {
+	SchemaVersion int            `json:"schema_version"`
+	Session       sessionRecord  `json:"session"`
+	Prompts       []promptRecord `json:"prompts"`
+}
+
+// promptRecord is the JSON shape of a prompt; the timestamp is milliseconds
+// since the epoch.
+type promptRecord struct {
+	ID          int64  `json:"id"`
+	Timestamp   int64  `json:"timestamp"`
+	Text        string `json:"text"`
+	Occurrences int    `json:"occurrences"`
+}
+
+// printPromptsJSON prints the session and its prompts, given newest first,
+// oldest first.
+func printPromptsJSON(sess store.Session, prompts []store.Prompt) error {
+	out := promptExport{SchemaVersion: JSONSchemaVersion, Session: newSessionRecord(sess), Prompts: make([]promptRecord, 0, len(prompts))}
+	for _, p := range slices.Backward(prompts) {
+		out.Prompts = append(out.Prompts, promptRecord{ID: p.ID, Timestamp: p.Timestamp, Text: p.Text, Occurrences: p.Occurrences})
+	}
+	return writeJSON(out)
+}
+
+func init() {
+	promptsCmd.AddCommand(promptsRmCmd)
+	promptsCmd.Flags().BoolVar(&flagJSON, "json", false, "Export the session and its prompts as JSON")
+	promptsCmd.Flags().BoolVar(&flagMarkdown, "markdown", false, "Export the session's notes and prompts as Markdown")
+}
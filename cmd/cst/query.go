@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+// --- Query Command ---
+
+var (
+	flagWhere  []string
+	flagOrder  string
+	flagLimit  int
+	flagFields bool
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Query sessions with filters and print JSON",
+	Long: `Query sessions and print them as JSON (see README for the output schema).
+
+Each --where takes "<field> <op> <value>"; multiple filters are ANDed.
+Operators: = != < <= > >= ~ (contains, case-insensitive) !~ (does not contain).
+Time fields accept an age ("90m", "7d"), a date (2006-01-02), an RFC 3339
+timestamp or milliseconds since the epoch. Run with --fields to list fields.
+
+Examples:
+  cst query --where 'project ~ api' --where 'last_activity > 7d'
+  cst query --where 'prompt ~ migration' --order started_at --limit 5
+  cst query --where 'active = false' --where 'model ~ opus' | jq -r '.[].id'`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if flagFields {
+			printQueryFields()
+			return nil
+		}
+
+		now := time.Now()
+		var filters []store.Filter
+		for _, expr := range flagWhere {
+			f, err := store.ParseFilter(expr, now)
+			if err != nil {
+				return err
+			}
+			filters = append(filters, f)
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		sessions, err := s.QuerySessions(filters, flagOrder, flagLimit)
+		if err != nil {
+			return err
+		}
+
+		return printSessionsJSON(sessions)
+	},
+}
+
+func init() {
+	queryCmd.Flags().StringArrayVar(&flagWhere, "where", nil, "Filter as '<field> <op> <value>' (repeatable)")
+	queryCmd.Flags().StringVar(&flagOrder, "order", "-last_activity", "Field to sort by; prefix with - for descending")
+	queryCmd.Flags().IntVar(&flagLimit, "limit", 0, "Maximum number of sessions (0 for all)")
+	queryCmd.Flags().BoolVar(&flagFields, "fields", false, "List queryable fields and exit")
+}
+
+func printQueryFields() {
+	kinds := map[store.FieldKind]string{
+		store.FieldText: "text",
+		store.FieldTime: "time",
+		store.FieldBool: "bool",
+		store.FieldInt:  "int",
+	}
+	fmt.Printf("%-16s  %-5s  %s\n", "FIELD", "TYPE", "DESCRIPTION")
+	for _, f := range store.QueryFields {
+		fmt.Printf("%-16s  %-5s  %s\n", f.Name, kinds[f.Kind], f.Doc)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/report"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+// --- Report Command ---
+
+var (
+	flagReportSince    string
+	flagReportFormat   string
+	flagReportOutput   string
+	flagReportTemplate string
+	flagReportTop      int
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Summarize recent usage as Markdown or HTML, e.g. for a retro",
+	Long: `Write a summary of the sessions active in the last --since: sessions, prompts
+and time worked per project, the model mix, and the prompts sent most often.
+The report is built locally from the session database; nothing is sent
+anywhere.
+
+The format is Markdown unless --format html is given or --output ends in
+.html. --template renders a Go template of your own instead of the built-in
+one, with the same data and functions (see the README). Top prompts only
+count the last prompts each session keeps.`,
+	Example: `  cst report --since 14d > retro.md
+  cst report -p . -o report.html`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		age, err := parseAge(flagReportSince)
+		if err != nil {
+			return err
+		}
+		format := flagReportFormat
+		if format == "" {
+			format = report.FormatMarkdown
+			if ext := strings.ToLower(filepath.Ext(flagReportOutput)); ext == ".html" || ext == ".htm" {
+				format = report.FormatHTML
+			}
+		}
+		project := flagProject
+		if project != "" {
+			if project, err = filepath.Abs(project); err != nil {
+				return err
+			}
+			project = store.ResolvePath(project)
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		now := time.Now()
+		data := report.Data{Since: now.Add(-age), Generated: now, Project: project}
+		if data.Usage, err = s.Usage(data.Since, project, flagReportTop); err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if flagReportOutput != "" {
+			f, err := os.Create(flagReportOutput)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = f.Close() }()
+			w = f
+		}
+		if err := report.Render(w, format, flagReportTemplate, data); err != nil {
+			return err
+		}
+		if flagReportOutput != "" {
+			fmt.Fprintf(os.Stderr, "Wrote %s\n", flagReportOutput)
+		}
+		return nil
+	},
+}
+
+func init() {
+	reportCmd.Flags().StringVar(&flagReportSince, "since", "7d", "Cover sessions active within this long (e.g. 7d, 14d, 12h)")
+	reportCmd.Flags().StringVarP(&flagProject, "project", "p", "", "Only report on this project")
+	reportCmd.Flags().StringVar(&flagReportFormat, "format", "", "Output format: "+strings.Join(report.Formats, " or ")+" (default from --output, else markdown)")
+	reportCmd.Flags().StringVarP(&flagReportOutput, "output", "o", "", "Write the report to this file instead of stdout")
+	reportCmd.Flags().StringVar(&flagReportTemplate, "template", "", "Render this Go template file instead of the built-in one")
+	reportCmd.Flags().IntVar(&flagReportTop, "top", 10, "Number of top prompts listed (0 for none)")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// --- Set-Args Command ---
+
+var setArgsCmd = &cobra.Command{
+	Use:   "set-args <session> [-- claude args...]",
+	Short: "Set the claude arguments a session is always resumed with",
+	Long: `Store claude arguments on a session, used whenever it is resumed from the
+launcher or by cst continue. They override flag by flag the arguments from
+project_args in the config, which override extra_args and the other global
+settings; arguments given after -- when resuming override them all.
+
+Without --, show the session's arguments. A -- with nothing after it clears
+them.`,
+	Example: `  cst set-args 3f2a91c0 -- --model opus
+  cst set-args 3f2a91c0 --`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dash := cmd.ArgsLenAtDash()
+		if dash > 1 || dash < 0 && len(args) > 1 {
+			return errors.New("give one session, then the claude arguments after --")
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		sess, err := lookupSession(s, args[0])
+		if err != nil {
+			return err
+		}
+		if dash < 0 {
+			if len(sess.ResumeArgs) == 0 {
+				fmt.Printf("Session %s has no arguments of its own\n", textutil.ShortID(sess.ID))
+			} else {
+				fmt.Printf("Session %s: %s\n", textutil.ShortID(sess.ID), strings.Join(sess.ResumeArgs, " "))
+			}
+			return nil
+		}
+
+		claudeArgs := args[dash:]
+		if err := s.SetResumeArgs(sess.ID, claudeArgs); err != nil {
+			return err
+		}
+		if len(claudeArgs) == 0 {
+			fmt.Printf("Cleared arguments of session %s\n", textutil.ShortID(sess.ID))
+		} else {
+			fmt.Printf("Session %s: %s\n", textutil.ShortID(sess.ID), strings.Join(claudeArgs, " "))
+		}
+		return nil
+	},
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/redact"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+	"github.com/imyousuf/claude-session-tracker/internal/transcript"
+)
+
+// --- Share Command ---
+
+var (
+	flagShareFormat     string
+	flagShareOutput     string
+	flagShareNote       string
+	flagShareTranscript int
+)
+
+// shareFormats are the values of cst share --format.
+var shareFormats = []string{"markdown", "text"}
+
+var shareCmd = &cobra.Command{
+	Use:   "share <session-id>",
+	Short: "Write a redacted summary of a session to hand to a teammate",
+	Long: `Write a self-contained summary of a session: its details, the files it
+changed, every stored prompt and, with --transcript N, the last N messages
+between you and Claude. It is Markdown, or plain text with --format text,
+ready to paste into a gist, an issue or a chat.
+
+Before it is written, secrets are replaced with [REDACTED]: private keys,
+cloud and API tokens, passwords in assignments and URLs, bearer tokens and
+email addresses. Paths under your home directory are shortened to ~. Add
+patterns of your own with redact_patterns in cst config set. Redaction
+catches common formats, not everything, so read the result before sharing.
+
+Unlike cst bundle, which copies the whole session for cst bundle import,
+a share is for people to read. The session ID may be a unique prefix.`,
+	Example: `  cst share 3f2a91c0 > session.md
+  cst share 3f2a91c0 --transcript 20 --note "where the retry loop came from"
+  cst share 3f2a91c0 --format text | gh gist create -f session.txt -`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !slices.Contains(shareFormats, flagShareFormat) {
+			return fmt.Errorf("unknown --format %q (want %s)", flagShareFormat, strings.Join(shareFormats, " or "))
+		}
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			return err
+		}
+		home, _ := os.UserHomeDir()
+		r, err := redact.New(home, cfg.RedactPatterns)
+		if err != nil {
+			return err
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		sess, err := lookupSession(s, args[0])
+		if err != nil {
+			return err
+		}
+		prompts, err := s.GetPrompts(sess.ID, store.DefaultMaxPrompt)
+		if err != nil {
+			return err
+		}
+		slices.Reverse(prompts) // oldest first
+
+		sh := share{sess: sess, prompts: prompts, note: flagShareNote, redactor: r}
+		if path := sessionTranscript(sess); path != "" {
+			if sh.files, err = transcript.FilesTouched(path); err != nil {
+				return fmt.Errorf("read transcript: %w", err)
+			}
+			if flagShareTranscript > 0 {
+				if sh.messages, err = transcript.Messages(path, flagShareTranscript); err != nil {
+					return fmt.Errorf("read transcript: %w", err)
+				}
+			}
+		} else if flagShareTranscript > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: no transcript found for session %s; sharing prompts only\n", textutil.ShortID(sess.ID))
+		}
+
+		var doc string
+		if flagShareFormat == "text" {
+			doc = sh.text()
+		} else {
+			doc = sh.markdown()
+		}
+		doc = r.String(doc)
+
+		if flagShareOutput == "" {
+			fmt.Print(doc)
+		} else if err := os.WriteFile(flagShareOutput, []byte(doc), 0600); err != nil {
+			return err
+		}
+		if n := r.Count(); n > 0 {
+			fmt.Fprintf(os.Stderr, "Secrets redacted: %d.\n", n)
+		}
+		if flagShareOutput != "" {
+			fmt.Fprintf(os.Stderr, "Wrote %s.\n", flagShareOutput)
+		}
+		return nil
+	},
+}
+
+// share is what cst share writes about a session.
+type share struct {
+	sess     store.Session
+	prompts  []store.Prompt // oldest first
+	note     string
+	files    []transcript.TouchedFile
+	messages []transcript.Message
+	redactor *redact.Redactor
+}
+
+// title names the session by its title, else its first prompt. It is
+// redacted before it is shortened, which could cut a secret short of its
+// pattern.
+func (sh share) title() string {
+	title := sh.redactor.String(cmp.Or(sh.sess.Title, sh.sess.FirstPrompt, "(no prompts)"))
+	return textutil.Truncate(strings.Join(strings.Fields(title), " "), 80, "...")
+}
+
+// details lists the session's attributes as label and value pairs.
+func (sh share) details() [][2]string {
+	sess := sh.sess
+	details := [][2]string{{"Session", sess.ID}, {"Project", sess.Project}}
+	if sess.Branch != "" {
+		details = append(details, [2]string{"Branch", sess.Branch})
+	}
+	if sess.Model != "" {
+		details = append(details, [2]string{"Model", sess.Model})
+	}
+	details = append(details,
+		[2]string{"Started", shareTime(sess.StartedAt)},
+		[2]string{"Last active", shareTime(sess.LastActivity)})
+	if sess.WorkedMS > 0 {
+		details = append(details, [2]string{"Worked", launcher.FormatDuration(sess.WorkedMS)})
+	}
+	prompts := fmt.Sprint(sess.PromptCount)
+	if kept := len(sh.prompts); kept < sess.PromptCount {
+		prompts += fmt.Sprintf(" (the latest %d are kept)", kept)
+	}
+	details = append(details, [2]string{"Prompts", prompts})
+	if len(sess.Tags) > 0 {
+		details = append(details, [2]string{"Tags", strings.Join(sess.Tags, ", ")})
+	}
+	if len(sess.Links) > 0 {
+		details = append(details, [2]string{"Links", strings.Join(sess.Links, " ")})
+	}
+	if sess.Outcome != "" {
+		details = append(details, [2]string{"Outcome", strings.TrimSpace(sess.Outcome + " " + sess.OutcomeNote)})
+	}
+	return details
+}
+
+// relFile returns path relative to the session's project, if it is inside.
+func (sh share) relFile(path string) string {
+	if rel, ok := strings.CutPrefix(path, sh.sess.Project+"/"); ok {
+		return rel
+	}
+	return path
+}
+
+func (sh share) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", sh.title())
+	for _, d := range sh.details() {
+		switch d[0] {
+		case "Session", "Project", "Branch":
+			fmt.Fprintf(&b, "- %s: `%s`\n", d[0], d[1])
+		default:
+			fmt.Fprintf(&b, "- %s: %s\n", d[0], d[1])
+		}
+	}
+	if sh.note != "" {
+		fmt.Fprintf(&b, "\n## Note\n\n%s\n", sh.note)
+	}
+	if sh.sess.Notes != "" {
+		fmt.Fprintf(&b, "\n## Session notes\n\n%s\n", sh.sess.Notes)
+	}
+	if len(sh.files) > 0 {
+		b.WriteString("\n## Files changed\n\n")
+		for _, f := range sh.files {
+			fmt.Fprintf(&b, "- `%s` (edits: %d)\n", sh.relFile(f.Path), f.Edits)
+		}
+	}
+	b.WriteString("\n## Prompts\n")
+	if len(sh.prompts) == 0 {
+		b.WriteString("\nNo prompts recorded.\n")
+	}
+	for i, p := range sh.prompts {
+		fmt.Fprintf(&b, "\n### %d. %s%s\n\n%s\n", i+1, shareTime(p.Timestamp), shareRepeats(p), p.Text)
+	}
+	if len(sh.messages) > 0 {
+		fmt.Fprintf(&b, "\n## Transcript (last %d messages)\n", len(sh.messages))
+		for _, m := range sh.messages {
+			fmt.Fprintf(&b, "\n**%s**%s\n\n%s\n", shareRole(m), shareMessageTime(m, " · "), m.Text)
+		}
+	}
+	fmt.Fprintf(&b, "\n---\n\nShared with cst %s. Secrets were redacted.\n", Version)
+	return b.String()
+}
+
+func (sh share) text() string {
+	var b strings.Builder
+	title := sh.title()
+	fmt.Fprintf(&b, "%s\n%s\n\n", title, strings.Repeat("=", len([]rune(title))))
+	width := 0
+	for _, d := range sh.details() {
+		width = max(width, len(d[0]))
+	}
+	for _, d := range sh.details() {
+		fmt.Fprintf(&b, "%-*s  %s\n", width+1, d[0]+":", d[1])
+	}
+	section := func(name string) {
+		fmt.Fprintf(&b, "\n%s\n%s\n\n", name, strings.Repeat("-", len(name)))
+	}
+	if sh.note != "" {
+		section("Note")
+		b.WriteString(sh.note + "\n")
+	}
+	if sh.sess.Notes != "" {
+		section("Session notes")
+		b.WriteString(sh.sess.Notes + "\n")
+	}
+	if len(sh.files) > 0 {
+		section("Files changed")
+		for _, f := range sh.files {
+			fmt.Fprintf(&b, "  %s (edits: %d)\n", sh.relFile(f.Path), f.Edits)
+		}
+	}
+	section("Prompts")
+	if len(sh.prompts) == 0 {
+		b.WriteString("No prompts recorded.\n")
+	}
+	for i, p := range sh.prompts {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%d. %s%s\n%s\n", i+1, shareTime(p.Timestamp), shareRepeats(p), indent(p.Text, "   "))
+	}
+	if len(sh.messages) > 0 {
+		section(fmt.Sprintf("Transcript (last %d messages)", len(sh.messages)))
+		for i, m := range sh.messages {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "%s%s:\n%s\n", shareRole(m), shareMessageTime(m, ", "), indent(m.Text, "   "))
+		}
+	}
+	fmt.Fprintf(&b, "\n--\nShared with cst %s. Secrets were redacted.\n", Version)
+	return b.String()
+}
+
+func shareTime(ms int64) string {
+	if ms == 0 {
+		return "unknown"
+	}
+	return time.UnixMilli(ms).Format("2006-01-02 15:04")
+}
+
+// shareRepeats marks a prompt sent several times in a row.
+func shareRepeats(p store.Prompt) string {
+	if p.Occurrences > 1 {
+		return fmt.Sprintf(" (sent %d times)", p.Occurrences)
+	}
+	return ""
+}
+
+func shareRole(m transcript.Message) string {
+	if m.Role == "assistant" {
+		return "Claude"
+	}
+	return "User"
+}
+
+// shareMessageTime returns the time of m after sep, or "" if it has none.
+func shareMessageTime(m transcript.Message, sep string) string {
+	if m.Time.IsZero() {
+		return ""
+	}
+	return sep + m.Time.Local().Format("2006-01-02 15:04")
+}
+
+// indent prefixes every line of s with prefix.
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+func init() {
+	shareCmd.Flags().StringVar(&flagShareFormat, "format", "markdown", "Output format: markdown or text")
+	shareCmd.Flags().StringVarP(&flagShareOutput, "output", "o", "", "Write to this file instead of stdout")
+	shareCmd.Flags().StringVar(&flagShareNote, "note", "", "A note for the reader, put before the prompts")
+	shareCmd.Flags().IntVar(&flagShareTranscript, "transcript", 0, "Include the last N messages of the transcript")
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+// --- Stats Command ---
+
+var (
+	flagWeeks        int
+	flagStatsFormat  string
+	flagStatsColumns string
+	flagStatsTools   bool
+)
+
+// statsColumns are the columns of cst stats --format csv and tsv, one row
+// per day of the heatmap.
+var statsColumns = map[string]func(day time.Time, prompts int) string{
+	"date":    func(day time.Time, _ int) string { return day.Format(time.DateOnly) },
+	"weekday": func(day time.Time, _ int) string { return day.Weekday().String() },
+	"prompts": func(_ time.Time, prompts int) string { return strconv.Itoa(prompts) },
+}
+
+// defaultStatsColumns are the columns of cst stats --format csv and tsv
+// without --columns.
+var defaultStatsColumns = []string{"date", "weekday", "prompts"}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show session totals and a heatmap of prompts per day",
+	Long: `Show session totals and a calendar heatmap of prompts per day.
+
+The heatmap counts prompts still in the history, which keeps the last 10
+prompts per session, so very busy sessions are undercounted.
+
+--format csv and --format tsv print the heatmap's days instead, one row
+each, oldest first, with a header row. --columns picks among date, weekday
+and prompts:
+
+  cst stats --weeks 53 --format csv > prompts.csv
+
+--tools prints the calls of each tool across sessions instead of the
+heatmap. Tools are only counted with tool_stats on, see cst config.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if flagWeeks < 1 || flagWeeks > 53 {
+			return fmt.Errorf("--weeks must be between 1 and 53")
+		}
+		if flagStatsFormat != "" {
+			return printStatsDelimited()
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		sum, err := s.Summary()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Sessions: %d (%d active) across %d projects\n", sum.Sessions, sum.Active, sum.Projects)
+		fmt.Printf("Prompts:  %d recorded\n", sum.Prompts)
+		fmt.Printf("Worked:   %s (pauses over the idle gap excluded)\n", launcher.FormatDuration(sum.WorkedMS))
+		if size, err := s.Size(); err == nil {
+			fmt.Printf("Database: %s\n", formatDBSize(size))
+		}
+		agents, err := s.AgentPrompts()
+		if err != nil {
+			return err
+		}
+		if len(agents) > 0 {
+			fmt.Println("\nPrompts to subagents:")
+			for _, a := range agents {
+				fmt.Printf("  %-20s %5d in %d session(s)\n", a.Agent, a.Prompts, a.Sessions)
+			}
+		}
+		fmt.Println()
+
+		if flagStatsTools {
+			return printToolUsage(s)
+		}
+		today := time.Now()
+		start := heatmapStart(today, flagWeeks)
+		days, err := s.PromptsPerDay(start)
+		if err != nil {
+			return err
+		}
+		fmt.Print(renderHeatmap(days, start, today))
+		return nil
+	},
+}
+
+// printToolUsage prints the calls of each tool, most used first, with a bar
+// of their share of the most used.
+func printToolUsage(s *store.Store) error {
+	tools, err := s.ToolUsage()
+	if err != nil {
+		return err
+	}
+	if len(tools) == 0 {
+		fmt.Println("No tool calls counted. Turn on tool_stats to count them:")
+		fmt.Println("  cst config set tool_stats true")
+		return nil
+	}
+	fmt.Println("Tool calls:")
+	peak := tools[0].Uses()
+	for _, t := range tools {
+		fmt.Printf("  %-20s %6d in %4d session(s)  %s\n", t.Tool, t.Uses(), t.Sessions, launcher.ToolBar(t.Uses(), peak, 30))
+	}
+	return nil
+}
+
+// printStatsDelimited prints the prompts per day of the heatmap as CSV or
+// TSV.
+func printStatsDelimited() error {
+	if flagStatsFormat != "csv" && flagStatsFormat != "tsv" {
+		return fmt.Errorf("unknown --format %q, expected csv or tsv", flagStatsFormat)
+	}
+	names := defaultStatsColumns
+	if flagStatsColumns != "" {
+		names = splitArgs(flagStatsColumns)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("--columns: no columns given")
+	}
+	for _, name := range names {
+		if statsColumns[name] == nil {
+			return fmt.Errorf("--columns: unknown column %q, expected one of %s", name, strings.Join(defaultStatsColumns, ", "))
+		}
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = s.Close() }()
+
+	today := time.Now()
+	start := heatmapStart(today, flagWeeks)
+	days, err := s.PromptsPerDay(start)
+	if err != nil {
+		return err
+	}
+	var rows [][]string
+	for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+		row := make([]string, len(names))
+		for i, name := range names {
+			row[i] = statsColumns[name](day, days[day.Format(time.DateOnly)])
+		}
+		rows = append(rows, row)
+	}
+	return writeDelimited(flagStatsFormat, names, rows)
+}
+
+// formatDBSize describes the size of the database, and how it compares to
+// max_db_size_mb if that is set.
+func formatDBSize(size store.DBSize) string {
+	text := formatBytes(size.File)
+	if size.WAL > 0 {
+		text += fmt.Sprintf(" + %s write-ahead log", formatBytes(size.WAL))
+	}
+	cfg, _ := config.Load(config.DefaultConfigPath())
+	if cfg.MaxDBSizeMB > 0 {
+		text += fmt.Sprintf(" (max_db_size_mb %d", cfg.MaxDBSizeMB)
+		if size.Total() > int64(cfg.MaxDBSizeMB)<<20 {
+			text += ", over: run cst cleanup"
+		}
+		text += ")"
+	}
+	return text
+}
+
+func init() {
+	statsCmd.Flags().IntVar(&flagWeeks, "weeks", 12, "Number of weeks shown in the heatmap")
+	statsCmd.Flags().StringVar(&flagStatsFormat, "format", "", "Print prompts per day as csv or tsv instead")
+	statsCmd.Flags().BoolVar(&flagStatsTools, "tools", false, "Print the calls of each tool instead of the heatmap")
+	statsCmd.Flags().StringVar(&flagStatsColumns, "columns", "", "Comma-separated columns of --format csv or tsv, in order: "+strings.Join(defaultStatsColumns, ", "))
+}
+
+// heatmapColors color the heatmap cells for increasing activity; the glyphs
+// of the cells keep the levels distinguishable when colors are unavailable.
+var heatmapColors = []lipgloss.Color{"#3a3a3a", "#0e4429", "#006d32", "#26a641", "#39d353"}
+
+// heatmapLevels returns the rendered cells for increasing activity.
+func heatmapLevels() []string {
+	levels := make([]string, len(heatmapColors))
+	for i, c := range heatmapColors {
+		levels[i] = lipgloss.NewStyle().Foreground(c).Render(launcher.CurrentGlyphs().Heat[i])
+	}
+	return levels
+}
+
+// heatmapStart returns midnight on the Sunday that begins the first of weeks
+// columns, the last of which contains today.
+func heatmapStart(today time.Time, weeks int) time.Time {
+	y, m, d := today.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, today.Location())
+	return midnight.AddDate(0, 0, -int(today.Weekday())-7*(weeks-1))
+}
+
+// renderHeatmap draws one column per week and one row per weekday, in the
+// style of a GitHub contribution graph, with month labels above the columns.
+func renderHeatmap(days map[string]int, start, today time.Time) string {
+	weeks := int(today.Sub(start).Hours()/24)/7 + 1
+
+	levels := heatmapLevels()
+	peak, total := 0, 0
+	for _, n := range days {
+		peak = max(peak, n)
+		total += n
+	}
+	level := func(n int) int {
+		if n == 0 || peak == 0 {
+			return 0
+		}
+		return (n*(len(levels)-1) + peak - 1) / peak // ceil, so any activity shows
+	}
+
+	var b strings.Builder
+
+	// Month labels: each cell is two columns wide; a label needs four.
+	labels := []rune(strings.Repeat(" ", 4+2*weeks+4))
+	lastMonth, nextFree := time.Month(0), 0
+	for w := range weeks {
+		day := start.AddDate(0, 0, 7*w)
+		// Skip a partial first month when the next one starts right after.
+		skip := w == 0 && day.AddDate(0, 0, 7).Month() != day.Month()
+		if day.Month() != lastMonth && 4+2*w >= nextFree && !skip {
+			copy(labels[4+2*w:], []rune(day.Format("Jan")))
+			nextFree = 4 + 2*w + 4
+		}
+		lastMonth = day.Month()
+	}
+	b.WriteString(strings.TrimRight(string(labels), " "))
+	b.WriteString("\n")
+
+	for wd := range 7 {
+		label := ""
+		if wd%2 == 1 {
+			label = time.Weekday(wd).String()[:3]
+		}
+		fmt.Fprintf(&b, "%-4s", label)
+		for w := range weeks {
+			day := start.AddDate(0, 0, 7*w+wd)
+			if day.After(today) {
+				break
+			}
+			b.WriteString(levels[level(days[day.Format(time.DateOnly)])])
+			b.WriteString(" ")
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "\n%d prompts in the last %d weeks   Less %s More\n",
+		total, weeks, strings.Join(levels, " "))
+	return b.String()
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/procutil"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// --- Status Command ---
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show running sessions, highlighting those waiting on you",
+	Long: `Show running sessions across all projects. Sessions that Claude has
+flagged through the Notification hook as needing permission or input are
+listed first, longest wait first, until the session sees new activity.
+
+With --format, print a single line instead, for status bars such as tmux's
+status-right, starship or i3bar. FORMAT is "line", for a summary such as
+"● 2 active · api-server 3m ago", or a Go template over these fields:
+
+  .Active   number of running sessions
+  .Waiting  number of them waiting on you
+  .Project  directory name of the most recently active one
+  .Dir      its full project path
+  .Ago      how long since its last activity, e.g. "3m ago"
+  .ID       its short ID
+  .Crowded  projects with more than one running session, such as
+            "2 active in api-server", when warn_on_multiple_active_per_project
+            is set
+
+The line comes from a single query of the sessions table. Sessions whose
+claude process is gone are left out but not ended, as cst status does
+without --format.`,
+	Example: `  cst status --format line
+  cst status --format '{{if .Waiting}}! {{.Waiting}}{{else}}{{.Active}}{{end}}'
+  set -g status-right '#(cst status --format line)'   # in ~/.tmux.conf`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if flagStatusFormat != "" {
+			return printStatusLine(flagStatusFormat)
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		if err := s.RefreshActive(procutil.IsProcessAlive); err != nil {
+			return err
+		}
+		sessions, err := s.ListAll()
+		if err != nil {
+			return err
+		}
+
+		active := sessions[:0]
+		for _, sess := range sessions {
+			if sess.Active {
+				active = append(active, sess)
+			}
+		}
+		if len(active) == 0 {
+			fmt.Println("No running sessions.")
+			return nil
+		}
+
+		waiting := awaitingFirst(active)
+		fmt.Printf("%d running, %d waiting on you\n", len(active), waiting)
+		if warnCrowded() {
+			for _, crowded := range launcher.CrowdedProjects(active) {
+				fmt.Printf("! %s\n", crowded)
+			}
+		}
+		fmt.Println()
+		for i, sess := range active {
+			where := sess.Project
+			if term := launcher.FormatTerminal(sess); term != "" {
+				where += "  (" + term + ")"
+			}
+			if i < waiting {
+				message := sess.AwaitingMessage
+				if message == "" {
+					message = "waiting for input"
+				}
+				fmt.Printf("! %-8s  waiting %-11s  %s\n", textutil.ShortID(sess.ID), waitingFor(sess), where)
+				fmt.Printf("  %-8s  %s\n", "", message)
+				continue
+			}
+			fmt.Printf("  %-8s  active  %-11s  %s\n", textutil.ShortID(sess.ID), launcher.FormatRelativeTime(sess.LastActivity), where)
+		}
+		return nil
+	},
+}
+
+var flagStatusFormat string
+
+// statusLineFormat is the template of cst status --format line, taking the
+// active and idle markers and the separator from the glyphs in use.
+const statusLineFormat = `{{if .Active}}%[1]s {{.Active}} active{{if .Waiting}} %[3]s {{.Waiting}} waiting{{end}} %[3]s {{.Project}} {{.Ago}}{{if .Crowded}} %[3]s {{.Crowded}}{{end}}{{else}}%[2]s no sessions{{end}}`
+
+// statusLine is the data of the cst status --format template.
+type statusLine struct {
+	Active  int
+	Waiting int
+	Project string
+	Dir     string
+	Ago     string
+	ID      string
+	Crowded string
+}
+
+// statusLineTimeout bounds the query of cst status --format, so that status
+// bars polling it never pile up behind a slow filesystem.
+const statusLineTimeout = time.Second
+
+// printStatusLine prints the running sessions as one line rendered from
+// format, "line" or a template. It neither refreshes nor writes the store,
+// to stay fast enough to run from a status bar every few seconds.
+func printStatusLine(format string) error {
+	if format == "line" {
+		g := launcher.CurrentGlyphs()
+		format = fmt.Sprintf(statusLineFormat, g.Active, g.Idle, g.Dot)
+	}
+	tmpl, err := template.New("status").Parse(format)
+	if err != nil {
+		return fmt.Errorf("parse --format: %w", err)
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = s.Close() }()
+	s.SetTimeout(statusLineTimeout)
+	sessions, err := s.ListRunning()
+	if err != nil {
+		return err
+	}
+
+	var line statusLine
+	var alive []store.Session
+	for _, sess := range sessions {
+		if sess.PID == nil || !procutil.IsProcessAlive(*sess.PID) {
+			continue
+		}
+		alive = append(alive, sess)
+		if line.Active == 0 {
+			line.Project = filepath.Base(sess.Project)
+			line.Dir = sess.Project
+			line.Ago = launcher.FormatRelativeTime(sess.LastActivity)
+			line.ID = textutil.ShortID(sess.ID)
+		}
+		line.Active++
+		if sess.AwaitingSince > 0 {
+			line.Waiting++
+		}
+	}
+
+	if warnCrowded() {
+		line.Crowded = strings.Join(launcher.CrowdedProjects(alive), ", ")
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, line); err != nil {
+		return fmt.Errorf("render --format: %w", err)
+	}
+	fmt.Println(strings.TrimRight(b.String(), "\n"))
+	return nil
+}
+
+// warnCrowded reports whether warn_on_multiple_active_per_project is set.
+// An unreadable config leaves it off, as status bars have nowhere to show
+// the error.
+func warnCrowded() bool {
+	cfg, _ := config.Load(config.DefaultConfigPath())
+	return cfg.WarnOnMultipleActivePerProject
+}
+
+func init() {
+	statusCmd.Flags().StringVar(&flagStatusFormat, "format", "", `Print one line: "line" or a Go template (see above)`)
+}
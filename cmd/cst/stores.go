@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+// --- Secondary Stores ---
+
+// openSecondaries opens the extra_stores from config read-only. A store that
+// cannot be opened, e.g. an unmounted remote copy, is skipped with a warning
+// so that the local sessions are still listed.
+func openSecondaries(cfg config.Config) []store.Secondary {
+	var secondaries []store.Secondary
+	for _, extra := range cfg.ExtraStores {
+		s, err := store.OpenReadOnly(extra.ExpandedPath())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping store %s: %v\n", extra.Name, err)
+			continue
+		}
+		secondaries = append(secondaries, store.Secondary{Name: extra.Name, Store: s})
+	}
+	return secondaries
+}
+
+func closeSecondaries(secondaries []store.Secondary) {
+	for _, sec := range secondaries {
+		_ = sec.Store.Close()
+	}
+}
+
+var configStoreCmd = &cobra.Command{
+	Use:   "store",
+	Short: "List extra session databases shown by list and launch",
+	Long: `Manage extra_stores: additional session databases, such as a copy of
+~/.cst/sessions.db synced or mounted from another machine. Their sessions are
+merged into cst list and the launcher, labelled with the store name, and are
+read-only: they cannot be resumed or deleted from here. The database must be
+written by the same cst version.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			return err
+		}
+		if len(cfg.ExtraStores) == 0 {
+			fmt.Println("No extra stores.")
+			return nil
+		}
+		for _, extra := range cfg.ExtraStores {
+			fmt.Printf("%-12s  %s\n", extra.Name, extra.Path)
+		}
+		return nil
+	},
+}
+
+var configStoreAddCmd = &cobra.Command{
+	Use:   "add <name> <path>",
+	Short: "Show sessions from another database, read-only",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		if err := cfg.AddExtraStore(args[0], args[1]); err != nil {
+			return err
+		}
+		extra := cfg.ExtraStores[len(cfg.ExtraStores)-1]
+		if s, err := store.OpenReadOnly(extra.ExpandedPath()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s cannot be opened yet: %v\n", extra.Path, err)
+		} else {
+			_ = s.Close()
+		}
+		if err := config.Save(cfgPath, cfg); err != nil {
+			return err
+		}
+		fmt.Printf("Added store %s (%s)\n", args[0], args[1])
+		return nil
+	},
+}
+
+var configStoreRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Stop showing sessions from an extra database",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		if !cfg.RemoveExtraStore(args[0]) {
+			return fmt.Errorf("no store named %q", args[0])
+		}
+		if err := config.Save(cfgPath, cfg); err != nil {
+			return err
+		}
+		fmt.Printf("Removed store %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configStoreCmd)
+	configStoreCmd.AddCommand(configStoreAddCmd)
+	configStoreCmd.AddCommand(configStoreRemoveCmd)
+}
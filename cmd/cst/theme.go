@@ -0,0 +1,109 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+)
+
+// --- Theme Colors ---
+
+var configColorCmd = &cobra.Command{
+	Use:   "color",
+	Short: "List color overrides of the custom launcher theme",
+	Long: `Manage theme_colors: per-element colors of the custom launcher theme. The
+custom theme starts from the dark or light theme, matching the terminal
+background, and replaces the colors set here. Setting a color switches the
+theme to custom.
+
+Elements: ` + strings.Join(launcher.ThemeElements, ", ") + `
+Colors are hex (#rgb or #rrggbb) or ANSI color numbers (0-255).`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			return err
+		}
+		if len(cfg.ThemeColors) == 0 {
+			fmt.Println("No theme colors set.")
+			return nil
+		}
+		for _, element := range launcher.ThemeElements {
+			if c, ok := cfg.ThemeColors[element]; ok {
+				fmt.Printf("%-10s  %s\n", element, c)
+			}
+		}
+		if cfg.Theme != "custom" {
+			fmt.Printf("\nNot in use: theme is %s. Run `cst config set theme custom` to apply them.\n", cmp.Or(cfg.Theme, launcher.Themes[0]))
+		}
+		return nil
+	},
+}
+
+var configColorSetCmd = &cobra.Command{
+	Use:   "set <element> <color>",
+	Short: "Set a color of the custom launcher theme",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		element, color := args[0], args[1]
+		if err := launcher.ValidateThemeColor(element, color); err != nil {
+			return err
+		}
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		if cfg.ThemeColors == nil {
+			cfg.ThemeColors = make(map[string]string)
+		}
+		cfg.ThemeColors[element] = color
+		switched := cfg.Theme != "custom"
+		cfg.Theme = "custom"
+		if err := config.Save(cfgPath, cfg); err != nil {
+			return err
+		}
+		fmt.Printf("Set %s = %s\n", element, color)
+		if switched {
+			fmt.Println("Theme set to custom.")
+		}
+		return nil
+	},
+}
+
+var configColorUnsetCmd = &cobra.Command{
+	Use:   "unset <element>...",
+	Short: "Restore default colors of the custom launcher theme",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		for _, element := range args {
+			if _, ok := cfg.ThemeColors[element]; !ok {
+				return fmt.Errorf("%s is not set", element)
+			}
+			delete(cfg.ThemeColors, element)
+		}
+		if err := config.Save(cfgPath, cfg); err != nil {
+			return err
+		}
+		for _, element := range args {
+			fmt.Printf("Unset %s\n", element)
+		}
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configColorCmd)
+	configColorCmd.AddCommand(configColorSetCmd)
+	configColorCmd.AddCommand(configColorUnsetCmd)
+}
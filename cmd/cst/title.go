@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+	"github.com/imyousuf/claude-session-tracker/internal/title"
+)
+
+// --- Title Command ---
+
+var (
+	flagTitleAll    bool
+	flagSummaryOnly bool
+)
+
+var titleCmd = &cobra.Command{
+	Use:   "title [<session> [title...]]",
+	Short: "Title a session from its transcript summary or its prompts",
+	Long: `Give a session a short title, shown by the title column of cst list and
+the launcher. With a title, set it; an empty title ("") clears it. Without
+one, take the summary Claude Code writes into the transcript or, if there is
+none, ask claude -p to summarize the session's prompts.
+
+With auto_title set to summary or claude (cst config set auto_title claude),
+sessions are titled this way when they end. --all titles every ended session
+that has no title yet.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch {
+		case flagTitleAll && len(args) > 0:
+			return errors.New("--all takes no session")
+		case !flagTitleAll && len(args) == 0:
+			return errors.New("give a session, or --all")
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		if flagTitleAll {
+			sessions, err := s.ListUntitled()
+			if err != nil {
+				return err
+			}
+			titled := 0
+			for _, sess := range sessions {
+				t, err := generateTitle(cmd.Context(), s, sess)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "cst: session %s: %v\n", textutil.ShortID(sess.ID), err)
+					continue
+				}
+				if t == "" {
+					continue
+				}
+				if err := s.SetTitle(sess.ID, t); err != nil {
+					return err
+				}
+				fmt.Printf("%s  %s\n", textutil.ShortID(sess.ID), t)
+				titled++
+			}
+			fmt.Printf("Titled %d of %d sessions.\n", titled, len(sessions))
+			return nil
+		}
+
+		sess, err := lookupSession(s, args[0])
+		if err != nil {
+			return err
+		}
+		var t string
+		if len(args) > 1 {
+			t = title.Clean(strings.Join(args[1:], " "))
+		} else if t, err = generateTitle(cmd.Context(), s, sess); err != nil {
+			return err
+		} else if t == "" {
+			return fmt.Errorf("session %s has no transcript summary; run without --summary-only to generate a title", textutil.ShortID(sess.ID))
+		}
+		if err := s.SetTitle(sess.ID, t); err != nil {
+			return err
+		}
+		if t == "" {
+			fmt.Printf("Cleared title of session %s\n", textutil.ShortID(sess.ID))
+		} else {
+			fmt.Printf("Session %s: %s\n", textutil.ShortID(sess.ID), t)
+		}
+		return nil
+	},
+}
+
+// generateTitle returns a title from the transcript summary of sess or,
+// unless --summary-only, one generated from its prompts with claude -p.
+// Returns "" if --summary-only and the transcript has no summary.
+func generateTitle(ctx context.Context, s *store.Store, sess store.Session) (string, error) {
+	if path := sessionTranscript(sess); path != "" {
+		t, err := title.FromTranscript(path)
+		if err != nil || t != "" {
+			return t, err
+		}
+	}
+	if flagSummaryOnly {
+		return "", nil
+	}
+	prompts, err := s.GetPrompts(sess.ID, store.DefaultMaxPrompt)
+	if err != nil {
+		return "", err
+	}
+	cfg, _ := config.Load(config.DefaultConfigPath())
+	return title.FromPrompts(ctx, cfg.ClaudeCommand(), title.Prompts(sess, prompts))
+}
+
+func init() {
+	titleCmd.Flags().BoolVar(&flagTitleAll, "all", false, "Title every ended session without a title")
+	titleCmd.Flags().BoolVar(&flagSummaryOnly, "summary-only", false, "Only use transcript summaries, never run claude -p")
+}
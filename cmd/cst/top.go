@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+// --- Top Command ---
+
+// flagTopInterval is its own variable for its own default, see
+// flagDaemonInterval.
+var flagTopInterval time.Duration
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Live view of running sessions with their CPU, memory and prompt rate",
+	Long: `Show running sessions one per row, like top: the claude process's PID,
+its CPU use since the last refresh and resident memory, how long the session
+has run since it started or was last resumed, the prompts sent per hour and
+the last prompt, refreshed every --interval.
+
+CPU and memory are read from /proc and so only show on Linux, and only for
+sessions running on this machine. Keys: s steps the order through CPU,
+prompt rate and last activity, a jumps to the selected session's tmux pane
+or terminal window, x stops it with SIGTERM after confirmation, q quits.
+To resume a session, use the launcher; cst watch groups running sessions by
+project.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not load config: %v\n", err)
+		}
+		if flagTopInterval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+
+		project := ""
+		if flagProject != "" {
+			project = store.ResolvePath(flagProject)
+		}
+		m := launcher.NewTop(s, project, flagTopInterval).WithConfig(cfg)
+		if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+			return fmt.Errorf("run TUI: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	topCmd.Flags().StringVarP(&flagProject, "project", "p", "", "Only sessions of this project")
+	topCmd.Flags().DurationVar(&flagTopInterval, "interval", time.Second, "Refresh interval")
+}
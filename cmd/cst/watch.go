@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+// --- Watch Command ---
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Dashboard of running sessions grouped by project",
+	Long: `Show running sessions grouped by project, refreshed every --interval.
+Each project shows how many sessions are running, waiting on you, and the
+time they have worked. Each session's last activity is colored by age:
+recent, quiet, or older than idle_gap_minutes.
+
+Keys: a jumps to the selected session's tmux pane or terminal window, x stops
+it with SIGTERM after confirmation, q quits. For a plain table that can be
+piped or logged, use cst list --watch.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not load config: %v\n", err)
+		}
+		if flagInterval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+
+		project := ""
+		if flagProject != "" {
+			project = store.ResolvePath(flagProject)
+		}
+		m := launcher.NewDashboard(s, project, flagInterval).WithConfig(cfg)
+		if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+			return fmt.Errorf("run TUI: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	watchCmd.Flags().StringVarP(&flagProject, "project", "p", "", "Only sessions of this project")
+	watchCmd.Flags().DurationVar(&flagInterval, "interval", 2*time.Second, "Refresh interval")
+}
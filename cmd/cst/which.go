@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/hook"
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+	"github.com/imyousuf/claude-session-tracker/internal/transcript"
+)
+
+// --- Which Command ---
+
+var whichCmd = &cobra.Command{
+	Use:   "which <file|dir>",
+	Short: "List the sessions that changed a file",
+	Long: `List the sessions whose Edit, MultiEdit, Write or NotebookEdit tool calls
+changed a file, or any file under a directory, most recent first, for
+tracking down where a change came from.
+
+Files are read from session transcripts, here, for every session whose
+transcript changed since the last run, so running sessions are covered.
+Transcripts Claude Code has since deleted leave what was recorded before.`,
+	Example: `  cst which internal/store/store.go
+  cst which src/ --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = s.Close() }()
+
+		if !flagReadOnly {
+			if err := indexFiles(s); err != nil {
+				return err
+			}
+		}
+
+		touches, err := s.FilesTouching(path)
+		if err != nil {
+			return err
+		}
+		// Claude Code records paths as the session saw them, which may be
+		// through a symlink either way
+		if resolved := store.ResolvePath(path); resolved != path {
+			more, err := s.FilesTouching(resolved)
+			if err != nil {
+				return err
+			}
+			touches = append(touches, more...)
+		}
+
+		sessions := make(map[string]store.Session)
+		for _, t := range touches {
+			if _, ok := sessions[t.SessionID]; ok {
+				continue
+			}
+			if sessions[t.SessionID], err = s.GetSession(t.SessionID); err != nil {
+				return fmt.Errorf("session %s: %w", textutil.ShortID(t.SessionID), err)
+			}
+		}
+
+		if flagJSON {
+			return printWhichJSON(path, touches, sessions)
+		}
+		if len(touches) == 0 {
+			fmt.Printf("No session changed %s\n", path)
+			return nil
+		}
+		fmt.Printf("%-8s  %-10s  %5s  %-40s  %s\n", "SESSION", "CHANGED", "EDITS", "FILE", "PROMPT")
+		for _, t := range touches {
+			sess := sessions[t.SessionID]
+			file := t.Path
+			if rel, ok := strings.CutPrefix(file, sess.Project+"/"); ok {
+				file = rel
+			}
+			changed := "-"
+			if t.LastAt > 0 {
+				changed = launcher.FormatRelativeTime(t.LastAt)
+			}
+			fmt.Printf("%-8s  %-10s  %5d  %-40s  %s\n", textutil.ShortID(sess.ID), changed, t.Edits, file, whichLabel(sess))
+		}
+		return nil
+	},
+}
+
+// indexFiles records the files of every session whose transcript changed
+// since they were last recorded. Transcripts that cannot be read are
+// skipped.
+func indexFiles(s *store.Store) error {
+	states, err := s.FileIndexStates()
+	if err != nil {
+		return err
+	}
+	found, err := transcript.All(transcript.DefaultDir())
+	if err != nil {
+		return err
+	}
+	for _, st := range states {
+		path := st.TranscriptPath
+		if path == "" {
+			path = found[st.SessionID]
+		}
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().UnixMilli() <= st.IndexedAt {
+			continue
+		}
+		if err := hook.IndexFiles(s, st.SessionID, path); err != nil {
+			slog.Warn("reading files touched failed", "session", st.SessionID, "transcript", path, "err", err)
+		}
+	}
+	return nil
+}
+
+// whichLabel names a session by its title, else its first prompt.
+func whichLabel(sess store.Session) string {
+	label := sess.Title
+	if label == "" {
+		label = sess.FirstPrompt
+	}
+	return textutil.Truncate(label, 50, "...")
+}
+
+// whichResult is the JSON object printed by cst which --json.
+type whichResult struct {
+	SchemaVersion int          `json:"schema_version"`
+	Path          string       `json:"path"`
+	Matches       []whichMatch `json:"matches"`
+}
+
+// whichMatch is a file a session changed; last_changed is milliseconds
+// since the epoch, 0 if unknown.
+type whichMatch struct {
+	File        string        `json:"file"`
+	Edits       int           `json:"edits"`
+	LastChanged int64         `json:"last_changed"`
+	Session     sessionRecord `json:"session"`
+}
+
+func printWhichJSON(path string, touches []store.FileTouch, sessions map[string]store.Session) error {
+	out := whichResult{SchemaVersion: JSONSchemaVersion, Path: path, Matches: make([]whichMatch, 0, len(touches))}
+	for _, t := range touches {
+		out.Matches = append(out.Matches, whichMatch{File: t.Path, Edits: t.Edits, LastChanged: t.LastAt, Session: newSessionRecord(sessions[t.SessionID])})
+	}
+	return writeJSON(out)
+}
+
+func init() {
+	whichCmd.Flags().BoolVar(&flagJSON, "json", false, "Output as JSON")
+	whichCmd.Flags().BoolVar(&flagReadOnly, "read-only", false, "Only search what was recorded, without reading transcripts into the database")
+}
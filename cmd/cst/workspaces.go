@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+)
+
+// --- Workspaces ---
+
+// workspaceProjects returns the project directories of the workspace given
+// with --workspace, or an error naming the known ones if there is none.
+func workspaceProjects(cfg config.Config, name string) ([]string, error) {
+	projects, ok := cfg.WorkspaceProjects(name)
+	if !ok {
+		names := slices.Sorted(maps.Keys(cfg.Workspaces))
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no workspace named %q; add one with cst config workspace add", name)
+		}
+		return nil, fmt.Errorf("no workspace named %q (have %s)", name, strings.Join(names, ", "))
+	}
+	return projects, nil
+}
+
+var configWorkspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "List workspaces: named groups of projects",
+	Long: `Manage workspaces: named groups of project directories, such as the three
+repositories of a backend. cst -w <name> and cst list --workspace <name> show
+the sessions of every project in the workspace, and the launcher's scope key
+steps from the current project to its workspace to all projects. With
+default_scope set to workspace, the launcher starts on the workspace of the
+current project.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			return err
+		}
+		if len(cfg.Workspaces) == 0 {
+			fmt.Println("No workspaces.")
+			return nil
+		}
+		for _, name := range slices.Sorted(maps.Keys(cfg.Workspaces)) {
+			fmt.Printf("%-12s  %s\n", name, strings.Join(cfg.Workspaces[name], "  "))
+		}
+		return nil
+	},
+}
+
+var configWorkspaceAddCmd = &cobra.Command{
+	Use:   "add <name> <dir>...",
+	Short: "Add project directories to a workspace, creating it if needed",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		dirs, err := absDirs(args[1:])
+		if err != nil {
+			return err
+		}
+		if err := cfg.AddToWorkspace(args[0], dirs); err != nil {
+			return err
+		}
+		if err := config.Save(cfgPath, cfg); err != nil {
+			return err
+		}
+		fmt.Printf("Workspace %s: %s\n", args[0], strings.Join(cfg.Workspaces[args[0]], "  "))
+		return nil
+	},
+}
+
+var configWorkspaceRemoveCmd = &cobra.Command{
+	Use:   "remove <name> [dir...]",
+	Short: "Remove project directories from a workspace, or the whole workspace",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath := config.DefaultConfigPath()
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return err
+		}
+		dirs, err := absDirs(args[1:])
+		if err != nil {
+			return err
+		}
+		if !cfg.RemoveFromWorkspace(args[0], dirs) {
+			if _, ok := cfg.Workspaces[args[0]]; !ok {
+				return fmt.Errorf("no workspace named %q", args[0])
+			}
+			return fmt.Errorf("workspace %s does not have all of %s", args[0], strings.Join(dirs, ", "))
+		}
+		if err := config.Save(cfgPath, cfg); err != nil {
+			return err
+		}
+		if _, ok := cfg.Workspaces[args[0]]; ok {
+			fmt.Printf("Workspace %s: %s\n", args[0], strings.Join(cfg.Workspaces[args[0]], "  "))
+		} else {
+			fmt.Printf("Removed workspace %s\n", args[0])
+		}
+		return nil
+	},
+}
+
+// absDirs makes relative directories absolute, so that "." adds the
+// current directory. Directories starting with "~/" are kept as given.
+func absDirs(dirs []string) ([]string, error) {
+	out := make([]string, len(dirs))
+	for i, dir := range dirs {
+		if strings.HasPrefix(dir, "~/") || filepath.IsAbs(dir) {
+			out[i] = filepath.Clean(dir)
+			continue
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = abs
+	}
+	return out, nil
+}
+
+func init() {
+	configCmd.AddCommand(configWorkspaceCmd)
+	configWorkspaceCmd.AddCommand(configWorkspaceAddCmd)
+	configWorkspaceCmd.AddCommand(configWorkspaceRemoveCmd)
+}
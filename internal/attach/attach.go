@@ -0,0 +1,186 @@
+package attach
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/imyousuf/claude-session-tracker/internal/procutil"
+)
+
+// ErrNoTarget is returned when neither a tmux pane nor a desktop window can
+// be matched to the session's process.
+var ErrNoTarget = errors.New("no tmux pane or terminal window found (needs tmux or wmctrl)")
+
+// Replaced in tests.
+var (
+	run = func(name string, args ...string) (string, error) {
+		out, err := exec.Command(name, args...).Output()
+		return string(out), err
+	}
+	parentPID = procutil.ParentPID
+	getenv    = os.Getenv
+)
+
+// Focus brings the terminal running process pid to the front and returns a
+// short description of what was focused. tty is the process's controlling
+// terminal as recorded at session start, e.g. "pts/3", or "" if unknown.
+//
+// A tmux pane on that tty, or whose shell is an ancestor of pid, is selected
+// first; from inside tmux the client switches to it, otherwise the window of
+// a terminal attached to that tmux session is raised. Without tmux, the
+// desktop window owned by an ancestor of pid is raised with wmctrl, which
+// only works on X11.
+func Focus(pid int, tty string) (string, error) {
+	if pid <= 0 {
+		return "", errors.New("no process recorded for the session")
+	}
+	ancestors := ancestry(pid)
+	if desc, ok, err := focusPane(ancestors, tty); ok || err != nil {
+		return desc, err
+	}
+	if desc, ok, err := focusWindow(ancestors); ok || err != nil {
+		return desc, err
+	}
+	return "", ErrNoTarget
+}
+
+// ancestry returns pid followed by its parents, nearest first.
+func ancestry(pid int) []int {
+	var pids []int
+	for pid > 1 && len(pids) < 64 && !slices.Contains(pids, pid) {
+		pids = append(pids, pid)
+		pid = parentPID(pid)
+	}
+	return pids
+}
+
+// --- tmux ---
+
+type pane struct {
+	id      string // e.g. "%3"
+	tty     string // e.g. "/dev/pts/3"
+	pid     int    // the pane's shell
+	session string
+	name    string // session:window.pane
+}
+
+const paneFormat = "#{pane_id}\t#{pane_tty}\t#{pane_pid}\t#{session_name}\t#{session_name}:#{window_index}.#{pane_index}"
+
+func focusPane(ancestors []int, tty string) (string, bool, error) {
+	out, err := run("tmux", "list-panes", "-a", "-F", paneFormat)
+	if err != nil {
+		return "", false, nil // tmux missing or no server running
+	}
+	p, ok := findPane(parsePanes(out), ancestors, tty)
+	if !ok {
+		return "", false, nil
+	}
+
+	for _, args := range [][]string{
+		{"select-window", "-t", p.id},
+		{"select-pane", "-t", p.id},
+	} {
+		if _, err := run("tmux", args...); err != nil {
+			return "", true, fmt.Errorf("tmux %s: %w", args[0], err)
+		}
+	}
+	desc := "tmux pane " + p.name
+	if getenv("TMUX") != "" {
+		if _, err := run("tmux", "switch-client", "-t", p.id); err != nil {
+			return "", true, fmt.Errorf("tmux switch-client: %w", err)
+		}
+		return desc, true, nil
+	}
+
+	// Outside tmux, raise a terminal that has the session attached.
+	clients, _ := run("tmux", "list-clients", "-t", p.session, "-F", "#{client_pid}")
+	for _, line := range strings.Fields(clients) {
+		if pid, err := strconv.Atoi(line); err == nil {
+			if win, ok, _ := focusWindow(ancestry(pid)); ok {
+				return desc + " in " + win, true, nil
+			}
+		}
+	}
+	return desc, true, nil
+}
+
+func parsePanes(out string) []pane {
+	var panes []pane
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		f := strings.Split(line, "\t")
+		if len(f) != 5 {
+			continue
+		}
+		pid, _ := strconv.Atoi(f[2])
+		panes = append(panes, pane{id: f[0], tty: f[1], pid: pid, session: f[3], name: f[4]})
+	}
+	return panes
+}
+
+// findPane prefers the pane on the session's terminal and falls back to the
+// pane whose shell started the process.
+func findPane(panes []pane, ancestors []int, tty string) (pane, bool) {
+	if tty != "" {
+		for _, p := range panes {
+			if p.tty == "/dev/"+tty {
+				return p, true
+			}
+		}
+	}
+	for _, p := range panes {
+		if slices.Contains(ancestors, p.pid) {
+			return p, true
+		}
+	}
+	return pane{}, false
+}
+
+// --- Desktop windows ---
+
+type window struct {
+	id    string // e.g. "0x03a00003"
+	pid   int
+	title string
+}
+
+func focusWindow(ancestors []int) (string, bool, error) {
+	out, err := run("wmctrl", "-lp")
+	if err != nil {
+		return "", false, nil // wmctrl missing or no X display
+	}
+	windows := parseWindows(out)
+	for _, pid := range ancestors {
+		for _, w := range windows {
+			if w.pid != pid {
+				continue
+			}
+			if _, err := run("wmctrl", "-ia", w.id); err != nil {
+				return "", true, fmt.Errorf("wmctrl: %w", err)
+			}
+			return fmt.Sprintf("window %q", w.title), true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// parseWindows parses `wmctrl -lp` lines: id, desktop, pid, host, title.
+func parseWindows(out string) []window {
+	var windows []window
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		f := strings.Fields(line)
+		if len(f) < 4 {
+			continue
+		}
+		pid, err := strconv.Atoi(f[2])
+		if err != nil || pid <= 0 {
+			continue
+		}
+		windows = append(windows, window{id: f[0], pid: pid, title: strings.Join(f[4:], " ")})
+	}
+	return windows
+}
@@ -0,0 +1,107 @@
+package attach
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fake replaces the package's hooks with a process tree of
+// 500 (terminal) -> 400 (shell) -> 300 (claude) and canned command output.
+// Commands without output fail, as if the program were missing.
+func fake(t *testing.T, outputs map[string]string, env map[string]string) *[]string {
+	t.Helper()
+	origRun, origParent, origGetenv := run, parentPID, getenv
+	t.Cleanup(func() { run, parentPID, getenv = origRun, origParent, origGetenv })
+
+	var calls []string
+	run = func(name string, args ...string) (string, error) {
+		call := strings.Join(append([]string{name}, args...), " ")
+		calls = append(calls, call)
+		for prefix, out := range outputs {
+			if strings.HasPrefix(call, prefix) {
+				return out, nil
+			}
+		}
+		return "", errors.New("exec: not found")
+	}
+	parentPID = func(pid int) int { return map[int]int{300: 400, 400: 500, 500: 1}[pid] }
+	getenv = func(k string) string { return env[k] }
+	return &calls
+}
+
+func TestFocusTmuxPaneByTTY(t *testing.T) {
+	calls := fake(t, map[string]string{
+		"tmux list-panes": "%1\t/dev/pts/1\t900\twork\twork:0.0\n%7\t/dev/pts/3\t901\tapi\tapi:2.1\n",
+		"tmux select":     "",
+		"tmux switch":     "",
+	}, map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0"})
+
+	desc, err := Focus(300, "pts/3")
+	if err != nil {
+		t.Fatalf("Focus: %v", err)
+	}
+	if desc != "tmux pane api:2.1" {
+		t.Errorf("desc = %q", desc)
+	}
+	for _, want := range []string{"tmux select-window -t %7", "tmux select-pane -t %7", "tmux switch-client -t %7"} {
+		if !slices.Contains(*calls, want) {
+			t.Errorf("missing call %q in %q", want, *calls)
+		}
+	}
+}
+
+func TestFocusTmuxPaneOutsideTmuxRaisesClient(t *testing.T) {
+	calls := fake(t, map[string]string{
+		"tmux list-panes":   "%7\t/dev/pts/9\t400\tapi\tapi:2.1\n",
+		"tmux select":       "",
+		"tmux list-clients": "500\n",
+		"wmctrl -lp":        "0x01  0 500  host  kitty: api\n",
+		"wmctrl -ia":        "",
+	}, nil)
+
+	// No tty recorded: the pane is found by its shell, an ancestor of 300.
+	desc, err := Focus(300, "")
+	if err != nil {
+		t.Fatalf("Focus: %v", err)
+	}
+	if desc != `tmux pane api:2.1 in window "kitty: api"` {
+		t.Errorf("desc = %q", desc)
+	}
+	if slices.Contains(*calls, "tmux switch-client -t %7") {
+		t.Error("switch-client called outside tmux")
+	}
+	if !slices.Contains(*calls, "wmctrl -ia 0x01") {
+		t.Errorf("terminal window not raised: %q", *calls)
+	}
+}
+
+func TestFocusWindowWithoutTmux(t *testing.T) {
+	calls := fake(t, map[string]string{
+		"wmctrl -lp": "0x01  0 777  host  other\n0x02  0 500  host  kitty: ~/api\n",
+		"wmctrl -ia": "",
+	}, nil)
+
+	desc, err := Focus(300, "pts/3")
+	if err != nil {
+		t.Fatalf("Focus: %v", err)
+	}
+	if desc != `window "kitty: ~/api"` {
+		t.Errorf("desc = %q", desc)
+	}
+	if !slices.Contains(*calls, "wmctrl -ia 0x02") {
+		t.Errorf("window not raised: %q", *calls)
+	}
+}
+
+func TestFocusNoTarget(t *testing.T) {
+	fake(t, map[string]string{"wmctrl -lp": "0x01  0 777  host  other\n"}, nil)
+
+	if _, err := Focus(300, "pts/3"); !errors.Is(err, ErrNoTarget) {
+		t.Errorf("Focus = %v, want ErrNoTarget", err)
+	}
+	if _, err := Focus(0, ""); err == nil {
+		t.Error("Focus without a PID should fail")
+	}
+}
@@ -0,0 +1,160 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// FormatVersion is the bundle layout version written to the manifest.
+const FormatVersion = 1
+
+const (
+	manifestName   = "manifest.json"
+	transcriptName = "transcript.jsonl"
+)
+
+// Manifest describes a shared session: its record, prompt history, working
+// directory trail, and whether the archive carries a transcript copy.
+type Manifest struct {
+	FormatVersion int        `json:"format_version"`
+	CreatedAt     int64      `json:"created_at"`
+	CreatedBy     string     `json:"created_by"`
+	Session       Session    `json:"session"`
+	Prompts       []Prompt   `json:"prompts"`
+	CWDHistory    []CWDEntry `json:"cwd_history"`
+	Notes         string     `json:"notes,omitempty"`
+	HasTranscript bool       `json:"has_transcript"`
+}
+
+// Session is the portable subset of a tracked session.
+type Session struct {
+	ID           string `json:"id"`
+	Project      string `json:"project"`
+	CWD          string `json:"cwd"`
+	Model        string `json:"model"`
+	StartedAt    int64  `json:"started_at"`
+	LastActivity int64  `json:"last_activity"`
+	// Prompts sent and the first of them, which Prompts may no longer hold
+	PromptCount int    `json:"prompt_count,omitempty"`
+	FirstPrompt string `json:"first_prompt,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+// Prompt is a recorded user prompt.
+type Prompt struct {
+	Text      string `json:"text"`
+	Timestamp int64  `json:"timestamp"`
+	// Occurrences is the times it was sent in a row, if more than once
+	Occurrences int `json:"occurrences,omitempty"`
+	// Agent is the subagent it was sent to, if not the main agent
+	Agent string `json:"agent,omitempty"`
+}
+
+// CWDEntry is one step of the working directory trail.
+type CWDEntry struct {
+	CWD       string `json:"cwd"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Write writes a gzipped tar archive holding the manifest and, if transcript
+// is non-nil, a copy of the transcript of transcriptSize bytes.
+func Write(w io.Writer, m Manifest, transcript io.Reader, transcriptSize int64) error {
+	m.FormatVersion = FormatVersion
+	m.HasTranscript = transcript != nil
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	modTime := time.UnixMilli(m.CreatedAt)
+
+	if err := writeEntry(tw, manifestName, int64(len(data)), modTime); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+
+	if transcript != nil {
+		if err := writeEntry(tw, transcriptName, transcriptSize, modTime); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, transcript); err != nil {
+			return fmt.Errorf("write transcript: %w", err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeEntry(tw *tar.Writer, name string, size int64, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: modTime,
+	}); err != nil {
+		return fmt.Errorf("write %s header: %w", name, err)
+	}
+	return nil
+}
+
+// Read parses a bundle written by Write. If the bundle carries a transcript,
+// saveTranscript is called with the already-parsed manifest and a reader over
+// the transcript contents.
+func Read(r io.Reader, saveTranscript func(Manifest, io.Reader) error) (Manifest, error) {
+	var m Manifest
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return m, fmt.Errorf("not a cst bundle: %w", err)
+	}
+	defer func() { _ = gz.Close() }()
+
+	tr := tar.NewReader(gz)
+	seenManifest := false
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return m, fmt.Errorf("read bundle: %w", err)
+		}
+		switch hdr.Name {
+		case manifestName:
+			if err := json.NewDecoder(tr).Decode(&m); err != nil {
+				return m, fmt.Errorf("parse manifest: %w", err)
+			}
+			if m.FormatVersion > FormatVersion {
+				return m, fmt.Errorf("bundle format version %d is newer than supported version %d", m.FormatVersion, FormatVersion)
+			}
+			seenManifest = true
+		case transcriptName:
+			if !seenManifest || m.Session.ID == "" {
+				return m, errors.New("bundle transcript precedes a valid manifest")
+			}
+			if saveTranscript != nil {
+				if err := saveTranscript(m, tr); err != nil {
+					return m, fmt.Errorf("save transcript: %w", err)
+				}
+			}
+		}
+	}
+	if !seenManifest {
+		return m, errors.New("bundle has no manifest")
+	}
+	if m.Session.ID == "" {
+		return m, errors.New("bundle manifest has no session ID")
+	}
+	return m, nil
+}
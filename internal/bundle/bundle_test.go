@@ -0,0 +1,79 @@
+package bundle
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+	m := Manifest{
+		CreatedAt: 1700000000000,
+		CreatedBy: "cst test",
+		Session: Session{
+			ID: "sess-1", Project: "/proj", CWD: "/proj/api",
+			Model: "opus", StartedAt: 1, LastActivity: 2,
+		},
+		Prompts:    []Prompt{{Text: "fix the bug", Timestamp: 1}},
+		CWDHistory: []CWDEntry{{CWD: "/proj", Timestamp: 1}},
+		Notes:      "look at the retry logic",
+	}
+	transcript := `{"type":"user"}` + "\n"
+
+	var buf bytes.Buffer
+	if err := Write(&buf, m, strings.NewReader(transcript), int64(len(transcript))); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	var gotTranscript string
+	got, err := Read(&buf, func(m Manifest, r io.Reader) error {
+		if m.Session.ID != "sess-1" {
+			t.Errorf("manifest passed to saveTranscript has ID %q", m.Session.ID)
+		}
+		data, err := io.ReadAll(r)
+		gotTranscript = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got.FormatVersion != FormatVersion {
+		t.Errorf("FormatVersion = %d, want %d", got.FormatVersion, FormatVersion)
+	}
+	if !got.HasTranscript {
+		t.Error("HasTranscript = false, want true")
+	}
+	if got.Session.ID != "sess-1" || got.Notes != "look at the retry logic" {
+		t.Errorf("unexpected manifest: %+v", got)
+	}
+	if len(got.Prompts) != 1 || got.Prompts[0].Text != "fix the bug" {
+		t.Errorf("Prompts = %+v", got.Prompts)
+	}
+	if gotTranscript != transcript {
+		t.Errorf("transcript = %q, want %q", gotTranscript, transcript)
+	}
+}
+
+func TestWriteWithoutTranscript(t *testing.T) {
+	var buf bytes.Buffer
+	m := Manifest{Session: Session{ID: "sess-1"}}
+	if err := Write(&buf, m, nil, 0); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	called := false
+	got, err := Read(&buf, func(Manifest, io.Reader) error { called = true; return nil })
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got.HasTranscript || called {
+		t.Errorf("HasTranscript = %v, save called = %v; want false, false", got.HasTranscript, called)
+	}
+}
+
+func TestReadRejectsGarbage(t *testing.T) {
+	if _, err := Read(strings.NewReader("not a bundle"), nil); err == nil {
+		t.Error("expected error reading a non-bundle")
+	}
+}
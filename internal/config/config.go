@@ -1,15 +1,34 @@
 package config
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 )
 
 const (
-	DefaultConfigDir  = ".cst"
-	DefaultConfigName = "config.json"
+	DefaultConfigDir   = ".cst"
+	DefaultConfigName  = "config.json"
+	DefaultLogName     = "cst.log"
+	DefaultScriptsName = "hooks.d"
+	DefaultPayloadsDir = "payloads"
+	DefaultHookErrors  = "hook-errors.log"
+	DefaultSocketName  = "daemon.sock"
+
+	// DefaultRetentionDays is the age after which cst cleanup removes
+	// inactive sessions unless RetentionDays says otherwise.
+	DefaultRetentionDays = 30
+
+	// DefaultRoughToolErrors is the number of failed tool calls at which a
+	// session is flagged as rough unless RoughToolErrors says otherwise.
+	DefaultRoughToolErrors = 5
 )
 
 // Config holds CST user preferences stored in ~/.cst/config.json.
@@ -19,18 +38,254 @@ type Config struct {
 
 	// ExtraArgs are additional arguments always passed to the claude CLI on resume.
 	ExtraArgs []string `json:"extra_args,omitempty"`
+
+	// ProjectArgs holds claude arguments added when resuming a session whose
+	// project matches the glob pattern key, as in ProjectEnv. They override
+	// ExtraArgs flag by flag, and longer patterns override shorter ones.
+	ProjectArgs map[string][]string `json:"project_args,omitempty"`
+
+	// ClaudeBin is the command that runs claude: a path such as
+	// ["~/.local/bin/claude"], or a wrapper such as ["ssh", "devbox", "claude"]
+	// that claude's arguments are appended to. Empty runs claude from PATH.
+	ClaudeBin []string `json:"claude_bin,omitempty"`
+
+	// ResumeCWD is the directory claude resumes a session in: "project"
+	// (default) or "last", the directory the session last worked in, such
+	// as a package of a monorepo, while it still exists.
+	ResumeCWD string `json:"resume_cwd,omitempty"`
+
+	// IgnoredProjects are glob patterns for directories whose sessions are never recorded.
+	IgnoredProjects []string `json:"ignored_projects,omitempty"`
+
+	// IgnorePromptPatterns are regular expressions for boilerplate prompts
+	// ("continue", "yes") that should not be stored in the prompt history.
+	IgnorePromptPatterns []string `json:"ignore_prompt_patterns,omitempty"`
+
+	// DebugLog enables the log file ~/.cst/cst.log, recording what hooks
+	// received and did, and slow queries.
+	DebugLog bool `json:"debug_log,omitempty"`
+
+	// RecordHookPayloads saves every raw hook payload to ~/.cst/payloads, to
+	// be replayed with cst hook replay.
+	RecordHookPayloads bool `json:"record_hook_payloads,omitempty"`
+
+	// HookFailMode is what a hook does when handling its event fails:
+	// "warn" (default) logs the error to ~/.cst/hook-errors.log, notes it on
+	// stderr and exits 0, "silent" only logs it, and "strict" also exits
+	// non-zero, which Claude Code shows in the session.
+	HookFailMode string `json:"hook_fail_mode,omitempty"`
+
+	// HookDaemon has hooks hand their events to cst daemon over
+	// ~/.cst/daemon.sock, which records them on its one open database,
+	// instead of each opening the database itself. Hooks fall back to
+	// opening it when no daemon listens.
+	HookDaemon bool `json:"hook_daemon,omitempty"`
+
+	// ToolStats has the tool hooks count each session's calls of each tool,
+	// for the preview and cst stats --tools. Off, they skip the write.
+	ToolStats bool `json:"tool_stats,omitempty"`
+
+	// RoughToolErrors is the number of failed tool calls at which the
+	// launcher flags a session as rough. Zero uses the default of 5;
+	// negative never flags one.
+	RoughToolErrors int `json:"rough_tool_errors,omitempty"`
+
+	// SlowQueryMS is the threshold in milliseconds above which store queries
+	// are written to the log. Zero uses the store default.
+	SlowQueryMS int `json:"slow_query_ms,omitempty"`
+
+	// Env holds environment variables injected into claude on resume.
+	Env map[string]string `json:"env,omitempty"`
+
+	// ProjectEnv holds environment variables injected only when resuming a
+	// session whose project matches the glob pattern key. Project values
+	// override Env; longer patterns override shorter ones.
+	ProjectEnv map[string]map[string]string `json:"project_env,omitempty"`
+
+	// PreviewPosition places the launcher preview pane: "right" (default),
+	// "bottom" or "hidden".
+	PreviewPosition string `json:"preview_position,omitempty"`
+
+	// PreviewWidth is the width of a side preview pane as a percentage of the
+	// terminal. Zero uses the default of half the terminal, at most 60 columns.
+	PreviewWidth int `json:"preview_width,omitempty"`
+
+	// IdleGapMinutes is the longest pause between hook events still counted
+	// as time worked in a session. Zero uses the store default of 15.
+	IdleGapMinutes int `json:"idle_gap_minutes,omitempty"`
+
+	// PIDGraceSeconds is how long a session's claude process may be gone
+	// before the session is shown as ended, so that claude restarting to
+	// update doesn't end it. Zero uses the store default of 10; negative
+	// ends it as soon as the process is found gone.
+	PIDGraceSeconds int `json:"pid_grace_seconds,omitempty"`
+
+	// MaxDBSizeMB caps the size of the session database, log included. When
+	// a session ends with the database over it, cst db shrink is started in
+	// the background, and cst cleanup runs it too: the prompt history of
+	// inactive sessions is trimmed until it fits. Zero means no cap.
+	MaxDBSizeMB int `json:"max_db_size_mb,omitempty"`
+
+	// Theme is the launcher color theme: "auto" (default, dark or light to
+	// match the terminal background), "dark", "light", "mono" or "custom".
+	Theme string `json:"theme,omitempty"`
+
+	// ThemeColors overrides colors of the custom theme by element name, such
+	// as "active" or "border". Values are hex colors or ANSI color numbers.
+	ThemeColors map[string]string `json:"theme_colors,omitempty"`
+
+	// Keybindings remaps launcher keys: the keys of each named action, such
+	// as "delete" or "view_prompts", replacing its defaults. "space" is the
+	// space bar.
+	Keybindings map[string][]string `json:"keybindings,omitempty"`
+
+	// ASCII draws the launcher and cst's output with ASCII characters only,
+	// in place of status dots, arrows, box borders and the like.
+	ASCII bool `json:"ascii,omitempty"`
+
+	// DefaultScope is the session list the launcher opens with: "project"
+	// (default), "workspace" (the project's workspace, if it is in one) or
+	// "all". The --all and --workspace flags override it.
+	DefaultScope string `json:"default_scope,omitempty"`
+
+	// Sort orders the launcher session list: "activity" (default, most recent
+	// first), "started" (newest first) or "project".
+	Sort string `json:"sort,omitempty"`
+
+	// Columns are the columns of cst list and the launcher session list, in
+	// order, such as ["status", "branch", "time", "prompt"]. Empty uses each
+	// one's default. The --columns flag overrides it.
+	Columns []string `json:"columns,omitempty"`
+
+	// RefreshSeconds reloads the launcher session list at this interval.
+	// Zero disables auto-refresh.
+	RefreshSeconds int `json:"refresh_seconds,omitempty"`
+
+	// RetentionDays is the default age for `cst cleanup`. Zero uses 30.
+	RetentionDays int `json:"retention_days,omitempty"`
+
+	// ClearedRetentionDays removes sessions ended with /clear after this
+	// many days, when sooner than their project's age limit. Zero treats
+	// them like any other session.
+	ClearedRetentionDays int `json:"cleared_retention_days,omitempty"`
+
+	// ProjectRetention overrides RetentionDays and caps the number of
+	// sessions kept for projects matching the glob pattern key, as in
+	// IgnoredProjects. The longest matching pattern wins.
+	ProjectRetention map[string]RetentionRule `json:"project_retention,omitempty"`
+
+	// TicketPatterns are regular expressions extracting ticket IDs from git
+	// branch names; the first capture group is the ID. Empty uses defaults
+	// matching "feature/JIRA-123-x" and "1234-fix-x".
+	TicketPatterns []string `json:"ticket_patterns,omitempty"`
+
+	// RedactPatterns are regular expressions for secrets `cst share` removes
+	// on top of its defaults; with a capture group, only the first group is
+	// removed.
+	RedactPatterns []string `json:"redact_patterns,omitempty"`
+
+	// ScriptTimeoutSeconds bounds each script in ~/.cst/hooks.d.
+	// Zero uses the default of 3 seconds; keep it under the 5s hook timeout in hooks.json.
+	ScriptTimeoutSeconds int `json:"script_timeout_seconds,omitempty"`
+
+	// PromptStorage is how long prompts are stored: "truncate" (the
+	// default) cuts them to PromptMaxLen, "full" also keeps them whole for
+	// search and export, and "compressed" keeps them whole but compressed,
+	// out of reach of search.
+	PromptStorage string `json:"prompt_storage,omitempty"`
+
+	// PromptMaxLen is the length in bytes prompts are cut to for listings.
+	// Zero uses the default of 200.
+	PromptMaxLen int `json:"prompt_max_len,omitempty"`
+
+	// OutcomeSurvey marks sessions as waiting for an outcome label when they
+	// end; `cst outcomes` lists them.
+	OutcomeSurvey bool `json:"outcome_survey,omitempty"`
+
+	// WarnOnMultipleActivePerProject records, when a session starts, the
+	// other sessions already running in its project, and has the launcher
+	// and `cst status` point out projects with more than one running.
+	WarnOnMultipleActivePerProject bool `json:"warn_on_multiple_active_per_project,omitempty"`
+
+	// AutoTitle titles sessions when they end: "summary" takes the summary
+	// Claude Code writes into the transcript, "claude" falls back to asking
+	// claude -p to summarize the prompts. Empty or "off" disables it.
+	AutoTitle string `json:"auto_title,omitempty"`
+
+	// ExtraStores are additional session databases, such as a copy synced
+	// from another machine, whose sessions list and launch show read-only.
+	ExtraStores []ExtraStore `json:"extra_stores,omitempty"`
+
+	// Workspaces are named groups of project directories, such as the
+	// repositories of one system, that list and launch can show together.
+	// A leading "~/" in a directory is expanded.
+	Workspaces map[string][]string `json:"workspaces,omitempty"`
+
+	// UnknownKeys are the keys in the file that no field has, such as
+	// misspelt ones, sorted. They are not saved back.
+	UnknownKeys []string `json:"-"`
+}
+
+// RetentionRule is the cleanup policy of the projects matching a pattern.
+type RetentionRule struct {
+	// Days is the age after which cst cleanup removes inactive sessions.
+	// Zero uses RetentionDays; negative keeps sessions regardless of age.
+	Days int `json:"days,omitempty"`
+	// MaxSessions caps the sessions kept; the oldest inactive ones are
+	// removed when a session starts and by cst cleanup. Zero for no cap.
+	MaxSessions int `json:"max_sessions,omitempty"`
+}
+
+// ExtraStore is a secondary session database shown alongside the local one.
+type ExtraStore struct {
+	// Name labels the store's sessions, e.g. "laptop".
+	Name string `json:"name"`
+	// Path is the database file; a leading "~/" is expanded.
+	Path string `json:"path"`
+}
+
+// ExpandedPath returns Path with a leading "~/" expanded.
+func (e ExtraStore) ExpandedPath() string {
+	return ExpandHome(e.Path)
 }
 
-// DefaultConfigPath returns the path to ~/.cst/config.json.
+// DefaultConfigPath returns the path to ~/.cst/config.json, or to the
+// config.json of the profile in use.
 func DefaultConfigPath() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		home = "."
-	}
-	return filepath.Join(home, DefaultConfigDir, DefaultConfigName)
+	return filepath.Join(Dir(), DefaultConfigName)
+}
+
+// DefaultLogPath returns the path to ~/.cst/cst.log.
+func DefaultLogPath() string {
+	return filepath.Join(filepath.Dir(DefaultConfigPath()), DefaultLogName)
+}
+
+// DefaultScriptsDir returns the path to ~/.cst/hooks.d, which holds
+// user scripts run after each hook event.
+func DefaultScriptsDir() string {
+	return filepath.Join(filepath.Dir(DefaultConfigPath()), DefaultScriptsName)
+}
+
+// DefaultPayloadsPath returns the path to ~/.cst/payloads, which holds
+// recorded hook payloads.
+func DefaultPayloadsPath() string {
+	return filepath.Join(filepath.Dir(DefaultConfigPath()), DefaultPayloadsDir)
+}
+
+// DefaultHookErrorsPath returns the path to ~/.cst/hook-errors.log, where
+// failed hook events are logged.
+func DefaultHookErrorsPath() string {
+	return filepath.Join(filepath.Dir(DefaultConfigPath()), DefaultHookErrors)
+}
+
+// DefaultSocketPath returns the path to ~/.cst/daemon.sock, where cst daemon
+// takes hook events with hook_daemon on.
+func DefaultSocketPath() string {
+	return filepath.Join(filepath.Dir(DefaultConfigPath()), DefaultSocketName)
 }
 
 // Load reads the config from the given path. Returns a zero Config if the file doesn't exist.
+// Unknown keys are logged as warnings and listed in UnknownKeys.
 func Load(path string) (Config, error) {
 	var cfg Config
 	data, err := os.ReadFile(path)
@@ -43,6 +298,19 @@ func Load(path string) (Config, error) {
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return cfg, fmt.Errorf("parse config: %w", err)
 	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err == nil {
+		known := knownNames()
+		for name := range raw {
+			if !known[name] {
+				cfg.UnknownKeys = append(cfg.UnknownKeys, name)
+			}
+		}
+		slices.Sort(cfg.UnknownKeys)
+		for _, name := range cfg.UnknownKeys {
+			slog.Warn("unknown config key", "key", name, "path", path)
+		}
+	}
 	return cfg, nil
 }
 
@@ -69,3 +337,440 @@ func (c Config) ClaudeArgs() []string {
 	args = append(args, c.ExtraArgs...)
 	return args
 }
+
+// ClaudeCommand returns the command that runs claude, ClaudeBin or just
+// "claude", with a leading "~/" of the program expanded.
+func (c Config) ClaudeCommand() []string {
+	if len(c.ClaudeBin) == 0 {
+		return []string{"claude"}
+	}
+	cmd := slices.Clone(c.ClaudeBin)
+	cmd[0] = ExpandHome(cmd[0])
+	return cmd
+}
+
+// IsProjectIgnored reports whether dir, or any of its parent directories,
+// matches one of the IgnoredProjects glob patterns. A leading "~/" in a
+// pattern is expanded to the user's home directory.
+func (c Config) IsProjectIgnored(dir string) bool {
+	if dir == "" || len(c.IgnoredProjects) == 0 {
+		return false
+	}
+	for _, pattern := range c.IgnoredProjects {
+		if matchProject(pattern, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// Environ returns base with the configured environment variables for project
+// applied on top. Global Env entries are applied first, then every matching
+// ProjectEnv pattern from the shortest to the longest.
+func (c Config) Environ(project string, base []string) []string {
+	if len(c.Env) == 0 && len(c.ProjectEnv) == 0 {
+		return base
+	}
+
+	vars := make(map[string]string)
+	maps.Copy(vars, c.Env)
+	for _, pattern := range matchingPatterns(c.ProjectEnv, project) {
+		maps.Copy(vars, c.ProjectEnv[pattern])
+	}
+
+	env := make([]string, 0, len(base)+len(vars))
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, ok := vars[name]; !ok {
+			env = append(env, kv)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		env = append(env, name+"="+vars[name])
+	}
+	return env
+}
+
+// ResumeArgs returns the claude arguments for resuming a session of project
+// that has sessionArgs of its own: ClaudeArgs, overridden flag by flag by
+// the matching ProjectArgs and then by sessionArgs, as MergeArgs does.
+func (c Config) ResumeArgs(project string, sessionArgs []string) []string {
+	layers := [][]string{c.ClaudeArgs()}
+	for _, pattern := range matchingPatterns(c.ProjectArgs, project) {
+		layers = append(layers, c.ProjectArgs[pattern])
+	}
+	return MergeArgs(append(layers, sessionArgs)...)
+}
+
+// ResumeDir returns the directory to resume a session of project in, whose
+// last working directory is cwd: cwd with ResumeCWD "last", unless it is
+// unknown or gone, else project.
+func (c Config) ResumeDir(project, cwd string) string {
+	if c.ResumeCWD != "last" || cwd == "" {
+		return project
+	}
+	if info, err := os.Stat(cwd); err != nil || !info.IsDir() {
+		return project
+	}
+	return cwd
+}
+
+// RoughErrors returns the number of failed tool calls at which a session is
+// flagged as rough, or 0 to never flag one.
+func (c Config) RoughErrors() int {
+	if c.RoughToolErrors < 0 {
+		return 0
+	}
+	return cmp.Or(c.RoughToolErrors, DefaultRoughToolErrors)
+}
+
+// MergeArgs merges lists of command-line arguments, later lists overriding
+// earlier ones flag by flag. A flag is an argument starting with "-",
+// together with the argument after it unless that is a flag too or the flag
+// has an "=value". A flag in a later list replaces its occurrences in the
+// earlier ones; other arguments are kept as they are.
+func MergeArgs(layers ...[]string) []string {
+	type group struct {
+		name string // "" for a plain argument
+		args []string
+	}
+	var groups []group
+	for _, layer := range layers {
+		var own []group
+		for i := 0; i < len(layer); i++ {
+			arg := layer[i]
+			if !strings.HasPrefix(arg, "-") || arg == "-" || arg == "--" {
+				own = append(own, group{args: []string{arg}})
+				continue
+			}
+			name, _, hasValue := strings.Cut(arg, "=")
+			g := group{name: name, args: []string{arg}}
+			if !hasValue && i+1 < len(layer) && !strings.HasPrefix(layer[i+1], "-") {
+				i++
+				g.args = append(g.args, layer[i])
+			}
+			own = append(own, g)
+		}
+		for _, g := range own {
+			if g.name != "" {
+				groups = slices.DeleteFunc(groups, func(prev group) bool { return prev.name == g.name })
+			}
+		}
+		groups = append(groups, own...)
+	}
+	var args []string
+	for _, g := range groups {
+		args = append(args, g.args...)
+	}
+	return args
+}
+
+// matchingPatterns returns the glob pattern keys of m that match project, or
+// a directory above it, shortest first.
+func matchingPatterns[V any](m map[string]V, project string) []string {
+	if project == "" {
+		return nil
+	}
+	var patterns []string
+	for pattern := range m {
+		if matchProject(pattern, project) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	slices.SortFunc(patterns, func(a, b string) int {
+		if n := len(a) - len(b); n != 0 {
+			return n
+		}
+		return strings.Compare(a, b)
+	})
+	return patterns
+}
+
+// RetentionFor returns how long, in days, inactive sessions of project are
+// kept and how many sessions it keeps at most, from the longest matching
+// ProjectRetention pattern, falling back to RetentionDays. Zero means no
+// limit. It is a store.RetentionPolicy.
+func (c Config) RetentionFor(project string) (days, maxSessions int) {
+	days = cmp.Or(c.RetentionDays, DefaultRetentionDays)
+	best := ""
+	for pattern := range c.ProjectRetention {
+		if len(pattern) < len(best) || (len(pattern) == len(best) && pattern > best) ||
+			project == "" || !matchProject(pattern, project) {
+			continue
+		}
+		best = pattern
+	}
+	if best == "" {
+		return days, 0
+	}
+	rule := c.ProjectRetention[best]
+	switch {
+	case rule.Days < 0:
+		days = 0
+	case rule.Days > 0:
+		days = rule.Days
+	}
+	return days, max(rule.MaxSessions, 0)
+}
+
+// SetRetention sets the retention rule for projects matching pattern, a glob
+// pattern as in IgnoredProjects.
+func (c *Config) SetRetention(pattern string, rule RetentionRule) error {
+	if _, err := filepath.Match(pattern, ""); err != nil || pattern == "" {
+		return fmt.Errorf("invalid pattern %q", pattern)
+	}
+	if rule.MaxSessions < 0 {
+		return fmt.Errorf("max sessions must not be negative")
+	}
+	if c.ProjectRetention == nil {
+		c.ProjectRetention = make(map[string]RetentionRule)
+	}
+	c.ProjectRetention[pattern] = rule
+	return nil
+}
+
+// UnsetRetention removes the retention rule for pattern.
+// Returns false if there is none.
+func (c *Config) UnsetRetention(pattern string) bool {
+	if _, ok := c.ProjectRetention[pattern]; !ok {
+		return false
+	}
+	delete(c.ProjectRetention, pattern)
+	if len(c.ProjectRetention) == 0 {
+		c.ProjectRetention = nil
+	}
+	return true
+}
+
+// SetEnv sets an environment variable injected on resume. An empty project
+// sets it globally; otherwise project is a glob pattern as in IgnoredProjects.
+func (c *Config) SetEnv(project, name, value string) error {
+	if name == "" || strings.ContainsAny(name, "= \t\n") {
+		return fmt.Errorf("invalid environment variable name %q", name)
+	}
+	if project == "" {
+		if c.Env == nil {
+			c.Env = make(map[string]string)
+		}
+		c.Env[name] = value
+		return nil
+	}
+	if _, err := filepath.Match(project, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", project, err)
+	}
+	if c.ProjectEnv == nil {
+		c.ProjectEnv = make(map[string]map[string]string)
+	}
+	if c.ProjectEnv[project] == nil {
+		c.ProjectEnv[project] = make(map[string]string)
+	}
+	c.ProjectEnv[project][name] = value
+	return nil
+}
+
+// UnsetEnv removes an environment variable set with SetEnv.
+// Returns false if the variable was not set.
+func (c *Config) UnsetEnv(project, name string) bool {
+	env := c.Env
+	if project != "" {
+		env = c.ProjectEnv[project]
+	}
+	if _, ok := env[name]; !ok {
+		return false
+	}
+	delete(env, name)
+	if len(env) == 0 {
+		if project == "" {
+			c.Env = nil
+		} else {
+			delete(c.ProjectEnv, project)
+			if len(c.ProjectEnv) == 0 {
+				c.ProjectEnv = nil
+			}
+		}
+	}
+	return true
+}
+
+// AddExtraStore adds a secondary session database under a unique name.
+func (c *Config) AddExtraStore(name, path string) error {
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return fmt.Errorf("invalid store name %q", name)
+	}
+	if path == "" {
+		return fmt.Errorf("store path must not be empty")
+	}
+	if slices.ContainsFunc(c.ExtraStores, func(e ExtraStore) bool { return e.Name == name }) {
+		return fmt.Errorf("store %q already exists", name)
+	}
+	c.ExtraStores = append(c.ExtraStores, ExtraStore{Name: name, Path: path})
+	return nil
+}
+
+// RemoveExtraStore removes the secondary store with the given name.
+// Returns false if there is none.
+func (c *Config) RemoveExtraStore(name string) bool {
+	i := slices.IndexFunc(c.ExtraStores, func(e ExtraStore) bool { return e.Name == name })
+	if i < 0 {
+		return false
+	}
+	c.ExtraStores = slices.Delete(c.ExtraStores, i, i+1)
+	if len(c.ExtraStores) == 0 {
+		c.ExtraStores = nil
+	}
+	return true
+}
+
+// WorkspaceProjects returns the project directories of workspace name, with
+// "~/" expanded and symlinks resolved, and whether there is such a workspace.
+func (c Config) WorkspaceProjects(name string) ([]string, bool) {
+	dirs, ok := c.Workspaces[name]
+	if !ok {
+		return nil, false
+	}
+	projects := make([]string, len(dirs))
+	for i, dir := range dirs {
+		projects[i] = resolveDir(dir)
+	}
+	return projects, true
+}
+
+// WorkspaceOf returns the name of the workspace that project belongs to, the
+// first by name if there are several, or "" if none.
+func (c Config) WorkspaceOf(project string) string {
+	project = resolveDir(project)
+	for _, name := range slices.Sorted(maps.Keys(c.Workspaces)) {
+		if projects, _ := c.WorkspaceProjects(name); slices.Contains(projects, project) {
+			return name
+		}
+	}
+	return ""
+}
+
+// AddToWorkspace adds project directories to workspace name, creating it if
+// needed. Directories already in it are skipped.
+func (c *Config) AddToWorkspace(name string, dirs []string) error {
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return fmt.Errorf("invalid workspace name %q", name)
+	}
+	if len(dirs) == 0 {
+		return fmt.Errorf("workspace %s needs at least one project directory", name)
+	}
+	if c.Workspaces == nil {
+		c.Workspaces = make(map[string][]string)
+	}
+	for _, dir := range dirs {
+		if dir == "" {
+			return fmt.Errorf("project directory must not be empty")
+		}
+		if !slices.Contains(c.Workspaces[name], dir) {
+			c.Workspaces[name] = append(c.Workspaces[name], dir)
+		}
+	}
+	return nil
+}
+
+// RemoveFromWorkspace removes project directories from workspace name, or
+// the whole workspace if dirs is empty. A workspace left without directories
+// is removed. Returns false if the workspace or a directory is not there.
+func (c *Config) RemoveFromWorkspace(name string, dirs []string) bool {
+	current, ok := c.Workspaces[name]
+	if !ok {
+		return false
+	}
+	if len(dirs) > 0 {
+		for _, dir := range dirs {
+			i := slices.Index(current, dir)
+			if i < 0 {
+				return false
+			}
+			current = slices.Delete(slices.Clone(current), i, i+1)
+		}
+		c.Workspaces[name] = current
+	}
+	if len(dirs) == 0 || len(current) == 0 {
+		delete(c.Workspaces, name)
+	}
+	if len(c.Workspaces) == 0 {
+		c.Workspaces = nil
+	}
+	return true
+}
+
+// IsPromptIgnored reports whether prompt matches any of the IgnorePromptPatterns.
+// Invalid patterns are skipped.
+func (c Config) IsPromptIgnored(prompt string) bool {
+	for _, pattern := range c.IgnorePromptPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(prompt) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddIgnoredProject adds a glob pattern to IgnoredProjects.
+// Returns false if the pattern is already present.
+func (c *Config) AddIgnoredProject(pattern string) (bool, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return false, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	if slices.Contains(c.IgnoredProjects, pattern) {
+		return false, nil
+	}
+	c.IgnoredProjects = append(c.IgnoredProjects, pattern)
+	return true, nil
+}
+
+// RemoveIgnoredProject removes a glob pattern from IgnoredProjects.
+// Returns false if the pattern was not present.
+func (c *Config) RemoveIgnoredProject(pattern string) bool {
+	i := slices.Index(c.IgnoredProjects, pattern)
+	if i < 0 {
+		return false
+	}
+	c.IgnoredProjects = slices.Delete(c.IgnoredProjects, i, i+1)
+	if len(c.IgnoredProjects) == 0 {
+		c.IgnoredProjects = nil
+	}
+	return true
+}
+
+// matchProject reports whether dir, or any of its parent directories, matches
+// the glob pattern. A leading "~/" in the pattern is expanded.
+func matchProject(pattern, dir string) bool {
+	pattern = ExpandHome(pattern)
+	for p := filepath.Clean(dir); ; p = filepath.Dir(p) {
+		if ok, _ := filepath.Match(pattern, p); ok {
+			return true
+		}
+		if parent := filepath.Dir(p); parent == p {
+			return false
+		}
+	}
+}
+
+// resolveDir expands a leading "~/" in dir and resolves symlinks, as
+// project directories are recorded.
+func resolveDir(dir string) string {
+	dir = filepath.Clean(ExpandHome(dir))
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return resolved
+	}
+	return dir
+}
+
+// ExpandHome replaces a leading ~ in p with the user's home directory.
+func ExpandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, strings.TrimPrefix(p, "~"))
+}
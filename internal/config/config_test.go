@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -102,3 +103,336 @@ func TestClaudeArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestResumeArgs(t *testing.T) {
+	cfg := Config{
+		DangerouslySkipPermissions: true,
+		ExtraArgs:                  []string{"--model", "sonnet", "--add-dir", "/shared"},
+		ProjectArgs: map[string][]string{
+			"/src":     {"--model", "opus", "--verbose"},
+			"/src/api": {"--model=haiku"},
+			"/other":   {"--debug"},
+		},
+	}
+	tests := []struct {
+		project string
+		session []string
+		want    []string
+	}{
+		{"/home/me", nil, []string{"--dangerously-skip-permissions", "--model", "sonnet", "--add-dir", "/shared"}},
+		{"/src/web", nil, []string{"--dangerously-skip-permissions", "--add-dir", "/shared", "--model", "opus", "--verbose"}},
+		{"/src/api", nil, []string{"--dangerously-skip-permissions", "--add-dir", "/shared", "--verbose", "--model=haiku"}},
+		{"/src/api", []string{"--model", "opus", "--add-dir", "/a", "--add-dir", "/b"},
+			[]string{"--dangerously-skip-permissions", "--verbose", "--model", "opus", "--add-dir", "/a", "--add-dir", "/b"}},
+	}
+	for _, tc := range tests {
+		if got := cfg.ResumeArgs(tc.project, tc.session); !slices.Equal(got, tc.want) {
+			t.Errorf("ResumeArgs(%q, %q) = %q, want %q", tc.project, tc.session, got, tc.want)
+		}
+	}
+}
+
+func TestResumeDir(t *testing.T) {
+	project := t.TempDir()
+	sub := filepath.Join(project, "pkg", "api")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		mode, cwd, want string
+	}{
+		{"", sub, project},
+		{"project", sub, project},
+		{"last", sub, sub},
+		{"last", "", project},
+		{"last", filepath.Join(project, "gone"), project},
+	}
+	for _, tc := range tests {
+		cfg := Config{ResumeCWD: tc.mode}
+		if got := cfg.ResumeDir(project, tc.cwd); got != tc.want {
+			t.Errorf("ResumeDir with %q, cwd %q = %q, want %q", tc.mode, tc.cwd, got, tc.want)
+		}
+	}
+}
+
+func TestRoughErrors(t *testing.T) {
+	for _, tc := range []struct{ set, want int }{
+		{0, DefaultRoughToolErrors},
+		{3, 3},
+		{-1, 0},
+	} {
+		if got := (Config{RoughToolErrors: tc.set}).RoughErrors(); got != tc.want {
+			t.Errorf("RoughErrors with %d = %d, want %d", tc.set, got, tc.want)
+		}
+	}
+}
+
+func TestClaudeCommand(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("no home directory: %v", err)
+	}
+	for _, tc := range []struct {
+		bin  []string
+		want []string
+	}{
+		{nil, []string{"claude"}},
+		{[]string{"~/.local/bin/claude"}, []string{filepath.Join(home, ".local/bin/claude")}},
+		{[]string{"ssh", "devbox", "claude"}, []string{"ssh", "devbox", "claude"}},
+	} {
+		cfg := Config{ClaudeBin: tc.bin}
+		if got := cfg.ClaudeCommand(); !slices.Equal(got, tc.want) {
+			t.Errorf("ClaudeCommand with %q = %q, want %q", tc.bin, got, tc.want)
+		}
+	}
+	cfg := Config{ClaudeBin: []string{"~/claude"}}
+	_ = cfg.ClaudeCommand()
+	if cfg.ClaudeBin[0] != "~/claude" {
+		t.Error("ClaudeCommand modified ClaudeBin")
+	}
+}
+
+func TestIsProjectIgnored(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("no home directory: %v", err)
+	}
+	cfg := Config{IgnoredProjects: []string{"/tmp/*", "~/clients/acme", "/work/*-scratch"}}
+
+	tests := []struct {
+		dir  string
+		want bool
+	}{
+		{"/tmp/foo", true},
+		{"/tmp/foo/bar", true},
+		{"/tmp", false},
+		{filepath.Join(home, "clients", "acme"), true},
+		{filepath.Join(home, "clients", "acme", "api"), true},
+		{filepath.Join(home, "clients", "other"), false},
+		{"/work/jan-scratch", true},
+		{"/work/project", false},
+		{"", false},
+	}
+	for _, tc := range tests {
+		if got := cfg.IsProjectIgnored(tc.dir); got != tc.want {
+			t.Errorf("IsProjectIgnored(%q) = %v, want %v", tc.dir, got, tc.want)
+		}
+	}
+}
+
+func TestAddRemoveIgnoredProject(t *testing.T) {
+	var cfg Config
+
+	added, err := cfg.AddIgnoredProject("/tmp/*")
+	if err != nil || !added {
+		t.Fatalf("AddIgnoredProject = %v, %v; want true, nil", added, err)
+	}
+	added, err = cfg.AddIgnoredProject("/tmp/*")
+	if err != nil || added {
+		t.Fatalf("AddIgnoredProject duplicate = %v, %v; want false, nil", added, err)
+	}
+	if _, err := cfg.AddIgnoredProject("[bad"); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+
+	if cfg.RemoveIgnoredProject("/nope") {
+		t.Error("RemoveIgnoredProject of missing pattern = true, want false")
+	}
+	if !cfg.RemoveIgnoredProject("/tmp/*") {
+		t.Error("RemoveIgnoredProject = false, want true")
+	}
+	if cfg.IgnoredProjects != nil {
+		t.Errorf("IgnoredProjects = %v, want nil", cfg.IgnoredProjects)
+	}
+}
+
+func TestIsPromptIgnored(t *testing.T) {
+	cfg := Config{IgnorePromptPatterns: []string{`^(?i)(yes|ok|continue)$`, `[invalid`}}
+
+	tests := []struct {
+		prompt string
+		want   bool
+	}{
+		{"yes", true},
+		{"OK", true},
+		{"continue", true},
+		{"continue with the refactor", false},
+		{"fix the bug", false},
+	}
+	for _, tc := range tests {
+		if got := cfg.IsPromptIgnored(tc.prompt); got != tc.want {
+			t.Errorf("IsPromptIgnored(%q) = %v, want %v", tc.prompt, got, tc.want)
+		}
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	cfg := Config{
+		Env: map[string]string{"HTTPS_PROXY": "http://proxy:3128", "ANTHROPIC_MODEL": "sonnet"},
+		ProjectEnv: map[string]map[string]string{
+			"/work/*":     {"ANTHROPIC_MODEL": "opus"},
+			"/work/infra": {"ANTHROPIC_MODEL": "haiku", "AWS_PROFILE": "infra"},
+		},
+	}
+	base := []string{"PATH=/usr/bin", "ANTHROPIC_MODEL=default"}
+
+	tests := []struct {
+		project string
+		want    []string
+	}{
+		{"/home/me/app", []string{"PATH=/usr/bin", "ANTHROPIC_MODEL=sonnet", "HTTPS_PROXY=http://proxy:3128"}},
+		{"/work/api", []string{"PATH=/usr/bin", "ANTHROPIC_MODEL=opus", "HTTPS_PROXY=http://proxy:3128"}},
+		{"/work/infra/modules", []string{"PATH=/usr/bin", "ANTHROPIC_MODEL=haiku", "AWS_PROFILE=infra", "HTTPS_PROXY=http://proxy:3128"}},
+	}
+	for _, tc := range tests {
+		got := cfg.Environ(tc.project, base)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("Environ(%q) = %v, want %v", tc.project, got, tc.want)
+		}
+	}
+
+	if got := (Config{}).Environ("/work/api", base); !slices.Equal(got, base) {
+		t.Errorf("Environ with no env config = %v, want base unchanged", got)
+	}
+}
+
+func TestRetentionFor(t *testing.T) {
+	cfg := Config{
+		RetentionDays: 60,
+		ProjectRetention: map[string]RetentionRule{
+			"/scratch/*":    {MaxSessions: 20},
+			"/work":         {Days: -1},
+			"/work/sandbox": {Days: 7, MaxSessions: 5},
+		},
+	}
+
+	tests := []struct {
+		project           string
+		days, maxSessions int
+	}{
+		{"/home/me/app", 60, 0},
+		{"/scratch/tmp1", 60, 20},
+		{"/work/api", 0, 0},
+		{"/work/sandbox/x", 7, 5},
+	}
+	for _, tc := range tests {
+		days, maxSessions := cfg.RetentionFor(tc.project)
+		if days != tc.days || maxSessions != tc.maxSessions {
+			t.Errorf("RetentionFor(%q) = %d, %d, want %d, %d", tc.project, days, maxSessions, tc.days, tc.maxSessions)
+		}
+	}
+
+	if days, _ := (Config{}).RetentionFor("/any"); days != DefaultRetentionDays {
+		t.Errorf("default retention = %d days, want %d", days, DefaultRetentionDays)
+	}
+	if err := cfg.SetRetention("[bad", RetentionRule{}); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+	if !cfg.UnsetRetention("/work") || cfg.UnsetRetention("/work") {
+		t.Error("UnsetRetention should remove a policy once")
+	}
+}
+
+func TestSetUnsetEnv(t *testing.T) {
+	var cfg Config
+
+	if err := cfg.SetEnv("", "ANTHROPIC_MODEL", "opus"); err != nil {
+		t.Fatalf("SetEnv: %v", err)
+	}
+	if err := cfg.SetEnv("~/clients/*", "HTTPS_PROXY", "http://proxy:3128"); err != nil {
+		t.Fatalf("SetEnv project: %v", err)
+	}
+	if err := cfg.SetEnv("", "BAD=NAME", "x"); err == nil {
+		t.Error("expected error for name containing '='")
+	}
+	if err := cfg.SetEnv("[bad", "X", "y"); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+	if cfg.Env["ANTHROPIC_MODEL"] != "opus" || cfg.ProjectEnv["~/clients/*"]["HTTPS_PROXY"] != "http://proxy:3128" {
+		t.Fatalf("unexpected env config: %+v %+v", cfg.Env, cfg.ProjectEnv)
+	}
+
+	if cfg.UnsetEnv("", "HTTPS_PROXY") {
+		t.Error("UnsetEnv of variable set only for a project = true, want false")
+	}
+	if !cfg.UnsetEnv("~/clients/*", "HTTPS_PROXY") || cfg.ProjectEnv != nil {
+		t.Errorf("UnsetEnv project: ProjectEnv = %v, want nil", cfg.ProjectEnv)
+	}
+	if !cfg.UnsetEnv("", "ANTHROPIC_MODEL") || cfg.Env != nil {
+		t.Errorf("UnsetEnv: Env = %v, want nil", cfg.Env)
+	}
+}
+
+func TestAddRemoveExtraStore(t *testing.T) {
+	var cfg Config
+
+	if err := cfg.AddExtraStore("laptop", "~/sync/sessions.db"); err != nil {
+		t.Fatalf("AddExtraStore: %v", err)
+	}
+	if err := cfg.AddExtraStore("laptop", "/other.db"); err == nil {
+		t.Error("expected error for duplicate name")
+	}
+	if err := cfg.AddExtraStore("bad name", "/x.db"); err == nil {
+		t.Error("expected error for name with a space")
+	}
+	if err := cfg.AddExtraStore("empty", ""); err == nil {
+		t.Error("expected error for empty path")
+	}
+
+	home, _ := os.UserHomeDir()
+	if got, want := cfg.ExtraStores[0].ExpandedPath(), filepath.Join(home, "sync/sessions.db"); got != want {
+		t.Errorf("ExpandedPath = %q, want %q", got, want)
+	}
+
+	if cfg.RemoveExtraStore("desktop") {
+		t.Error("RemoveExtraStore of missing store = true, want false")
+	}
+	if !cfg.RemoveExtraStore("laptop") {
+		t.Error("RemoveExtraStore = false, want true")
+	}
+	if cfg.ExtraStores != nil {
+		t.Errorf("ExtraStores = %v, want nil", cfg.ExtraStores)
+	}
+}
+
+func TestWorkspaces(t *testing.T) {
+	var cfg Config
+	api, web := t.TempDir(), t.TempDir()
+
+	if err := cfg.AddToWorkspace("backend", []string{api, web}); err != nil {
+		t.Fatalf("AddToWorkspace: %v", err)
+	}
+	if err := cfg.AddToWorkspace("backend", []string{api}); err != nil || len(cfg.Workspaces["backend"]) != 2 {
+		t.Errorf("adding a directory twice: %v, %v", err, cfg.Workspaces["backend"])
+	}
+	if err := cfg.AddToWorkspace("bad name", []string{api}); err == nil {
+		t.Error("expected error for name with a space")
+	}
+	if err := cfg.AddToWorkspace("empty", nil); err == nil {
+		t.Error("expected error for no directories")
+	}
+
+	projects, ok := cfg.WorkspaceProjects("backend")
+	if !ok || len(projects) != 2 {
+		t.Fatalf("WorkspaceProjects = %v, %v", projects, ok)
+	}
+	if _, ok := cfg.WorkspaceProjects("frontend"); ok {
+		t.Error("WorkspaceProjects of missing workspace = true, want false")
+	}
+	if got := cfg.WorkspaceOf(web); got != "backend" {
+		t.Errorf("WorkspaceOf(web) = %q, want backend", got)
+	}
+	if got := cfg.WorkspaceOf(t.TempDir()); got != "" {
+		t.Errorf("WorkspaceOf(other) = %q, want none", got)
+	}
+
+	if cfg.RemoveFromWorkspace("backend", []string{"/not/in/it"}) {
+		t.Error("RemoveFromWorkspace of missing directory = true, want false")
+	}
+	if !cfg.RemoveFromWorkspace("backend", []string{api}) || !slices.Equal(cfg.Workspaces["backend"], []string{web}) {
+		t.Errorf("RemoveFromWorkspace(api): Workspaces = %v", cfg.Workspaces)
+	}
+	if !cfg.RemoveFromWorkspace("backend", []string{web}) || cfg.Workspaces != nil {
+		t.Errorf("removing the last directory: Workspaces = %v, want nil", cfg.Workspaces)
+	}
+}
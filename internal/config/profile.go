@@ -0,0 +1,178 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Profiles keep separate session histories, such as personal and client
+// work: each has its own directory under ~/.cst/profiles, holding its
+// config, database, log, scripts and daemon socket. The default profile is
+// ~/.cst itself.
+const (
+	DefaultProfile = "default"
+	ProfilesDir    = "profiles"
+
+	// ActiveProfileName is the file in ~/.cst naming the profile cst profile
+	// use chose; without it the default profile is used.
+	ActiveProfileName = "profile"
+
+	// ProfileEnv names the profile to use, over the one cst profile use
+	// chose. cst sets it for the claude it resumes, so that the hooks of the
+	// session record into the profile it was resumed from.
+	ProfileEnv = "CST_PROFILE"
+)
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// profile is the profile in use, "" for the default one.
+var profile string
+
+// BaseDir returns ~/.cst, the default profile's directory, which holds the
+// other profiles.
+func BaseDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, DefaultConfigDir)
+}
+
+// Dir returns the directory of the profile in use.
+func Dir() string {
+	if profile == "" {
+		return BaseDir()
+	}
+	return ProfileDir(profile)
+}
+
+// ProfileDir returns the directory of the named profile.
+func ProfileDir(name string) string {
+	if name == DefaultProfile {
+		return BaseDir()
+	}
+	return filepath.Join(BaseDir(), ProfilesDir, name)
+}
+
+// Profile returns the name of the profile in use.
+func Profile() string {
+	if profile == "" {
+		return DefaultProfile
+	}
+	return profile
+}
+
+// SetProfile switches the paths returned by this package, such as
+// DefaultConfigPath, to the named profile, which must exist.
+func SetProfile(name string) error {
+	if name == DefaultProfile {
+		profile = ""
+		return nil
+	}
+	if err := checkProfile(name); err != nil {
+		return err
+	}
+	profile = name
+	return nil
+}
+
+// ResolveProfile returns the profile to use: flag, when given, else
+// $CST_PROFILE, else the one cst profile use chose, else the default.
+func ResolveProfile(flag string) (string, error) {
+	if flag != "" {
+		return flag, nil
+	}
+	if env := os.Getenv(ProfileEnv); env != "" {
+		return env, nil
+	}
+	return ActiveProfile()
+}
+
+// ActiveProfile returns the profile cst profile use chose, or the default.
+func ActiveProfile() (string, error) {
+	data, err := os.ReadFile(filepath.Join(BaseDir(), ActiveProfileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultProfile, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if name := strings.TrimSpace(string(data)); name != "" {
+		return name, nil
+	}
+	return DefaultProfile, nil
+}
+
+// UseProfile makes the named profile, which must exist, the one used
+// without --profile or $CST_PROFILE.
+func UseProfile(name string) error {
+	path := filepath.Join(BaseDir(), ActiveProfileName)
+	if name == DefaultProfile {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := checkProfile(name); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(name+"\n"), 0644)
+}
+
+// CreateProfile creates the named profile's directory. Its config and
+// database are created on first use.
+func CreateProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if name == DefaultProfile {
+		return fmt.Errorf("profile %q always exists", name)
+	}
+	dir := ProfileDir(name)
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// Profiles lists the profiles, the default one first.
+func Profiles() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(BaseDir(), ProfilesDir))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && profileName.MatchString(e.Name()) && e.Name() != DefaultProfile {
+			names = append(names, e.Name())
+		}
+	}
+	slices.Sort(names)
+	return append([]string{DefaultProfile}, names...), nil
+}
+
+// ValidateProfileName reports whether name can name a profile: letters,
+// digits, dots, dashes and underscores, not starting with a dot or dash.
+func ValidateProfileName(name string) error {
+	if !profileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '-' and '_'", name)
+	}
+	return nil
+}
+
+// checkProfile returns an error unless the named profile exists.
+func checkProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if info, err := os.Stat(ProfileDir(name)); err != nil || !info.IsDir() {
+		return fmt.Errorf("no profile %q, create it with cst profile create %s", name, name)
+	}
+	return nil
+}
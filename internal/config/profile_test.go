@@ -0,0 +1,100 @@
+package config
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(ProfileEnv, "")
+	t.Cleanup(func() { profile = "" })
+	base := filepath.Join(home, ".cst")
+
+	if got := DefaultConfigPath(); got != filepath.Join(base, "config.json") {
+		t.Errorf("DefaultConfigPath() = %q, want the default profile's", got)
+	}
+	if err := SetProfile("work"); err == nil {
+		t.Error("SetProfile of a missing profile succeeded")
+	}
+	if err := CreateProfile("../work"); err == nil {
+		t.Error("CreateProfile accepted a path")
+	}
+	if err := CreateProfile("work"); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+	if err := CreateProfile("work"); err == nil {
+		t.Error("CreateProfile of an existing profile succeeded")
+	}
+	if err := CreateProfile("client-a"); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+	names, err := Profiles()
+	if err != nil {
+		t.Fatalf("Profiles: %v", err)
+	}
+	if want := []string{"default", "client-a", "work"}; !slices.Equal(names, want) {
+		t.Errorf("Profiles() = %v, want %v", names, want)
+	}
+
+	if err := SetProfile("work"); err != nil {
+		t.Fatalf("SetProfile: %v", err)
+	}
+	if got := Profile(); got != "work" {
+		t.Errorf("Profile() = %q, want work", got)
+	}
+	if got, want := DefaultSocketPath(), filepath.Join(base, "profiles", "work", "daemon.sock"); got != want {
+		t.Errorf("DefaultSocketPath() = %q, want %q", got, want)
+	}
+	if err := SetProfile(DefaultProfile); err != nil {
+		t.Fatalf("SetProfile default: %v", err)
+	}
+	if got := Dir(); got != base {
+		t.Errorf("Dir() = %q, want %q", got, base)
+	}
+}
+
+func TestResolveProfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ProfileEnv, "")
+	if err := CreateProfile("work"); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+
+	resolve := func() string {
+		t.Helper()
+		name, err := ResolveProfile("")
+		if err != nil {
+			t.Fatalf("ResolveProfile: %v", err)
+		}
+		return name
+	}
+	if got := resolve(); got != DefaultProfile {
+		t.Errorf("no profile used: got %q, want default", got)
+	}
+	if err := UseProfile("missing"); err == nil {
+		t.Error("UseProfile of a missing profile succeeded")
+	}
+	if err := UseProfile("work"); err != nil {
+		t.Fatalf("UseProfile: %v", err)
+	}
+	if got := resolve(); got != "work" {
+		t.Errorf("after use: got %q, want work", got)
+	}
+	t.Setenv(ProfileEnv, "other")
+	if got := resolve(); got != "other" {
+		t.Errorf("with %s: got %q, want other", ProfileEnv, got)
+	}
+	if got, _ := ResolveProfile("flag"); got != "flag" {
+		t.Errorf("with a flag: got %q, want flag", got)
+	}
+	t.Setenv(ProfileEnv, "")
+	if err := UseProfile(DefaultProfile); err != nil {
+		t.Fatalf("UseProfile default: %v", err)
+	}
+	if got := resolve(); got != DefaultProfile {
+		t.Errorf("after use default: got %q, want default", got)
+	}
+}
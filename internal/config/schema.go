@@ -0,0 +1,315 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Type is the type of a config key's value.
+type Type string
+
+const (
+	TypeBool   Type = "bool"
+	TypeInt    Type = "int"
+	TypeString Type = "string"
+	TypeList   Type = "list" // of strings
+)
+
+// Accepted values of the keys that take one of a fixed set, the default
+// first.
+var (
+	PreviewPositions = []string{"right", "bottom", "hidden"}
+	Themes           = []string{"auto", "dark", "light", "mono", "custom"}
+	Scopes           = []string{"project", "workspace", "all"}
+	SortOrders       = []string{"activity", "started", "project"}
+	AutoTitleModes   = []string{"off", "summary", "claude"}
+	HookFailModes    = []string{"warn", "silent", "strict"}
+	PromptStorages   = []string{"truncate", "full", "compressed"}
+	ResumeCWDs       = []string{"project", "last"}
+)
+
+// Key describes a config key that cst config set and cst config edit
+// change. Keys holding maps, such as project_env, have commands of their
+// own and are not in the schema.
+type Key struct {
+	Name        string
+	Type        Type
+	Default     string // the behavior when unset, as shown to users
+	Description string
+	Choices     []string // for a string taking one of a fixed set
+	// Regexps marks a list of regular expressions, which may hold commas,
+	// as in {1,3}: a value is a JSON array of patterns, or else one pattern,
+	// never split on commas.
+	Regexps bool
+	check   func(v any) error
+}
+
+// schema lists the keys in the order cst config list-keys shows them.
+var schema = []Key{
+	{Name: "dangerously_skip_permissions", Type: TypeBool, Default: "false",
+		Description: "Always pass --dangerously-skip-permissions to claude"},
+	{Name: "extra_args", Type: TypeList,
+		Description: "Additional args to pass to claude on resume"},
+	{Name: "claude_bin", Type: TypeList, Default: "claude from PATH",
+		Description: "Command that runs claude, or a wrapper such as ssh,devbox,claude that claude's arguments are appended to"},
+	{Name: "resume_cwd", Type: TypeString, Default: "project", Choices: ResumeCWDs,
+		Description: "Directory claude resumes a session in: its project, or the last directory it worked in"},
+	{Name: "ignore_prompt_patterns", Type: TypeList, Regexps: true,
+		Description: `Regexes of prompts not stored in history, e.g. "^(?i)(yes|ok|continue)$"`, check: regexps},
+	{Name: "debug_log", Type: TypeBool, Default: "false",
+		Description: "Log hook activity and slow queries to ~/.cst/cst.log"},
+	{Name: "slow_query_ms", Type: TypeInt, Default: "100",
+		Description: "Log store queries slower than this many milliseconds", check: nonNegative},
+	{Name: "preview_position", Type: TypeString, Default: "right", Choices: PreviewPositions,
+		Description: "Where the launcher shows the preview pane"},
+	{Name: "preview_width", Type: TypeInt, Default: "half the terminal, at most 60 columns",
+		Description: "Side preview width as a percentage, 20 to 80 (0 for the default)", check: previewWidth},
+	{Name: "idle_gap_minutes", Type: TypeInt, Default: "15",
+		Description: "Pauses longer than this don't count as time worked", check: nonNegative},
+	{Name: "max_db_size_mb", Type: TypeInt, Default: "no cap",
+		Description: "Trim the prompts of inactive sessions when the database grows past this, on session end and cst cleanup", check: nonNegative},
+	{Name: "pid_grace_seconds", Type: TypeInt, Default: "10",
+		Description: "How long a session's claude process may be gone, e.g. while it restarts, before the session ends (negative for none)"},
+	{Name: "theme", Type: TypeString, Default: "auto", Choices: Themes,
+		Description: "Launcher color theme; see cst config color"},
+	{Name: "ascii", Type: TypeBool, Default: "false",
+		Description: "Draw the launcher and output with ASCII characters only"},
+	{Name: "default_scope", Type: TypeString, Default: "project", Choices: Scopes,
+		Description: "Sessions the launcher shows when --all and --workspace are not given"},
+	{Name: "sort", Type: TypeString, Default: "activity", Choices: SortOrders,
+		Description: "Launcher session order"},
+	{Name: "refresh_seconds", Type: TypeInt, Default: "off",
+		Description: "Reload the launcher session list periodically", check: nonNegative},
+	{Name: "retention_days", Type: TypeInt, Default: strconv.Itoa(DefaultRetentionDays),
+		Description: "Default age for cst cleanup", check: nonNegative},
+	{Name: "cleared_retention_days", Type: TypeInt, Default: "off",
+		Description: "Age after which cst cleanup removes sessions ended with /clear", check: nonNegative},
+	{Name: "ticket_patterns", Type: TypeList, Regexps: true, Default: "JIRA-123 and 1234- prefixes",
+		Description: "Regexes extracting ticket IDs from git branches as tags; the first group is the ID", check: regexps},
+	{Name: "redact_patterns", Type: TypeList, Regexps: true,
+		Description: "Extra regexes of secrets cst share removes; with a group, only the first group", check: regexps},
+	{Name: "script_timeout_seconds", Type: TypeInt, Default: "3",
+		Description: "Kill scripts in ~/.cst/hooks.d after this long, or sooner to finish within the hook timeout", check: nonNegative},
+	{Name: "hook_fail_mode", Type: TypeString, Default: "warn", Choices: HookFailModes,
+		Description: "When a hook fails: log it to ~/.cst/hook-errors.log and note it on stderr (warn), only log it (silent), or also exit non-zero, showing it in Claude Code (strict)"},
+	{Name: "hook_daemon", Type: TypeBool, Default: "false",
+		Description: "Hooks hand their events to cst daemon over ~/.cst/daemon.sock instead of opening the database, while it runs"},
+	{Name: "tool_stats", Type: TypeBool, Default: "false",
+		Description: "Count the calls of each tool per session, shown in the preview and cst stats --tools; one more write per tool call"},
+	{Name: "rough_tool_errors", Type: TypeInt, Default: strconv.Itoa(DefaultRoughToolErrors),
+		Description: "Flag sessions with this many failed tool calls as rough in the launcher; negative never flags them"},
+	{Name: "prompt_storage", Type: TypeString, Default: "truncate", Choices: PromptStorages,
+		Description: "Long prompts: cut to prompt_max_len (truncate), also kept whole for search and export (full), or also kept whole but compressed (compressed)"},
+	{Name: "prompt_max_len", Type: TypeInt, Default: "200",
+		Description: "Length in bytes long prompts are cut to for listings; 0 uses the default", check: nonNegative},
+	{Name: "outcome_survey", Type: TypeBool, Default: "false",
+		Description: "Ask for an outcome label (cst outcomes) when a session ends"},
+	{Name: "warn_on_multiple_active_per_project", Type: TypeBool, Default: "false",
+		Description: "Point out projects with more than one running session, which may edit the same files"},
+	{Name: "auto_title", Type: TypeString, Default: "off", Choices: AutoTitleModes,
+		Description: "Title ended sessions from the transcript summary, falling back to claude -p on the prompts with claude; see cst title"},
+	{Name: "columns", Type: TypeList, Default: "status, project, time, prompt",
+		Description: "Columns of cst list and the launcher list, in order"},
+}
+
+// Keys returns the schema of the keys cst config set accepts.
+func Keys() []Key {
+	return slices.Clone(schema)
+}
+
+// LookupKey returns the key named name.
+func LookupKey(name string) (Key, bool) {
+	i := slices.IndexFunc(schema, func(k Key) bool { return k.Name == name })
+	if i < 0 {
+		return Key{}, false
+	}
+	return schema[i], true
+}
+
+// RegisterCheck adds a check of the values of key name, for packages that
+// own their meaning, such as the launcher and its column names. It panics
+// if there is no such key.
+func RegisterCheck(name string, check func(v any) error) {
+	i := slices.IndexFunc(schema, func(k Key) bool { return k.Name == name })
+	if i < 0 {
+		panic("config: no key " + name)
+	}
+	prev := schema[i].check
+	schema[i].check = func(v any) error {
+		if prev != nil {
+			if err := prev(v); err != nil {
+				return err
+			}
+		}
+		return check(v)
+	}
+}
+
+// Parse converts a value given on the command line to the key's type. A
+// list is comma-separated, but for Regexps keys; "" or "[]" is an empty
+// list.
+func (k Key) Parse(s string) (any, error) {
+	switch k.Type {
+	case TypeBool:
+		switch s {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid value %q for %s, expected true or false", s, k.Name)
+	case TypeInt:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for %s, expected an integer", s, k.Name)
+		}
+		return n, nil
+	case TypeList:
+		var items []string
+		if s == "[]" {
+			return items, nil
+		}
+		if k.Regexps {
+			// A pattern may start with a character class, as in [A-Z]+-\d+,
+			// so only a value that is a JSON array is taken as one
+			if err := json.Unmarshal([]byte(s), &items); err == nil {
+				return items, nil
+			}
+			items = nil
+			if s = strings.TrimSpace(s); s != "" {
+				items = append(items, s)
+			}
+			return items, nil
+		}
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+	return s, nil
+}
+
+// Format renders a value of the key for display; lists are comma-separated,
+// and lists of patterns, but for a single one, a JSON array.
+func (k Key) Format(v any) string {
+	if items, ok := v.([]string); ok && k.Regexps && (len(items) > 1 || len(items) == 1 && strings.HasPrefix(items[0], "[")) {
+		data, _ := json.Marshal(items)
+		return string(data)
+	}
+	switch v := v.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case []string:
+		return strings.Join(v, ",")
+	case string:
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+// Value returns the value of key name in c: a bool, int, string or
+// []string. A key with choices that is unset has its default, the first
+// choice. It panics if there is no such key.
+func (c Config) Value(name string) any {
+	k, ok := LookupKey(name)
+	if !ok {
+		panic("config: no key " + name)
+	}
+	v := fieldByName(&c, name).Interface()
+	if s, ok := v.(string); ok && s == "" && len(k.Choices) > 0 {
+		return k.Choices[0]
+	}
+	return v
+}
+
+// Set validates v against the schema of key name and stores it in c. v
+// must have the key's type, as returned by Parse.
+func (c *Config) Set(name string, v any) error {
+	k, ok := LookupKey(name)
+	if !ok {
+		return fmt.Errorf("unknown config key: %q", name)
+	}
+	field := fieldByName(c, name)
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Type() != field.Type() {
+		return fmt.Errorf("invalid value for %s, expected a %s", name, k.Type)
+	}
+	if len(k.Choices) > 0 && !slices.Contains(k.Choices, v.(string)) {
+		return fmt.Errorf("invalid value %q for %s, expected one of %s", v, name, strings.Join(k.Choices, ", "))
+	}
+	if k.check != nil {
+		if err := k.check(v); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", k.Format(v), name, err)
+		}
+	}
+	if items, ok := v.([]string); ok && len(items) == 0 {
+		rv = reflect.Zero(field.Type()) // saved as absent rather than []
+	}
+	field.Set(rv)
+	return nil
+}
+
+// fieldByName returns the field of c whose JSON name is name.
+func fieldByName(c *Config, name string) reflect.Value {
+	rv := reflect.ValueOf(c).Elem()
+	for i := range rv.NumField() {
+		if jsonName(rv.Type().Field(i)) == name {
+			return rv.Field(i)
+		}
+	}
+	panic("config: no field " + name)
+}
+
+// jsonName returns the name a struct field has in JSON.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
+
+// knownNames returns the JSON names of every Config field, including those
+// without a schema entry.
+func knownNames() map[string]bool {
+	names := make(map[string]bool)
+	t := reflect.TypeFor[Config]()
+	for i := range t.NumField() {
+		if name := jsonName(t.Field(i)); name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+var errNegative = errors.New("expected a non-negative integer")
+
+func nonNegative(v any) error {
+	if v.(int) < 0 {
+		return errNegative
+	}
+	return nil
+}
+
+func previewWidth(v any) error {
+	if n := v.(int); n != 0 && (n < 20 || n > 80) {
+		return errors.New("expected a percentage between 20 and 80, or 0")
+	}
+	return nil
+}
+
+func regexps(v any) error {
+	for _, pattern := range v.([]string) {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSchemaCoversFields(t *testing.T) {
+	var cfg Config
+	for _, k := range Keys() {
+		// Value panics on a key without a field.
+		v := cfg.Value(k.Name)
+		if len(k.Choices) > 0 && v != k.Choices[0] {
+			t.Errorf("%s: unset value = %v, want %q", k.Name, v, k.Choices[0])
+		}
+		if k.Description == "" {
+			t.Errorf("%s: no description", k.Name)
+		}
+	}
+}
+
+func TestParseAndSet(t *testing.T) {
+	tests := []struct {
+		key, value string
+		wantErr    string
+	}{
+		{"debug_log", "true", ""},
+		{"debug_log", "yes", "expected true or false"},
+		{"slow_query_ms", "250", ""},
+		{"slow_query_ms", "fast", "expected an integer"},
+		{"slow_query_ms", "-1", "non-negative"},
+		{"pid_grace_seconds", "-1", ""},
+		{"preview_width", "50", ""},
+		{"preview_width", "90", "between 20 and 80"},
+		{"theme", "light", ""},
+		{"theme", "neon", "expected one of auto, dark"},
+		{"ticket_patterns", `["^([A-Z]+-\\d+)", "^(\\d+)-"]`, ""},
+		{"ticket_patterns", `[A-Z]+-[0-9]+`, ""},
+		// Not a JSON array, so one pattern, with an unclosed class
+		{"ticket_patterns", `["^([A-Z]+-\\d+)"`, "invalid pattern"},
+		{"ignore_prompt_patterns", "(", "invalid pattern"},
+		{"extra_args", "--verbose, --model", ""},
+	}
+	for _, tt := range tests {
+		k, ok := LookupKey(tt.key)
+		if !ok {
+			t.Fatalf("LookupKey(%q) not found", tt.key)
+		}
+		var cfg Config
+		v, err := k.Parse(tt.value)
+		if err == nil {
+			err = cfg.Set(tt.key, v)
+		}
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("set %s=%s: %v", tt.key, tt.value, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("set %s=%s: error %v, want one containing %q", tt.key, tt.value, err, tt.wantErr)
+		case err == nil && k.Format(cfg.Value(tt.key)) != k.Format(v):
+			t.Errorf("set %s=%s: value %v, want %v", tt.key, tt.value, cfg.Value(tt.key), v)
+		}
+	}
+
+	// Patterns are never split on commas
+	for value, want := range map[string][]string{
+		`^(yes|ok){1,3}$`:            {`^(yes|ok){1,3}$`},
+		`["a{1,3}", "b"]`:            {"a{1,3}", "b"},
+		`[A-Z]+-[0-9]+`:              {`[A-Z]+-[0-9]+`},
+		`[yn]`:                       {`[yn]`},
+		`  ^(?i)(yes|ok|continue)$ `: {`^(?i)(yes|ok|continue)$`},
+		"":                           nil,
+	} {
+		k, _ := LookupKey("ignore_prompt_patterns")
+		v, err := k.Parse(value)
+		if err != nil || !slices.Equal(v.([]string), want) {
+			t.Errorf("Parse(%q) = %q, %v; want %q", value, v, err, want)
+		}
+		if got, _ := k.Parse(k.Format(v)); !slices.Equal(got.([]string), want) {
+			t.Errorf("Parse(Format(%q)) = %q, want %q", v, got, want)
+		}
+	}
+
+	var cfg Config
+	if err := cfg.Set("extra_args", 3); err == nil {
+		t.Error("Set of an int to a list key succeeded")
+	}
+	if err := cfg.Set("no_such_key", true); err == nil {
+		t.Error("Set of an unknown key succeeded")
+	}
+	cfg.ExtraArgs = []string{"--verbose"}
+	if err := cfg.Set("extra_args", []string{}); err != nil || cfg.ExtraArgs != nil {
+		t.Errorf("clearing extra_args: %v, %v", err, cfg.ExtraArgs)
+	}
+}
+
+func TestRegisterCheck(t *testing.T) {
+	saved := slices.Clone(schema)
+	t.Cleanup(func() { schema = saved })
+
+	RegisterCheck("slow_query_ms", func(v any) error {
+		if v.(int) > 1000 {
+			return errors.New("too slow")
+		}
+		return nil
+	})
+	var cfg Config
+	if err := cfg.Set("slow_query_ms", 2000); err == nil || !strings.Contains(err.Error(), "too slow") {
+		t.Errorf("Set 2000: %v, want too slow", err)
+	}
+	if err := cfg.Set("slow_query_ms", -1); err == nil || !strings.Contains(err.Error(), "non-negative") {
+		t.Errorf("Set -1: %v, want the built-in check to still apply", err)
+	}
+	if err := cfg.Set("slow_query_ms", 500); err != nil {
+		t.Errorf("Set 500: %v", err)
+	}
+}
+
+func TestLoadUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"debug_log": true, "sortt": "started", "project_env": {}, "colour": "red"}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := []string{"colour", "sortt"}; !slices.Equal(cfg.UnknownKeys, want) {
+		t.Errorf("UnknownKeys = %v, want %v", cfg.UnknownKeys, want)
+	}
+	if !cfg.DebugLog {
+		t.Error("known keys were not loaded")
+	}
+}
@@ -0,0 +1,96 @@
+package gitutil
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultTicketPatterns match ticket IDs in branch names such as
+// "feature/JIRA-123-login" (JIRA-123) and "1234-fix-x" (1234).
+var DefaultTicketPatterns = []string{
+	`\b([A-Z][A-Z0-9]+-[0-9]+)\b`,
+	`(?:^|/)([0-9]+)[-_]`,
+}
+
+// Branch returns the branch checked out in the repository containing dir,
+// or "" if dir is not in a repository or HEAD is detached. It reads .git
+// directly rather than running git, so hooks stay fast.
+func Branch(dir string) string {
+	gitDir := findGitDir(dir)
+	if gitDir == "" {
+		return ""
+	}
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/heads/")
+	if !ok {
+		return ""
+	}
+	return ref
+}
+
+// findGitDir walks up from dir to the nearest .git, following the
+// "gitdir:" file that worktrees and submodules use instead of a directory.
+func findGitDir(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		dotGit := filepath.Join(d, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return dotGit
+			}
+			data, err := os.ReadFile(dotGit)
+			if err != nil {
+				return ""
+			}
+			target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+			if !ok {
+				return ""
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(d, target)
+			}
+			return target
+		}
+		if parent := filepath.Dir(d); parent == d {
+			return ""
+		}
+	}
+}
+
+// TicketIDs extracts ticket IDs from a branch name using the given regular
+// expressions, or DefaultTicketPatterns if none are given. A pattern's first
+// capture group is the ID; without one, the whole match is. Invalid patterns
+// are skipped and duplicates dropped.
+func TicketIDs(branch string, patterns []string) []string {
+	if branch == "" {
+		return nil
+	}
+	if len(patterns) == 0 {
+		patterns = DefaultTicketPatterns
+	}
+	var ids []string
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			continue
+		}
+		for _, m := range re.FindAllStringSubmatch(branch, -1) {
+			id := m[0]
+			if len(m) > 1 {
+				id = m[1]
+			}
+			if id != "" && !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,69 @@
+package gitutil
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestBranch(t *testing.T) {
+	repo := t.TempDir()
+	writeFile(t, filepath.Join(repo, ".git", "HEAD"), "ref: refs/heads/feature/JIRA-123-login\n")
+	sub := filepath.Join(repo, "cmd", "app")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := Branch(sub); got != "feature/JIRA-123-login" {
+		t.Errorf("Branch(subdir) = %q, want feature/JIRA-123-login", got)
+	}
+
+	// A worktree points at its git dir through a .git file.
+	worktree := t.TempDir()
+	wtGit := filepath.Join(repo, ".git", "worktrees", "wt")
+	writeFile(t, filepath.Join(wtGit, "HEAD"), "ref: refs/heads/1234-fix-x\n")
+	writeFile(t, filepath.Join(worktree, ".git"), "gitdir: "+wtGit+"\n")
+	if got := Branch(worktree); got != "1234-fix-x" {
+		t.Errorf("Branch(worktree) = %q, want 1234-fix-x", got)
+	}
+
+	detached := t.TempDir()
+	writeFile(t, filepath.Join(detached, ".git", "HEAD"), "0123456789abcdef0123456789abcdef01234567\n")
+	if got := Branch(detached); got != "" {
+		t.Errorf("Branch(detached) = %q, want empty", got)
+	}
+}
+
+func TestTicketIDs(t *testing.T) {
+	for _, tc := range []struct {
+		branch   string
+		patterns []string
+		want     []string
+	}{
+		{"feature/JIRA-123-login", nil, []string{"JIRA-123"}},
+		{"JIRA-123", nil, []string{"JIRA-123"}},
+		{"1234-fix-x", nil, []string{"1234"}},
+		{"bugfix/42_crash", nil, []string{"42"}},
+		{"ABC-1-and-ABC-1-again", nil, []string{"ABC-1"}},
+		{"main", nil, nil},
+		{"fix-123-thing", nil, nil}, // lowercase words are not project keys
+		{"", nil, nil},
+		{"gh-77-docs", []string{`gh-([0-9]+)`}, []string{"77"}},
+		{"T42", []string{`T[0-9]+`, `[`}, []string{"T42"}},
+	} {
+		if got := TicketIDs(tc.branch, tc.patterns); !slices.Equal(got, tc.want) {
+			t.Errorf("TicketIDs(%q, %q) = %q, want %q", tc.branch, tc.patterns, got, tc.want)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,82 @@
+package hook
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/gitutil"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/transcript"
+)
+
+// Adoptable reports whether a session read from its transcript can be
+// adopted: it is not a subagent's, has times and a working directory
+// outside the ignored projects, and holds at least one prompt that would be
+// stored.
+func Adoptable(cfg config.Config, t transcript.Session) bool {
+	if t.Sidechain || t.CWD == "" || t.End.IsZero() || cfg.IsProjectIgnored(t.CWD) {
+		return false
+	}
+	return len(adoptedPrompts(cfg, t)) > 0
+}
+
+// Adopt records a session that ran before cst's hooks were installed, as
+// read from its transcript at path, the way the hooks would have: with its
+// prompts, ticket tags from its branch and the files it changed. It returns
+// false if the session is known already or not Adoptable.
+func Adopt(s *store.Store, cfg config.Config, t transcript.Session, path string) (bool, error) {
+	if !Adoptable(cfg, t) {
+		return false, nil
+	}
+	prompts := adoptedPrompts(cfg, t)
+	sess := store.Session{
+		ID:             t.ID,
+		Project:        t.CWD,
+		CWD:            t.CWD,
+		StartedAt:      t.Start.UnixMilli(),
+		LastActivity:   t.End.UnixMilli(),
+		Model:          t.Model,
+		LastPromptAt:   prompts[len(prompts)-1].Timestamp,
+		TranscriptPath: path,
+		Branch:         t.Branch,
+		Host:           store.LocalHost(),
+		User:           currentUser(),
+	}
+	ok, err := s.AdoptSession(sess, prompts)
+	if err != nil || !ok {
+		return false, err
+	}
+	if t.Branch != "" {
+		if err := s.AddTags(t.ID, gitutil.TicketIDs(t.Branch, cfg.TicketPatterns), sess.LastActivity); err != nil {
+			return true, fmt.Errorf("add tags: %w", err)
+		}
+	}
+	if err := IndexFiles(s, t.ID, path); err != nil {
+		return true, fmt.Errorf("index files: %w", err)
+	}
+	slog.Debug("session adopted", "session", t.ID, "project", t.CWD, "prompts", len(prompts))
+	return true, nil
+}
+
+// adoptedPrompts returns the prompts of t the hooks would have stored,
+// folding repeats as AddPrompt does.
+func adoptedPrompts(cfg config.Config, t transcript.Session) []store.Prompt {
+	var prompts []store.Prompt
+	for _, m := range t.Prompts {
+		if cfg.IsPromptIgnored(m.Text) {
+			continue
+		}
+		ts := m.Time.UnixMilli()
+		if m.Time.IsZero() {
+			ts = t.End.UnixMilli()
+		}
+		if n := len(prompts); n > 0 && prompts[n-1].Text == m.Text {
+			prompts[n-1].Timestamp = max(prompts[n-1].Timestamp, ts)
+			prompts[n-1].Occurrences++
+			continue
+		}
+		prompts = append(prompts, store.Prompt{Text: m.Text, Timestamp: ts, Occurrences: 1})
+	}
+	return prompts
+}
@@ -0,0 +1,73 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/transcript"
+)
+
+func TestAdopt(t *testing.T) {
+	s := testStore(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sess-1.jsonl")
+	lines := `{"type":"user","cwd":"/proj","gitBranch":"feature/JIRA-7-login","timestamp":"2026-05-01T10:00:00Z","message":{"content":"fix the login bug"}}
+{"type":"assistant","timestamp":"2026-05-01T10:01:00Z","message":{"model":"claude-opus-4-1","content":[{"type":"tool_use","name":"Edit","input":{"file_path":"/proj/auth.go"}}]}}
+{"type":"user","timestamp":"2026-05-01T10:02:00Z","message":{"content":"ok"}}
+{"type":"user","timestamp":"2026-05-01T10:03:00Z","message":{"content":"run the tests"}}
+{"type":"user","timestamp":"2026-05-01T10:04:00Z","message":{"content":"run the tests"}}
+`
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	read, err := transcript.ReadSession(path)
+	if err != nil {
+		t.Fatalf("ReadSession: %v", err)
+	}
+
+	cfg := config.Config{IgnorePromptPatterns: []string{"^ok$"}}
+	if ok, err := Adopt(s, cfg, read, path); err != nil || !ok {
+		t.Fatalf("Adopt = %v, %v, want true", ok, err)
+	}
+	if ok, err := Adopt(s, cfg, read, path); err != nil || ok {
+		t.Errorf("Adopt again = %v, %v, want false", ok, err)
+	}
+
+	sess, err := s.GetSession("sess-1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.Project != "/proj" || sess.Model != "claude-opus-4-1" || sess.Active || sess.TranscriptPath != path {
+		t.Errorf("adopted session = %+v", sess)
+	}
+	if sess.PromptCount != 3 || sess.FirstPrompt != "fix the login bug" || sess.LastPrompt != "run the tests" {
+		t.Errorf("PromptCount/FirstPrompt/LastPrompt = %d/%q/%q", sess.PromptCount, sess.FirstPrompt, sess.LastPrompt)
+	}
+	if !slices.Equal(sess.Tags, []string{"JIRA-7"}) {
+		t.Errorf("Tags = %v, want [JIRA-7]", sess.Tags)
+	}
+	prompts, err := s.GetPrompts("sess-1", 10)
+	if err != nil {
+		t.Fatalf("GetPrompts: %v", err)
+	}
+	if len(prompts) != 2 || prompts[0].Occurrences != 2 {
+		t.Errorf("prompts = %+v, want 2 with the latest sent twice", prompts)
+	}
+	if files, err := s.FilesTouching("/proj/auth.go"); err != nil || len(files) != 1 {
+		t.Errorf("FilesTouching = %+v, %v, want the adopted session", files, err)
+	}
+
+	// Subagents and sessions without prompts are not adopted
+	for _, tr := range []transcript.Session{
+		{ID: "agent-1", CWD: "/proj", End: read.End, Prompts: read.Prompts, Sidechain: true},
+		{ID: "sess-2", CWD: "/proj", End: read.End},
+		{ID: "sess-3", CWD: "/proj", End: read.End, Prompts: read.Prompts[1:2]},
+	} {
+		if ok, err := Adopt(s, cfg, tr, path); err != nil || ok {
+			t.Errorf("Adopt(%s) = %v, %v, want false", tr.ID, ok, err)
+		}
+	}
+}
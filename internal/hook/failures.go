@@ -0,0 +1,73 @@
+package hook
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MaxFailureLogSize is the size past which the hook error log is moved to
+// <path>.1 before the next failure is written.
+const MaxFailureLogSize = 1 << 20
+
+// Failure is a hook event whose handling failed.
+type Failure struct {
+	Time      time.Time
+	Event     string
+	SessionID string // as sent, possibly empty or invalid
+	Err       string
+}
+
+// LogFailure appends f to the hook error log at path, one tab-separated line
+// per failure.
+func LogFailure(path string, f Failure) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > MaxFailureLogSize {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	// Keep each failure on one line, whatever the error says
+	flat := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+	_, err = fmt.Fprintf(file, "%s\t%s\t%s\t%s\n", f.Time.UTC().Format(time.RFC3339),
+		flat.Replace(f.Event), flat.Replace(f.SessionID), flat.Replace(f.Err))
+	return errors.Join(err, file.Close())
+}
+
+// ReadFailures returns the failures in the hook error log at path, oldest
+// first. A missing log has none; lines that don't parse are skipped.
+func ReadFailures(path string) ([]Failure, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var failures []Failure
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, fields[0])
+		if err != nil {
+			continue
+		}
+		failures = append(failures, Failure{Time: ts, Event: fields[1], SessionID: fields[2], Err: fields[3]})
+	}
+	return failures, scanner.Err()
+}
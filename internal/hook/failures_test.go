@@ -0,0 +1,56 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogAndReadFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cst", "hook-errors.log")
+	if failures, err := ReadFailures(path); err != nil || len(failures) != 0 {
+		t.Fatalf("ReadFailures of missing log = %v, %v, want none", failures, err)
+	}
+
+	at := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	for i, f := range []Failure{
+		{Event: "SessionStart", SessionID: "sess-1", Err: "database is locked"},
+		{Event: "UserPromptSubmit", Err: "panic: boom\n\tgoroutine 1"},
+	} {
+		f.Time = at.Add(time.Duration(i) * time.Minute)
+		if err := LogFailure(path, f); err != nil {
+			t.Fatalf("LogFailure: %v", err)
+		}
+	}
+
+	failures, err := ReadFailures(path)
+	if err != nil {
+		t.Fatalf("ReadFailures: %v", err)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("got %d failures, want 2", len(failures))
+	}
+	if f := failures[0]; !f.Time.Equal(at) || f.Event != "SessionStart" || f.SessionID != "sess-1" || f.Err != "database is locked" {
+		t.Errorf("first failure = %+v", f)
+	}
+	// Multi-line errors stay on one line
+	if f := failures[1]; f.SessionID != "" || f.Err != "panic: boom  goroutine 1" {
+		t.Errorf("second failure = %+v", f)
+	}
+
+	// A full log is moved aside
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", MaxFailureLogSize+1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LogFailure(path, Failure{Time: at, Event: "SessionEnd", Err: "disk full"}); err != nil {
+		t.Fatalf("LogFailure: %v", err)
+	}
+	if failures, err := ReadFailures(path); err != nil || len(failures) != 1 || failures[0].Event != "SessionEnd" {
+		t.Errorf("after rotation: %v, %v, want only the new failure", failures, err)
+	}
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("rotated log: %v", err)
+	}
+}
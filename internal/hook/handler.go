@@ -1,14 +1,26 @@
 package hook
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"os/exec"
+	"os/user"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/gitutil"
+	"github.com/imyousuf/claude-session-tracker/internal/procutil"
 	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/title"
+	"github.com/imyousuf/claude-session-tracker/internal/transcript"
 )
 
 // HookInput represents the JSON payload sent to hook commands via stdin.
@@ -22,46 +34,198 @@ type HookInput struct {
 	Model          string `json:"model,omitempty"`
 	Prompt         string `json:"prompt,omitempty"`
 	Reason         string `json:"reason,omitempty"`
+	ToolName       string `json:"tool_name,omitempty"`
+	Message        string `json:"message,omitempty"`
+	AgentID        string `json:"agent_id,omitempty"` // set in subagents
+	AgentType      string `json:"agent_type,omitempty"`
+	// ToolResponse is the tool's result in PostToolUse, as sent.
+	ToolResponse json.RawMessage `json:"tool_response,omitempty"`
+
+	// Unknown holds the payload's fields that cst does not know, as sent.
+	Unknown map[string]json.RawMessage `json:"-"`
+	// Mistyped lists the known fields whose values had an unexpected JSON
+	// type. They are left empty.
+	Mistyped []string `json:"-"`
+
+	// PID is the Claude Code process that ran the hook, and Terminal the
+	// terminal emulator the hook's environment names, set together for
+	// events the daemon handles on a hook's behalf. Otherwise they are
+	// taken from the process handling the event.
+	PID      int    `json:"-"`
+	Terminal string `json:"-"`
+}
+
+// claudePID returns the Claude Code process that ran the hook: the hook's
+// parent, unless the event was forwarded.
+func (in HookInput) claudePID() int {
+	if in.PID != 0 {
+		return in.PID
+	}
+	return os.Getppid()
+}
+
+// terminal returns the terminal emulator the hook ran in, if known.
+func (in HookInput) terminal() string {
+	if in.PID != 0 {
+		return in.Terminal
+	}
+	return procutil.TerminalFromEnv(os.Getenv)
+}
+
+// agent returns the subagent the event comes from, by its type such as
+// "Explore" or a custom subagent's name, or "" for the main agent. A
+// session started with claude --agent names its agent too, but is still
+// the main one.
+func (in HookInput) agent() string {
+	if in.AgentID == "" {
+		return ""
+	}
+	return cmp.Or(in.AgentType, "subagent")
 }
 
-const maxPromptLen = 200
+// toolFailed reports whether the tool's result in a PostToolUse event says
+// the call failed: an error result, as MCP tools give, or a command that
+// exited non-zero. Results of other shapes count as successes.
+func (in HookInput) toolFailed() bool {
+	var resp struct {
+		IsError    bool  `json:"is_error"`
+		MCPIsError bool  `json:"isError"`
+		Success    *bool `json:"success"`
+		ExitCode   int   `json:"exitCode"`
+	}
+	if len(in.ToolResponse) == 0 || json.Unmarshal(in.ToolResponse, &resp) != nil {
+		return false
+	}
+	return resp.IsError || resp.MCPIsError || (resp.Success != nil && !*resp.Success) || resp.ExitCode != 0
+}
+
+// Anomaly reasons recorded in the store's hook_anomalies counters.
+const (
+	ReasonPlaceholder = "unknown session, placeholder created"
+)
+
+var (
+	ErrMissingSessionID = errors.New("missing session ID")
+	ErrInvalidSessionID = errors.New("session ID is not a UUID")
+	ErrMissingField     = errors.New("missing required field")
+)
 
-// ReadInput reads and parses the hook input JSON from the given reader.
+var sessionIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Validate checks that the payload carries a well-formed Claude Code session
+// ID, so malformed payloads cannot scatter junk rows across the store, and
+// the other fields the handler of event needs.
+func Validate(event string, input HookInput) error {
+	switch {
+	case input.SessionID == "":
+		return ErrMissingSessionID
+	case !sessionIDPattern.MatchString(input.SessionID):
+		return fmt.Errorf("%w: %q", ErrInvalidSessionID, input.SessionID)
+	}
+	for _, name := range requiredFields[event] {
+		if *input.field(name) == "" {
+			return fmt.Errorf("%w %s", ErrMissingField, name)
+		}
+	}
+	return nil
+}
+
+// ReadInput reads and parses the hook input JSON from the given reader. It
+// is tolerant of schema drift: unknown fields are kept in Unknown, and known
+// fields with values of an unexpected type are listed in Mistyped and left
+// empty. Only input that is not a JSON object is an error; see Drift to
+// reject the rest.
 func ReadInput(r io.Reader) (HookInput, error) {
 	var input HookInput
-	if err := json.NewDecoder(r).Decode(&input); err != nil {
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&fields); err != nil {
 		return input, fmt.Errorf("decode hook input: %w", err)
 	}
+	for name, raw := range fields {
+		if name == "tool_response" {
+			input.ToolResponse = raw // of any shape, see toolFailed
+			continue
+		}
+		target := input.field(name)
+		if target == nil {
+			if !ignoredFields[name] {
+				if input.Unknown == nil {
+					input.Unknown = make(map[string]json.RawMessage)
+				}
+				input.Unknown[name] = raw
+			}
+			continue
+		}
+		if err := json.Unmarshal(raw, target); err != nil {
+			input.Mistyped = append(input.Mistyped, name)
+		}
+	}
+	slices.Sort(input.Mistyped)
 	return input, nil
 }
 
 // HandleSessionStart processes a SessionStart hook event.
 // It creates or activates the session in the store.
-func HandleSessionStart(s *store.Store, input HookInput) error {
+func HandleSessionStart(s *store.Store, cfg config.Config, input HookInput) error {
 	now := time.Now().UnixMilli()
-	pid := os.Getppid()
+	pid := input.claudePID()
 
 	// Try to activate an existing session first
 	err := s.Activate(input.SessionID, pid, input.Model, input.CWD)
 	if err != nil {
 		// Session doesn't exist yet — create it
-		sess := store.Session{
-			ID:           input.SessionID,
-			Project:      input.CWD,
-			CWD:          input.CWD,
-			StartedAt:    now,
-			LastActivity: now,
-			PID:          &pid,
-			Active:       true,
-			Model:        input.Model,
-		}
-		if err := s.UpsertSession(sess); err != nil {
+		slog.Debug("new session", "session", input.SessionID, "project", input.CWD, "pid", pid)
+		if err := s.UpsertSession(newSession(input, pid, now)); err != nil {
 			return fmt.Errorf("upsert session: %w", err)
 		}
 	}
 
-	// Enforce session cap
-	if err := s.EnforceCap(store.DefaultMaxCap); err != nil {
+	if err := s.AddCWD(input.SessionID, input.CWD, now); err != nil {
+		return fmt.Errorf("add cwd: %w", err)
+	}
+
+	if err := s.SetTerminal(input.SessionID, procutil.TTY(pid), input.terminal()); err != nil {
+		return fmt.Errorf("set terminal: %w", err)
+	}
+	if err := s.SetHost(input.SessionID, store.LocalHost(), currentUser()); err != nil {
+		return fmt.Errorf("set host: %w", err)
+	}
+
+	if err := s.SetPermissionMode(input.SessionID, input.PermissionMode); err != nil {
+		return fmt.Errorf("set permission mode: %w", err)
+	}
+	if err := s.SetTranscriptPath(input.SessionID, input.TranscriptPath); err != nil {
+		return fmt.Errorf("set transcript path: %w", err)
+	}
+
+	// Subagents and claude -p runs are kept, but hidden from listings by default
+	headless := input.AgentID != "" || isPrintMode(pid)
+	if err := s.SetHeadless(input.SessionID, headless); err != nil {
+		return fmt.Errorf("set headless: %w", err)
+	}
+
+	if cfg.WarnOnMultipleActivePerProject && !headless {
+		if err := recordConcurrent(s, input.SessionID); err != nil {
+			return err
+		}
+	}
+
+	if err := recordBranch(s, cfg, input, now); err != nil {
+		return err
+	}
+
+	if input.Source == "resume" {
+		if err := s.RecordActivity(input.SessionID, input.CWD, store.ActivityResume, now); err != nil {
+			return fmt.Errorf("record resume: %w", err)
+		}
+	}
+
+	// Enforce the session cap, and per-project caps if any are configured
+	var policy store.RetentionPolicy
+	if len(cfg.ProjectRetention) > 0 {
+		policy = cfg.RetentionFor
+	}
+	if err := s.EnforceCap(store.DefaultMaxCap, policy); err != nil {
 		return fmt.Errorf("enforce cap: %w", err)
 	}
 
@@ -69,38 +233,300 @@ func HandleSessionStart(s *store.Store, input HookInput) error {
 }
 
 // HandlePrompt processes a UserPromptSubmit hook event.
-// It records the user's prompt and updates the session's last activity.
-func HandlePrompt(s *store.Store, input HookInput) error {
+// It records the user's prompt as the session's latest activity, with its
+// permission mode, working directory and branch, in one write. Prompts
+// matching the configured ignore patterns only update activity.
+func HandlePrompt(s *store.Store, cfg config.Config, input HookInput) error {
 	prompt := strings.TrimSpace(input.Prompt)
 
 	// Skip slash commands and empty prompts
 	if prompt == "" || strings.HasPrefix(prompt, "/") {
+		slog.Debug("prompt skipped: empty or slash command", "session", input.SessionID)
 		return nil
 	}
 
-	// Truncate long prompts
-	if len(prompt) > maxPromptLen {
-		prompt = prompt[:maxPromptLen-3] + "..."
+	now := time.Now().UnixMilli()
+
+	// A prompt for a session we never saw start (e.g. the plugin was enabled
+	// mid-session) gets a placeholder so the prompt is not lost.
+	exists, err := s.SessionExists(input.SessionID)
+	if err != nil {
+		return fmt.Errorf("check session: %w", err)
+	}
+	if !exists {
+		slog.Warn("prompt for unknown session, creating placeholder", "session", input.SessionID)
+		if err := s.UpsertSession(newSession(input, input.claudePID(), now)); err != nil {
+			return fmt.Errorf("create placeholder session: %w", err)
+		}
+		if err := s.SetHost(input.SessionID, store.LocalHost(), currentUser()); err != nil {
+			return fmt.Errorf("set host: %w", err)
+		}
+		if err := s.RecordHookAnomaly("UserPromptSubmit", ReasonPlaceholder, now); err != nil {
+			return fmt.Errorf("record anomaly: %w", err)
+		}
 	}
 
-	now := time.Now().UnixMilli()
+	activity := store.PromptActivity{SessionID: input.SessionID, Agent: input.agent(), CWD: input.CWD,
+		PermissionMode: input.PermissionMode, At: now}
+	if cfg.IsPromptIgnored(prompt) {
+		slog.Debug("prompt not stored: matches ignore pattern", "session", input.SessionID)
+	} else {
+		activity.Prompt = prompt
+	}
+	if branch := gitutil.Branch(input.CWD); branch != "" {
+		activity.Branch, activity.Tags = branch, gitutil.TicketIDs(branch, cfg.TicketPatterns)
+		slog.Debug("branch recorded", "session", input.SessionID, "branch", branch, "tags", activity.Tags)
+	}
+	if err := s.RecordPromptActivity(activity); err != nil {
+		return fmt.Errorf("record prompt: %w", err)
+	}
+	return nil
+}
 
-	if err := s.AddPrompt(input.SessionID, prompt, now); err != nil {
-		return fmt.Errorf("add prompt: %w", err)
+// HandlePreTool processes a PreToolUse hook event. With tool_stats on, it
+// counts the call of the tool; it records nothing else, so that hooking it
+// costs little.
+func HandlePreTool(s *store.Store, cfg config.Config, input HookInput) error {
+	return countTool(s, cfg, input, false)
+}
+
+// HandleTool processes a PostToolUse hook event.
+// It acts as a heartbeat, updating the session's last tool activity and
+// permission mode, which may have changed since the last prompt. A call
+// whose result says it failed counts as a tool error; with tool_stats on,
+// any other counts as completed.
+func HandleTool(s *store.Store, cfg config.Config, input HookInput) error {
+	if err := toolHeartbeat(s, input); err != nil {
+		return err
+	}
+	if input.toolFailed() {
+		return countToolError(s, input)
 	}
+	return countTool(s, cfg, input, true)
+}
 
-	if err := s.UpdateActivity(input.SessionID, input.CWD, now); err != nil {
-		return fmt.Errorf("update activity: %w", err)
+// HandleToolFailure processes a PostToolUseFailure hook event, which Claude
+// Code sends instead of PostToolUse when a tool call fails. It is the same
+// heartbeat, and counts the call as a tool error.
+func HandleToolFailure(s *store.Store, cfg config.Config, input HookInput) error {
+	if err := toolHeartbeat(s, input); err != nil {
+		return err
 	}
+	return countToolError(s, input)
+}
 
+// toolHeartbeat records a tool call as the session's latest activity, with
+// its permission mode and working directory, in one write, as every tool
+// call of every session runs it.
+func toolHeartbeat(s *store.Store, input HookInput) error {
+	if err := s.RecordToolActivity(input.SessionID, input.CWD, input.PermissionMode, time.Now().UnixMilli()); err != nil {
+		return fmt.Errorf("record tool activity: %w", err)
+	}
+	return nil
+}
+
+func countToolError(s *store.Store, input HookInput) error {
+	if err := s.CountToolError(input.SessionID); err != nil {
+		return fmt.Errorf("count tool error: %w", err)
+	}
+	return nil
+}
+
+// countTool counts a call of the event's tool when tool_stats is on.
+func countTool(s *store.Store, cfg config.Config, input HookInput, completed bool) error {
+	if !cfg.ToolStats || input.ToolName == "" {
+		return nil
+	}
+	if err := s.CountToolUse(input.SessionID, input.ToolName, completed, time.Now().UnixMilli()); err != nil {
+		return fmt.Errorf("count tool use: %w", err)
+	}
+	return nil
+}
+
+// HandleNotification processes a Notification hook event, which Claude Code
+// sends when it needs permission or has been idle waiting for input. The
+// session stays marked as awaiting the user until its next activity.
+func HandleNotification(s *store.Store, cfg config.Config, input HookInput) error {
+	if err := s.SetAwaiting(input.SessionID, input.Message, time.Now().UnixMilli()); err != nil {
+		return fmt.Errorf("set awaiting: %w", err)
+	}
 	return nil
 }
 
 // HandleSessionEnd processes a SessionEnd hook event.
-// It marks the session as inactive.
-func HandleSessionEnd(s *store.Store, input HookInput) error {
-	if err := s.Deactivate(input.SessionID); err != nil {
-		return fmt.Errorf("deactivate session: %w", err)
+// It marks the session as inactive, recording why it ended, and, when the
+// outcome survey is enabled, as waiting for an outcome label. With
+// auto_title on, an untitled session is titled.
+func HandleSessionEnd(s *store.Store, cfg config.Config, input HookInput) error {
+	if err := s.EndSession(input.SessionID, input.Reason, time.Now().UnixMilli()); err != nil {
+		return fmt.Errorf("end session: %w", err)
+	}
+	if cfg.OutcomeSurvey {
+		if err := s.RequestOutcome(input.SessionID); err != nil {
+			return fmt.Errorf("request outcome: %w", err)
+		}
+		slog.Debug("outcome requested", "session", input.SessionID)
+	}
+	if cfg.AutoTitle != "" && cfg.AutoTitle != title.ModeOff {
+		// A missing title is not worth failing the hook over
+		if err := autoTitle(s, cfg, input); err != nil {
+			slog.Warn("auto title failed", "session", input.SessionID, "err", err)
+		}
+	}
+	if cfg.MaxDBSizeMB > 0 {
+		// Shrinking trims and vacuums, which takes longer than a hook may
+		if size, err := s.Size(); err == nil && size.Total() > int64(cfg.MaxDBSizeMB)<<20 {
+			if err := startShrinker(); err != nil {
+				slog.Warn("shrinking database failed", "err", err)
+			}
+		}
 	}
 	return nil
 }
+
+// isPrintMode reports whether the claude process pid runs with -p. Replaced
+// in tests.
+var isPrintMode = func(pid int) bool {
+	return procutil.IsPrintMode(procutil.Cmdline(pid))
+}
+
+// isAlive reports whether pid is a running claude process. Replaced in tests.
+var isAlive = procutil.IsProcessAlive
+
+// startTitler runs cst title for a session in the background, as claude -p
+// takes longer than a hook may. Replaced in tests.
+var startTitler = func(sessionID string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, "title", sessionID)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// startShrinker runs cst db shrink in the background. Replaced in tests.
+var startShrinker = func() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, "db", "shrink")
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// autoTitle titles an untitled session from the summary in its transcript
+// or, in claude mode, starts generating one from its prompts.
+func autoTitle(s *store.Store, cfg config.Config, input HookInput) error {
+	sess, err := s.GetSession(input.SessionID)
+	if err != nil || sess.Title != "" {
+		return err
+	}
+	if input.TranscriptPath != "" {
+		t, err := title.FromTranscript(input.TranscriptPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if t != "" {
+			slog.Debug("titled from transcript", "session", sess.ID, "title", t)
+			return s.SetTitle(sess.ID, t)
+		}
+	}
+	if cfg.AutoTitle == title.ModeClaude && sess.PromptCount > 0 {
+		slog.Debug("titling with claude -p", "session", sess.ID)
+		return startTitler(sess.ID)
+	}
+	return nil
+}
+
+// IndexFiles records the files a session changed, per its transcript at
+// path, for cst which, which calls it for the sessions whose transcript
+// changed since. Hooks leave it alone: a long transcript takes longer to
+// read than they may take.
+func IndexFiles(s *store.Store, sessionID, path string) error {
+	files, err := transcript.FilesTouched(path)
+	if err != nil {
+		return err
+	}
+	touches := make([]store.FileTouch, len(files))
+	for i, f := range files {
+		touches[i] = store.FileTouch{Path: f.Path, Edits: f.Edits}
+		if !f.Last.IsZero() {
+			touches[i].LastAt = f.Last.UnixMilli()
+		}
+	}
+	return s.SetFiles(sessionID, touches, time.Now().UnixMilli())
+}
+
+// recordBranch stores the git branch of the session's working directory and
+// tags the session with ticket IDs found in it. Tags accumulate, so a session
+// that moves between branches keeps every ticket it worked on.
+func recordBranch(s *store.Store, cfg config.Config, input HookInput, now int64) error {
+	branch := gitutil.Branch(input.CWD)
+	if branch == "" {
+		return nil
+	}
+	if err := s.SetBranch(input.SessionID, branch); err != nil {
+		return fmt.Errorf("set branch: %w", err)
+	}
+	tags := gitutil.TicketIDs(branch, cfg.TicketPatterns)
+	if err := s.AddTags(input.SessionID, tags, now); err != nil {
+		return fmt.Errorf("add tags: %w", err)
+	}
+	slog.Debug("branch recorded", "session", input.SessionID, "branch", branch, "tags", tags)
+	return nil
+}
+
+// recordConcurrent records the other sessions running in the project of the
+// session that just started, whose claude is still alive, on the session.
+func recordConcurrent(s *store.Store, sessionID string) error {
+	running, err := s.ListRunning()
+	if err != nil {
+		return fmt.Errorf("list running: %w", err)
+	}
+	i := slices.IndexFunc(running, func(sess store.Session) bool { return sess.ID == sessionID })
+	if i < 0 {
+		return nil
+	}
+	var others []string
+	for _, sess := range running {
+		if sess.ID != sessionID && sess.Project == running[i].Project && sess.PID != nil && isAlive(*sess.PID) {
+			others = append(others, sess.ID)
+		}
+	}
+	if len(others) == 0 {
+		return nil
+	}
+	slog.Debug("other sessions are running in the project", "session", sessionID, "project", running[i].Project, "others", others)
+	if err := s.SetConcurrentWith(sessionID, others); err != nil {
+		return fmt.Errorf("set concurrent sessions: %w", err)
+	}
+	return nil
+}
+
+// currentUser returns the login name of the user running the hook.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// newSession builds the record for a session first seen in the given hook event.
+func newSession(input HookInput, pid int, now int64) store.Session {
+	return store.Session{
+		ID:           input.SessionID,
+		Project:      input.CWD,
+		CWD:          input.CWD,
+		StartedAt:    now,
+		LastActivity: now,
+		PID:          &pid,
+		Active:       true,
+		Model:        input.Model,
+	}
+}
@@ -1,10 +1,16 @@
 package hook
 
 import (
+	"encoding/json"
+	"errors"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/imyousuf/claude-session-tracker/internal/config"
 	"github.com/imyousuf/claude-session-tracker/internal/store"
 )
 
@@ -30,7 +36,7 @@ func TestHandleSessionStartNew(t *testing.T) {
 		Model:         "claude-sonnet-4-6",
 	}
 
-	if err := HandleSessionStart(s, input); err != nil {
+	if err := HandleSessionStart(s, config.Config{}, input); err != nil {
 		t.Fatalf("HandleSessionStart: %v", err)
 	}
 
@@ -50,6 +56,126 @@ func TestHandleSessionStartNew(t *testing.T) {
 	if sessions[0].Model != "claude-sonnet-4-6" {
 		t.Errorf("Model = %q, want %q", sessions[0].Model, "claude-sonnet-4-6")
 	}
+	if sessions[0].Host != store.LocalHost() {
+		t.Errorf("Host = %q, want %q", sessions[0].Host, store.LocalHost())
+	}
+}
+
+func TestHandleSessionStartForwarded(t *testing.T) {
+	s := testStore(t)
+	defer func(orig func(int) bool) { isPrintMode = orig }(isPrintMode)
+	var checked int
+	isPrintMode = func(pid int) bool { checked = pid; return false }
+
+	// The daemon handles the event with the pid and terminal of the hook
+	input := HookInput{SessionID: "sess-1", CWD: "/proj", Source: "startup", PID: 4242, Terminal: "kitty"}
+	if err := HandleSessionStart(s, config.Config{}, input); err != nil {
+		t.Fatalf("HandleSessionStart: %v", err)
+	}
+	sess, err := s.GetSession("sess-1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.PID == nil || *sess.PID != 4242 {
+		t.Errorf("PID = %v, want 4242", sess.PID)
+	}
+	if checked != 4242 {
+		t.Errorf("print mode checked for pid %d, want 4242", checked)
+	}
+	if sess.Terminal != "kitty" {
+		t.Errorf("Terminal = %q, want kitty", sess.Terminal)
+	}
+}
+
+func TestHandleSessionStartHeadless(t *testing.T) {
+	s := testStore(t)
+	printMode := true
+	defer func(orig func(int) bool) { isPrintMode = orig }(isPrintMode)
+	isPrintMode = func(int) bool { return printMode }
+
+	start := func(input HookInput) store.Session {
+		t.Helper()
+		input.CWD = "/proj"
+		if err := HandleSessionStart(s, config.Config{}, input); err != nil {
+			t.Fatalf("HandleSessionStart: %v", err)
+		}
+		sess, err := s.GetSession(input.SessionID)
+		if err != nil {
+			t.Fatalf("GetSession: %v", err)
+		}
+		return sess
+	}
+
+	if sess := start(HookInput{SessionID: "print-1", Source: "startup"}); !sess.Headless {
+		t.Error("claude -p session not marked headless")
+	}
+	printMode = false
+	if sess := start(HookInput{SessionID: "agent-1", AgentID: "a1"}); !sess.Headless {
+		t.Error("subagent session not marked headless")
+	}
+	if sess := start(HookInput{SessionID: "user-1", Source: "startup"}); sess.Headless {
+		t.Error("interactive session marked headless")
+	}
+	// Resuming a claude -p session interactively makes it a normal one
+	if sess := start(HookInput{SessionID: "print-1", Source: "resume"}); sess.Headless {
+		t.Error("interactively resumed session still headless")
+	}
+
+	sessions, err := s.ListSessions(store.ListOptions{ExcludeHeadless: true})
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	var ids []string
+	for _, sess := range sessions {
+		ids = append(ids, sess.ID)
+	}
+	slices.Sort(ids)
+	if want := []string{"print-1", "user-1"}; !slices.Equal(ids, want) {
+		t.Errorf("ListSessions without headless = %q, want %q", ids, want)
+	}
+}
+
+func TestHandleSessionStartConcurrent(t *testing.T) {
+	s := testStore(t)
+	defer func(orig func(int) bool) { isAlive = orig }(isAlive)
+	alive, dead := os.Getpid(), 99999
+	isAlive = func(pid int) bool { return pid != dead }
+	cfg := config.Config{WarnOnMultipleActivePerProject: true}
+
+	now := time.Now().UnixMilli()
+	for _, sess := range []store.Session{
+		{ID: "running", Project: "/proj", PID: &alive},
+		{ID: "gone", Project: "/proj", PID: &dead},
+		{ID: "elsewhere", Project: "/other", PID: &alive},
+	} {
+		sess.CWD, sess.StartedAt, sess.LastActivity, sess.Active = sess.Project, now, now, true
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+
+	start := func(cfg config.Config) []string {
+		t.Helper()
+		input := HookInput{SessionID: "new", CWD: "/proj", HookEventName: "SessionStart", Source: "startup"}
+		if err := HandleSessionStart(s, cfg, input); err != nil {
+			t.Fatalf("HandleSessionStart: %v", err)
+		}
+		if err := s.EndSession("new", "other", now); err != nil {
+			t.Fatalf("EndSession: %v", err)
+		}
+		sess, err := s.GetSession("new")
+		if err != nil {
+			t.Fatalf("GetSession: %v", err)
+		}
+		return sess.ConcurrentWith
+	}
+	if got := start(config.Config{}); got != nil {
+		t.Errorf("ConcurrentWith without the option = %v, want none", got)
+	}
+	// Only the live session of the same project counts
+	if got := start(cfg); !slices.Equal(got, []string{"running"}) {
+		t.Errorf("ConcurrentWith = %v, want [running]", got)
+	}
 }
 
 func TestHandleSessionStartResume(t *testing.T) {
@@ -61,7 +187,7 @@ func TestHandleSessionStartResume(t *testing.T) {
 		HookEventName: "SessionStart", Source: "startup",
 		Model: "sonnet",
 	}
-	if err := HandleSessionStart(s, input); err != nil {
+	if err := HandleSessionStart(s, config.Config{}, input); err != nil {
 		t.Fatalf("HandleSessionStart: %v", err)
 	}
 
@@ -73,7 +199,7 @@ func TestHandleSessionStartResume(t *testing.T) {
 	// Resume
 	input.Source = "resume"
 	input.Model = "opus"
-	if err := HandleSessionStart(s, input); err != nil {
+	if err := HandleSessionStart(s, config.Config{}, input); err != nil {
 		t.Fatalf("HandleSessionStart resume: %v", err)
 	}
 
@@ -96,7 +222,7 @@ func TestHandlePrompt(t *testing.T) {
 	s := testStore(t)
 
 	// Create session first
-	if err := HandleSessionStart(s, HookInput{
+	if err := HandleSessionStart(s, config.Config{}, HookInput{
 		SessionID: "sess-1", CWD: "/proj",
 		HookEventName: "SessionStart", Source: "startup", Model: "sonnet",
 	}); err != nil {
@@ -104,12 +230,26 @@ func TestHandlePrompt(t *testing.T) {
 	}
 
 	// Submit a prompt
-	if err := HandlePrompt(s, HookInput{
-		SessionID: "sess-1", CWD: "/proj",
+	calls := func() map[string]int {
+		counts := make(map[string]int)
+		for _, st := range s.QueryStats() {
+			counts[st.Op] = st.Calls
+		}
+		return counts
+	}
+	before := calls()
+	if err := HandlePrompt(s, config.Config{}, HookInput{
+		SessionID: "sess-1", CWD: "/proj", PermissionMode: "plan",
 		HookEventName: "UserPromptSubmit", Prompt: "fix the bug",
 	}); err != nil {
 		t.Fatalf("HandlePrompt: %v", err)
 	}
+	// Everything about the prompt is written at once
+	for op, n := range calls() {
+		if n != before[op] && op != "SessionExists" && op != "RecordPromptActivity" {
+			t.Errorf("HandlePrompt ran %s, want everything in RecordPromptActivity", op)
+		}
+	}
 
 	prompts, err := s.GetPrompts("sess-1", 10)
 	if err != nil {
@@ -123,10 +263,54 @@ func TestHandlePrompt(t *testing.T) {
 	}
 }
 
+func TestPermissionModeTracksLatestEvent(t *testing.T) {
+	s := testStore(t)
+	cfg := config.Config{}
+
+	if err := HandleSessionStart(s, cfg, HookInput{
+		SessionID: "sess-1", CWD: "/proj", Source: "startup", PermissionMode: "default",
+	}); err != nil {
+		t.Fatalf("HandleSessionStart: %v", err)
+	}
+	mode := func() string {
+		t.Helper()
+		sess, err := s.GetSession("sess-1")
+		if err != nil {
+			t.Fatalf("GetSession: %v", err)
+		}
+		return sess.PermissionMode
+	}
+	if got := mode(); got != "default" {
+		t.Errorf("mode after start = %q, want default", got)
+	}
+
+	if err := HandlePrompt(s, cfg, HookInput{SessionID: "sess-1", CWD: "/proj", Prompt: "plan it", PermissionMode: "plan"}); err != nil {
+		t.Fatalf("HandlePrompt: %v", err)
+	}
+	if got := mode(); got != "plan" {
+		t.Errorf("mode after prompt = %q, want plan", got)
+	}
+
+	// Events without a mode keep the recorded one
+	if err := HandleTool(s, cfg, HookInput{SessionID: "sess-1", CWD: "/proj", ToolName: "Read"}); err != nil {
+		t.Fatalf("HandleTool: %v", err)
+	}
+	if got := mode(); got != "plan" {
+		t.Errorf("mode after tool use without mode = %q, want plan", got)
+	}
+
+	if err := HandleTool(s, cfg, HookInput{SessionID: "sess-1", CWD: "/proj", ToolName: "Edit", PermissionMode: "acceptEdits"}); err != nil {
+		t.Fatalf("HandleTool: %v", err)
+	}
+	if got := mode(); got != "acceptEdits" {
+		t.Errorf("mode after tool use = %q, want acceptEdits", got)
+	}
+}
+
 func TestHandlePromptSkipsSlashCommands(t *testing.T) {
 	s := testStore(t)
 
-	if err := HandleSessionStart(s, HookInput{
+	if err := HandleSessionStart(s, config.Config{}, HookInput{
 		SessionID: "sess-1", CWD: "/proj",
 		HookEventName: "SessionStart", Source: "startup", Model: "sonnet",
 	}); err != nil {
@@ -134,7 +318,7 @@ func TestHandlePromptSkipsSlashCommands(t *testing.T) {
 	}
 
 	for _, cmd := range []string{"/exit", "/model", "/compact", "/help"} {
-		if err := HandlePrompt(s, HookInput{
+		if err := HandlePrompt(s, config.Config{}, HookInput{
 			SessionID: "sess-1", CWD: "/proj",
 			HookEventName: "UserPromptSubmit", Prompt: cmd,
 		}); err != nil {
@@ -151,10 +335,144 @@ func TestHandlePromptSkipsSlashCommands(t *testing.T) {
 	}
 }
 
+func TestHandlePromptRecordsAgent(t *testing.T) {
+	s := testStore(t)
+	if err := HandleSessionStart(s, config.Config{}, HookInput{
+		SessionID: "sess-1", CWD: "/proj", HookEventName: "SessionStart", Source: "startup",
+	}); err != nil {
+		t.Fatalf("HandleSessionStart: %v", err)
+	}
+
+	for _, in := range []HookInput{
+		{Prompt: "to main"},
+		{Prompt: "main, run as --agent", AgentType: "reviewer"},
+		{Prompt: "to explore", AgentID: "a1", AgentType: "Explore"},
+		{Prompt: "to an unnamed subagent", AgentID: "a2"},
+	} {
+		in.SessionID, in.CWD, in.HookEventName = "sess-1", "/proj", "UserPromptSubmit"
+		if err := HandlePrompt(s, config.Config{}, in); err != nil {
+			t.Fatalf("HandlePrompt: %v", err)
+		}
+	}
+
+	prompts, err := s.GetPrompts("sess-1", 10)
+	if err != nil {
+		t.Fatalf("GetPrompts: %v", err)
+	}
+	var got []string
+	for _, p := range slices.Backward(prompts) {
+		got = append(got, p.Agent)
+	}
+	if want := []string{"", "", "Explore", "subagent"}; !slices.Equal(got, want) {
+		t.Errorf("agents = %q, want %q", got, want)
+	}
+}
+
+func TestToolHooksCountWithToolStats(t *testing.T) {
+	s := testStore(t)
+	if err := HandleSessionStart(s, config.Config{}, HookInput{
+		SessionID: "sess-1", CWD: "/proj", HookEventName: "SessionStart", Source: "startup",
+	}); err != nil {
+		t.Fatalf("HandleSessionStart: %v", err)
+	}
+	tool := func(cfg config.Config, handle func(*store.Store, config.Config, HookInput) error, name string) {
+		t.Helper()
+		if err := handle(s, cfg, HookInput{SessionID: "sess-1", CWD: "/proj", ToolName: name}); err != nil {
+			t.Fatalf("tool hook: %v", err)
+		}
+	}
+
+	// Off by default
+	tool(config.Config{}, HandlePreTool, "Bash")
+	tool(config.Config{}, HandleTool, "Bash")
+	if tools, err := s.SessionTools("sess-1"); err != nil || len(tools) != 0 {
+		t.Fatalf("SessionTools with tool_stats off = %v, %v, want none", tools, err)
+	}
+
+	on := config.Config{ToolStats: true}
+	tool(on, HandlePreTool, "Bash")
+	tool(on, HandleTool, "Bash")
+	tool(on, HandlePreTool, "Bash") // denied
+	tool(on, HandlePreTool, "Edit")
+	tool(on, HandleTool, "Edit")
+	tool(on, HandleTool, "")
+
+	tools, err := s.SessionTools("sess-1")
+	if err != nil {
+		t.Fatalf("SessionTools: %v", err)
+	}
+	want := []store.ToolCount{
+		{Tool: "Bash", Calls: 2, Completed: 1, Sessions: 1},
+		{Tool: "Edit", Calls: 1, Completed: 1, Sessions: 1},
+	}
+	if !slices.Equal(tools, want) {
+		t.Errorf("SessionTools = %+v, want %+v", tools, want)
+	}
+}
+
+func TestToolHooksCountErrors(t *testing.T) {
+	s := testStore(t)
+	if err := HandleSessionStart(s, config.Config{}, HookInput{
+		SessionID: "sess-1", CWD: "/proj", HookEventName: "SessionStart", Source: "startup",
+	}); err != nil {
+		t.Fatalf("HandleSessionStart: %v", err)
+	}
+
+	for _, tc := range []struct {
+		response string
+		failed   bool
+	}{
+		{`{"stdout": "ok", "stderr": "", "interrupted": false}`, false},
+		{`{"stdout": "", "exitCode": 1}`, true},
+		{`{"content": [], "isError": true}`, true},
+		{`{"success": false}`, true},
+		{`{"success": true, "filePath": "/proj/a.go"}`, false},
+		{`"plain text"`, false},
+		{``, false},
+	} {
+		payload := `{"session_id": "sess-1", "cwd": "/proj", "hook_event_name": "PostToolUse", "tool_name": "Bash"`
+		if tc.response != "" {
+			payload += `, "tool_response": ` + tc.response
+		}
+		in, err := ReadInput(strings.NewReader(payload + "}"))
+		if err != nil {
+			t.Fatalf("ReadInput: %v", err)
+		}
+		if len(in.Unknown) > 0 || len(in.Mistyped) > 0 {
+			t.Errorf("tool_response %s: drift %v", tc.response, in.Drift())
+		}
+		if got := in.toolFailed(); got != tc.failed {
+			t.Errorf("toolFailed with %s = %v, want %v", tc.response, got, tc.failed)
+		}
+	}
+
+	in := HookInput{SessionID: "sess-1", CWD: "/proj", ToolName: "Bash", ToolResponse: json.RawMessage(`{"exitCode": 2}`)}
+	if err := HandleTool(s, config.Config{}, in); err != nil {
+		t.Fatalf("HandleTool: %v", err)
+	}
+	in.ToolResponse = nil
+	if err := HandleTool(s, config.Config{}, in); err != nil {
+		t.Fatalf("HandleTool: %v", err)
+	}
+	if err := HandleToolFailure(s, config.Config{}, in); err != nil {
+		t.Fatalf("HandleToolFailure: %v", err)
+	}
+	sess, err := s.GetSession("sess-1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.ToolErrors != 2 {
+		t.Errorf("ToolErrors = %d, want 2", sess.ToolErrors)
+	}
+	if sess.LastToolAt == 0 {
+		t.Error("tool failure left LastToolAt unset")
+	}
+}
+
 func TestHandlePromptSkipsEmpty(t *testing.T) {
 	s := testStore(t)
 
-	if err := HandleSessionStart(s, HookInput{
+	if err := HandleSessionStart(s, config.Config{}, HookInput{
 		SessionID: "sess-1", CWD: "/proj",
 		HookEventName: "SessionStart", Source: "startup", Model: "sonnet",
 	}); err != nil {
@@ -162,7 +480,7 @@ func TestHandlePromptSkipsEmpty(t *testing.T) {
 	}
 
 	for _, p := range []string{"", "   ", "\t\n"} {
-		if err := HandlePrompt(s, HookInput{
+		if err := HandlePrompt(s, config.Config{}, HookInput{
 			SessionID: "sess-1", CWD: "/proj",
 			HookEventName: "UserPromptSubmit", Prompt: p,
 		}); err != nil {
@@ -182,7 +500,7 @@ func TestHandlePromptSkipsEmpty(t *testing.T) {
 func TestHandlePromptTruncatesLong(t *testing.T) {
 	s := testStore(t)
 
-	if err := HandleSessionStart(s, HookInput{
+	if err := HandleSessionStart(s, config.Config{}, HookInput{
 		SessionID: "sess-1", CWD: "/proj",
 		HookEventName: "SessionStart", Source: "startup", Model: "sonnet",
 	}); err != nil {
@@ -190,7 +508,7 @@ func TestHandlePromptTruncatesLong(t *testing.T) {
 	}
 
 	longPrompt := strings.Repeat("a", 300)
-	if err := HandlePrompt(s, HookInput{
+	if err := HandlePrompt(s, config.Config{}, HookInput{
 		SessionID: "sess-1", CWD: "/proj",
 		HookEventName: "UserPromptSubmit", Prompt: longPrompt,
 	}); err != nil {
@@ -201,25 +519,81 @@ func TestHandlePromptTruncatesLong(t *testing.T) {
 	if err != nil {
 		t.Fatalf("GetPrompts: %v", err)
 	}
-	if len(prompts[0].Text) != maxPromptLen {
-		t.Errorf("prompt length = %d, want %d", len(prompts[0].Text), maxPromptLen)
+	if len(prompts[0].Text) != store.DefaultPromptLen {
+		t.Errorf("prompt length = %d, want %d", len(prompts[0].Text), store.DefaultPromptLen)
 	}
 	if !strings.HasSuffix(prompts[0].Text, "...") {
 		t.Error("truncated prompt should end with ...")
 	}
+
+	// With compressed prompt storage, the store keeps it whole
+	s.SetPromptStorage(store.PromptsCompressed, 0)
+	longPrompt = strings.Repeat("b", 300)
+	if err := HandlePrompt(s, config.Config{}, HookInput{
+		SessionID: "sess-1", CWD: "/proj",
+		HookEventName: "UserPromptSubmit", Prompt: longPrompt,
+	}); err != nil {
+		t.Fatalf("HandlePrompt: %v", err)
+	}
+	if prompts, err = s.GetPrompts("sess-1", 1); err != nil || prompts[0].Text != longPrompt {
+		t.Errorf("compressed prompt = %v, %v, want it whole", prompts, err)
+	}
+}
+
+func TestHandlePromptIgnorePatterns(t *testing.T) {
+	s := testStore(t)
+	cfg := config.Config{IgnorePromptPatterns: []string{`^(?i)(yes|ok|continue)$`}}
+
+	if err := HandleSessionStart(s, cfg, HookInput{
+		SessionID: "sess-1", CWD: "/proj",
+		HookEventName: "SessionStart", Source: "startup", Model: "sonnet",
+	}); err != nil {
+		t.Fatalf("HandleSessionStart: %v", err)
+	}
+
+	if err := HandlePrompt(s, cfg, HookInput{
+		SessionID: "sess-1", CWD: "/proj",
+		HookEventName: "UserPromptSubmit", Prompt: "fix the bug",
+	}); err != nil {
+		t.Fatalf("HandlePrompt: %v", err)
+	}
+
+	before, err := s.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	if err := HandlePrompt(s, cfg, HookInput{
+		SessionID: "sess-1", CWD: "/proj",
+		HookEventName: "UserPromptSubmit", Prompt: "Continue",
+	}); err != nil {
+		t.Fatalf("HandlePrompt: %v", err)
+	}
+
+	sessions, err := s.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	if sessions[0].LastPrompt != "fix the bug" {
+		t.Errorf("LastPrompt = %q, want %q", sessions[0].LastPrompt, "fix the bug")
+	}
+	if sessions[0].LastActivity <= before[0].LastActivity {
+		t.Error("ignored prompt should still update last_activity")
+	}
 }
 
 func TestHandleSessionEnd(t *testing.T) {
 	s := testStore(t)
 
-	if err := HandleSessionStart(s, HookInput{
+	if err := HandleSessionStart(s, config.Config{}, HookInput{
 		SessionID: "sess-1", CWD: "/proj",
 		HookEventName: "SessionStart", Source: "startup", Model: "sonnet",
 	}); err != nil {
 		t.Fatalf("HandleSessionStart: %v", err)
 	}
 
-	if err := HandleSessionEnd(s, HookInput{
+	if err := HandleSessionEnd(s, config.Config{}, HookInput{
 		SessionID: "sess-1", HookEventName: "SessionEnd", Reason: "other",
 	}); err != nil {
 		t.Fatalf("HandleSessionEnd: %v", err)
@@ -232,6 +606,127 @@ func TestHandleSessionEnd(t *testing.T) {
 	if sessions[0].Active {
 		t.Error("session should be inactive after SessionEnd")
 	}
+	if sessions[0].EndReason != "other" {
+		t.Errorf("EndReason = %q, want other", sessions[0].EndReason)
+	}
+}
+
+func TestSessionEndRequestsOutcome(t *testing.T) {
+	s := testStore(t)
+	cfg := config.Config{OutcomeSurvey: true}
+
+	if err := HandleSessionStart(s, cfg, HookInput{
+		SessionID: "sess-1", CWD: "/proj", HookEventName: "SessionStart", Source: "startup",
+	}); err != nil {
+		t.Fatalf("HandleSessionStart: %v", err)
+	}
+	if err := HandleSessionEnd(s, cfg, HookInput{SessionID: "sess-1", HookEventName: "SessionEnd"}); err != nil {
+		t.Fatalf("HandleSessionEnd: %v", err)
+	}
+
+	pending, err := s.ListUnlabeled()
+	if err != nil {
+		t.Fatalf("ListUnlabeled: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != "sess-1" {
+		t.Errorf("ListUnlabeled = %v, want [sess-1]", pending)
+	}
+}
+
+func TestIndexFiles(t *testing.T) {
+	s := testStore(t)
+	path := filepath.Join(t.TempDir(), "sess-1.jsonl")
+	transcript := `{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Edit","input":{"file_path":"/proj/main.go"}}]}}`
+	if err := os.WriteFile(path, []byte(transcript+"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	input := HookInput{SessionID: "sess-1", CWD: "/proj", TranscriptPath: path, HookEventName: "SessionStart"}
+	if err := HandleSessionStart(s, config.Config{}, input); err != nil {
+		t.Fatalf("HandleSessionStart: %v", err)
+	}
+	input.HookEventName = "SessionEnd"
+	if err := HandleSessionEnd(s, config.Config{}, input); err != nil {
+		t.Fatalf("HandleSessionEnd: %v", err)
+	}
+
+	// The hook leaves reading the transcript to cst which
+	if got, _ := s.FilesTouching("/proj/main.go"); len(got) != 0 {
+		t.Errorf("FilesTouching after SessionEnd = %+v, want none", got)
+	}
+	if err := IndexFiles(s, "sess-1", path); err != nil {
+		t.Fatalf("IndexFiles: %v", err)
+	}
+	got, err := s.FilesTouching("/proj/main.go")
+	if err != nil {
+		t.Fatalf("FilesTouching: %v", err)
+	}
+	if len(got) != 1 || got[0].SessionID != "sess-1" || got[0].Edits != 1 {
+		t.Errorf("FilesTouching = %+v, want one edit by sess-1", got)
+	}
+	sess, err := s.GetSession("sess-1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.TranscriptPath != path {
+		t.Errorf("TranscriptPath = %q, want %q", sess.TranscriptPath, path)
+	}
+}
+
+func TestSessionEndAutoTitle(t *testing.T) {
+	orig := startTitler
+	t.Cleanup(func() { startTitler = orig })
+	var started []string
+	startTitler = func(id string) error {
+		started = append(started, id)
+		return nil
+	}
+
+	s := testStore(t)
+	cfg := config.Config{AutoTitle: "claude"}
+	dir := t.TempDir()
+	withSummary := filepath.Join(dir, "sess-1.jsonl")
+	if err := os.WriteFile(withSummary, []byte(`{"type":"summary","summary":"Fix retry loop"}`+"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	for _, id := range []string{"sess-1", "sess-2"} {
+		if err := HandleSessionStart(s, cfg, HookInput{SessionID: id, CWD: "/proj", HookEventName: "SessionStart"}); err != nil {
+			t.Fatalf("HandleSessionStart: %v", err)
+		}
+		if err := HandlePrompt(s, cfg, HookInput{SessionID: id, CWD: "/proj", Prompt: "fix the retry loop"}); err != nil {
+			t.Fatalf("HandlePrompt: %v", err)
+		}
+	}
+
+	// The transcript summary is used when there is one
+	if err := HandleSessionEnd(s, cfg, HookInput{SessionID: "sess-1", TranscriptPath: withSummary}); err != nil {
+		t.Fatalf("HandleSessionEnd: %v", err)
+	}
+	sess, err := s.GetSession("sess-1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.Title != "Fix retry loop" {
+		t.Errorf("Title = %q, want the transcript summary", sess.Title)
+	}
+
+	// Otherwise claude -p is started in the background
+	if err := HandleSessionEnd(s, cfg, HookInput{SessionID: "sess-2", TranscriptPath: filepath.Join(dir, "missing.jsonl")}); err != nil {
+		t.Fatalf("HandleSessionEnd: %v", err)
+	}
+	if !slices.Equal(started, []string{"sess-2"}) {
+		t.Errorf("titler started for %q, want [sess-2]", started)
+	}
+
+	// Summary mode never runs claude
+	started = nil
+	cfg.AutoTitle = "summary"
+	if err := HandleSessionEnd(s, cfg, HookInput{SessionID: "sess-2"}); err != nil {
+		t.Fatalf("HandleSessionEnd: %v", err)
+	}
+	if len(started) != 0 {
+		t.Errorf("titler started in summary mode for %q", started)
+	}
 }
 
 func TestReadInput(t *testing.T) {
@@ -247,3 +742,235 @@ func TestReadInput(t *testing.T) {
 		t.Errorf("Source = %q, want %q", input.Source, "startup")
 	}
 }
+
+func TestReadInputDrift(t *testing.T) {
+	json := `{"session_id":"abc","cwd":"/proj","model":{"id":"sonnet"},"effort":"high","tool_input":{},"prompt":null}`
+	input, err := ReadInput(strings.NewReader(json))
+	if err != nil {
+		t.Fatalf("ReadInput: %v", err)
+	}
+	if input.SessionID != "abc" || input.CWD != "/proj" || input.Model != "" {
+		t.Errorf("input = %+v, want known fields kept and the mistyped model empty", input)
+	}
+	if string(input.Unknown["effort"]) != `"high"` || len(input.Unknown) != 1 {
+		t.Errorf("Unknown = %s, want only effort", input.Unknown)
+	}
+	want := []string{"unknown field effort", "field model has an unexpected type"}
+	if got := input.Drift(); !slices.Equal(got, want) {
+		t.Errorf("Drift = %q, want %q", got, want)
+	}
+
+	if _, err := ReadInput(strings.NewReader(`["abc"]`)); err == nil {
+		t.Error("ReadInput of a non-object succeeded")
+	}
+}
+
+func TestHandleToolAndResumeActivity(t *testing.T) {
+	s := testStore(t)
+
+	input := HookInput{
+		SessionID: "sess-1", CWD: "/proj",
+		HookEventName: "SessionStart", Source: "startup", Model: "sonnet",
+	}
+	if err := HandleSessionStart(s, config.Config{}, input); err != nil {
+		t.Fatalf("HandleSessionStart: %v", err)
+	}
+
+	if err := HandleTool(s, config.Config{}, HookInput{
+		SessionID: "sess-1", CWD: "/proj",
+		HookEventName: "PostToolUse", ToolName: "Bash",
+	}); err != nil {
+		t.Fatalf("HandleTool: %v", err)
+	}
+
+	sessions, err := s.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	if sessions[0].LastToolAt == 0 {
+		t.Error("LastToolAt should be set after PostToolUse")
+	}
+	if sessions[0].LastResumeAt != 0 {
+		t.Error("LastResumeAt should not be set on startup")
+	}
+
+	input.Source = "resume"
+	if err := HandleSessionStart(s, config.Config{}, input); err != nil {
+		t.Fatalf("HandleSessionStart resume: %v", err)
+	}
+	sessions, err = s.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	if sessions[0].LastResumeAt == 0 {
+		t.Error("LastResumeAt should be set after resume")
+	}
+}
+
+func TestHandlePromptRecordsCWDTrail(t *testing.T) {
+	s := testStore(t)
+
+	if err := HandleSessionStart(s, config.Config{}, HookInput{
+		SessionID: "sess-1", CWD: "/proj",
+		HookEventName: "SessionStart", Source: "startup", Model: "sonnet",
+	}); err != nil {
+		t.Fatalf("HandleSessionStart: %v", err)
+	}
+	for _, cwd := range []string{"/proj/api", "/proj/api", "/proj/web"} {
+		time.Sleep(time.Millisecond)
+		if err := HandlePrompt(s, config.Config{}, HookInput{
+			SessionID: "sess-1", CWD: cwd,
+			HookEventName: "UserPromptSubmit", Prompt: "work in " + cwd,
+		}); err != nil {
+			t.Fatalf("HandlePrompt: %v", err)
+		}
+	}
+
+	history, err := s.GetCWDHistory("sess-1")
+	if err != nil {
+		t.Fatalf("GetCWDHistory: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("history len = %d, want 3: %+v", len(history), history)
+	}
+	if history[2].CWD != "/proj/web" {
+		t.Errorf("last cwd = %q, want %q", history[2].CWD, "/proj/web")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	const id = "0f8fad5b-d9cb-469f-a165-70867728950e"
+	tests := []struct {
+		event string
+		input HookInput
+		want  error
+	}{
+		{"SessionEnd", HookInput{SessionID: id}, nil},
+		{"SessionEnd", HookInput{SessionID: "0F8FAD5B-D9CB-469F-A165-70867728950E"}, nil},
+		{"SessionEnd", HookInput{}, ErrMissingSessionID},
+		{"SessionEnd", HookInput{SessionID: "sess-1"}, ErrInvalidSessionID},
+		{"SessionEnd", HookInput{SessionID: id + "' OR 1=1"}, ErrInvalidSessionID},
+		{"SessionStart", HookInput{SessionID: id}, ErrMissingField},
+		{"SessionStart", HookInput{SessionID: id, CWD: "/proj"}, nil},
+	}
+	for _, tc := range tests {
+		err := Validate(tc.event, tc.input)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("Validate(%s, %+v) = %v, want %v", tc.event, tc.input, err, tc.want)
+		}
+	}
+	if err := Validate("UserPromptSubmit", HookInput{SessionID: id}); err == nil || err.Error() != "missing required field cwd" {
+		t.Errorf("Validate without cwd = %v, want it to name the field", err)
+	}
+}
+
+func TestHandlePromptUnknownSessionCreatesPlaceholder(t *testing.T) {
+	s := testStore(t)
+
+	if err := HandlePrompt(s, config.Config{}, HookInput{
+		SessionID: "sess-9", CWD: "/proj",
+		HookEventName: "UserPromptSubmit", Prompt: "pick up where we left off",
+	}); err != nil {
+		t.Fatalf("HandlePrompt: %v", err)
+	}
+
+	sess, err := s.GetSession("sess-9")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.Project != "/proj" || !sess.Active {
+		t.Errorf("placeholder = %+v, want active session in /proj", sess)
+	}
+	prompts, err := s.GetPrompts("sess-9", 10)
+	if err != nil {
+		t.Fatalf("GetPrompts: %v", err)
+	}
+	if len(prompts) != 1 {
+		t.Fatalf("expected 1 prompt, got %d", len(prompts))
+	}
+
+	anomalies, err := s.HookAnomalies()
+	if err != nil {
+		t.Fatalf("HookAnomalies: %v", err)
+	}
+	if len(anomalies) != 1 || anomalies[0].Reason != ReasonPlaceholder || anomalies[0].Count != 1 {
+		t.Errorf("anomalies = %+v, want one placeholder entry", anomalies)
+	}
+}
+
+func TestHandleNotificationUntilNextActivity(t *testing.T) {
+	s := testStore(t)
+
+	if err := HandleSessionStart(s, config.Config{}, HookInput{
+		SessionID: "sess-1", CWD: "/proj",
+		HookEventName: "SessionStart", Source: "startup",
+	}); err != nil {
+		t.Fatalf("HandleSessionStart: %v", err)
+	}
+	if err := HandleNotification(s, config.Config{}, HookInput{
+		SessionID: "sess-1", CWD: "/proj",
+		HookEventName: "Notification", Message: "Claude needs your permission to use Bash",
+	}); err != nil {
+		t.Fatalf("HandleNotification: %v", err)
+	}
+
+	sess, err := s.GetSession("sess-1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.AwaitingSince == 0 || sess.AwaitingMessage != "Claude needs your permission to use Bash" {
+		t.Errorf("awaiting = %d %q, want set with message", sess.AwaitingSince, sess.AwaitingMessage)
+	}
+
+	if err := HandleTool(s, config.Config{}, HookInput{
+		SessionID: "sess-1", CWD: "/proj",
+		HookEventName: "PostToolUse", ToolName: "Bash",
+	}); err != nil {
+		t.Fatalf("HandleTool: %v", err)
+	}
+	sess, err = s.GetSession("sess-1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.AwaitingSince != 0 {
+		t.Errorf("AwaitingSince = %d after tool use, want 0", sess.AwaitingSince)
+	}
+}
+
+func TestBranchTicketsBecomeTags(t *testing.T) {
+	s := testStore(t)
+	repo := t.TempDir()
+	setHead := func(branch string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repo, ".git", "HEAD"), []byte("ref: refs/heads/"+branch+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	input := HookInput{SessionID: "sess-1", CWD: repo, Source: "startup"}
+	setHead("feature/ABC-42-login")
+	if err := HandleSessionStart(s, config.Config{}, input); err != nil {
+		t.Fatalf("HandleSessionStart: %v", err)
+	}
+
+	// Switching branches mid-session keeps the earlier ticket.
+	setHead("77-fix-crash")
+	input.Prompt = "fix the crash"
+	if err := HandlePrompt(s, config.Config{}, input); err != nil {
+		t.Fatalf("HandlePrompt: %v", err)
+	}
+
+	sess, err := s.GetSession("sess-1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.Branch != "77-fix-crash" {
+		t.Errorf("Branch = %q, want 77-fix-crash", sess.Branch)
+	}
+	if want := []string{"ABC-42", "77"}; !slices.Equal(sess.Tags, want) {
+		t.Errorf("Tags = %q, want %q", sess.Tags, want)
+	}
+}
@@ -0,0 +1,57 @@
+package hook
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// DefaultMaxPayloads caps the recorded hook payloads kept; the oldest are
+// removed first.
+const DefaultMaxPayloads = 1000
+
+// payloadTimeFormat sorts recordings chronologically by file name.
+const payloadTimeFormat = "20060102T150405.000"
+
+// RecordPayload writes a raw hook payload to dir as
+// <time>-<event>-<session>.json, readable only by the user since payloads
+// hold prompts, then removes the oldest recordings beyond maxKept. Returns
+// the path written.
+func RecordPayload(dir, event, sessionID string, payload []byte, now time.Time, maxKept int) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	// The session ID is only used in the name once it is known to be safe
+	id := "invalid"
+	if sessionIDPattern.MatchString(sessionID) {
+		id = sessionID[:8]
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s-%s.json", now.Format(payloadTimeFormat), event, id))
+	if err := os.WriteFile(path, payload, 0600); err != nil {
+		return "", err
+	}
+
+	recorded, err := ListPayloads(dir)
+	if err != nil {
+		return path, err
+	}
+	for _, old := range recorded[:max(len(recorded)-maxKept, 0)] {
+		if err := os.Remove(old); err != nil && !os.IsNotExist(err) {
+			return path, err
+		}
+	}
+	return path, nil
+}
+
+// ListPayloads returns the payload recordings in dir, oldest first. A
+// missing directory holds none.
+func ListPayloads(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
@@ -0,0 +1,55 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRecordPayload(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "payloads")
+	start := time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	const id = "3f2a91c0-1111-2222-3333-444455556666"
+
+	var written []string
+	for i, sessionID := range []string{id, id, "../../etc/passwd"} {
+		path, err := RecordPayload(dir, "UserPromptSubmit", sessionID, []byte(`{"prompt":"hi"}`), start.Add(time.Duration(i)*time.Second), 2)
+		if err != nil {
+			t.Fatalf("RecordPayload: %v", err)
+		}
+		written = append(written, path)
+	}
+	if want := filepath.Join(dir, "20261015T093000.000-UserPromptSubmit-3f2a91c0.json"); written[0] != want {
+		t.Errorf("path = %q, want %q", written[0], want)
+	}
+	if want := filepath.Join(dir, "20261015T093002.000-UserPromptSubmit-invalid.json"); written[2] != want {
+		t.Errorf("path for a bad session ID = %q, want %q", written[2], want)
+	}
+
+	// Only the newest two are kept
+	got, err := ListPayloads(dir)
+	if err != nil {
+		t.Fatalf("ListPayloads: %v", err)
+	}
+	if len(got) != 2 || got[0] != written[1] || got[1] != written[2] {
+		t.Errorf("ListPayloads = %q, want %q", got, written[1:])
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(got[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("payload mode = %o, want 600", perm)
+		}
+	}
+}
+
+func TestListPayloadsMissingDir(t *testing.T) {
+	got, err := ListPayloads(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || len(got) != 0 {
+		t.Errorf("ListPayloads of a missing directory = %q, %v", got, err)
+	}
+}
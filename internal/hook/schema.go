@@ -0,0 +1,83 @@
+package hook
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// requiredFields lists, per event, the fields besides session_id that its
+// handler cannot do without.
+var requiredFields = map[string][]string{
+	"SessionStart":     {"cwd"},
+	"UserPromptSubmit": {"cwd"},
+}
+
+// ignoredFields are fields Claude Code documents that cst has no use for.
+// They are not reported as unknown.
+var ignoredFields = map[string]bool{
+	"tool_input":          true,
+	"error":               true, // PostToolUseFailure
+	"is_interrupt":        true,
+	"tool_use_id":         true,
+	"stop_hook_active":    true,
+	"trigger":             true,
+	"custom_instructions": true,
+}
+
+// fieldNamePattern matches field names that are safe to use in an anomaly
+// reason.
+var fieldNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// field returns the HookInput field named name in the JSON payload, or nil
+// if there is none.
+func (in *HookInput) field(name string) *string {
+	switch name {
+	case "session_id":
+		return &in.SessionID
+	case "transcript_path":
+		return &in.TranscriptPath
+	case "cwd":
+		return &in.CWD
+	case "permission_mode":
+		return &in.PermissionMode
+	case "hook_event_name":
+		return &in.HookEventName
+	case "source":
+		return &in.Source
+	case "model":
+		return &in.Model
+	case "prompt":
+		return &in.Prompt
+	case "reason":
+		return &in.Reason
+	case "tool_name":
+		return &in.ToolName
+	case "message":
+		return &in.Message
+	case "agent_id":
+		return &in.AgentID
+	case "agent_type":
+		return &in.AgentType
+	}
+	return nil
+}
+
+// Drift describes how the payload departed from the schema cst knows: one
+// reason per unknown field, then one per mistyped field. Reasons name the
+// field but never its value, so they can be counted as hook anomalies.
+func (in HookInput) Drift() []string {
+	var reasons []string
+	for name := range in.Unknown {
+		if !fieldNamePattern.MatchString(name) {
+			name = "(unprintable name)"
+		}
+		reasons = append(reasons, "unknown field "+name)
+	}
+	slices.Sort(reasons)
+	reasons = slices.Compact(reasons)
+	for _, name := range in.Mistyped {
+		reasons = append(reasons, fmt.Sprintf("field %s has an unexpected type", name))
+	}
+	return reasons
+}
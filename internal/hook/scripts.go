@@ -0,0 +1,125 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// DefaultScriptTimeout bounds how long a single hook script may run. It is
+// kept under the 5s hook timeout in hooks.json so a slow script cannot get
+// the cst hook itself killed.
+const DefaultScriptTimeout = 3 * time.Second
+
+// ScriptWaitDelay is how long a script killed at its timeout is waited on,
+// for children that inherited its pipes, before they are closed.
+const ScriptWaitDelay = time.Second
+
+// ScriptResult describes a hook script that failed or timed out.
+type ScriptResult struct {
+	Path string
+	Err  error
+}
+
+// RunScripts runs every executable in dir/<event> concurrently, each with
+// payload on stdin and CST_EVENT and CST_SESSION_ID in its environment.
+// Scripts are isolated from cst and from each other: stdout is discarded (so
+// it never reaches Claude Code as hook output), a script exceeding timeout is
+// killed, and failures are returned rather than stopping the others. A
+// missing directory runs nothing.
+func RunScripts(dir, event, sessionID string, payload []byte, timeout time.Duration) []ScriptResult {
+	scripts := findScripts(filepath.Join(dir, event))
+	if len(scripts) == 0 {
+		return nil
+	}
+	if timeout <= 0 {
+		timeout = DefaultScriptTimeout
+	}
+
+	results := make([]ScriptResult, len(scripts))
+	var wg sync.WaitGroup
+	for i, path := range scripts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = ScriptResult{Path: path, Err: runScript(path, event, sessionID, payload, timeout)}
+		}()
+	}
+	wg.Wait()
+
+	return slices.DeleteFunc(results, func(r ScriptResult) bool { return r.Err == nil })
+}
+
+func runScript(path, event, sessionID string, payload []byte, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Dir = filepath.Dir(path)
+	cmd.Env = append(os.Environ(), "CST_EVENT="+event, "CST_SESSION_ID="+sessionID)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = io.Discard
+	cmd.Stderr = &stderr
+	// Don't wait on children that inherited the pipes after a kill.
+	cmd.WaitDelay = ScriptWaitDelay
+
+	err := cmd.Run()
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("timed out after %s", timeout)
+	case err != nil:
+		if msg := firstLine(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// findScripts lists the executables in dir by name. Hidden files and editor
+// backups are skipped so that disabling a script is a rename away.
+func findScripts(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var scripts []string
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() || !isExecutable(name, info.Mode()) {
+			continue
+		}
+		scripts = append(scripts, filepath.Join(dir, name))
+	}
+	return scripts
+}
+
+func isExecutable(name string, mode os.FileMode) bool {
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(name))
+		return ext == ".exe" || ext == ".bat" || ext == ".cmd"
+	}
+	return mode&0111 != 0
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return textutil.Truncate(line, 200, "...")
+}
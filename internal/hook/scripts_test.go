@@ -0,0 +1,69 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunScripts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts")
+	}
+	dir := t.TempDir()
+	eventDir := filepath.Join(dir, "Notification")
+	out := filepath.Join(t.TempDir(), "out")
+
+	writeScript := func(name, body string, mode os.FileMode) {
+		t.Helper()
+		if err := os.MkdirAll(eventDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(eventDir, name), []byte("#!/bin/sh\n"+body+"\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeScript("10-record", `cat > `+out+`; echo "$CST_EVENT $CST_SESSION_ID" >> `+out+`; echo to-stdout`, 0755)
+	writeScript("20-fail", `echo "boom" >&2; exit 3`, 0755)
+	writeScript("30-slow", `sleep 10`, 0755)
+	writeScript("40-not-executable", `touch `+out+`.bad`, 0644)
+	writeScript(".50-hidden", `touch `+out+`.bad`, 0755)
+
+	start := time.Now()
+	failed := RunScripts(dir, "Notification", "sess-1", []byte(`{"message":"hi"}`), 300*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("RunScripts took %s; the slow script was not killed", elapsed)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("record script did not run: %v", err)
+	}
+	if got, want := string(data), "{\"message\":\"hi\"}Notification sess-1\n"; got != want {
+		t.Errorf("record script saw %q, want %q", got, want)
+	}
+	if _, err := os.Stat(out + ".bad"); err == nil {
+		t.Error("a non-executable or hidden script ran")
+	}
+
+	if len(failed) != 2 {
+		t.Fatalf("failed = %v, want 2 results", failed)
+	}
+	byName := map[string]string{}
+	for _, r := range failed {
+		byName[filepath.Base(r.Path)] = r.Err.Error()
+	}
+	if !strings.Contains(byName["20-fail"], "boom") {
+		t.Errorf("20-fail error = %q, want the stderr line", byName["20-fail"])
+	}
+	if !strings.Contains(byName["30-slow"], "timed out") {
+		t.Errorf("30-slow error = %q, want a timeout", byName["30-slow"])
+	}
+
+	if got := RunScripts(dir, "SessionEnd", "sess-1", nil, time.Second); got != nil {
+		t.Errorf("RunScripts for an event without scripts = %v, want nil", got)
+	}
+}
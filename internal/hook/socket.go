@@ -0,0 +1,120 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"time"
+)
+
+// ErrNoDaemon is returned, wrapped, by Forward when no daemon listens on
+// the socket; the hook then handles the event itself.
+var ErrNoDaemon = errors.New("no cst daemon listening")
+
+// Request is a hook event forwarded to the daemon.
+type Request struct {
+	Event   string `json:"event"`
+	Payload string `json:"payload"` // as Claude Code sent it
+	Strict  bool   `json:"strict,omitempty"`
+	// The hook's parent and terminal, see HookInput.PID
+	PID      int    `json:"pid"`
+	Terminal string `json:"terminal,omitempty"`
+}
+
+type reply struct {
+	Error string `json:"error,omitempty"`
+}
+
+// serveTimeout bounds how long the daemon waits on a hook to send its
+// request or read the reply.
+const serveTimeout = 5 * time.Second
+
+// Forward hands a hook event to the daemon listening on the unix socket at
+// path and returns the error the daemon reports handling it. ctx bounds the
+// whole exchange.
+func Forward(ctx context.Context, path string, req Request) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNoDaemon, err)
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return fmt.Errorf("send to daemon: %w", err)
+	}
+	var r reply
+	if err := json.NewDecoder(conn).Decode(&r); err != nil {
+		return fmt.Errorf("read from daemon: %w", err)
+	}
+	if r.Error != "" {
+		return errors.New(r.Error)
+	}
+	return nil
+}
+
+// Listen listens on the unix socket at path, readable only by the user.
+// It replaces a socket left by a daemon that is gone, and fails if a
+// daemon still answers on it.
+func Listen(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("a cst daemon already listens on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Serve handles the hook events forwarded on ln, one at a time, so that
+// hooks never contend with each other for the database. It replies with
+// the error handle returns and runs until ln is closed.
+func Serve(ln net.Listener, handle func(Request) error) error {
+	for {
+		conn, err := ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		serveConn(conn, handle)
+	}
+}
+
+func serveConn(conn net.Conn, handle func(Request) error) {
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(serveTimeout))
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+	var r reply
+	if err := handle(req); err != nil {
+		r.Error = err.Error()
+	}
+	// Past the hook's deadline this fails; the hook has already given up
+	_ = conn.SetDeadline(time.Now().Add(serveTimeout))
+	_ = json.NewEncoder(conn).Encode(r)
+}
@@ -0,0 +1,98 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestForward(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix sockets")
+	}
+	path := filepath.Join(t.TempDir(), "d.sock")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := Forward(ctx, path, Request{Event: "Stop"}); !errors.Is(err, ErrNoDaemon) {
+		t.Fatalf("Forward with no daemon = %v, want ErrNoDaemon", err)
+	}
+
+	ln, err := Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan Request, 2)
+	served := make(chan error, 1)
+	go func() {
+		served <- Serve(ln, func(req Request) error {
+			got <- req
+			if req.Event == "Fail" {
+				return errors.New("rejected")
+			}
+			return nil
+		})
+	}()
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("socket mode = %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+	if _, err := Listen(path); err == nil {
+		t.Error("Listen on a socket a daemon answers on succeeded")
+	}
+
+	want := Request{Event: "Stop", Payload: `{"session_id":"s1"}`, Strict: true, PID: 42, Terminal: "kitty"}
+	if err := Forward(ctx, path, want); err != nil {
+		t.Fatalf("Forward: %v", err)
+	}
+	if req := <-got; req != want {
+		t.Errorf("daemon got %+v, want %+v", req, want)
+	}
+	if err := Forward(ctx, path, Request{Event: "Fail"}); err == nil || err.Error() != "rejected" {
+		t.Errorf("Forward = %v, want the daemon's error", err)
+	}
+	<-got
+
+	if err := ln.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve after Close = %v", err)
+	}
+}
+
+func TestListenReplacesStaleSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix sockets")
+	}
+	path := filepath.Join(t.TempDir(), "d.sock")
+	// A daemon that died leaves its socket file behind
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("stale socket: %v", err)
+	}
+
+	ln, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen over a stale socket: %v", err)
+	}
+	_ = ln.Close()
+
+	notSocket := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notSocket, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(notSocket); err == nil {
+		t.Error("Listen replaced a regular file")
+	}
+}
@@ -0,0 +1,328 @@
+// Package journal writes sessions to a directory of Markdown notes, one per
+// day or one per session, such as Obsidian daily notes. Each note only has
+// a marked block rewritten, so that what the user writes around it is kept,
+// and a state file in the directory remembers what was written, so that a
+// later run only rewrites the notes of sessions that changed since.
+package journal
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// Modes of a journal: which notes sessions are written to.
+const (
+	ByDay     = "day"
+	BySession = "session"
+)
+
+// Modes lists the journal modes; the first is the default.
+var Modes = []string{ByDay, BySession}
+
+// StateName is the file in the journal directory that records what was
+// written.
+const StateName = ".cst-journal.json"
+
+// The lines around the part of a note the journal writes.
+const (
+	blockStart = "<!-- cst journal: start, edits between these lines are overwritten -->"
+	blockEnd   = "<!-- cst journal: end -->"
+)
+
+// maxPromptLen caps the width of a prompt in a note, in terminal cells.
+const maxPromptLen = 300
+
+// Entry is a session to journal and its stored prompts, oldest first.
+type Entry struct {
+	Session store.Session
+	Prompts []store.Prompt
+}
+
+// Options says where and how to write a journal.
+type Options struct {
+	Dir  string
+	Mode string // ByDay or BySession
+	// Since leaves the notes of earlier days alone; prompts sent before
+	// it are not written. By day, it counts from the start of its day.
+	Since time.Time
+	// Full rewrites the notes of every entry, changed or not.
+	Full bool
+}
+
+// Result is what Write did.
+type Result struct {
+	Written   []string // paths of the notes written
+	Unchanged int      // entries left as they were
+}
+
+// state is what a journal directory's StateName holds: the last activity of
+// each session when its notes were written.
+type state struct {
+	Mode     string           `json:"mode"`
+	Sessions map[string]int64 `json:"sessions"`
+}
+
+// Write writes the notes of entries that changed since the last Write to
+// opts.Dir, and records them as written.
+func Write(entries []Entry, opts Options) (Result, error) {
+	var res Result
+	if !slices.Contains(Modes, opts.Mode) {
+		return res, fmt.Errorf("unknown journal mode %q, expected one of %s", opts.Mode, strings.Join(Modes, ", "))
+	}
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return res, err
+	}
+	statePath := filepath.Join(opts.Dir, StateName)
+	prev, err := readState(statePath)
+	if err != nil {
+		return res, err
+	}
+	// Notes written in the other mode are all written anew
+	full := opts.Full || prev.Mode != opts.Mode
+	if opts.Mode == ByDay {
+		// A day is written whole, so none of its prompts can be left out
+		opts.Since = DayStart(opts.Since)
+	}
+
+	changed := func(e Entry) bool {
+		written, ok := prev.Sessions[e.Session.ID]
+		return full || !ok || written != e.Session.LastActivity
+	}
+	for _, e := range entries {
+		if !changed(e) {
+			res.Unchanged++
+		}
+	}
+	notes := make(map[string]string) // file name to block
+	switch opts.Mode {
+	case ByDay:
+		// A day is written whole, with its unchanged sessions too
+		for day, es := range byDay(entries, opts.Since) {
+			if slices.ContainsFunc(es, func(e dayEntry) bool { return changed(e.Entry) }) {
+				notes[day+".md"] = dayBlock(es)
+			}
+		}
+	case BySession:
+		for _, e := range entries {
+			if changed(e) {
+				notes[sessionNote(e.Session)] = sessionBlock(e, opts.Since)
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(notes)) {
+		path := filepath.Join(opts.Dir, name)
+		if err := writeBlock(path, notes[name]); err != nil {
+			return res, err
+		}
+		res.Written = append(res.Written, path)
+	}
+
+	next := state{Mode: opts.Mode, Sessions: make(map[string]int64, len(entries))}
+	for _, e := range entries {
+		next.Sessions[e.Session.ID] = e.Session.LastActivity
+	}
+	return res, writeState(statePath, next)
+}
+
+// dayEntry is a session on a day, with the prompts sent that day.
+type dayEntry struct {
+	Entry
+	at int64 // the first of them, or the session's last activity
+}
+
+// byDay groups entries by the days, in local time, they sent prompts on
+// since since: a session resumed the next day is on both. Sessions without
+// prompts are on the day of their last activity.
+func byDay(entries []Entry, since time.Time) map[string][]dayEntry {
+	days := make(map[string][]dayEntry)
+	for _, e := range entries {
+		var prompts map[string][]store.Prompt
+		for _, p := range e.Prompts {
+			if p.Timestamp < since.UnixMilli() {
+				continue
+			}
+			if prompts == nil {
+				prompts = make(map[string][]store.Prompt)
+			}
+			day := dayOf(p.Timestamp)
+			prompts[day] = append(prompts[day], p)
+		}
+		if prompts == nil {
+			day := dayOf(e.Session.LastActivity)
+			days[day] = append(days[day], dayEntry{Entry{Session: e.Session}, e.Session.LastActivity})
+			continue
+		}
+		for day, ps := range prompts {
+			days[day] = append(days[day], dayEntry{Entry{e.Session, ps}, ps[0].Timestamp})
+		}
+	}
+	for _, es := range days {
+		slices.SortFunc(es, func(a, b dayEntry) int {
+			return cmp.Or(cmp.Compare(a.at, b.at), strings.Compare(a.Session.ID, b.Session.ID))
+		})
+	}
+	return days
+}
+
+// DayStart returns the local midnight starting the day of t, from which a
+// journal by day covers that day.
+func DayStart(t time.Time) time.Time {
+	y, m, d := t.Local().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+func dayOf(ms int64) string {
+	return time.UnixMilli(ms).Format(time.DateOnly)
+}
+
+// sessionNote returns the file name of a session's note: the day it
+// started and its short ID, which a retitle does not change.
+func sessionNote(sess store.Session) string {
+	return dayOf(sess.StartedAt) + " " + textutil.ShortID(sess.ID) + ".md"
+}
+
+// title names a session by its title, else its first prompt.
+func title(sess store.Session) string {
+	return textutil.Truncate(oneline(cmp.Or(sess.Title, sess.FirstPrompt, "(no prompts)")), 80, "...")
+}
+
+// oneline collapses whitespace in s to single spaces.
+func oneline(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// details lists a session's attributes as Markdown list items.
+func details(b *strings.Builder, sess store.Session) {
+	fmt.Fprintf(b, "- Project: `%s`", sess.Project)
+	if sess.Branch != "" {
+		fmt.Fprintf(b, " · Branch: `%s`", sess.Branch)
+	}
+	fmt.Fprintf(b, "\n- Session: `%s`", sess.ID)
+	if sess.Model != "" {
+		fmt.Fprintf(b, " · Model: %s", sess.Model)
+	}
+	b.WriteString("\n")
+	if sess.WorkedMS > 0 {
+		fmt.Fprintf(b, "- Worked: %s\n", launcher.FormatDuration(sess.WorkedMS))
+	}
+	if len(sess.Tags) > 0 {
+		fmt.Fprintf(b, "- Tags: %s\n", strings.Join(sess.Tags, ", "))
+	}
+	if len(sess.Links) > 0 {
+		links := make([]string, len(sess.Links))
+		for i, l := range sess.Links {
+			links[i] = "<" + l + ">"
+		}
+		fmt.Fprintf(b, "- Links: %s\n", strings.Join(links, " "))
+	}
+	if sess.Outcome != "" {
+		fmt.Fprintf(b, "- Outcome: %s\n", strings.TrimSpace(sess.Outcome+" "+sess.OutcomeNote))
+	}
+}
+
+// prompt writes a prompt as a list item led by its time in layout.
+func prompt(b *strings.Builder, p store.Prompt, layout string) {
+	text := textutil.Truncate(oneline(p.Text), maxPromptLen, "...")
+	fmt.Fprintf(b, "  - %s %s\n", time.UnixMilli(p.Timestamp).Format(layout), text)
+}
+
+func dayBlock(entries []dayEntry) string {
+	var b strings.Builder
+	b.WriteString("## Claude Code sessions\n")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n### %s %s\n\n", time.UnixMilli(e.at).Format("15:04"), title(e.Session))
+		details(&b, e.Session)
+		if len(e.Prompts) > 0 {
+			b.WriteString("- Prompts:\n")
+			for _, p := range e.Prompts {
+				prompt(&b, p, "15:04")
+			}
+		}
+	}
+	return b.String()
+}
+
+func sessionBlock(e Entry, since time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", title(e.Session))
+	details(&b, e.Session)
+	fmt.Fprintf(&b, "- Started: %s\n", time.UnixMilli(e.Session.StartedAt).Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "- Last active: %s\n", time.UnixMilli(e.Session.LastActivity).Format("2006-01-02 15:04"))
+	var prompts []store.Prompt
+	for _, p := range e.Prompts {
+		if p.Timestamp >= since.UnixMilli() {
+			prompts = append(prompts, p)
+		}
+	}
+	if len(prompts) > 0 {
+		b.WriteString("- Prompts:\n")
+		for _, p := range prompts {
+			prompt(&b, p, "2006-01-02 15:04")
+		}
+	}
+	return b.String()
+}
+
+// writeBlock writes block between the journal's marker lines in the note at
+// path: in place of what was between them, else after what the note holds.
+// New notes are readable only by the user, as they hold prompts.
+func writeBlock(path, block string) error {
+	content := blockStart + "\n" + block + blockEnd + "\n"
+	old, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return os.WriteFile(path, []byte(content), 0600)
+	case err != nil:
+		return err
+	}
+	if before, rest, ok := bytes.Cut(old, []byte(blockStart+"\n")); ok {
+		if _, after, ok := bytes.Cut(rest, []byte(blockEnd+"\n")); ok {
+			return os.WriteFile(path, slices.Concat(before, []byte(content), after), 0600)
+		}
+	}
+	if len(old) > 0 {
+		if !bytes.HasSuffix(old, []byte("\n")) {
+			old = append(old, '\n')
+		}
+		old = append(old, '\n')
+	}
+	return os.WriteFile(path, append(old, content...), 0600)
+}
+
+func readState(path string) (state, error) {
+	var st state
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, fmt.Errorf("read %s: %w", path, err)
+	}
+	return st, nil
+}
+
+func writeState(path string, st state) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
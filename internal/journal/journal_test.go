@@ -0,0 +1,171 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+func at(day, hour int) int64 {
+	return time.Date(2026, 10, day, hour, 0, 0, 0, time.Local).UnixMilli()
+}
+
+func entry(id, title string, prompts ...int64) Entry {
+	e := Entry{Session: store.Session{ID: id, Title: title, Project: "/src/app", StartedAt: prompts[0], LastActivity: prompts[len(prompts)-1]}}
+	for i, ts := range prompts {
+		e.Prompts = append(e.Prompts, store.Prompt{SessionID: id, Text: title + " prompt " + string(rune('a'+i)), Timestamp: ts})
+	}
+	return e
+}
+
+func read(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestWriteByDay(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Dir: dir, Mode: ByDay, Since: time.UnixMilli(at(13, 0))}
+	// The fix session was resumed the next day; the old one predates Since
+	entries := []Entry{
+		entry("11111111-aaaa", "Fix login", at(14, 9), at(15, 10)),
+		entry("22222222-bbbb", "Add docs", at(14, 8)),
+		entry("33333333-cccc", "Old work", at(12, 8), at(14, 12)),
+	}
+
+	res, err := Write(entries, opts)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(res.Written) != 2 || res.Unchanged != 0 {
+		t.Errorf("first Write = %+v, want 2 notes written", res)
+	}
+	day14 := read(t, filepath.Join(dir, "2026-10-14.md"))
+	for _, want := range []string{"### 08:00 Add docs", "### 09:00 Fix login", "  - 09:00 Fix login prompt a", "### 12:00 Old work"} {
+		if !strings.Contains(day14, want) {
+			t.Errorf("2026-10-14.md lacks %q:\n%s", want, day14)
+		}
+	}
+	if strings.Index(day14, "Add docs") > strings.Index(day14, "Fix login") {
+		t.Error("sessions not in the order of their first prompt that day")
+	}
+	if strings.Contains(day14, "Old work prompt a") {
+		t.Error("prompt from before Since written")
+	}
+	day15 := read(t, filepath.Join(dir, "2026-10-15.md"))
+	if !strings.Contains(day15, "Fix login prompt b") || strings.Contains(day15, "Add docs") {
+		t.Errorf("2026-10-15.md:\n%s", day15)
+	}
+
+	// Notes of the day the user adds to keep what they wrote
+	path := filepath.Join(dir, "2026-10-15.md")
+	if err := os.WriteFile(path, []byte("# Thursday\n\n"+day15+"\nMy own notes\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = Write(entries, opts)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(res.Written) != 0 || res.Unchanged != 3 {
+		t.Errorf("Write with nothing changed = %+v", res)
+	}
+
+	entries[0].Session.LastActivity = at(15, 11)
+	entries[0].Prompts = append(entries[0].Prompts, store.Prompt{Text: "Fix login prompt c", Timestamp: at(15, 11)})
+	if res, err = Write(entries, opts); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	// Both days the changed session is on are written
+	if len(res.Written) != 2 || res.Unchanged != 2 {
+		t.Errorf("Write after a change = %+v", res)
+	}
+	got := read(t, path)
+	if !strings.HasPrefix(got, "# Thursday\n\n") || !strings.HasSuffix(got, "\nMy own notes\n") {
+		t.Errorf("text around the block not kept:\n%s", got)
+	}
+	if !strings.Contains(got, "  - 11:00 Fix login prompt c") {
+		t.Errorf("new prompt not written:\n%s", got)
+	}
+	if strings.Count(got, blockStart) != 1 {
+		t.Errorf("block written twice:\n%s", got)
+	}
+}
+
+func TestWriteByDayFromDayStart(t *testing.T) {
+	dir := t.TempDir()
+	// Since falls between the sessions of the 14th
+	opts := Options{Dir: dir, Mode: ByDay, Since: time.UnixMilli(at(14, 9))}
+	entries := []Entry{
+		entry("11111111-aaaa", "Early work", at(14, 8)),
+		entry("22222222-bbbb", "Late work", at(14, 8), at(14, 10)),
+	}
+	if _, err := Write(entries, opts); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	entries[1].Session.LastActivity = at(14, 11)
+	entries[1].Prompts = append(entries[1].Prompts, store.Prompt{Text: "Late work prompt c", Timestamp: at(14, 11)})
+	if _, err := Write(entries, opts); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	// Rewriting the day keeps what came before Since on it
+	day14 := read(t, filepath.Join(dir, "2026-10-14.md"))
+	for _, want := range []string{"Early work prompt a", "Late work prompt a", "Late work prompt c"} {
+		if !strings.Contains(day14, want) {
+			t.Errorf("2026-10-14.md lacks %q:\n%s", want, day14)
+		}
+	}
+}
+
+func TestWriteBySession(t *testing.T) {
+	dir := t.TempDir()
+	e := entry("11111111-aaaa", "Fix login", at(14, 9), at(15, 10))
+	e.Session.Links = []string{"https://example.com/issue/1"}
+	e.Session.WorkedMS = 90 * 60 * 1000
+
+	if _, err := Write([]Entry{e}, Options{Dir: dir, Mode: BySession}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	got := read(t, filepath.Join(dir, "2026-10-14 11111111.md"))
+	for _, want := range []string{"# Fix login\n", "- Links: <https://example.com/issue/1>", "- Worked: 1h 30m", "  - 2026-10-15 10:00 Fix login prompt b"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("note lacks %q:\n%s", want, got)
+		}
+	}
+
+	// Switching modes writes every note anew
+	res, err := Write([]Entry{e}, Options{Dir: dir, Mode: ByDay})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(res.Written) != 2 {
+		t.Errorf("Write in another mode = %+v, want both days written", res)
+	}
+
+	if _, err := Write(nil, Options{Dir: dir, Mode: "week"}); err == nil {
+		t.Error("Write with an unknown mode succeeded")
+	}
+}
+
+func TestWriteBlockAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	if err := os.WriteFile(path, []byte("# Today\nno trailing newline"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeBlock(path, "block\n"); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Today\nno trailing newline\n\n" + blockStart + "\nblock\n" + blockEnd + "\n"
+	if got := read(t, path); got != want {
+		t.Errorf("note = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,354 @@
+package launcher
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// Column is a field of the session rows shown by cst list and the launcher.
+type Column struct {
+	Name   string // name in the columns config and --columns
+	Header string // heading in cst list
+	Width  int    // cells; 0 for the column that takes the remaining width
+	search bool   // launcher search matches are highlighted in it
+
+	// text returns the cell content: styled for the launcher, plain for
+	// cst list, whose status words scripts may rely on.
+	text  func(sess store.Session, styled bool) string
+	style func(sess store.Session) lipgloss.Style
+	// value returns the cell for CSV and TSV where it differs from the
+	// plain text, which is shortened or relative for people to read.
+	value func(sess store.Session) string
+}
+
+// Value returns the cell of sess for CSV and TSV output: plain, with IDs
+// and paths whole, times in RFC 3339 and durations in seconds.
+func (c Column) Value(sess store.Session) string {
+	if c.value != nil {
+		return c.value(sess)
+	}
+	return c.text(sess, false)
+}
+
+// rfc3339 formats a time in milliseconds for CSV; "" for never.
+func rfc3339(ms int64) string {
+	if ms == 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).Format(time.RFC3339)
+}
+
+// plainStyle returns a cell style without a fixed width or margins; the
+// renderer pads.
+func plainStyle(st *lipgloss.Style) func(store.Session) lipgloss.Style {
+	return func(store.Session) lipgloss.Style { return st.UnsetWidth().UnsetMargins() }
+}
+
+// Columns lists every available column.
+var Columns = []Column{
+	{Name: "status", Header: "STATUS", Width: 8, text: statusText, style: statusStyle},
+	{Name: "mode", Header: "MODE", Width: 6, text: modeText, style: modeStyle},
+	{Name: "id", Header: "ID", Width: 8, style: plainStyle(&inactiveStatusStyle),
+		text:  func(sess store.Session, _ bool) string { return textutil.ShortID(sess.ID) },
+		value: func(sess store.Session) string { return sess.ID }},
+	{Name: "project", Header: "PROJECT", Width: 20, search: true, style: plainStyle(&headerStyle),
+		text:  func(sess store.Session, _ bool) string { return filepath.Base(sess.Project) },
+		value: func(sess store.Session) string { return sess.Project }},
+	{Name: "branch", Header: "BRANCH", Width: 20, search: true, style: plainStyle(&inactiveStatusStyle),
+		text: func(sess store.Session, _ bool) string { return sess.Branch }},
+	{Name: "model", Header: "MODEL", Width: 14, search: true, style: plainStyle(&modelStyle),
+		text: func(sess store.Session, styled bool) string {
+			if styled {
+				return shortModel(sess.Model)
+			}
+			return sess.Model
+		}},
+	{Name: "time", Header: "LAST SEEN", Width: 10, style: plainStyle(&timeStyle),
+		text:  func(sess store.Session, _ bool) string { return FormatRelativeTime(sess.LastActivity) },
+		value: func(sess store.Session) string { return rfc3339(sess.LastActivity) }},
+	{Name: "started", Header: "STARTED", Width: 10, style: plainStyle(&timeStyle),
+		text:  func(sess store.Session, _ bool) string { return FormatRelativeTime(sess.StartedAt) },
+		value: func(sess store.Session) string { return rfc3339(sess.StartedAt) }},
+	{Name: "worked", Header: "WORKED", Width: 8, style: plainStyle(&timeStyle),
+		text: func(sess store.Session, _ bool) string {
+			if sess.WorkedMS == 0 {
+				return ""
+			}
+			return FormatDuration(sess.WorkedMS)
+		},
+		value: func(sess store.Session) string { return strconv.FormatInt(sess.WorkedMS/1000, 10) }},
+	{Name: "tokens", Header: "TOKENS", Width: 8, style: plainStyle(&timeStyle),
+		text: func(sess store.Session, _ bool) string {
+			if n := sess.InputTokens + sess.OutputTokens; n > 0 {
+				return FormatTokens(n)
+			}
+			return ""
+		},
+		value: func(sess store.Session) string { return strconv.FormatInt(sess.InputTokens+sess.OutputTokens, 10) }},
+	{Name: "host", Header: "HOST", Width: 10, style: plainStyle(&inactiveStatusStyle),
+		text: func(sess store.Session, styled bool) string {
+			if sess.Host == "" && styled {
+				return "?"
+			}
+			return sess.Host
+		}},
+	{Name: "tags", Header: "TAGS", Width: 16, search: true, style: plainStyle(&modelStyle),
+		text: func(sess store.Session, _ bool) string { return strings.Join(sess.Tags, ",") }},
+	{Name: "errors", Header: "ERRORS", Width: 6, text: errorsText, style: errorsStyle},
+	{Name: "outcome", Header: "OUTCOME", Width: 10, style: plainStyle(&inactiveStatusStyle),
+		text: func(sess store.Session, _ bool) string { return sess.Outcome }},
+	{Name: "prompts", Header: "PROMPTS", Width: 11, style: plainStyle(&timeStyle),
+		text: func(sess store.Session, styled bool) string {
+			switch {
+			case !styled:
+				return strconv.Itoa(sess.PromptCount)
+			case sess.PromptCount == 1:
+				return "1 prompt"
+			case sess.PromptCount > 0:
+				return fmt.Sprintf("%d prompts", sess.PromptCount)
+			}
+			return ""
+		}},
+	{Name: "title", Header: "TITLE", Width: 30, search: true, style: plainStyle(&headerStyle),
+		text: func(sess store.Session, _ bool) string { return cmp.Or(sess.Title, sess.FirstPrompt) }},
+	{Name: "first", Header: "FIRST PROMPT", Width: 30, search: true, style: plainStyle(&promptStyle),
+		text: func(sess store.Session, _ bool) string { return sess.FirstPrompt }},
+	{Name: "prompt", Header: "LAST PROMPT", search: true, style: plainStyle(&promptStyle),
+		text: func(sess store.Session, styled bool) string {
+			switch {
+			case sess.LastPrompt != "":
+				return sess.LastPrompt
+			case styled:
+				return "(no prompts yet)"
+			}
+			return "(none)"
+		},
+		value: func(sess store.Session) string { return sess.LastPrompt }},
+}
+
+// Default column lists of cst list and the launcher.
+var (
+	DefaultListColumns     = []string{"status", "mode", "id", "time", "model", "prompts", "prompt"}
+	DefaultLauncherColumns = []string{"status", "mode", "errors", "time", "model", "prompts", "prompt"}
+)
+
+// ColumnNames returns the names of all available columns.
+func ColumnNames() []string {
+	names := make([]string, len(Columns))
+	for i, c := range Columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// ParseColumns resolves column names, in display order, to columns.
+func ParseColumns(names []string) ([]Column, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no columns given")
+	}
+	cols := make([]Column, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		i := slices.IndexFunc(Columns, func(c Column) bool { return c.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown column %q, expected one of %s", name, strings.Join(ColumnNames(), ", "))
+		}
+		cols = append(cols, Columns[i])
+	}
+	return cols, nil
+}
+
+// mustColumns is ParseColumns for the built-in defaults.
+func mustColumns(names []string) []Column {
+	cols, err := ParseColumns(names)
+	if err != nil {
+		panic(err)
+	}
+	return cols
+}
+
+// FixedWidth returns the width taken by the fixed-width columns of cols and
+// the separators between all of them.
+func FixedWidth(cols []Column, sep string) int {
+	w := max(len(cols)-1, 0) * lipgloss.Width(sep)
+	for _, c := range cols {
+		w += c.Width
+	}
+	return w
+}
+
+// RenderRow formats sess as cols joined by sep. Fixed columns are cut and
+// padded to their width; the flexible column is cut to flexWidth with an
+// ellipsis. When styled, cells are colored for the launcher.
+func RenderRow(cols []Column, sess store.Session, sep string, flexWidth int, styled bool) string {
+	return renderRow(cols, sess, sep, flexWidth, styled, nil)
+}
+
+// renderRow is RenderRow that, when styled, also highlights the runes of
+// searchable cells matching the launcher search pattern.
+func renderRow(cols []Column, sess store.Session, sep string, flexWidth int, styled bool, pattern []rune) string {
+	cells := make([]string, len(cols))
+	for i, c := range cols {
+		cell := fitCell(c.text(sess, styled), c, flexWidth, i == len(cols)-1)
+		switch {
+		case styled && c.search && len(pattern) > 0:
+			cell = highlightMatch(pattern, cell, c.style(sess))
+		case styled:
+			cell = c.style(sess).Render(cell)
+		}
+		cells[i] = cell
+	}
+	return strings.Join(cells, sep)
+}
+
+// RenderHeader returns the heading line and the underline for cols, as
+// printed by cst list.
+func RenderHeader(cols []Column, sep string, flexWidth int) (header, rule string) {
+	heads := make([]string, len(cols))
+	rules := make([]string, len(cols))
+	for i, c := range cols {
+		last := i == len(cols)-1
+		heads[i] = fitCell(c.Header, c, flexWidth, last)
+		w := c.Width
+		if w == 0 {
+			w = lipgloss.Width(c.Header)
+		}
+		rules[i] = strings.Repeat("-", w)
+	}
+	return strings.Join(heads, sep), strings.Join(rules, sep)
+}
+
+// fitCell cuts s to the column width and pads it, except for the last cell.
+func fitCell(s string, c Column, flexWidth int, last bool) string {
+	width, tail := c.Width, ""
+	if width == 0 {
+		width, tail = max(flexWidth, 10), "..."
+	}
+	s = textutil.Truncate(s, width, tail)
+	if last {
+		return s
+	}
+	return s + strings.Repeat(" ", width-lipgloss.Width(s))
+}
+
+// statusText is the status cell: glyphs and short words in the launcher,
+// the words of cst list otherwise.
+func statusText(sess store.Session, styled bool) string {
+	if styled {
+		switch {
+		case sess.Source != "":
+			return glyphs.Source + " " + sess.Source
+		case sess.Active && sess.AwaitingSince > 0:
+			return glyphs.Waiting + " WAIT"
+		case sess.Active:
+			return glyphs.Active + " ACTIVE"
+		case sess.ReadOnly:
+			return glyphs.Import + " import"
+		case sess.EndReason == store.EndReasonClear:
+			return glyphs.Idle + " clear"
+		}
+		return glyphs.Idle + " idle"
+	}
+	switch {
+	case sess.Source != "":
+		return "@" + sess.Source
+	case sess.Active && sess.AwaitingSince > 0:
+		return "WAITING"
+	case sess.Active:
+		return "ACTIVE"
+	case sess.ReadOnly:
+		return "imported"
+	}
+	return "inactive"
+}
+
+// roughErrors is the number of failed tool calls at which a session is
+// rough, 0 for never; see SetRoughErrors.
+var roughErrors = config.DefaultRoughToolErrors
+
+// SetRoughErrors sets the number of failed tool calls at which sessions are
+// flagged as rough, per the rough_tool_errors config; 0 never flags them.
+func SetRoughErrors(n int) {
+	roughErrors = n
+}
+
+// rough reports whether so many of sess's tool calls failed that starting
+// afresh may beat resuming it.
+func rough(sess store.Session) bool {
+	return roughErrors > 0 && sess.ToolErrors >= roughErrors
+}
+
+// errorsText is the errors cell: the failed tool calls, flagged when the
+// session is rough.
+func errorsText(sess store.Session, styled bool) string {
+	switch {
+	case !styled:
+		return strconv.Itoa(sess.ToolErrors)
+	case rough(sess):
+		return fmt.Sprintf("%s %d", glyphs.Rough, sess.ToolErrors)
+	case sess.ToolErrors > 0:
+		return strconv.Itoa(sess.ToolErrors)
+	}
+	return ""
+}
+
+func errorsStyle(sess store.Session) lipgloss.Style {
+	if rough(sess) {
+		return staleStatusStyle
+	}
+	return timeStyle.UnsetWidth().UnsetMargins()
+}
+
+// PermissionModeBadge returns a short label for a Claude Code permission
+// mode, or "" for the default mode.
+func PermissionModeBadge(mode string) string {
+	switch mode {
+	case "", "default":
+		return ""
+	case "acceptEdits":
+		return "edits"
+	case "bypassPermissions":
+		return "bypass"
+	}
+	return mode
+}
+
+func modeText(sess store.Session, _ bool) string {
+	return PermissionModeBadge(sess.PermissionMode)
+}
+
+// modeStyle makes modes that skip permission prompts stand out.
+func modeStyle(sess store.Session) lipgloss.Style {
+	switch sess.PermissionMode {
+	case "bypassPermissions":
+		return staleStatusStyle
+	case "acceptEdits":
+		return quietStatusStyle
+	}
+	return modelStyle.UnsetWidth()
+}
+
+func statusStyle(sess store.Session) lipgloss.Style {
+	switch {
+	case sess.Source != "" || sess.ReadOnly:
+		return inactiveStatusStyle
+	case sess.Active && sess.AwaitingSince > 0:
+		return waitingStatusStyle
+	case sess.Active:
+		return activeStatusStyle
+	case sess.EndReason == store.EndReasonClear:
+		return quietStatusStyle
+	}
+	return inactiveStatusStyle
+}
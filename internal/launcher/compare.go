@@ -0,0 +1,328 @@
+package launcher
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+	"github.com/imyousuf/claude-session-tracker/internal/transcript"
+)
+
+// Comparison is two sessions side by side, for choosing which of them to
+// resume.
+type Comparison struct {
+	A, B     store.Session
+	PromptsA []store.Prompt // oldest first
+	PromptsB []store.Prompt
+	FilesA   []transcript.TouchedFile // changed, per the transcripts
+	FilesB   []transcript.TouchedFile
+}
+
+// CompareField is a field of two compared sessions.
+type CompareField struct {
+	Name string
+	A, B string
+}
+
+// comparePrompts bounds the prompts compared per session; fewer are stored.
+const comparePrompts = 100
+
+// LoadComparison reads the stored prompts of a from sa and of b from sb,
+// and the files their transcripts say they changed.
+func LoadComparison(sa, sb *store.Store, a, b store.Session) (Comparison, error) {
+	c := Comparison{A: a, B: b, FilesA: filesTouched(a), FilesB: filesTouched(b)}
+	var err error
+	if c.PromptsA, err = sa.GetPrompts(a.ID, comparePrompts); err != nil {
+		return c, err
+	}
+	if c.PromptsB, err = sb.GetPrompts(b.ID, comparePrompts); err != nil {
+		return c, err
+	}
+	slices.Reverse(c.PromptsA)
+	slices.Reverse(c.PromptsB)
+	return c, nil
+}
+
+// Fields lists what the sessions are, to be shown side by side.
+func (c Comparison) Fields() []CompareField {
+	fields := []struct {
+		name  string
+		value func(store.Session) string
+	}{
+		{"Title", func(s store.Session) string { return cmp.Or(s.Title, s.FirstPrompt) }},
+		{"Project", func(s store.Session) string { return s.Project }},
+		{"Branch", func(s store.Session) string { return s.Branch }},
+		{"Model", func(s store.Session) string { return s.Model }},
+		{"Status", func(s store.Session) string { return statusText(s, false) }},
+		{"Started", func(s store.Session) string { return FormatRelativeTime(s.StartedAt) }},
+		{"Last seen", func(s store.Session) string { return FormatRelativeTime(s.LastActivity) }},
+		{"Worked", func(s store.Session) string {
+			if s.WorkedMS == 0 {
+				return ""
+			}
+			return FormatDuration(s.WorkedMS)
+		}},
+		{"Prompts", func(s store.Session) string { return strconv.Itoa(s.PromptCount) }},
+		{"Tokens", func(s store.Session) string {
+			if n := s.InputTokens + s.OutputTokens; n > 0 {
+				return FormatTokens(n)
+			}
+			return ""
+		}},
+		{"Tool errors", func(s store.Session) string {
+			if s.ToolErrors == 0 {
+				return ""
+			}
+			return strconv.Itoa(s.ToolErrors)
+		}},
+		{"Outcome", func(s store.Session) string { return strings.TrimSpace(s.Outcome + " " + s.OutcomeNote) }},
+	}
+	out := make([]CompareField, len(fields))
+	for i, f := range fields {
+		out[i] = CompareField{f.name, f.value(c.A), f.value(c.B)}
+	}
+	return out
+}
+
+// PromptKey is what tells prompts apart when comparing sessions: their
+// words, whatever the spacing or case.
+func PromptKey(p store.Prompt) string {
+	return strings.ToLower(strings.Join(strings.Fields(p.Text), " "))
+}
+
+// SharedPrompts returns the PromptKey of the prompts sent in both sessions.
+func (c Comparison) SharedPrompts() map[string]bool {
+	inA := make(map[string]bool, len(c.PromptsA))
+	for _, p := range c.PromptsA {
+		inA[PromptKey(p)] = true
+	}
+	shared := make(map[string]bool)
+	for _, p := range c.PromptsB {
+		if k := PromptKey(p); inA[k] {
+			shared[k] = true
+		}
+	}
+	return shared
+}
+
+// SharedFiles returns the paths of the files both sessions changed.
+func (c Comparison) SharedFiles() map[string]bool {
+	inA := make(map[string]bool, len(c.FilesA))
+	for _, f := range c.FilesA {
+		inA[f.Path] = true
+	}
+	shared := make(map[string]bool)
+	for _, f := range c.FilesB {
+		if inA[f.Path] {
+			shared[f.Path] = true
+		}
+	}
+	return shared
+}
+
+// compareView is the compare screen: two sessions side by side, one of
+// which enter resumes.
+type compareView struct {
+	c      *Comparison // nil until loaded
+	err    error
+	side   int // 0 for A, 1 for B
+	scroll int // prompt rows scrolled past
+}
+
+type comparisonLoaded struct {
+	c   Comparison
+	err error
+}
+
+// markOrCompare marks the selected session for comparing, or compares it
+// with the one marked before.
+func (m Model) markOrCompare() (tea.Model, tea.Cmd) {
+	if len(m.filtered) == 0 {
+		return m, nil
+	}
+	sess := m.sessions[m.filtered[m.cursor]]
+	switch {
+	case m.compareFrom == nil:
+		m.compareFrom = &sess
+		m.statusMsg = "Select the session to compare " + textutil.ShortID(sess.ID) + " with and press " + keys.Compare.Help().Key
+		return m, nil
+	case m.compareFrom.ID == sess.ID:
+		m.compareFrom = nil
+		m.statusMsg = "Comparison canceled"
+		return m, nil
+	}
+	a, b := *m.compareFrom, sess
+	m.compareFrom = nil
+	m.compare = &compareView{}
+	sa, sb := m.storeFor(a), m.storeFor(b)
+	return m, func() tea.Msg {
+		c, err := LoadComparison(sa, sb, a, b)
+		return comparisonLoaded{c: c, err: err}
+	}
+}
+
+func (m Model) comparisonLoadedMsg(msg comparisonLoaded) Model {
+	if m.compare == nil {
+		return m
+	}
+	view := *m.compare
+	view.err = msg.err
+	if msg.err == nil {
+		view.c = &msg.c
+	}
+	m.compare = &view
+	return m
+}
+
+// compareHint is the list's key hint for comparing, naming the session
+// marked to compare.
+func (m Model) compareHint() string {
+	if m.compareFrom != nil {
+		return keys.Compare.Help().Key + " compare with " + textutil.ShortID(m.compareFrom.ID)
+	}
+	return keys.Compare.Help().Key + " compare"
+}
+
+// compareSideKeys switch the compare screen between its two sessions.
+var compareSideKeys = []string{"left", "right", "tab", "h", "l"}
+
+func (m Model) handleCompareKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	view := *m.compare
+	m.statusMsg = ""
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+	case msg.String() == "esc", key.Matches(msg, keys.Quit):
+		m.compare = nil
+		return m, nil
+	case slices.Contains(compareSideKeys, msg.String()):
+		view.side = 1 - view.side
+	case key.Matches(msg, keys.Up):
+		view.scroll = max(view.scroll-1, 0)
+	case key.Matches(msg, keys.Down):
+		if view.c != nil {
+			view.scroll = min(view.scroll+1, max(len(view.c.PromptsA), len(view.c.PromptsB))-1)
+		}
+	case key.Matches(msg, keys.Enter):
+		if view.c == nil {
+			return m, nil
+		}
+		sess := view.c.A
+		if view.side == 1 {
+			sess = view.c.B
+		}
+		if blocked := resumeBlocked(sess); blocked != "" {
+			m.statusMsg = blocked
+			return m, nil
+		}
+		m.compare = nil
+		return m.startResume(sess), nil
+	}
+	m.compare = &view
+	return m, nil
+}
+
+func (m Model) renderCompare() string {
+	view := m.compare
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("Compare sessions") + "\n")
+
+	const labelWidth = 13
+	colWidth := max((m.width-labelWidth-4)/2, 16)
+	// cell pads s, cut to the column, after styling it
+	cell := func(s string, style func(...string) string) string {
+		s = textutil.Truncate(s, colWidth-1, glyphs.Ellipsis)
+		return style(s) + strings.Repeat(" ", colWidth-textutil.Width(s))
+	}
+	plain := func(s ...string) string { return strings.Join(s, "") }
+	flat := func(s string) string { return strings.Join(strings.Fields(s), " ") }
+
+	switch {
+	case view.err != nil:
+		b.WriteString(errorStyle.Render("  Cannot compare: "+view.err.Error()) + "\n")
+	case view.c == nil:
+		b.WriteString(hintStyle.Render("  Loading sessions...") + "\n")
+	}
+	if view.c == nil {
+		b.WriteString("\n" + statusBarStyle.Render("esc close"))
+		return b.String()
+	}
+	c := view.c
+
+	line := strings.Repeat(" ", labelWidth+2)
+	for i, sess := range []store.Session{c.A, c.B} {
+		if i == view.side {
+			line += cell(glyphs.Marker+" "+textutil.ShortID(sess.ID), selectedStyle.Render)
+		} else {
+			line += cell("  "+textutil.ShortID(sess.ID), plain)
+		}
+	}
+	b.WriteString(line + "\n")
+
+	// Fields the sessions share are dimmed, so that differences stand out
+	for _, f := range c.Fields() {
+		if f.A == "" && f.B == "" {
+			continue
+		}
+		style := plain
+		if f.A == f.B {
+			style = hintStyle.Render
+		}
+		fmt.Fprintf(&b, "  %-*s%s%s\n", labelWidth, f.Name, cell("  "+flat(f.A), style), cell("  "+flat(f.B), style))
+	}
+
+	shared := c.SharedPrompts()
+	fmt.Fprintf(&b, "\n  %s\n", previewHeaderStyle.Render(fmt.Sprintf("Prompts, oldest first (%s sent in both: %d)", glyphs.Marker, len(shared))))
+	prompt := func(ps []store.Prompt, i int) string {
+		if i >= len(ps) {
+			return cell("", plain)
+		}
+		text := flat(ps[i].Text)
+		if shared[PromptKey(ps[i])] {
+			return cell(glyphs.Marker+" "+text, activeStatusStyle.Render)
+		}
+		return cell("  "+text, promptStyle.Render)
+	}
+	// Header, fields, files and status bar lines around the prompt rows
+	rows := max(m.height-len(c.Fields())-12, 3)
+	n := max(len(c.PromptsA), len(c.PromptsB))
+	if n == 0 {
+		b.WriteString(hintStyle.Render("  No prompts recorded") + "\n")
+	}
+	start := min(view.scroll, max(n-rows, 0))
+	for i := start; i < min(start+rows, n); i++ {
+		fmt.Fprintf(&b, "  %-*s%s%s\n", labelWidth, "", prompt(c.PromptsA, i), prompt(c.PromptsB, i))
+	}
+
+	files := fmt.Sprintf("%d and %d changed", len(c.FilesA), len(c.FilesB))
+	if both := c.SharedFiles(); len(both) > 0 {
+		names := slices.Sorted(maps.Keys(both))
+		for i, name := range names {
+			names[i] = strings.TrimPrefix(name, c.A.Project+"/")
+		}
+		files += fmt.Sprintf(", %d by both: %s", len(both), strings.Join(names, ", "))
+	}
+	fmt.Fprintf(&b, "\n  %-*s%s\n", labelWidth, "Files", textutil.Truncate(files, max(m.width-labelWidth-4, 10), glyphs.Ellipsis))
+
+	b.WriteString("\n")
+	if m.statusMsg != "" {
+		b.WriteString(errorStyle.Render(m.statusMsg))
+	}
+	b.WriteString("\n")
+	hints := []string{
+		glyphs.Left + "/" + glyphs.Right + " choose",
+		keys.Up.Help().Key + "/" + keys.Down.Help().Key + " scroll",
+		keys.Enter.Help().Key + " resume",
+		"esc close",
+	}
+	b.WriteString(statusBarStyle.Render(strings.Join(hints, "  "+glyphs.Separator+"  ")))
+	return b.String()
+}
@@ -0,0 +1,357 @@
+package launcher
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// ConfigEditor is the full-screen editor of cst config edit. Like the
+// launcher's settings screen, it saves every change as it is made.
+type ConfigEditor struct {
+	path   string
+	cfg    config.Config
+	keys   []config.Key
+	cursor int
+
+	// list is the list being edited, if a list key is open
+	list *listEdit
+	// editing is set while a value is typed into text
+	editing bool
+	text    string
+
+	status string
+	failed bool // status is an error
+	width  int
+	height int
+}
+
+// listEdit is an open list key. adding is set while text is a new item.
+type listEdit struct {
+	items  []string
+	cursor int
+	adding bool
+}
+
+// NewConfigEditor returns an editor of the config at path, with every key
+// of config.Keys.
+func NewConfigEditor(path string, cfg config.Config) ConfigEditor {
+	setTheme(cfg.Theme, cfg.ThemeColors)
+	return ConfigEditor{path: path, cfg: cfg, keys: config.Keys()}
+}
+
+func init() {
+	config.RegisterCheck("columns", func(v any) error {
+		_, err := ParseColumns(v.([]string))
+		return err
+	})
+}
+
+func (e ConfigEditor) Init() tea.Cmd {
+	return nil
+}
+
+func (e ConfigEditor) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		e.width, e.height = msg.Width, msg.Height
+		return e, nil
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return e, tea.Quit
+		}
+		switch {
+		case e.editing:
+			return e.handleTextKey(msg), nil
+		case e.list != nil:
+			return e.handleListKey(msg), nil
+		}
+		return e.handleFieldKey(msg)
+	}
+	return e, nil
+}
+
+// handleFieldKey handles input on the list of keys.
+func (e ConfigEditor) handleFieldKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	k := e.keys[e.cursor]
+	switch msg.String() {
+	case "q", "esc":
+		return e, tea.Quit
+	case "left", "h":
+		return e.step(k, -1), nil
+	case "right", "l", " ":
+		return e.step(k, 1), nil
+	case "enter":
+		switch {
+		case k.Type == config.TypeBool || len(k.Choices) > 0:
+			return e.step(k, 1), nil
+		case k.Type == config.TypeList:
+			e.list = &listEdit{items: e.cfg.Value(k.Name).([]string)}
+		default:
+			e.editing, e.text = true, k.Format(e.cfg.Value(k.Name))
+		}
+		e.status = ""
+		return e, nil
+	}
+	switch {
+	case key.Matches(msg, keys.Up):
+		e.cursor = (e.cursor + len(e.keys) - 1) % len(e.keys)
+	case key.Matches(msg, keys.Down):
+		e.cursor = (e.cursor + 1) % len(e.keys)
+	}
+	return e, nil
+}
+
+// step toggles a bool key or moves a key with choices delta places.
+func (e ConfigEditor) step(k config.Key, delta int) ConfigEditor {
+	switch v := e.cfg.Value(k.Name).(type) {
+	case bool:
+		return e.apply(k, !v)
+	case string:
+		if len(k.Choices) > 0 {
+			return e.apply(k, cycle(k.Choices, v, delta))
+		}
+	}
+	return e
+}
+
+// handleTextKey handles input while a value or list item is typed.
+func (e ConfigEditor) handleTextKey(msg tea.KeyMsg) ConfigEditor {
+	if e.list != nil {
+		l := *e.list
+		e.list = &l
+	}
+	switch msg.String() {
+	case "esc":
+		e.editing = false
+		if e.list != nil {
+			e.list.adding = false
+		}
+		e.status = ""
+	case "enter":
+		k := e.keys[e.cursor]
+		if e.list == nil {
+			v, err := k.Parse(strings.TrimSpace(e.text))
+			if err != nil {
+				e.status, e.failed = err.Error(), true
+				return e
+			}
+			if e = e.apply(k, v); !e.failed {
+				e.editing = false
+			}
+			return e
+		}
+		item := strings.TrimSpace(e.text)
+		if item == "" {
+			e.status, e.failed = "An item cannot be empty; d deletes it", true
+			return e
+		}
+		items := slices.Clone(e.list.items)
+		cursor := e.list.cursor
+		if e.list.adding {
+			cursor = len(items)
+			items = append(items, item)
+		} else {
+			items[cursor] = item
+		}
+		if e = e.applyList(k, items); !e.failed {
+			e.editing, e.list.adding, e.list.cursor = false, false, cursor
+		}
+	case "backspace":
+		if r := []rune(e.text); len(r) > 0 {
+			e.text = string(r[:len(r)-1])
+		}
+	case "ctrl+u":
+		e.text = ""
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			e.text += string(msg.Runes)
+		}
+	}
+	return e
+}
+
+// handleListKey handles input while a list key is open.
+func (e ConfigEditor) handleListKey(msg tea.KeyMsg) ConfigEditor {
+	k := e.keys[e.cursor]
+	l := *e.list
+	e.list = &l
+	switch msg.String() {
+	case "q", "esc":
+		e.list = nil
+		e.status = ""
+		return e
+	case "a":
+		l.adding = true
+		e.editing, e.text, e.status = true, "", ""
+		return e
+	case "enter", "e":
+		if len(l.items) > 0 {
+			e.editing, e.text, e.status = true, l.items[l.cursor], ""
+		}
+		return e
+	case "d", "delete":
+		if len(l.items) > 0 {
+			e = e.applyList(k, slices.Delete(slices.Clone(l.items), l.cursor, l.cursor+1))
+			e.list.cursor = min(l.cursor, max(len(e.list.items)-1, 0))
+		}
+		return e
+	case "K", "J":
+		to := l.cursor - 1
+		if msg.String() == "J" {
+			to = l.cursor + 1
+		}
+		if to >= 0 && to < len(l.items) {
+			items := slices.Clone(l.items)
+			items[l.cursor], items[to] = items[to], items[l.cursor]
+			if e = e.applyList(k, items); !e.failed {
+				e.list.cursor = to
+			}
+		}
+		return e
+	}
+	switch {
+	case key.Matches(msg, keys.Up) && l.cursor > 0:
+		l.cursor--
+	case key.Matches(msg, keys.Down) && l.cursor < len(l.items)-1:
+		l.cursor++
+	}
+	return e
+}
+
+// apply sets a key and saves the config. The file is re-read first so
+// that changes made meanwhile by other cst commands survive.
+func (e ConfigEditor) apply(k config.Key, v any) ConfigEditor {
+	cfg, err := config.Load(e.path)
+	if err == nil {
+		err = cfg.Set(k.Name, v)
+	}
+	if err == nil {
+		err = config.Save(e.path, cfg)
+	}
+	if err != nil {
+		e.status, e.failed = err.Error(), true
+		return e
+	}
+	e.cfg = cfg
+	e.status, e.failed = "Saved "+k.Name, false
+	if k.Name == "theme" {
+		setTheme(cfg.Theme, cfg.ThemeColors)
+	}
+	return e
+}
+
+// applyList sets the open list key to items and saves the config.
+func (e ConfigEditor) applyList(k config.Key, items []string) ConfigEditor {
+	if e = e.apply(k, items); !e.failed {
+		e.list.items = e.cfg.Value(k.Name).([]string)
+	}
+	return e
+}
+
+func (e ConfigEditor) View() string {
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("Config  " + e.path))
+	b.WriteString("\n")
+
+	if e.list != nil {
+		e.renderList(&b)
+	} else {
+		e.renderFields(&b)
+	}
+
+	k := e.keys[e.cursor]
+	b.WriteString("\n")
+	if e.editing {
+		fmt.Fprintf(&b, "  %s: %s%s\n", k.Name, e.text, glyphs.Cursor)
+	} else {
+		help := k.Description
+		if k.Default != "" {
+			help += " (default " + k.Default + ")"
+		}
+		b.WriteString(hintStyle.Render("  "+help) + "\n")
+	}
+	if e.failed {
+		b.WriteString(errorStyle.Render(e.status))
+	} else {
+		b.WriteString(hintStyle.Render(e.status))
+	}
+	b.WriteString("\n")
+
+	var hints []string
+	switch {
+	case e.editing:
+		hints = []string{"enter apply", "ctrl+u clear", "esc discard"}
+	case e.list != nil:
+		hints = []string{"a add", "enter edit", "d delete", "K/J move", "esc back"}
+	default:
+		hints = []string{keys.Up.Help().Key + "/" + keys.Down.Help().Key + " select", "enter edit", glyphs.Left + "/" + glyphs.Right + " change", "q quit"}
+	}
+	b.WriteString(statusBarStyle.Render(strings.Join(hints, "  "+glyphs.Separator+"  ")))
+	return b.String()
+}
+
+// renderFields lists the keys and their values, scrolled to keep the
+// selected one in view.
+func (e ConfigEditor) renderFields(b *strings.Builder) {
+	keyWidth := 0
+	for _, k := range e.keys {
+		keyWidth = max(keyWidth, len(k.Name))
+	}
+	rows := len(e.keys)
+	if e.height > 0 {
+		rows = max(min(rows, e.height-6), 1)
+	}
+	offset := max(e.cursor-rows+1, 0)
+
+	for i := offset; i < min(offset+rows, len(e.keys)); i++ {
+		k := e.keys[i]
+		line := fmt.Sprintf("  %-*s   %s", keyWidth, k.Name, formatValue(k, e.cfg))
+		if w := e.width - 1; w > 3 {
+			line = textutil.Truncate(line, w, "...")
+		}
+		if i == e.cursor {
+			line = selectLine(line)
+		}
+		b.WriteString(line + "\n")
+	}
+}
+
+// renderList lists the items of the open list key.
+func (e ConfigEditor) renderList(b *strings.Builder) {
+	fmt.Fprintf(b, "  %s\n", e.keys[e.cursor].Name)
+	if len(e.list.items) == 0 && !e.list.adding {
+		b.WriteString(hintStyle.Render("    (empty; a adds an item)") + "\n")
+	}
+	for i, item := range e.list.items {
+		line := fmt.Sprintf("    %d. %s", i+1, item)
+		if i == e.list.cursor && !e.list.adding {
+			line = selectLine(line)
+		}
+		b.WriteString(line + "\n")
+	}
+}
+
+// formatValue renders the value of k in cfg for the list of keys.
+func formatValue(k config.Key, cfg config.Config) string {
+	v := cfg.Value(k.Name)
+	switch {
+	case k.Type == config.TypeList:
+		items := v.([]string)
+		if len(items) == 0 {
+			return hintStyle.Render("(none)")
+		}
+		return strings.Join(items, "  "+glyphs.Dot+"  ")
+	case len(k.Choices) > 0:
+		return glyphs.ChoiceOpen + " " + k.Format(v) + " " + glyphs.ChoiceClose
+	case v == "":
+		return hintStyle.Render("(not set)")
+	}
+	return k.Format(v)
+}
@@ -0,0 +1,397 @@
+package launcher
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/procutil"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// freshActivity is how recent a running session's last activity must be
+// to count as busy rather than quiet on the dashboard.
+const freshActivity = time.Minute
+
+// Dashboard is the Bubbletea model for `cst watch`: running sessions grouped
+// by project, refreshed periodically, with keys to jump to or stop them.
+type Dashboard struct {
+	store      *store.Store
+	project    string // "" for all projects
+	interval   time.Duration
+	idleGap    time.Duration // activity older than this shows as stale
+	groups     []projectGroup
+	rows       []store.Session // sessions in display order, for the cursor
+	cursor     int
+	confirming bool // stop confirmation
+	statusMsg  string
+	err        error
+	width      int
+	height     int
+	updated    time.Time
+}
+
+// projectGroup is one project's running sessions, waiting ones first.
+type projectGroup struct {
+	project  string
+	sessions []store.Session
+	waiting  int
+	workedMS int64
+}
+
+type dashboardLoaded struct {
+	sessions []store.Session
+	err      error
+}
+
+type dashboardTick struct{}
+
+// NewDashboard creates a dashboard of the running sessions in project, or
+// in all projects if project is "", reloaded every interval.
+func NewDashboard(s *store.Store, project string, interval time.Duration) Dashboard {
+	return Dashboard{
+		store:    s,
+		project:  project,
+		interval: interval,
+		idleGap:  store.DefaultIdleGap,
+	}
+}
+
+// WithConfig applies the theme and idle gap from cfg.
+func (d Dashboard) WithConfig(cfg config.Config) Dashboard {
+	setTheme(cfg.Theme, cfg.ThemeColors)
+	if cfg.IdleGapMinutes > 0 {
+		d.idleGap = time.Duration(cfg.IdleGapMinutes) * time.Minute
+	}
+	return d
+}
+
+func loadDashboard(s *store.Store, project string) tea.Cmd {
+	return func() tea.Msg {
+		if err := s.RefreshActive(procutil.IsProcessAlive); err != nil {
+			return dashboardLoaded{err: err}
+		}
+		sessions, err := s.ListSessions(store.ListOptions{Project: project})
+		if err != nil {
+			return dashboardLoaded{err: err}
+		}
+		return dashboardLoaded{sessions: slices.DeleteFunc(sessions, func(sess store.Session) bool { return !sess.Active })}
+	}
+}
+
+func (d Dashboard) tick() tea.Cmd {
+	return tea.Tick(d.interval, func(time.Time) tea.Msg { return dashboardTick{} })
+}
+
+// Init implements tea.Model.
+func (d Dashboard) Init() tea.Cmd {
+	return tea.Batch(loadDashboard(d.store, d.project), d.tick())
+}
+
+// Update implements tea.Model.
+func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.width = msg.Width
+		d.height = msg.Height
+		return d, nil
+
+	case dashboardLoaded:
+		d.err = msg.err
+		selected := ""
+		if d.cursor < len(d.rows) {
+			selected = d.rows[d.cursor].ID
+		}
+		d.groups = groupByProject(msg.sessions)
+		d.rows = nil
+		for _, g := range d.groups {
+			d.rows = append(d.rows, g.sessions...)
+		}
+		d.cursor = max(slices.IndexFunc(d.rows, func(sess store.Session) bool { return sess.ID == selected }), 0)
+		d.updated = time.Now()
+		return d, nil
+
+	case dashboardTick:
+		if d.confirming {
+			// Don't move the target of a pending stop.
+			return d, d.tick()
+		}
+		return d, tea.Batch(loadDashboard(d.store, d.project), d.tick())
+
+	case attached:
+		if msg.err != nil {
+			d.statusMsg = "Cannot jump to session: " + msg.err.Error()
+		} else {
+			d.statusMsg = "Focused " + msg.desc
+		}
+		return d, nil
+
+	case tea.KeyMsg:
+		return d.handleKey(msg)
+	}
+	return d, nil
+}
+
+func (d Dashboard) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if d.confirming {
+		d.confirming = false
+		d.statusMsg = ""
+		switch msg.String() {
+		case "y", "Y":
+			if d.cursor < len(d.rows) {
+				return d.stop(d.rows[d.cursor])
+			}
+		}
+		return d, nil
+	}
+
+	d.statusMsg = ""
+	switch {
+	case key.Matches(msg, keys.Quit):
+		return d, tea.Quit
+
+	case key.Matches(msg, keys.Up):
+		if d.cursor > 0 {
+			d.cursor--
+		}
+
+	case key.Matches(msg, keys.Down):
+		if d.cursor < len(d.rows)-1 {
+			d.cursor++
+		}
+
+	case key.Matches(msg, keys.Attach):
+		if d.cursor < len(d.rows) {
+			sess := d.rows[d.cursor]
+			if !sess.IsLocal() {
+				d.statusMsg = "Session runs on " + sess.Host
+				return d, nil
+			}
+			d.statusMsg = "Looking for session " + textutil.ShortID(sess.ID) + "..."
+			return d, focusSession(sess)
+		}
+
+	case key.Matches(msg, keys.Stop):
+		if d.cursor < len(d.rows) {
+			d.statusMsg, d.confirming = stopPrompt(d.rows[d.cursor])
+		}
+	}
+	return d, nil
+}
+
+// stop sends SIGTERM to a session's claude process, see stopSession, and
+// reloads.
+func (d Dashboard) stop(sess store.Session) (tea.Model, tea.Cmd) {
+	d.statusMsg = stopSession(sess)
+	return d, loadDashboard(d.store, d.project)
+}
+
+// stopPrompt returns the question confirming that sess is to be stopped,
+// for the dashboard and cst top, or, with ok false, why it can't be.
+func stopPrompt(sess store.Session) (status string, ok bool) {
+	switch {
+	case !sess.IsLocal():
+		return "Session runs on " + sess.Host, false
+	case sess.PID == nil:
+		return "No process recorded for session " + textutil.ShortID(sess.ID), false
+	}
+	return fmt.Sprintf("Stop session %s (SIGTERM)? (y/N)", textutil.ShortID(sess.ID)), true
+}
+
+// stopSession sends SIGTERM to the claude process of sess, once confirmed
+// per stopPrompt, and returns the status to show.
+func stopSession(sess store.Session) string {
+	if status, ok := stopPrompt(sess); !ok {
+		return status
+	}
+	if err := procutil.Terminate(*sess.PID); err != nil {
+		return "Cannot stop session: " + err.Error()
+	}
+	return "Stopped session " + textutil.ShortID(sess.ID)
+}
+
+// groupByProject groups sessions by project, busiest project first. Within
+// a project, sessions waiting on the user come first, longest wait first,
+// then the most recently active.
+func groupByProject(sessions []store.Session) []projectGroup {
+	byProject := map[string]*projectGroup{}
+	var groups []*projectGroup
+	for _, sess := range sessions {
+		g, ok := byProject[sess.Project]
+		if !ok {
+			g = &projectGroup{project: sess.Project}
+			byProject[sess.Project] = g
+			groups = append(groups, g)
+		}
+		g.sessions = append(g.sessions, sess)
+		g.workedMS += sess.WorkedMS
+		if sess.AwaitingSince > 0 {
+			g.waiting++
+		}
+	}
+
+	out := make([]projectGroup, 0, len(groups))
+	for _, g := range groups {
+		slices.SortStableFunc(g.sessions, func(a, b store.Session) int {
+			switch {
+			case a.AwaitingSince > 0 && b.AwaitingSince > 0:
+				return cmp.Compare(a.AwaitingSince, b.AwaitingSince)
+			case a.AwaitingSince > 0:
+				return -1
+			case b.AwaitingSince > 0:
+				return 1
+			}
+			return cmp.Compare(b.LastActivity, a.LastActivity)
+		})
+		out = append(out, *g)
+	}
+	slices.SortStableFunc(out, func(a, b projectGroup) int {
+		if c := cmp.Compare(b.waiting, a.waiting); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(len(b.sessions), len(a.sessions)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.project, b.project)
+	})
+	return out
+}
+
+// View implements tea.Model.
+func (d Dashboard) View() string {
+	if d.err != nil {
+		return errorStyle.Render("Error: " + d.err.Error())
+	}
+	if d.width == 0 {
+		return "Loading..."
+	}
+
+	var b strings.Builder
+	scope := "all projects"
+	if d.project != "" {
+		scope = d.project
+	}
+	waiting := 0
+	for _, g := range d.groups {
+		waiting += g.waiting
+	}
+	title := fmt.Sprintf("cst watch  %d running, %d waiting", len(d.rows), waiting)
+	b.WriteString(headerStyle.Render(title + "  " + hintStyle.Render(fmt.Sprintf("%s %s every %s %s updated %s",
+		scope, glyphs.Dot, d.interval, glyphs.Dot, d.updated.Format("15:04:05")))))
+	b.WriteString("\n")
+
+	if len(d.rows) == 0 {
+		b.WriteString(hintStyle.Render("No running sessions."))
+		b.WriteString("\n")
+	}
+
+	var lines []string
+	row := 0
+	for _, g := range d.groups {
+		totals := fmt.Sprintf("%d running", len(g.sessions))
+		if g.waiting > 0 {
+			totals += fmt.Sprintf(" %s %d waiting", glyphs.Dot, g.waiting)
+		}
+		if g.workedMS > 0 {
+			totals += " " + glyphs.Dot + " " + FormatDuration(g.workedMS) + " worked"
+		}
+		lines = append(lines, previewHeaderStyle.UnsetMarginBottom().Render(filepath.Base(g.project))+"  "+
+			hintStyle.Render(g.project+"  "+totals))
+		for _, sess := range g.sessions {
+			line := d.renderRow(sess)
+			if row == d.cursor {
+				line = selectLine(line)
+			}
+			lines = append(lines, line)
+			row++
+		}
+		lines = append(lines, "")
+	}
+	b.WriteString(d.visible(lines))
+
+	b.WriteString("\n")
+	if d.statusMsg != "" {
+		if d.confirming {
+			b.WriteString(errorStyle.Render(d.statusMsg))
+		} else {
+			b.WriteString(hintStyle.Render(d.statusMsg))
+		}
+	}
+	b.WriteString("\n")
+	hints := []string{
+		keys.Up.Help().Key + "/" + keys.Down.Help().Key + " navigate",
+		keys.Attach.Help().Key + " jump",
+		keys.Stop.Help().Key + " stop",
+		keys.Quit.Help().Key + " quit",
+	}
+	b.WriteString(statusBarStyle.Render(strings.Join(hints, "  "+glyphs.Separator+"  ")))
+	return b.String()
+}
+
+// visible keeps the selected row on screen when the groups don't fit.
+func (d Dashboard) visible(lines []string) string {
+	height := max(d.height-6, 1)
+	if len(lines) <= height {
+		return strings.Join(lines, "\n")
+	}
+	// Find the line of the selected row: each group adds a header before
+	// and a blank line after its sessions.
+	selected, row := 0, 0
+	for _, g := range d.groups {
+		selected++
+		if d.cursor < row+len(g.sessions) {
+			selected += d.cursor - row
+			break
+		}
+		selected += len(g.sessions) + 1
+		row += len(g.sessions)
+	}
+	start := max(selected-height+1, 0)
+	return strings.Join(lines[start:min(start+height, len(lines))], "\n")
+}
+
+func (d Dashboard) renderRow(sess store.Session) string {
+	var status, age string
+	since := time.Since(time.UnixMilli(sess.LastActivity))
+	switch {
+	case sess.AwaitingSince > 0:
+		status = waitingStatusStyle.Render(glyphs.Waiting)
+		age = waitingStatusStyle.Render(fmt.Sprintf("%-12s", "waiting "+strings.TrimSuffix(FormatRelativeTime(sess.AwaitingSince), " ago")))
+	case since < freshActivity:
+		status = activeStatusStyle.Render(glyphs.Active)
+		age = activeStatusStyle.Render(fmt.Sprintf("%-12s", FormatRelativeTime(sess.LastActivity)))
+	case since < d.idleGap:
+		status = quietStatusStyle.Render(glyphs.Active)
+		age = quietStatusStyle.Render(fmt.Sprintf("%-12s", FormatRelativeTime(sess.LastActivity)))
+	default:
+		status = staleStatusStyle.Render(glyphs.Active)
+		age = staleStatusStyle.Render(fmt.Sprintf("%-12s", FormatRelativeTime(sess.LastActivity)))
+	}
+
+	where := FormatTerminal(sess)
+	if !sess.IsLocal() {
+		where = "@" + sess.Host
+	}
+	promptWidth := max(d.width-2-2-8-1-12-1-16-1-14-1, 10)
+	prompt := sess.LastPrompt
+	if prompt == "" {
+		prompt = "(no prompts yet)"
+	}
+	prompt = textutil.Truncate(prompt, promptWidth, "...")
+	return fmt.Sprintf("  %s %-8s %s %s %-14.14s %s",
+		status,
+		textutil.ShortID(sess.ID),
+		age,
+		modelStyle.Render(shortModel(sess.Model)),
+		where,
+		promptStyle.Render(prompt),
+	)
+}
@@ -0,0 +1,32 @@
+package launcher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+func TestStopPrompt(t *testing.T) {
+	pid := 4242
+	tests := []struct {
+		name string
+		sess store.Session
+		want string
+		ok   bool
+	}{
+		{"local", store.Session{ID: "4f9c2a1e-7b3d", PID: &pid}, "Stop session 4f9c2a1e (SIGTERM)?", true},
+		{"other host", store.Session{ID: "4f9c2a1e-7b3d", PID: &pid, Host: "build-box-" + store.LocalHost()}, "Session runs on build-box-", false},
+		{"no process", store.Session{ID: "4f9c2a1e-7b3d"}, "No process recorded for session 4f9c2a1e", false},
+	}
+	for _, tc := range tests {
+		status, ok := stopPrompt(tc.sess)
+		if ok != tc.ok || !strings.HasPrefix(status, tc.want) {
+			t.Errorf("%s: stopPrompt = %q, %v; want %q..., %v", tc.name, status, ok, tc.want, tc.ok)
+		}
+	}
+	// Sessions that can't be stopped are refused again at the confirmation
+	if got := stopSession(tests[2].sess); !strings.HasPrefix(got, "No process recorded") {
+		t.Errorf("stopSession without a process = %q", got)
+	}
+}
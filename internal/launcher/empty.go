@@ -0,0 +1,88 @@
+package launcher
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const docsURL = "https://github.com/imyousuf/claude-session-tracker#readme"
+
+// renderEmptyState explains why no sessions are listed and what to do next.
+// A completely empty store gets first-run onboarding; otherwise the user is
+// pointed at the search or scope that hides the existing sessions.
+func (m Model) renderEmptyState() string {
+	var lines []string
+
+	switch {
+	case m.searchText != "":
+		lines = append(lines,
+			hintStyle.Render(fmt.Sprintf("No sessions match %q.", m.searchText)),
+			"",
+			emptyAction("/", "Start a new search"),
+		)
+	case m.total > 0:
+		empty := "No sessions for this project yet."
+		if m.scope == scopeWorkspace {
+			empty = "No sessions in workspace " + m.workspace + " yet."
+		}
+		action := fmt.Sprintf("Show all projects (%d sessions)", m.total)
+		if m.nextScope() == scopeWorkspace {
+			action = "Show workspace " + m.workspace
+		}
+		lines = append(lines, hintStyle.Render(empty), "", emptyAction(keys.Tab.Help().Key, action))
+	default:
+		lines = append(lines,
+			previewHeaderStyle.Render("No sessions tracked yet"),
+			"CST records sessions through Claude Code hooks. Once the plugin is",
+			"enabled, every new Claude session shows up here.",
+			"",
+			emptyAction(keys.Setup.Help().Key, "Show hook setup steps"),
+			emptyAction(keys.Docs.Help().Key, "Open the documentation"),
+			emptyAction("q", "Quit"),
+		)
+		if m.showSetup {
+			lines = append(lines, "", renderSetupSteps())
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func emptyAction(key, desc string) string {
+	return "  " + selectedStyle.Render(" "+key+" ") + "  " + desc
+}
+
+// renderSetupSteps lists what is needed for hooks to record sessions,
+// checking whether the cst binary is reachable by Claude Code.
+func renderSetupSteps() string {
+	binStatus := activeStatusStyle.Render("found: ")
+	if path, err := exec.LookPath("cst"); err == nil {
+		binStatus += path
+	} else {
+		binStatus = errorStyle.Render("not found on PATH")
+	}
+
+	steps := []string{
+		previewHeaderStyle.Render("Hook setup"),
+		"1. Put the cst binary on your PATH (" + binStatus + ")",
+		"2. In Claude Code, run /plugin and enable session-tracker",
+		"3. Start a new Claude session, then run cst again",
+	}
+	return previewStyle.Render(strings.Join(steps, "\n"))
+}
+
+// openURL opens url in the user's browser.
+func openURL(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
@@ -0,0 +1,179 @@
+package launcher
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// handleExpandedKey handles input while the full-screen prompt view is open.
+// Up/down select a prompt, d deletes it, and paging keys go to the viewport.
+func (m Model) handleExpandedKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.confirming {
+		m.confirming = false
+		m.statusMsg = ""
+		if msg.String() != "y" && msg.String() != "Y" {
+			return m, nil
+		}
+		p := m.viewPrompts()[m.promptCursor]
+		if err := m.store.DeletePrompt(p.ID); err != nil {
+			m.statusMsg = "Error deleting prompt: " + err.Error()
+			return m, nil
+		}
+		m.statusMsg = "Deleted prompt"
+		sess := m.sessions[m.filtered[m.cursor]]
+		return m, tea.Batch(loadPrompts(m.store, sess), m.loadSessions())
+	}
+
+	m.statusMsg = ""
+
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+	case key.Matches(msg, keys.Quit), key.Matches(msg, keys.Expand):
+		m.expanded = false
+		return m, nil
+	case key.Matches(msg, keys.Up):
+		if m.promptCursor > 0 {
+			m.promptCursor--
+			m.syncExpandView()
+		}
+		return m, nil
+	case key.Matches(msg, keys.Down):
+		if m.promptCursor < len(m.viewPrompts())-1 {
+			m.promptCursor++
+			m.syncExpandView()
+		}
+		return m, nil
+	case key.Matches(msg, keys.Delete):
+		if sess := m.sessions[m.filtered[m.cursor]]; sess.Source != "" {
+			m.statusMsg = "Cannot delete prompts of a session from store " + sess.Source
+			return m, nil
+		}
+		if m.store.ReadOnly() {
+			m.statusMsg = "Cannot delete prompts: the database is open read-only"
+			return m, nil
+		}
+		if len(m.viewPrompts()) > 0 {
+			m.confirming = true
+			m.statusMsg = "Delete the selected prompt? (y/N)"
+		}
+		return m, nil
+	case msg.String() == "m":
+		m.mainOnly = !m.mainOnly
+		m.statusMsg = "Prompts to all agents"
+		if m.mainOnly {
+			m.statusMsg = "Prompts to the main agent only"
+		}
+		m.promptCursor = 0
+		m.syncExpandView()
+		m.expandView.GotoTop()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.expandView, cmd = m.expandView.Update(msg)
+	return m, cmd
+}
+
+// syncExpandView sizes the full-screen viewport to the terminal, fills it
+// with the selected session's prompts, word-wrapped rather than truncated,
+// and scrolls so the selected prompt is visible.
+func (m *Model) syncExpandView() {
+	width := max(m.width, 20)
+	m.expandView.Width = width
+	m.expandView.Height = max(m.height-5, 3) // header + status + hints
+	m.promptCursor = min(m.promptCursor, max(len(m.viewPrompts())-1, 0))
+
+	content, top, bottom := m.expandedContent(width)
+	m.expandView.SetContent(content)
+	if top < m.expandView.YOffset {
+		m.expandView.SetYOffset(top)
+	} else if bottom >= m.expandView.YOffset+m.expandView.Height {
+		m.expandView.SetYOffset(bottom - m.expandView.Height + 1)
+	}
+}
+
+// expandedContent renders every prompt and returns the first and last line
+// of the selected one.
+func (m Model) expandedContent(width int) (content string, top, bottom int) {
+	prompts := m.viewPrompts()
+	if len(prompts) == 0 {
+		if len(m.prompts) > 0 {
+			return hintStyle.Render("No prompts to the main agent; m shows all"), 0, 0
+		}
+		return hintStyle.Render("No prompts recorded"), 0, 0
+	}
+	wrap := lipgloss.NewStyle().Width(width - 2).PaddingLeft(2)
+	var blocks []string
+	line := 0
+	for i, p := range prompts {
+		marker := "  "
+		if i == m.promptCursor {
+			marker = glyphs.Marker + " "
+		}
+		header := marker + hintStyle.Render(formatAbsoluteTime(p.Timestamp)+"  "+FormatRelativeTime(p.Timestamp)+occurrences(p)+agentLabel(p))
+		text := wrap.Render(previewPromptStyle.Render(p.Text))
+		if i == m.promptCursor {
+			header = selectedStyle.Render(header)
+			top = line
+			bottom = line + lipgloss.Height(text)
+		}
+		block := header + "\n" + text
+		blocks = append(blocks, block)
+		line += lipgloss.Height(block) + 1 // blank line between blocks
+	}
+	return strings.Join(blocks, "\n\n"), top, bottom
+}
+
+func (m Model) renderExpanded() string {
+	sess := m.sessions[m.filtered[m.cursor]]
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(fmt.Sprintf("Prompts for session %s", textutil.ShortID(sess.ID))))
+	b.WriteString("\n")
+	b.WriteString(m.expandView.View())
+	b.WriteString("\n")
+	if m.confirming {
+		b.WriteString(errorStyle.Render(m.statusMsg))
+	} else if m.statusMsg != "" {
+		b.WriteString(hintStyle.Render(m.statusMsg))
+	}
+	b.WriteString("\n")
+	hints := []string{
+		keys.Up.Help().Key + "/" + keys.Down.Help().Key + " select",
+		"pgup/pgdn scroll",
+		keys.Delete.Help().Key + " delete prompt",
+	}
+	if m.mainOnly {
+		hints = append(hints, "m all agents")
+	} else if slices.ContainsFunc(m.prompts, func(p store.Prompt) bool { return p.Agent != "" }) {
+		hints = append(hints, "m main agent only")
+	}
+	hints = append(hints,
+		fmt.Sprintf("%3.f%%", m.expandView.ScrollPercent()*100),
+		keys.Expand.Help().Key+"/esc close",
+	)
+	b.WriteString(statusBarStyle.Render(strings.Join(hints, "  "+glyphs.Separator+"  ")))
+	return b.String()
+}
+
+// viewPrompts returns the prompts the prompt view lists: all of them, or
+// only those sent to the main agent.
+func (m Model) viewPrompts() []store.Prompt {
+	if !m.mainOnly {
+		return m.prompts
+	}
+	var prompts []store.Prompt
+	for _, p := range m.prompts {
+		if p.Agent == "" {
+			prompts = append(prompts, p)
+		}
+	}
+	return prompts
+}
@@ -0,0 +1,151 @@
+package launcher
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// PromptsMarkdown formats a session's notes and prompts, given newest first
+// as GetPrompts returns them, as a Markdown document in the order they were
+// sent.
+func PromptsMarkdown(sess store.Session, prompts []store.Prompt) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Session %s\n\n", sess.ID)
+	if sess.Title != "" {
+		fmt.Fprintf(&b, "- Title: %s\n", sess.Title)
+	}
+	fmt.Fprintf(&b, "- Project: `%s`\n", sess.Project)
+	if sess.Branch != "" {
+		fmt.Fprintf(&b, "- Branch: `%s`\n", sess.Branch)
+	}
+	if sess.Model != "" {
+		fmt.Fprintf(&b, "- Model: %s\n", sess.Model)
+	}
+	fmt.Fprintf(&b, "- Started: %s\n", formatTimestamp(sess.StartedAt))
+	if sess.Notes != "" {
+		fmt.Fprintf(&b, "\n## Notes\n\n%s\n", sess.Notes)
+	}
+	b.WriteString("\n## Prompts\n")
+	if len(prompts) == 0 {
+		b.WriteString("\nNo prompts recorded.\n")
+	}
+	for i, p := range slices.Backward(prompts) {
+		fmt.Fprintf(&b, "\n### %d. %s\n\n%s\n", len(prompts)-i, formatTimestamp(p.Timestamp), p.Text)
+	}
+	return b.String()
+}
+
+// ContextMarkdown formats sessions, most recent first, with their latest
+// prompts from history (newest first by session ID, as PromptHistory
+// returns them) as a compact Markdown digest for a new claude session to
+// read, from CLAUDE.md or a slash command. scope names what was listed, a
+// project or "all projects"; a session of another project names its own.
+func ContextMarkdown(scope string, sessions []store.Session, history map[string][]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Earlier Claude sessions: %s\n\n", scope)
+	if len(sessions) == 0 {
+		b.WriteString("No earlier sessions recorded.\n")
+		return b.String()
+	}
+	b.WriteString("Most recent first, recorded by cst. Prompts are in the order they were sent; only the latest are kept.\n")
+	for _, sess := range sessions {
+		heading := sess.Title
+		if heading == "" {
+			heading = contextLine(cmp.Or(sess.FirstPrompt, sess.LastPrompt, "(no prompts)"), 80)
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", heading)
+
+		meta := []string{"`" + textutil.ShortID(sess.ID) + "`", formatTimestamp(sess.LastActivity)}
+		if sess.Project != scope {
+			meta = append(meta, "`"+sess.Project+"`")
+		}
+		if sess.Branch != "" {
+			meta = append(meta, "branch `"+sess.Branch+"`")
+		}
+		if sess.WorkedMS > 0 {
+			meta = append(meta, FormatDuration(sess.WorkedMS)+" worked")
+		}
+		if sess.Active {
+			meta = append(meta, "still running")
+		}
+		if sess.Outcome != "" {
+			meta = append(meta, "outcome: "+strings.TrimSpace(sess.Outcome+" "+contextLine(sess.OutcomeNote, 120)))
+		}
+		b.WriteString(strings.Join(meta, " · ") + "\n")
+		if len(sess.Links) > 0 {
+			fmt.Fprintf(&b, "\nLinks: %s\n", strings.Join(sess.Links, " "))
+		}
+		if sess.Notes != "" {
+			fmt.Fprintf(&b, "\nNotes: %s\n", contextLine(sess.Notes, 300))
+		}
+
+		prompts := history[sess.ID]
+		if len(prompts) == 0 && sess.LastPrompt != "" {
+			prompts = []string{sess.LastPrompt}
+		}
+		if len(prompts) > 0 {
+			b.WriteString("\n")
+		}
+		if earlier := sess.PromptCount - len(prompts); earlier > 0 && len(prompts) > 0 {
+			fmt.Fprintf(&b, "- (%d earlier prompts)\n", earlier)
+		}
+		for _, p := range slices.Backward(prompts) {
+			fmt.Fprintf(&b, "- %s\n", contextLine(p, 200))
+		}
+	}
+	return b.String()
+}
+
+// contextLine collapses s to a single line at most width cells wide.
+func contextLine(s string, width int) string {
+	return textutil.Truncate(strings.Join(strings.Fields(s), " "), width, "…")
+}
+
+// formatTimestamp formats a millisecond timestamp in local time.
+func formatTimestamp(tsMs int64) string {
+	return time.UnixMilli(tsMs).Format("2006-01-02 15:04")
+}
+
+// copiedPrompts reports copying a session's prompts to the clipboard.
+type copiedPrompts struct {
+	n   int
+	err error
+}
+
+// copyPrompts copies the prompt history of sess as Markdown to the
+// clipboard of the terminal.
+func copyPrompts(s *store.Store, sess store.Session) tea.Cmd {
+	return func() tea.Msg {
+		prompts, err := s.GetPrompts(sess.ID, store.DefaultMaxPrompt)
+		if err != nil {
+			return copiedPrompts{err: err}
+		}
+		if len(prompts) == 0 {
+			return copiedPrompts{}
+		}
+		return copiedPrompts{n: len(prompts), err: copyToClipboard(PromptsMarkdown(sess, prompts))}
+	}
+}
+
+// copyToClipboard sets the system clipboard with an OSC 52 escape sequence,
+// which works over SSH in terminals that support it. It is written to
+// stderr to stay out of the way of the TUI renderer; inside tmux it is
+// wrapped for passthrough.
+func copyToClipboard(text string) error {
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	}
+	_, err := seq.WriteTo(os.Stderr)
+	return err
+}
@@ -0,0 +1,113 @@
+package launcher
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Scores of fuzzyMatch, modelled on sahilm/fuzzy: matches at the start of
+// the text or a word and runs of adjacent matches rank first.
+const (
+	firstCharBonus       = 10
+	wordStartBonus       = 20
+	adjacentBonus        = 5
+	leadingCharPenalty   = -5
+	maxLeadingPenalty    = -15
+	unmatchedCharPenalty = -1
+)
+
+// fuzzyPattern prepares a search string for fuzzyMatch: lower-cased, with
+// whitespace dropped.
+func fuzzyPattern(search string) []rune {
+	var pattern []rune
+	for _, r := range search {
+		if !unicode.IsSpace(r) {
+			pattern = append(pattern, unicode.ToLower(r))
+		}
+	}
+	return pattern
+}
+
+// fuzzyMatch reports whether the runes of pattern appear in text in order,
+// ignoring case. It returns a score, higher for better matches, and the
+// rune indices of text that matched.
+func fuzzyMatch(pattern []rune, text string) (score int, matched []int, ok bool) {
+	if len(pattern) == 0 {
+		return 0, nil, true
+	}
+	runes := []rune(text)
+	p := 0
+	for i, r := range runes {
+		if p == len(pattern) {
+			break
+		}
+		if unicode.ToLower(r) != pattern[p] {
+			continue
+		}
+		switch {
+		case i == 0:
+			score += firstCharBonus
+		case isWordSeparator(runes[i-1]) || (unicode.IsUpper(r) && unicode.IsLower(runes[i-1])):
+			score += wordStartBonus
+		}
+		if len(matched) > 0 && matched[len(matched)-1] == i-1 {
+			score += adjacentBonus
+		}
+		if len(matched) == 0 {
+			score += max(leadingCharPenalty*i, maxLeadingPenalty)
+		}
+		matched = append(matched, i)
+		p++
+	}
+	if p < len(pattern) {
+		return 0, nil, false
+	}
+	score += unmatchedCharPenalty * (len(runes) - len(matched))
+	return score, matched, true
+}
+
+func isWordSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("/-_.:,", r)
+}
+
+// searchScore returns the best fuzzyMatch score of pattern over fields.
+func searchScore(pattern []rune, fields []string) (best int, ok bool) {
+	for _, field := range fields {
+		if score, _, matched := fuzzyMatch(pattern, field); matched && (!ok || score > best) {
+			best, ok = score, true
+		}
+	}
+	return best, ok
+}
+
+// highlightMatch renders text in style with the runes that fuzzy-match
+// pattern underlined and bold. Text that does not match is rendered plainly.
+func highlightMatch(pattern []rune, text string, style lipgloss.Style) string {
+	_, matched, ok := fuzzyMatch(pattern, text)
+	if !ok || len(matched) == 0 {
+		return style.Render(text)
+	}
+	hit := style.Underline(true).Bold(true)
+	runes := []rune(text)
+	var b strings.Builder
+	start := 0
+	for start < len(runes) {
+		isHit := len(matched) > 0 && matched[0] == start
+		end := start
+		for end < len(runes) && (len(matched) > 0 && matched[0] == end) == isHit {
+			if isHit {
+				matched = matched[1:]
+			}
+			end++
+		}
+		if isHit {
+			b.WriteString(hit.Render(string(runes[start:end])))
+		} else {
+			b.WriteString(style.Render(string(runes[start:end])))
+		}
+		start = end
+	}
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package launcher
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Glyphs are the non-ASCII characters the TUI and cst's text output draw
+// with, so that they can be swapped for ASCII on terminals, fonts and screen
+// readers that handle them badly.
+type Glyphs struct {
+	Active, Waiting, Idle string // session status markers
+	Source, Import        string // sessions from another database or a bundle
+	Separator             string // between key hints
+	Dot                   string // between items on a line
+	Ellipsis              string
+	Up, Down, Left, Right string
+	Cursor                string // text input cursor
+	Marker                string // the selected prompt in the prompt view
+	Times                 string // repeat count, as in "×3"
+	ChoiceOpen            string // around a value that ←/→ change
+	ChoiceClose           string
+	Heat                  []string // cst stats heatmap cells, least to most activity
+	Bar                   string   // tool call counts, repeated
+	Rough                 string   // sessions with many failed tool calls
+	Border                lipgloss.Border
+}
+
+var unicodeGlyphs = Glyphs{
+	Active: "●", Waiting: "⚑", Idle: "○",
+	Source: "◆", Import: "◇",
+	Separator: "│", Dot: "·", Ellipsis: "…",
+	Up: "↑", Down: "↓", Left: "←", Right: "→",
+	Cursor: "█", Marker: "▶", Times: "×",
+	ChoiceOpen: "‹", ChoiceClose: "›",
+	Bar: "▇", Rough: "⚠",
+	Heat:   []string{"·", "░", "▒", "▓", "█"},
+	Border: lipgloss.RoundedBorder(),
+}
+
+var asciiGlyphs = Glyphs{
+	Active: "*", Waiting: "!", Idle: "-",
+	Source: "+", Import: "=",
+	Separator: "|", Dot: "-", Ellipsis: "...",
+	Up: "^", Down: "v", Left: "<-", Right: "->",
+	Cursor: "_", Marker: ">", Times: "x",
+	ChoiceOpen: "<", ChoiceClose: ">",
+	Bar: "#", Rough: "!!",
+	Heat:   []string{".", ":", "+", "*", "#"},
+	Border: lipgloss.ASCIIBorder(),
+}
+
+var (
+	glyphs  = unicodeGlyphs
+	noColor bool
+)
+
+// CurrentGlyphs returns the glyphs in use: ASCII ones after SetASCII(true).
+func CurrentGlyphs() Glyphs {
+	return glyphs
+}
+
+// SetASCII switches all output to ASCII glyphs, per the ascii config.
+func SetASCII(on bool) {
+	glyphs = unicodeGlyphs
+	if on {
+		glyphs = asciiGlyphs
+	}
+	keys.Up.SetHelp(glyphs.Up+"/k", "up")
+	keys.Down.SetHelp(glyphs.Down+"/j", "down")
+	previewStyle = previewStyle.Border(glyphs.Border)
+}
+
+// SetNoColor turns off colors and text attributes everywhere, for --no-color
+// and NO_COLOR. The selected line is then marked with ">" instead.
+func SetNoColor(on bool) {
+	noColor = on
+	if on {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
+
+// selectLine highlights line as the one under the cursor. Without colors,
+// where a highlight would not show, its indent is replaced with a marker.
+func selectLine(line string) string {
+	if noColor && len(line) >= 2 && line[:2] == "  " {
+		line = "> " + line[2:]
+	}
+	return selectedStyle.Render(line)
+}
@@ -0,0 +1,208 @@
+package launcher
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyAction is a launcher key that the keybindings config can remap.
+type keyAction struct {
+	name    string
+	binding *key.Binding
+}
+
+// keyActions are the remappable keys, by the names used in the config.
+var keyActions = []keyAction{
+	{"up", &keys.Up},
+	{"down", &keys.Down},
+	{"resume", &keys.Enter},
+	{"continue", &keys.Continue},
+	{"toggle_scope", &keys.Tab},
+	{"delete", &keys.Delete},
+	{"quit", &keys.Quit},
+	{"search", &keys.Search},
+	{"setup", &keys.Setup},
+	{"docs", &keys.Docs},
+	{"page_up", &keys.PageUp},
+	{"page_down", &keys.PageDown},
+	{"view_prompts", &keys.Expand},
+	{"layout", &keys.Layout},
+	{"settings", &keys.Settings},
+	{"jump", &keys.Attach},
+	{"copy", &keys.Copy},
+	{"open_link", &keys.OpenLink},
+	{"stop", &keys.Stop},
+	{"projects", &keys.Projects},
+	{"compare", &keys.Compare},
+}
+
+// KeyActions names the launcher actions the keybindings config can remap.
+var KeyActions = func() []string {
+	names := make([]string, len(keyActions))
+	for i, a := range keyActions {
+		names[i] = a.name
+	}
+	return names
+}()
+
+// DefaultKeys holds the keys of each action before the keybindings config
+// remaps them.
+var DefaultKeys = func() map[string][]string {
+	defaults := make(map[string][]string, len(keyActions))
+	for _, a := range keyActions {
+		defaults[a.name] = a.binding.Keys()
+	}
+	return defaults
+}()
+
+// keyScreen is a screen whose actions must not share a key. fixed holds
+// keys the screen handles itself, by what they do.
+type keyScreen struct {
+	name    string
+	actions []string
+	fixed   map[string]string
+}
+
+var listActions = []string{"up", "down", "resume", "continue", "toggle_scope", "delete", "quit", "search",
+	"view_prompts", "layout", "settings", "jump", "copy", "page_up", "page_down", "projects", "compare"}
+
+var keyScreens = []keyScreen{
+	{name: "session list", actions: append(slices.Clip(listActions), "open_link")},
+	// With no sessions, setup and docs are checked before open_link.
+	{name: "empty session list", actions: append(slices.Clip(listActions), "setup", "docs")},
+	{name: "prompt view", actions: []string{"up", "down", "delete", "view_prompts", "quit"},
+		fixed: map[string]string{"ctrl+c": "quit", "m": "main agent only"}},
+	{name: "dashboard", actions: []string{"up", "down", "jump", "stop", "quit"},
+		fixed: map[string]string{"s": "sort (cst top)"}},
+	// Typed characters filter the projects, so only named keys move.
+	{name: "project switcher", actions: []string{"up", "down"},
+		fixed: map[string]string{"ctrl+c": "quit", "esc": "close", "enter": "choose",
+			"backspace": "delete a filter character", "ctrl+p": "up", "ctrl+n": "down"}},
+	{name: "compare screen", actions: []string{"up", "down", "resume", "quit"}, fixed: compareFixedKeys()},
+}
+
+// compareFixedKeys returns the keys the compare screen handles itself.
+func compareFixedKeys() map[string]string {
+	fixed := map[string]string{"ctrl+c": "quit", "esc": "quit"}
+	for _, k := range compareSideKeys {
+		fixed[k] = "choose side"
+	}
+	return fixed
+}
+
+// namedKeys are the key names, besides single characters, that bindings
+// may use, as bubbletea spells them.
+var namedKeys = []string{"up", "down", "left", "right", "home", "end", "pgup", "pgdown",
+	"tab", "shift+tab", "enter", "esc", "backspace", "delete", "insert"}
+
+// keyName returns the key as bubbletea reports it: "space" is " ".
+func keyName(k string) string {
+	if k == "space" {
+		return " "
+	}
+	return k
+}
+
+// ValidateKey checks that k names a key: a printable character, "space",
+// one of the named keys such as "pgdown" or "shift+tab", ctrl+ a letter,
+// alt+ a character or named key, or f1 to f20.
+func ValidateKey(k string) error {
+	k = keyName(k)
+	if r, size := utf8.DecodeRuneInString(k); size == len(k) && r != utf8.RuneError && unicode.IsPrint(r) {
+		return nil
+	}
+	if slices.Contains(namedKeys, k) {
+		return nil
+	}
+	if c, ok := strings.CutPrefix(k, "ctrl+"); ok && len(c) == 1 && c[0] >= 'a' && c[0] <= 'z' {
+		return nil
+	}
+	if c, ok := strings.CutPrefix(k, "alt+"); ok && c != "" && !strings.HasPrefix(c, "alt+") && ValidateKey(c) == nil {
+		return nil
+	}
+	if c, ok := strings.CutPrefix(k, "f"); ok {
+		if n, err := strconv.Atoi(c); err == nil && strconv.Itoa(n) == c && n >= 1 && n <= 20 {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown key %q, expected a character, space, ctrl+<letter>, alt+<key>, f1-f20 or one of %s",
+		k, strings.Join(namedKeys, ", "))
+}
+
+// ValidateKeybindings checks the keybindings config: known actions, at
+// least one valid key each, and no key doing two things on one screen once
+// they replace the defaults.
+func ValidateKeybindings(bindings map[string][]string) error {
+	bound := maps.Clone(DefaultKeys)
+	for _, action := range slices.Sorted(maps.Keys(bindings)) {
+		if _, ok := bound[action]; !ok {
+			return fmt.Errorf("unknown action %q, expected one of %s", action, strings.Join(KeyActions, ", "))
+		}
+		if len(bindings[action]) == 0 {
+			return fmt.Errorf("%s needs at least one key", action)
+		}
+		bound[action] = nil
+		for _, k := range bindings[action] {
+			if err := ValidateKey(k); err != nil {
+				return fmt.Errorf("%s: %w", action, err)
+			}
+			bound[action] = append(bound[action], keyName(k))
+		}
+	}
+	for _, screen := range keyScreens {
+		users := maps.Clone(screen.fixed)
+		if users == nil {
+			users = make(map[string]string)
+		}
+		for _, action := range screen.actions {
+			for _, k := range bound[action] {
+				if other, ok := users[k]; ok && other != action {
+					return fmt.Errorf("%s is bound to both %s and %s on the %s", keyLabel(k), other, action, screen.name)
+				}
+				users[k] = action
+			}
+		}
+	}
+	return nil
+}
+
+// keyLabel returns how k is shown in hints.
+func keyLabel(k string) string {
+	if k == " " {
+		return "space"
+	}
+	return k
+}
+
+// SetKeys remaps the launcher keys per the keybindings config, replacing
+// the default keys of each action it names. Invalid or conflicting
+// bindings are not applied at all, and the error says why.
+func SetKeys(bindings map[string][]string) error {
+	if len(bindings) == 0 {
+		return nil
+	}
+	if err := ValidateKeybindings(bindings); err != nil {
+		return err
+	}
+	for _, a := range keyActions {
+		ks, ok := bindings[a.name]
+		if !ok {
+			continue
+		}
+		names, labels := make([]string, len(ks)), make([]string, len(ks))
+		for i, k := range ks {
+			names[i] = keyName(k)
+			labels[i] = keyLabel(names[i])
+		}
+		a.binding.SetKeys(names...)
+		a.binding.SetHelp(strings.Join(labels, "/"), a.binding.Help().Desc)
+	}
+	return nil
+}
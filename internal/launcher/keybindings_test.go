@@ -0,0 +1,80 @@
+package launcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key string
+		ok  bool
+	}{
+		{"x", true},
+		{"P", true},
+		{"/", true},
+		{"space", true},
+		{"pgdown", true},
+		{"shift+tab", true},
+		{"ctrl+a", true},
+		{"alt+x", true},
+		{"alt+enter", true},
+		{"f1", true},
+		{"f20", true},
+		{"", false},
+		{"foo", false},
+		{"ctrl+1", false},
+		{"ctrl+ab", false},
+		{"alt+", false},
+		{"alt+alt+x", false},
+		{"f0", false},
+		{"f21", false},
+		{"f+1", false},
+		{"f01", false},
+	}
+	for _, tc := range tests {
+		if err := ValidateKey(tc.key); (err == nil) != tc.ok {
+			t.Errorf("ValidateKey(%q) = %v, want ok %v", tc.key, err, tc.ok)
+		}
+	}
+}
+
+func TestValidateKeybindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		bindings map[string][]string
+		wantErr  string // "" for valid bindings
+	}{
+		{"defaults", nil, ""},
+		{"remap", map[string][]string{"copy": {"Y", "ctrl+y"}}, ""},
+		// Remapped keys replace the defaults, freeing k for search
+		{"freed key", map[string][]string{"up": {"up", "space"}, "search": {"k"}}, ""},
+		{"fixed key doing the same", map[string][]string{"up": {"up", "ctrl+p"}}, ""},
+		// docs and open_link share o, but never on one screen
+		{"separate screens", map[string][]string{"docs": {"o"}}, ""},
+		{"unknown action", map[string][]string{"bogus": {"x"}}, `unknown action "bogus"`},
+		{"no keys", map[string][]string{"copy": {}}, "copy needs at least one key"},
+		{"unknown key", map[string][]string{"copy": {"ctrl+1"}}, `copy: unknown key "ctrl+1"`},
+		{"list conflict", map[string][]string{"search": {"j"}},
+			"j is bound to both down and search on the session list"},
+		{"space conflict", map[string][]string{"copy": {"space"}, "jump": {" "}},
+			"space is bound to both jump and copy on the session list"},
+		{"prompt view fixed key", map[string][]string{"delete": {"m"}},
+			"m is bound to both main agent only and delete on the prompt view"},
+		{"dashboard fixed key", map[string][]string{"stop": {"s"}},
+			"s is bound to both sort (cst top) and stop on the dashboard"},
+		{"project switcher fixed key", map[string][]string{"up": {"ctrl+n"}},
+			"ctrl+n is bound to both down and up on the project switcher"},
+		{"compare screen fixed key", map[string][]string{"down": {"down", "l"}},
+			"l is bound to both choose side and down on the compare screen"},
+	}
+	for _, tc := range tests {
+		err := ValidateKeybindings(tc.bindings)
+		switch {
+		case tc.wantErr == "" && err != nil:
+			t.Errorf("%s: ValidateKeybindings(%v) = %v, want nil", tc.name, tc.bindings, err)
+		case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+			t.Errorf("%s: ValidateKeybindings(%v) = %v, want %q", tc.name, tc.bindings, err, tc.wantErr)
+		}
+	}
+}
@@ -1,51 +1,125 @@
 package launcher
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/imyousuf/claude-session-tracker/internal/attach"
+	"github.com/imyousuf/claude-session-tracker/internal/config"
 	"github.com/imyousuf/claude-session-tracker/internal/procutil"
 	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+	"github.com/imyousuf/claude-session-tracker/internal/transcript"
 )
 
 // Result holds the outcome of the TUI session picker.
 type Result struct {
 	SessionID string
 	Project   string
+	Dir       string   // where claude starts: the project, or see config.ResumeDir
+	Args      []string // claude arguments after --resume <id>, as confirmed
 }
 
 type keyMap struct {
-	Up     key.Binding
-	Down   key.Binding
-	Enter  key.Binding
-	Tab    key.Binding
-	Delete key.Binding
-	Quit   key.Binding
-	Search key.Binding
+	Up       key.Binding
+	Down     key.Binding
+	Enter    key.Binding
+	Tab      key.Binding
+	Continue key.Binding
+	Delete   key.Binding
+	Quit     key.Binding
+	Search   key.Binding
+	Setup    key.Binding
+	Docs     key.Binding
+
+	PageUp   key.Binding
+	PageDown key.Binding
+	Expand   key.Binding
+	Layout   key.Binding
+	Settings key.Binding
+	Attach   key.Binding
+	Copy     key.Binding
+	OpenLink key.Binding
+	Stop     key.Binding // dashboard only
+	Projects key.Binding
+	Compare  key.Binding
 }
 
 var keys = keyMap{
-	Up:     key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
-	Down:   key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
-	Enter:  key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "resume")),
-	Tab:    key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "toggle all/project")),
-	Delete: key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "delete")),
-	Quit:   key.NewBinding(key.WithKeys("q", "esc", "ctrl+c"), key.WithHelp("q/esc", "quit")),
-	Search: key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search")),
+	Up:       key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down:     key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	Enter:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "resume")),
+	Continue: key.NewBinding(key.WithKeys("C"), key.WithHelp("C", "continue latest")),
+	Tab:      key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "toggle all/project")),
+	Delete:   key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "delete")),
+	Quit:     key.NewBinding(key.WithKeys("q", "esc", "ctrl+c"), key.WithHelp("q/esc", "quit")),
+	Search:   key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search")),
+	Setup:    key.NewBinding(key.WithKeys("h"), key.WithHelp("h", "hook setup")),
+	Docs:     key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "open docs")),
+
+	PageUp:   key.NewBinding(key.WithKeys("pgup", "ctrl+u"), key.WithHelp("pgup", "scroll preview up")),
+	PageDown: key.NewBinding(key.WithKeys("pgdown", "ctrl+d"), key.WithHelp("pgdn", "scroll preview down")),
+	Expand:   key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "view prompts")),
+	Layout:   key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "preview layout")),
+	Settings: key.NewBinding(key.WithKeys(","), key.WithHelp(",", "settings")),
+	Attach:   key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "jump to active session")),
+	Copy:     key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "copy prompts")),
+	OpenLink: key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "open link")),
+	Stop:     key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "stop session")),
+	Projects: key.NewBinding(key.WithKeys("P"), key.WithHelp("P", "switch project")),
+	Compare:  key.NewBinding(key.WithKeys("="), key.WithHelp("=", "compare")),
 }
 
+// PreviewPosition places the preview pane relative to the session list.
+type PreviewPosition string
+
+const (
+	PreviewRight  PreviewPosition = "right"
+	PreviewBottom PreviewPosition = "bottom"
+	PreviewHidden PreviewPosition = "hidden"
+)
+
+// previewCycle is the order the layout key steps through.
+var previewCycle = []PreviewPosition{PreviewRight, PreviewBottom, PreviewHidden}
+
+// A preview beside the list moves below it in terminals narrower than
+// narrowWidth, and a preview below it is hidden in terminals shorter than
+// shortHeight, where it would leave the list a few rows.
+const (
+	narrowWidth = 100
+	shortHeight = 20
+)
+
+// scope is the set of sessions the launcher lists. The scope key steps
+// through them in order, skipping scopeWorkspace without a workspace.
+type scope int
+
+const (
+	scopeProject scope = iota
+	scopeWorkspace
+	scopeAll
+)
+
 // Model is the Bubbletea model for the session picker TUI.
 type Model struct {
 	store      *store.Store
 	sessions   []store.Session
 	prompts    []store.Prompt
+	cwds       []store.CWDEntry
+	files      []transcript.TouchedFile // changed by the selected session
+	tools      []store.ToolCount        // called by the selected session
 	cursor     int
 	project    string
-	showAll    bool
+	scope      scope
 	width      int
 	height     int
 	err        error
@@ -53,54 +127,288 @@ type Model struct {
 	statusMsg  string
 	searching  bool
 	searchText string
-	filtered   []int // indices into sessions
-	confirming bool  // delete confirmation
+	filtered   []int               // indices into sessions, best search match first
+	history    map[string][]string // recent prompts by session ID
+	confirming bool                // delete confirmation
+	total      int                 // sessions tracked across all projects
+	showSetup  bool                // empty state: show hook setup steps
+	preview    viewport.Model
+	expanded   bool // full-screen prompt view
+	expandView viewport.Model
+	// Selected prompt in the full-screen view:
+	promptCursor int
+	mainOnly     bool // hide the prompts sent to subagents
+	previewPos   PreviewPosition
+	previewPct   int // side preview width as a percentage; 0 for the default
+	// Settings screen, editing the config file at cfgPath:
+	settingsOpen    bool
+	settingsCursor  int
+	cfg             config.Config
+	cfgPath         string
+	refreshGen      int             // identifies the current auto-refresh timer
+	changes         <-chan struct{} // writes to the store by other processes
+	secondaries     []store.Secondary
+	multiHost       bool     // sessions span machines: show a host column
+	includeHeadless bool     // list subagent and claude -p sessions too
+	columns         []Column // list columns; nil for the default
+	columnsFixed    bool     // columns came from --columns and ignore config
+	// Paging: the launcher loads the newest limit sessions and more as the
+	// cursor nears the end. Until a load without search has fit in the
+	// limit (complete), search goes to the store as well.
+	limit    int
+	more     bool   // the last load filled the limit
+	loading  bool   // a further page is on its way
+	complete bool   // all sessions in scope are loaded
+	searched string // search the sessions were loaded with
+	// Resuming: the plan awaiting confirmation, the claude_bin override and
+	// the arguments given after -- on the command line.
+	resume      *resumePlan
+	claudeBin   []string
+	passthrough []string
+	// Project switcher, while open:
+	picker *projectPicker
+	// Comparing: the session marked to compare with the next one chosen,
+	// and the compare screen, while open:
+	compareFrom *store.Session
+	compare     *compareView
+	// Workspace the project is in, or given with --workspace, if any:
+	workspace         string
+	workspaceProjects []string
 }
 
 // New creates a new launcher Model.
 func New(s *store.Store, project string, showAll bool) Model {
-	return Model{
-		store:   s,
-		project: project,
-		showAll: showAll,
+	m := Model{
+		store:      s,
+		project:    project,
+		previewPos: PreviewRight,
+	}
+	if showAll {
+		m.scope = scopeAll
+	}
+	return m
+}
+
+// WithWorkspace adds the workspace name, made of projects, to the scopes the
+// launcher steps through, and lists it first if selected.
+func (m Model) WithWorkspace(name string, projects []string, selected bool) Model {
+	m.workspace = name
+	m.workspaceProjects = projects
+	if selected {
+		m.scope = scopeWorkspace
+	}
+	return m
+}
+
+// WithPreview sets where the preview pane is shown and, when it is beside
+// the list, its width as a percentage of the terminal (0 for the default).
+// Unknown positions fall back to PreviewRight.
+func (m Model) WithPreview(pos PreviewPosition, widthPct int) Model {
+	if pos != PreviewBottom && pos != PreviewHidden {
+		pos = PreviewRight
 	}
+	m.previewPos = pos
+	m.previewPct = widthPct
+	return m
+}
+
+// WithConfig applies the launcher preferences in cfg and enables the
+// settings screen, which saves its changes to the config file at path.
+func (m Model) WithConfig(path string, cfg config.Config) Model {
+	m.cfgPath = path
+	m, _ = m.applyConfig(cfg)
+	return m
+}
+
+// WithColumns sets the session list columns, overriding the columns config.
+func (m Model) WithColumns(cols []Column) Model {
+	m.columns = cols
+	m.columnsFixed = true
+	return m
+}
+
+// WithHeadless lists subagent and non-interactive (claude -p) sessions,
+// which are hidden by default.
+func (m Model) WithHeadless(include bool) Model {
+	m.includeHeadless = include
+	return m
+}
+
+// WithSecondaries merges the sessions of read-only secondary stores into
+// the list, labelled with their store name.
+func (m Model) WithSecondaries(secondaries []store.Secondary) Model {
+	m.secondaries = secondaries
+	return m
+}
+
+// WithChanges reloads the list whenever changes reports a write to the
+// store, such as a hook recording a prompt, see Store.WatchChanges.
+func (m Model) WithChanges(changes <-chan struct{}) Model {
+	m.changes = changes
+	return m
+}
+
+// storeFor returns the store a session was listed from.
+func (m Model) storeFor(sess store.Session) *store.Store {
+	for _, sec := range m.secondaries {
+		if sec.Name == sess.Source {
+			return sec.Store
+		}
+	}
+	return m.store
 }
 
 type sessionsLoaded struct {
 	sessions []store.Session
+	history  map[string][]string // recent prompts by session, for search
+	total    int
+	search   string // search the store matched them with, if any
+	more     bool   // the page was full: older sessions were left out
 	err      error
 }
 
 type promptsLoaded struct {
 	prompts []store.Prompt
+	cwds    []store.CWDEntry
+	files   []transcript.TouchedFile
+	tools   []store.ToolCount
 }
 
-func loadSessions(s *store.Store, project string, showAll bool) tea.Cmd {
+// attached reports the result of focusing an active session's terminal.
+type attached struct {
+	desc string
+	err  error
+}
+
+func focusSession(sess store.Session) tea.Cmd {
+	return func() tea.Msg {
+		pid := 0
+		if sess.PID != nil {
+			pid = *sess.PID
+		}
+		desc, err := attach.Focus(pid, sess.TTY)
+		return attached{desc: desc, err: err}
+	}
+}
+
+// storeChanged reports a write to the store by another process.
+type storeChanged struct{}
+
+// waitForChange waits for the next write to the store, if its changes are
+// watched. It returns no message once they no longer are.
+func (m Model) waitForChange() tea.Cmd {
+	changes := m.changes
+	if changes == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		if _, ok := <-changes; !ok {
+			return nil
+		}
+		return storeChanged{}
+	}
+}
+
+// sessionPage is how many sessions the launcher loads at a time.
+const sessionPage = 200
+
+// loadSessions lists the newest sessions in the launcher's scope, up to
+// the limit, matching the store search if there is one.
+func (m Model) loadSessions() tea.Cmd {
+	s, secondaries := m.store, m.secondaries
+	limit, search := max(m.limit, sessionPage), m.storeSearch()
+	opts := store.ListOptions{ExcludeHeadless: !m.includeHeadless, Limit: limit}
+	opts.Search, opts.Model = splitSearch(search)
+	switch m.scope {
+	case scopeProject:
+		opts.Project = m.project
+	case scopeWorkspace:
+		opts.Projects = m.workspaceProjects
+	}
 	return func() tea.Msg {
 		// Refresh active sessions first
 		_ = s.RefreshActive(procutil.IsProcessAlive)
 
-		var sessions []store.Session
-		var err error
-		if showAll || project == "" {
-			sessions, err = s.ListAll()
-		} else {
-			sessions, err = s.ListByProject(project)
+		sessions, err := store.ListMerged(s, secondaries, opts)
+		if err != nil {
+			return sessionsLoaded{search: search, err: err}
+		}
+		total, err := s.CountSessions()
+		return sessionsLoaded{
+			sessions: sessions, history: loadHistory(s, secondaries, sessions), total: total,
+			search: search, more: len(sessions) >= limit, err: err,
 		}
-		return sessionsLoaded{sessions: sessions, err: err}
 	}
 }
 
-func loadPrompts(s *store.Store, sessionID string) tea.Cmd {
+// storeSearch returns the search for the store to match: none once all
+// sessions are loaded, as fuzzy search then sees them all.
+func (m Model) storeSearch() string {
+	if m.complete {
+		return ""
+	}
+	return strings.Join(strings.Fields(m.searchText), " ")
+}
+
+// searchChanged filters the loaded sessions by the new search text and,
+// while not all are loaded, has the store search the rest.
+func (m *Model) searchChanged() tea.Cmd {
+	m.buildFilter()
+	if m.storeSearch() == m.searched {
+		return nil
+	}
+	m.limit = sessionPage
+	return m.loadSessions()
+}
+
+// loadAhead loads the next page of sessions when the cursor is within a
+// screen of the end of those loaded.
+func (m *Model) loadAhead() tea.Cmd {
+	if !m.more || m.loading || m.cursor < len(m.filtered)-m.listHeight() {
+		return nil
+	}
+	m.loading = true
+	m.limit = max(m.limit, sessionPage) + sessionPage
+	return m.loadSessions()
+}
+
+// searchHistory is how many recent prompts of each session search covers.
+const searchHistory = 20
+
+// loadHistory returns the recent prompts of sessions from the store each
+// came from. A store that fails is left out: search then only sees the
+// sessions' last prompts.
+func loadHistory(s *store.Store, secondaries []store.Secondary, sessions []store.Session) map[string][]string {
+	ids := make(map[string][]string) // by source
+	for _, sess := range sessions {
+		ids[sess.Source] = append(ids[sess.Source], sess.ID)
+	}
+	history, _ := s.PromptHistory(ids[""], searchHistory)
+	if history == nil {
+		history = make(map[string][]string)
+	}
+	for _, sec := range secondaries {
+		more, _ := sec.Store.PromptHistory(ids[sec.Name], searchHistory)
+		maps.Copy(history, more)
+	}
+	return history
+}
+
+// loadPrompts loads what the preview shows of sess beyond its record: its
+// prompts, its directory trail, the files its transcript says it changed
+// and the tools it called.
+func loadPrompts(s *store.Store, sess store.Session) tea.Cmd {
 	return func() tea.Msg {
-		prompts, _ := s.GetPrompts(sessionID, 10)
-		return promptsLoaded{prompts: prompts}
+		prompts, _ := s.GetPrompts(sess.ID, 10)
+		cwds, _ := s.GetCWDHistory(sess.ID)
+		tools, _ := s.SessionTools(sess.ID)
+		return promptsLoaded{prompts: prompts, cwds: cwds, files: filesTouched(sess), tools: tools}
 	}
 }
 
 // Init implements tea.Model.
 func (m Model) Init() tea.Cmd {
-	return loadSessions(m.store, m.project, m.showAll)
+	return tea.Batch(m.loadSessions(), m.scheduleRefresh(), m.waitForChange())
 }
 
 // Update implements tea.Model.
@@ -109,19 +417,88 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		if m.expanded {
+			m.syncExpandView()
+		}
 		return m, nil
 
 	case sessionsLoaded:
+		if msg.search != m.storeSearch() {
+			return m, nil // overtaken by a newer search
+		}
+		m.loading = false
+		m.searched = msg.search
+		m.more = msg.more
+		if msg.search == "" && msg.err == nil {
+			m.complete = !msg.more
+		}
+		selected := m.selectedID()
 		m.sessions = msg.sessions
+		m.history = msg.history
+		m.total = msg.total
 		m.err = msg.err
+		sortSessions(m.sessions, m.cfg.Sort)
+		m.multiHost = hostCount(m.sessions) > 1
 		m.buildFilter()
+		m.selectID(selected)
 		if len(m.filtered) > 0 {
-			return m, loadPrompts(m.store, m.sessions[m.filtered[0]].ID)
+			sess := m.sessions[m.filtered[m.cursor]]
+			if sess.ID != selected {
+				m.preview.GotoTop()
+			}
+			return m, loadPrompts(m.storeFor(sess), sess)
 		}
 		return m, nil
 
+	case refreshTick:
+		if msg.gen != m.refreshGen {
+			return m, nil
+		}
+		if m.confirming {
+			// Don't move the target of a pending delete.
+			return m, m.scheduleRefresh()
+		}
+		return m, tea.Batch(m.loadSessions(), m.scheduleRefresh())
+
+	case storeChanged:
+		if m.confirming {
+			return m, m.waitForChange()
+		}
+		return m, tea.Batch(m.loadSessions(), m.waitForChange())
+
+	case attached:
+		if msg.err != nil {
+			m.statusMsg = "Cannot jump to session: " + msg.err.Error()
+		} else {
+			m.statusMsg = "Focused " + msg.desc
+		}
+		return m, nil
+
+	case copiedPrompts:
+		switch {
+		case msg.err != nil:
+			m.statusMsg = "Cannot copy prompts: " + msg.err.Error()
+		case msg.n == 0:
+			m.statusMsg = "No prompts to copy"
+		default:
+			m.statusMsg = fmt.Sprintf("Copied %d prompts to the clipboard", msg.n)
+		}
+		return m, nil
+
+	case projectsLoaded:
+		return m.projectsLoadedMsg(msg), nil
+
+	case comparisonLoaded:
+		return m.comparisonLoadedMsg(msg), nil
+
 	case promptsLoaded:
 		m.prompts = msg.prompts
+		m.cwds = msg.cwds
+		m.files = msg.files
+		m.tools = msg.tools
+		if m.expanded {
+			m.syncExpandView()
+		}
 		return m, nil
 
 	case tea.KeyMsg:
@@ -131,27 +508,42 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.expanded {
+		return m.handleExpandedKey(msg)
+	}
+	if m.settingsOpen {
+		return m.handleSettingsKey(msg)
+	}
+	if m.resume != nil {
+		return m.handleResumeKey(msg)
+	}
+	if m.picker != nil {
+		return m.handleProjectsKey(msg)
+	}
+	if m.compare != nil {
+		return m.handleCompareKey(msg)
+	}
+
 	// Handle search mode input
 	if m.searching {
 		switch {
 		case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
 			m.searching = false
 			m.searchText = ""
-			m.buildFilter()
-			return m, nil
+			return m, m.searchChanged()
 		case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
 			m.searching = false
 			return m, nil
 		case key.Matches(msg, key.NewBinding(key.WithKeys("backspace"))):
 			if len(m.searchText) > 0 {
 				m.searchText = m.searchText[:len(m.searchText)-1]
-				m.buildFilter()
+				return m, m.searchChanged()
 			}
 			return m, nil
 		default:
 			if len(msg.String()) == 1 {
 				m.searchText += msg.String()
-				m.buildFilter()
+				return m, m.searchChanged()
 			}
 			return m, nil
 		}
@@ -168,9 +560,9 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				if err := m.store.DeleteSession(sess.ID); err != nil {
 					m.statusMsg = "Error deleting: " + err.Error()
 				} else {
-					m.statusMsg = "Deleted session " + sess.ID[:8]
+					m.statusMsg = "Deleted session " + textutil.ShortID(sess.ID)
 				}
-				return m, loadSessions(m.store, m.project, m.showAll)
+				return m, m.loadSessions()
 			}
 			return m, nil
 		default:
@@ -189,32 +581,62 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, keys.Up):
 		if m.cursor > 0 {
 			m.cursor--
-			return m, loadPrompts(m.store, m.sessions[m.filtered[m.cursor]].ID)
+			m.preview.GotoTop()
+			sess := m.sessions[m.filtered[m.cursor]]
+			return m, loadPrompts(m.storeFor(sess), sess)
 		}
 
 	case key.Matches(msg, keys.Down):
 		if m.cursor < len(m.filtered)-1 {
 			m.cursor++
-			return m, loadPrompts(m.store, m.sessions[m.filtered[m.cursor]].ID)
+			m.preview.GotoTop()
+			sess := m.sessions[m.filtered[m.cursor]]
+			return m, tea.Batch(loadPrompts(m.storeFor(sess), sess), m.loadAhead())
 		}
 
 	case key.Matches(msg, keys.Enter):
 		if len(m.filtered) == 0 {
 			return m, nil
 		}
-		idx := m.filtered[m.cursor]
-		sess := m.sessions[idx]
-		if sess.Active {
-			m.statusMsg = "Cannot resume an active session"
+		sess := m.sessions[m.filtered[m.cursor]]
+		if blocked := resumeBlocked(sess); blocked != "" {
+			m.statusMsg = blocked
 			return m, nil
 		}
-		m.result = &Result{SessionID: sess.ID, Project: sess.Project}
-		return m, tea.Quit
+		return m.startResume(sess), nil
+
+	case key.Matches(msg, keys.Continue):
+		sess, ok := m.latestResumable()
+		if !ok {
+			m.statusMsg = "No session of this project to continue"
+			return m, nil
+		}
+		return m.startResume(sess), nil
+
+	case key.Matches(msg, keys.Attach):
+		if len(m.filtered) == 0 {
+			return m, nil
+		}
+		sess := m.sessions[m.filtered[m.cursor]]
+		switch {
+		case sess.Source != "":
+			m.statusMsg = "Session from store " + sess.Source + " runs on another machine"
+			return m, nil
+		case !sess.Active:
+			m.statusMsg = "Only active sessions can be jumped to; press " + keys.Enter.Help().Key + " to resume"
+			return m, nil
+		case !sess.IsLocal():
+			m.statusMsg = "Session runs on " + sess.Host
+			return m, nil
+		}
+		m.statusMsg = "Looking for session " + textutil.ShortID(sess.ID) + "..."
+		return m, focusSession(sess)
 
 	case key.Matches(msg, keys.Tab):
-		m.showAll = !m.showAll
+		m.scope = m.nextScope()
 		m.cursor = 0
-		return m, loadSessions(m.store, m.project, m.showAll)
+		m.limit, m.complete = sessionPage, false
+		return m, m.loadSessions()
 
 	case key.Matches(msg, keys.Delete):
 		if len(m.filtered) > 0 {
@@ -224,35 +646,190 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.statusMsg = "Cannot delete an active session"
 				return m, nil
 			}
+			if sess.Source != "" {
+				m.statusMsg = "Cannot delete a session from store " + sess.Source
+				return m, nil
+			}
+			if m.store.ReadOnly() {
+				m.statusMsg = "Cannot delete: the database is open read-only"
+				return m, nil
+			}
 			m.confirming = true
-			m.statusMsg = fmt.Sprintf("Delete session %s? (y/N)", sess.ID[:8])
+			m.statusMsg = fmt.Sprintf("Delete session %s? (y/N)", textutil.ShortID(sess.ID))
 		}
 
 	case key.Matches(msg, keys.Search):
 		m.searching = true
 		m.searchText = ""
+
+	case key.Matches(msg, keys.Layout):
+		i := slices.Index(previewCycle, m.previewPos)
+		m.previewPos = previewCycle[(i+1)%len(previewCycle)]
+		m.statusMsg = "Preview: " + string(m.previewPos)
+		switch layout := m.layout(); {
+		case layout == m.previewPos:
+		case layout == PreviewBottom:
+			m.statusMsg += fmt.Sprintf(" (below the list under %d columns)", narrowWidth)
+		default:
+			m.statusMsg += fmt.Sprintf(" (hidden under %d rows)", shortHeight)
+		}
+
+	case key.Matches(msg, keys.PageUp), key.Matches(msg, keys.PageDown):
+		if len(m.filtered) > 0 && m.layout() != PreviewHidden {
+			m.syncPreview()
+			if key.Matches(msg, keys.PageUp) {
+				m.preview.PageUp()
+			} else {
+				m.preview.PageDown()
+			}
+		}
+
+	case key.Matches(msg, keys.Expand):
+		if len(m.filtered) > 0 {
+			m.expanded = true
+			m.promptCursor = 0
+			m.syncExpandView()
+			m.expandView.GotoTop()
+		}
+
+	case key.Matches(msg, keys.Copy):
+		if len(m.filtered) > 0 {
+			sess := m.sessions[m.filtered[m.cursor]]
+			return m, copyPrompts(m.storeFor(sess), sess)
+		}
+
+	case key.Matches(msg, keys.Settings):
+		if m.cfgPath == "" {
+			m.statusMsg = "Settings are unavailable"
+			return m, nil
+		}
+		m.settingsOpen = true
+
+	case key.Matches(msg, keys.Projects):
+		return m.openProjects()
+
+	case key.Matches(msg, keys.Compare):
+		return m.markOrCompare()
+
+	case key.Matches(msg, keys.Setup) && m.total == 0:
+		m.showSetup = !m.showSetup
+
+	case key.Matches(msg, keys.Docs) && m.total == 0:
+		if err := openURL(docsURL); err != nil {
+			m.statusMsg = "Could not open browser: " + err.Error() + " (" + docsURL + ")"
+		} else {
+			m.statusMsg = "Opened " + docsURL
+		}
+
+	case key.Matches(msg, keys.OpenLink):
+		if len(m.filtered) > 0 {
+			m.statusMsg = openLink(m.sessions[m.filtered[m.cursor]])
+		}
 	}
 
 	return m, nil
 }
 
+// scopeHint describes what the scope key switches to.
+func (m Model) scopeHint() string {
+	switch m.nextScope() {
+	case scopeWorkspace:
+		return "workspace"
+	case scopeAll:
+		return "all projects"
+	}
+	return "this project"
+}
+
+// nextScope returns the scope after the current one: project, then the
+// workspace if there is one, then all projects.
+func (m Model) nextScope() scope {
+	next := (m.scope + 1) % (scopeAll + 1)
+	if next == scopeWorkspace && len(m.workspaceProjects) == 0 {
+		next = scopeAll
+	}
+	return next
+}
+
+// openLink opens the most recently added link of sess in the browser and
+// returns the status to show.
+func openLink(sess store.Session) string {
+	if len(sess.Links) == 0 {
+		return "No links; add one with cst link " + textutil.ShortID(sess.ID) + " <url>"
+	}
+	url := sess.Links[len(sess.Links)-1]
+	if err := openURL(url); err != nil {
+		return "Could not open browser: " + err.Error() + " (" + url + ")"
+	}
+	if n := len(sess.Links); n > 1 {
+		return fmt.Sprintf("Opened %s (latest of %d links)", url, n)
+	}
+	return "Opened " + url
+}
+
+// buildFilter lists the sessions that fuzzy-match the search text in their
+// prompt history, project, branch, tags, links or model, best match first.
+// Without a search, all sessions are listed in order. A "model:<name>" word
+// keeps only the sessions whose model contains name.
 func (m *Model) buildFilter() {
 	m.filtered = nil
-	search := strings.ToLower(m.searchText)
+	text, model := splitSearch(m.searchText)
+	pattern := fuzzyPattern(text)
+	scores := make(map[int]int)
 	for i, sess := range m.sessions {
-		if search != "" {
-			text := strings.ToLower(sess.LastPrompt + " " + sess.Project + " " + sess.Model)
-			if !strings.Contains(text, search) {
+		if model != "" && !strings.Contains(strings.ToLower(sess.Model), model) {
+			continue
+		}
+		if len(pattern) > 0 {
+			fields := slices.Concat([]string{sess.Title, sess.LastPrompt, sess.FirstPrompt, sess.Project, sess.Branch, sess.Model}, sess.Tags, sess.Links)
+			score, ok := searchScore(pattern, append(fields, m.history[sess.ID]...))
+			if !ok {
 				continue
 			}
+			scores[i] = score
 		}
 		m.filtered = append(m.filtered, i)
 	}
+	slices.SortStableFunc(m.filtered, func(a, b int) int { return cmp.Compare(scores[b], scores[a]) })
 	if m.cursor >= len(m.filtered) {
 		m.cursor = max(0, len(m.filtered)-1)
 	}
 }
 
+// splitSearch takes the "model:<name>" word out of the search text and
+// returns the rest and the lower-cased name.
+func splitSearch(search string) (text, model string) {
+	words := strings.Fields(search)
+	rest := words[:0]
+	for _, w := range words {
+		if name, ok := strings.CutPrefix(w, "model:"); ok {
+			model = strings.ToLower(name)
+			continue
+		}
+		rest = append(rest, w)
+	}
+	return strings.Join(rest, " "), model
+}
+
+// selectedID returns the ID of the session under the cursor, or "".
+func (m Model) selectedID() string {
+	if len(m.filtered) == 0 {
+		return ""
+	}
+	return m.sessions[m.filtered[m.cursor]].ID
+}
+
+// selectID moves the cursor to the session with the given ID, if listed,
+// so that reloading or re-sorting keeps the selection.
+func (m *Model) selectID(id string) {
+	for i, idx := range m.filtered {
+		if m.sessions[idx].ID == id {
+			m.cursor = i
+			return
+		}
+	}
+}
+
 // View implements tea.Model.
 func (m Model) View() string {
 	if m.err != nil {
@@ -263,50 +840,76 @@ func (m Model) View() string {
 		return "Loading..."
 	}
 
+	if m.expanded {
+		return m.renderExpanded()
+	}
+	if m.settingsOpen {
+		return m.renderSettings()
+	}
+	if m.resume != nil {
+		return m.renderResume()
+	}
+	if m.picker != nil {
+		return m.renderProjects()
+	}
+	if m.compare != nil {
+		return m.renderCompare()
+	}
+
 	var b strings.Builder
 
 	// Header
 	title := "Claude Code Sessions"
-	if !m.showAll && m.project != "" {
+	switch {
+	case m.scope == scopeProject && m.project != "":
 		title += "  " + hintStyle.Render(m.project)
-	} else if m.showAll {
+	case m.scope == scopeWorkspace:
+		title += "  " + hintStyle.Render(fmt.Sprintf("(workspace %s: %d projects)", m.workspace, len(m.workspaceProjects)))
+	case m.scope == scopeAll:
 		title += "  " + hintStyle.Render("(all projects)")
 	}
+	if m.store.ReadOnly() {
+		title += "  " + hintStyle.Render("[read-only]")
+	}
+	if m.cfg.WarnOnMultipleActivePerProject {
+		for _, crowded := range CrowdedProjects(m.sessions) {
+			title += "  " + waitingStatusStyle.Render(glyphs.Waiting+" "+crowded)
+		}
+	}
 	b.WriteString(headerStyle.Render(title))
 	b.WriteString("\n")
 
 	if len(m.filtered) == 0 {
-		b.WriteString(hintStyle.Render("No sessions found."))
-		if !m.showAll {
-			b.WriteString("\n" + hintStyle.Render("Press Tab to show all projects."))
+		b.WriteString(m.renderEmptyState())
+		if m.statusMsg != "" {
+			b.WriteString("\n" + hintStyle.Render(m.statusMsg))
 		}
 		b.WriteString("\n\n")
 		b.WriteString(m.renderHints())
 		return b.String()
 	}
 
-	// Calculate pane widths
-	previewWidth := min(m.width/2, 60)
-	listWidth := m.width - previewWidth - 3 // 3 for separator
-
-	// Build list pane
-	listContent := m.renderList(listWidth)
-
-	// Build preview pane
-	previewContent := m.renderPreview(previewWidth)
-
-	// Join horizontally
-	joined := lipgloss.JoinHorizontal(lipgloss.Top,
-		listContent,
-		"  ",
-		previewContent,
-	)
-	b.WriteString(joined)
+	previewWidth, _, _ := m.previewSize()
+	switch m.layout() {
+	case PreviewHidden:
+		b.WriteString(m.renderList(m.width))
+	case PreviewBottom:
+		b.WriteString(m.renderList(m.width))
+		b.WriteString("\n")
+		b.WriteString(m.renderPreview(previewWidth))
+	default:
+		listWidth := m.width - previewWidth - 3 // 3 for separator
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+			m.renderList(listWidth),
+			"  ",
+			m.renderPreview(previewWidth),
+		))
+	}
 	b.WriteString("\n")
 
 	// Status / search bar
 	if m.searching {
-		fmt.Fprintf(&b, "Search: %s█", m.searchText)
+		fmt.Fprintf(&b, "Search: %s%s", m.searchText, glyphs.Cursor)
 	} else if m.statusMsg != "" {
 		if m.confirming {
 			b.WriteString(errorStyle.Render(m.statusMsg))
@@ -324,7 +927,7 @@ func (m Model) View() string {
 
 func (m Model) renderList(width int) string {
 	var lines []string
-	availableHeight := m.height - 6 // header + hints + margins
+	availableHeight := m.listHeight()
 
 	// Calculate visible window
 	start := 0
@@ -338,52 +941,94 @@ func (m Model) renderList(width int) string {
 		sess := m.sessions[idx]
 		line := m.renderSessionLine(sess, width)
 		if i == m.cursor {
-			line = selectedStyle.Render(line)
+			line = selectLine(line)
 		}
 		lines = append(lines, line)
 	}
 
 	// Scroll indicators
 	if start > 0 {
-		lines = append([]string{hintStyle.Render("  ↑ more")}, lines...)
+		lines = append([]string{hintStyle.Render("  " + glyphs.Up + " more")}, lines...)
 	}
-	if end < len(m.filtered) {
-		lines = append(lines, hintStyle.Render("  ↓ more"))
+	if end < len(m.filtered) || m.more {
+		lines = append(lines, hintStyle.Render("  "+glyphs.Down+" more"))
 	}
 
 	return strings.Join(lines, "\n")
 }
 
 func (m Model) renderSessionLine(sess store.Session, width int) string {
-	var status string
-	if sess.Active {
-		status = activeStatusStyle.Render("● ACTIVE")
-	} else {
-		status = inactiveStatusStyle.Render("○ idle  ")
-	}
+	cols := m.listColumns()
+	flex := width - 2 - FixedWidth(cols, " ")
+	text, _ := splitSearch(m.searchText)
+	return "  " + renderRow(cols, sess, " ", flex, true, fuzzyPattern(text))
+}
 
-	relTime := FormatRelativeTime(sess.LastActivity)
-	model := shortModel(sess.Model)
+// listColumns returns the columns of the session list: --columns, then the
+// columns config, then the default, which gains a host column when the
+// sessions span machines.
+func (m Model) listColumns() []Column {
+	if m.columns != nil {
+		return m.columns
+	}
+	if m.multiHost {
+		return mustColumns(slices.Insert(slices.Clone(DefaultLauncherColumns), 1, "host"))
+	}
+	return mustColumns(DefaultLauncherColumns)
+}
 
-	// Prompt text gets remaining space
-	promptWidth := width - 10 - 16 - 10 // status + time + model
-	if promptWidth < 10 {
-		promptWidth = 10
+// layout returns where the preview is shown: where it was placed, unless
+// the terminal is too narrow for it beside the list or too short for it
+// below.
+func (m Model) layout() PreviewPosition {
+	pos := m.previewPos
+	if pos == PreviewRight && m.width < narrowWidth {
+		pos = PreviewBottom
 	}
-	prompt := sess.LastPrompt
-	if prompt == "" {
-		prompt = "(no prompts yet)"
+	if pos == PreviewBottom && m.height < shortHeight {
+		pos = PreviewHidden
 	}
-	if len(prompt) > promptWidth {
-		prompt = prompt[:promptWidth-3] + "..."
+	return pos
+}
+
+// listHeight returns the number of session rows that fit in the list pane.
+func (m Model) listHeight() int {
+	h := m.height - 6 // header + hints + margins
+	if m.layout() == PreviewBottom {
+		h -= m.bottomPreviewHeight() + 1
 	}
+	return max(h, 1)
+}
 
-	return fmt.Sprintf("  %s %s %s %s",
-		status,
-		timeStyle.Render(relTime),
-		modelStyle.Render(model),
-		promptStyle.Render(prompt),
-	)
+// bottomPreviewHeight is the total height of a preview placed below the list:
+// half of the space left after the header and hints.
+func (m Model) bottomPreviewHeight() int {
+	return max((m.height-6)/2, 7)
+}
+
+// previewSize returns the preview pane width and the size of the scrollable
+// area inside its border and padding.
+func (m Model) previewSize() (width, innerWidth, innerHeight int) {
+	height := m.height - 6
+	switch {
+	case m.layout() == PreviewBottom:
+		width = m.width - 2 // border
+		height = m.bottomPreviewHeight()
+	case m.previewPct > 0:
+		width = m.width * m.previewPct / 100
+	default:
+		width = min(m.width/2, 60)
+	}
+	return width, max(width-4, 1), max(height-4, 3)
+}
+
+// syncPreview loads the current preview content into the viewport so that
+// scrolling is clamped to the real content height.
+func (m *Model) syncPreview() {
+	width, innerWidth, innerHeight := m.previewSize()
+	m.preview.Width = innerWidth
+	m.preview.Height = innerHeight
+	m.preview.SetContent(m.previewContent(width))
 }
 
 func (m Model) renderPreview(width int) string {
@@ -391,60 +1036,206 @@ func (m Model) renderPreview(width int) string {
 		return ""
 	}
 
+	m.syncPreview()
+	content := m.preview.View()
+	if !m.preview.AtTop() || !m.preview.AtBottom() {
+		content += "\n" + hintStyle.Render(fmt.Sprintf("%3.f%%  pgup/pgdn", m.preview.ScrollPercent()*100))
+	}
+	return previewStyle.Width(width).Render(content)
+}
+
+func (m Model) previewContent(width int) string {
+
 	idx := m.filtered[m.cursor]
 	sess := m.sessions[idx]
 
 	var lines []string
 
 	// Session header
-	idShort := sess.ID
-	if len(idShort) > 8 {
-		idShort = idShort[:8]
+	lines = append(lines, previewHeaderStyle.Render(fmt.Sprintf("Session %s", textutil.ShortID(sess.ID))))
+	if sess.Title != "" {
+		lines = append(lines, fmt.Sprintf("Title:   %s", sess.Title))
 	}
-	lines = append(lines, previewHeaderStyle.Render(fmt.Sprintf("Session %s", idShort)))
 	lines = append(lines, fmt.Sprintf("Project: %s", sess.Project))
 	lines = append(lines, fmt.Sprintf("CWD:     %s", sess.CWD))
+	if len(m.cwds) > 1 {
+		lines = append(lines, fmt.Sprintf("Trail:   %s", formatCWDTrail(sess.Project, m.cwds, width-13)))
+	}
+	if len(m.files) > 0 {
+		lines = append(lines, fmt.Sprintf("Files:   %s", formatFilesTouched(sess.Project, m.files, width-13)))
+	}
+	if term := FormatTerminal(sess); term != "" && sess.Active {
+		lines = append(lines, fmt.Sprintf("TTY:     %s", term))
+	}
+	if sess.Branch != "" {
+		branch := sess.Branch
+		if len(sess.Tags) > 0 {
+			branch += "  " + hintStyle.Render("["+strings.Join(sess.Tags, ", ")+"]")
+		}
+		lines = append(lines, fmt.Sprintf("Branch:  %s", branch))
+	}
+	if sess.Host != "" {
+		host := sess.Host
+		if sess.User != "" {
+			host = sess.User + "@" + host
+		}
+		lines = append(lines, fmt.Sprintf("Host:    %s", host))
+	}
 	lines = append(lines, fmt.Sprintf("Model:   %s", sess.Model))
+	if PermissionModeBadge(sess.PermissionMode) != "" {
+		lines = append(lines, fmt.Sprintf("Mode:    %s", modeStyle(sess).Render(sess.PermissionMode)))
+	}
+	if len(sess.ResumeArgs) > 0 {
+		lines = append(lines, fmt.Sprintf("Args:    %s", joinShell(sess.ResumeArgs)))
+	}
+	for i, link := range slices.Backward(sess.Links) {
+		label := "Links:   "
+		if i < len(sess.Links)-1 {
+			label = "         "
+		}
+		lines = append(lines, label+link)
+	}
 	lines = append(lines, fmt.Sprintf("Started: %s", formatAbsoluteTime(sess.StartedAt)))
 	lines = append(lines, fmt.Sprintf("Active:  %s", formatAbsoluteTime(sess.LastActivity)))
+	if len(sess.ConcurrentWith) > 0 {
+		ids := make([]string, len(sess.ConcurrentWith))
+		for i, id := range sess.ConcurrentWith {
+			ids[i] = textutil.ShortID(id)
+		}
+		lines = append(lines, fmt.Sprintf("Overlap: started while %s ran here", strings.Join(ids, ", ")))
+	}
+	if !sess.Active && sess.EndReason != "" {
+		lines = append(lines, fmt.Sprintf("Ended:   %s", endReasonLabel(sess.EndReason)))
+	}
+	if sess.WorkedMS > 0 {
+		lines = append(lines, fmt.Sprintf("Worked:  %s", FormatDuration(sess.WorkedMS)))
+	}
+	if sess.PromptCount > 0 {
+		lines = append(lines, fmt.Sprintf("Prompts: %d", sess.PromptCount))
+	}
+	if sess.InputTokens > 0 || sess.OutputTokens > 0 {
+		tokens := fmt.Sprintf("%s in, %s out", FormatTokens(sess.InputTokens), FormatTokens(sess.OutputTokens))
+		if sess.CacheReadTokens > 0 {
+			tokens += fmt.Sprintf(", %s from cache", FormatTokens(sess.CacheReadTokens))
+		}
+		lines = append(lines, fmt.Sprintf("Tokens:  %s", tokens))
+	}
+	var calls []string
+	if sess.ToolUses > 0 {
+		calls = append(calls, fmt.Sprintf("%d calls", sess.ToolUses))
+	}
+	if sess.ToolErrors > 0 {
+		failed := fmt.Sprintf("%d failed", sess.ToolErrors)
+		if sess.ToolUses > 0 {
+			failed += fmt.Sprintf(" (%d%%)", 100*sess.ToolErrors/max(sess.ToolUses, sess.ToolErrors))
+		}
+		if rough(sess) {
+			failed = staleStatusStyle.Render(glyphs.Rough + " " + failed)
+		}
+		calls = append(calls, failed)
+	}
+	label := "Tools:   "
+	if len(calls) > 0 {
+		lines = append(lines, label+strings.Join(calls, ", "))
+		label = strings.Repeat(" ", len(label))
+	}
+	if len(m.tools) > 0 {
+		lines = append(lines, label+formatToolStats(m.tools, width-13))
+	}
+	if sess.FirstPrompt != "" {
+		first := sess.FirstPrompt
+		first = textutil.Truncate(first, max(width-9, 10), "...")
+		lines = append(lines, fmt.Sprintf("First:   %s", first))
+	}
+	if sess.Outcome != "" {
+		outcome := sess.Outcome
+		if sess.OutcomeNote != "" {
+			outcome += "  " + hintStyle.Render(sess.OutcomeNote)
+		}
+		lines = append(lines, fmt.Sprintf("Outcome: %s", outcome))
+	}
+	if sources := formatActivitySources(sess); sources != "" {
+		lines = append(lines, fmt.Sprintf("Last:    %s", sources))
+	}
+	if sess.Active && sess.AwaitingSince > 0 {
+		wait := "Waiting on you for " + strings.TrimSuffix(FormatRelativeTime(sess.AwaitingSince), " ago")
+		if sess.AwaitingMessage != "" {
+			wait += ": " + sess.AwaitingMessage
+		}
+		lines = append(lines, waitingStatusStyle.Render(wait))
+	}
+	switch {
+	case sess.Source != "":
+		lines = append(lines, hintStyle.Render("From store "+sess.Source+" (read-only)"))
+	case sess.ReadOnly:
+		lines = append(lines, hintStyle.Render("Imported from a bundle (read-only)"))
+	}
+	if sess.Notes != "" {
+		lines = append(lines, fmt.Sprintf("Notes:   %s", sess.Notes))
+	}
 	lines = append(lines, "")
 
 	// Prompts
 	if len(m.prompts) > 0 {
-		lines = append(lines, previewHeaderStyle.Render("Recent prompts:"))
+		header := "Recent prompts:"
+		if sess.PromptCount > len(m.prompts) {
+			header = fmt.Sprintf("Recent prompts (%d of %d):", len(m.prompts), sess.PromptCount)
+		}
+		lines = append(lines, previewHeaderStyle.Render(header))
 		for _, p := range m.prompts {
 			relTime := FormatRelativeTime(p.Timestamp)
 			text := p.Text
-			maxLen := width - 14
-			if maxLen < 10 {
-				maxLen = 10
-			}
-			if len(text) > maxLen {
-				text = text[:maxLen-3] + "..."
-			}
-			lines = append(lines, fmt.Sprintf("  %s  %s",
+			repeats := occurrences(p) + agentLabel(p)
+			text = textutil.Truncate(text, max(width-14-lipgloss.Width(repeats), 10), "...")
+			lines = append(lines, fmt.Sprintf("  %s  %s%s",
 				previewTimeStyle.Render(relTime),
 				previewPromptStyle.Render(text),
+				hintStyle.Render(repeats),
 			))
 		}
 	} else {
 		lines = append(lines, hintStyle.Render("No prompts recorded"))
 	}
 
-	content := strings.Join(lines, "\n")
-	return previewStyle.Width(width).Render(content)
+	return strings.Join(lines, "\n")
+}
+
+// occurrences marks a prompt sent several times in a row, e.g. " (×3)".
+func occurrences(p store.Prompt) string {
+	if p.Occurrences <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(" (%s%d)", glyphs.Times, p.Occurrences)
+}
+
+// agentLabel names the subagent a prompt was sent to, e.g. " → Explore".
+func agentLabel(p store.Prompt) string {
+	if p.Agent == "" {
+		return ""
+	}
+	return " " + glyphs.Right + " " + p.Agent
 }
 
 func (m Model) renderHints() string {
 	hints := []string{
 		keys.Up.Help().Key + "/" + keys.Down.Help().Key + " navigate",
 		keys.Enter.Help().Key + " resume",
-		keys.Tab.Help().Key + " toggle scope",
+		keys.Continue.Help().Key + " continue latest",
+		keys.Attach.Help().Key + " jump",
+		keys.Tab.Help().Key + " " + m.scopeHint(),
+		keys.Projects.Help().Key + " projects",
+		m.compareHint(),
 		keys.Search.Help().Key + " search",
+		keys.Expand.Help().Key + " view prompts",
+		keys.Copy.Help().Key + " copy prompts",
+		keys.OpenLink.Help().Key + " open link",
+		keys.Layout.Help().Key + " layout",
+		keys.Settings.Help().Key + " settings",
+		keys.PageUp.Help().Key + "/" + keys.PageDown.Help().Key + " scroll",
 		keys.Delete.Help().Key + " delete",
 		keys.Quit.Help().Key + " quit",
 	}
-	return statusBarStyle.Render(strings.Join(hints, "  │  "))
+	return statusBarStyle.Render(strings.Join(hints, "  "+glyphs.Separator+"  "))
 }
 
 // GetResult returns the selected session, or nil if the user quit without selecting.
@@ -472,6 +1263,116 @@ func FormatRelativeTime(tsMs int64) string {
 	}
 }
 
+// FormatDuration formats a duration in milliseconds as hours and minutes,
+// e.g. "2h 15m", "45m" or "<1m".
+func FormatDuration(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case h > 0:
+		return fmt.Sprintf("%dh %dm", h, m)
+	case m > 0:
+		return fmt.Sprintf("%dm", m)
+	default:
+		return "<1m"
+	}
+}
+
+// FormatTokens formats a token count briefly, e.g. "850", "12.3k" or "1.2M".
+func FormatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	}
+	return fmt.Sprint(n)
+}
+
+// CrowdedProjects describes the projects with more than one running
+// session among sessions, such as "2 active in api-server", by project.
+// Headless sessions, which run on behalf of another, are not counted.
+func CrowdedProjects(sessions []store.Session) []string {
+	counts := map[string]int{}
+	for _, sess := range sessions {
+		if sess.Active && !sess.Headless && sess.Source == "" {
+			counts[sess.Project]++
+		}
+	}
+	var crowded []string
+	for _, project := range slices.Sorted(maps.Keys(counts)) {
+		if counts[project] > 1 {
+			crowded = append(crowded, fmt.Sprintf("%d active in %s", counts[project], filepath.Base(project)))
+		}
+	}
+	return crowded
+}
+
+// hostCount returns the number of distinct hosts recorded on sessions.
+func hostCount(sessions []store.Session) int {
+	hosts := map[string]bool{}
+	for _, sess := range sessions {
+		if sess.Host != "" {
+			hosts[sess.Host] = true
+		}
+	}
+	return len(hosts)
+}
+
+// FormatTerminal describes where a session runs, e.g. "pts/3, kitty".
+// Returns "" if neither the TTY nor the terminal emulator is known.
+func FormatTerminal(sess store.Session) string {
+	var parts []string
+	for _, p := range []string{sess.TTY, sess.Terminal} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatActivitySources summarizes per-source activity,
+// e.g. "prompt 2h ago · tool 4m ago". Sources never seen are omitted.
+func formatActivitySources(sess store.Session) string {
+	var parts []string
+	for _, src := range []struct {
+		name string
+		ts   int64
+	}{
+		{"prompt", sess.LastPromptAt},
+		{"tool", sess.LastToolAt},
+		{"resume", sess.LastResumeAt},
+	} {
+		if src.ts > 0 {
+			parts = append(parts, src.name+" "+FormatRelativeTime(src.ts))
+		}
+	}
+	return strings.Join(parts, " "+glyphs.Dot+" ")
+}
+
+// formatCWDTrail renders the directories a session moved through relative to
+// its project, e.g. ". → api → web", dropping the oldest entries to fit maxLen.
+func formatCWDTrail(project string, cwds []store.CWDEntry, maxLen int) string {
+	parts := make([]string, 0, len(cwds))
+	for _, c := range cwds {
+		dir := c.CWD
+		if dir == project {
+			dir = "."
+		} else if rel, ok := strings.CutPrefix(dir, project+"/"); ok {
+			dir = rel
+		}
+		parts = append(parts, dir)
+	}
+
+	sep := " " + glyphs.Right + " "
+	trail := strings.Join(parts, sep)
+	for len(parts) > 1 && len([]rune(trail)) > maxLen {
+		parts = parts[1:]
+		trail = glyphs.Ellipsis + sep + strings.Join(parts, sep)
+	}
+	return trail
+}
+
 func formatAbsoluteTime(tsMs int64) string {
 	if tsMs == 0 {
 		return "unknown"
@@ -482,8 +1383,19 @@ func formatAbsoluteTime(tsMs int64) string {
 func shortModel(model string) string {
 	// "claude-sonnet-4-6" -> "sonnet-4-6"
 	model = strings.TrimPrefix(model, "claude-")
-	if len(model) > 14 {
-		model = model[:14]
+	return textutil.Truncate(model, 14, "")
+}
+
+// endReasonLabel describes the reason SessionEnd gave, naming the command
+// or key that ended the session where there is one.
+func endReasonLabel(reason string) string {
+	switch reason {
+	case "clear":
+		return "/clear"
+	case "logout":
+		return "/logout"
+	case "prompt_input_exit":
+		return "exit at the prompt"
 	}
-	return model
+	return reason
 }
@@ -0,0 +1,210 @@
+package launcher
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// projectPicker is the project switcher: every project with sessions, of
+// which the chosen one becomes the project the list is scoped to.
+type projectPicker struct {
+	projects []store.ProjectSummary // nil until loaded
+	filtered []int                  // indices into projects, best match first
+	cursor   int
+	filter   string
+	err      error
+}
+
+type projectsLoaded struct {
+	projects []store.ProjectSummary
+	err      error
+}
+
+func (m Model) loadProjects() tea.Cmd {
+	s, secondaries, excludeHeadless := m.store, m.secondaries, !m.includeHeadless
+	return func() tea.Msg {
+		projects, err := store.ProjectsMerged(s, secondaries, excludeHeadless)
+		return projectsLoaded{projects: projects, err: err}
+	}
+}
+
+// openProjects shows the project switcher and loads its projects.
+func (m Model) openProjects() (tea.Model, tea.Cmd) {
+	m.picker = &projectPicker{}
+	return m, m.loadProjects()
+}
+
+// projectsLoadedMsg fills the switcher, with the cursor on the project the
+// list is scoped to.
+func (m Model) projectsLoadedMsg(msg projectsLoaded) Model {
+	if m.picker == nil {
+		return m
+	}
+	picker := *m.picker
+	picker.projects, picker.err = msg.projects, msg.err
+	if picker.projects == nil {
+		picker.projects = []store.ProjectSummary{}
+	}
+	picker.buildFilter()
+	if i := slices.IndexFunc(picker.filtered, func(i int) bool { return picker.projects[i].Project == m.project }); i >= 0 {
+		picker.cursor = i
+	}
+	m.picker = &picker
+	return m
+}
+
+// buildFilter lists the projects whose path fuzzy-matches the filter, best
+// match first, or all of them in order without one.
+func (p *projectPicker) buildFilter() {
+	p.filtered = nil
+	pattern := fuzzyPattern(p.filter)
+	scores := make(map[int]int)
+	for i, proj := range p.projects {
+		if len(pattern) > 0 {
+			score, ok := searchScore(pattern, []string{proj.Project})
+			if !ok {
+				continue
+			}
+			scores[i] = score
+		}
+		p.filtered = append(p.filtered, i)
+	}
+	slices.SortStableFunc(p.filtered, func(a, b int) int { return cmp.Compare(scores[b], scores[a]) })
+	if p.cursor >= len(p.filtered) {
+		p.cursor = max(0, len(p.filtered)-1)
+	}
+}
+
+func (m Model) handleProjectsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	picker := *m.picker
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		if picker.filter == "" {
+			m.picker = nil
+			return m, nil
+		}
+		picker.filter = ""
+		picker.buildFilter()
+	case "enter":
+		if len(picker.filtered) == 0 {
+			return m, nil
+		}
+		return m.chooseProject(picker.projects[picker.filtered[picker.cursor]].Project)
+	case "ctrl+p":
+		picker.cursor = max(picker.cursor-1, 0)
+	case "ctrl+n":
+		picker.cursor = max(min(picker.cursor+1, len(picker.filtered)-1), 0)
+	case "backspace":
+		if r := []rune(picker.filter); len(r) > 0 {
+			picker.filter = string(r[:len(r)-1])
+			picker.buildFilter()
+		}
+	default:
+		switch {
+		case msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace:
+			picker.filter += string(msg.Runes)
+			picker.cursor = 0
+			picker.buildFilter()
+		case key.Matches(msg, keys.Up):
+			picker.cursor = max(picker.cursor-1, 0)
+		case key.Matches(msg, keys.Down):
+			picker.cursor = max(min(picker.cursor+1, len(picker.filtered)-1), 0)
+		}
+	}
+	m.picker = &picker
+	return m, nil
+}
+
+// chooseProject scopes the list to project, and the workspace scope to the
+// workspace it is in.
+func (m Model) chooseProject(project string) (tea.Model, tea.Cmd) {
+	m.picker = nil
+	m.project = project
+	m.workspace = m.cfg.WorkspaceOf(project)
+	m.workspaceProjects, _ = m.cfg.WorkspaceProjects(m.workspace)
+	m.scope = scopeProject
+	m.cursor = 0
+	m.limit, m.complete = sessionPage, false
+	m.statusMsg = "Project " + project
+	return m, m.loadSessions()
+}
+
+func (m Model) renderProjects() string {
+	picker := m.picker
+	var b strings.Builder
+	title := "Projects"
+	if picker.projects != nil {
+		title += "  " + hintStyle.Render(fmt.Sprintf("(%d)", len(picker.projects)))
+	}
+	b.WriteString(headerStyle.Render(title) + "\n")
+	if picker.filter != "" {
+		fmt.Fprintf(&b, "  Filter: %s%s\n\n", picker.filter, glyphs.Cursor)
+	} else {
+		b.WriteString(hintStyle.Render("  Type to filter by path") + "\n\n")
+	}
+
+	// Header, filter and status bar lines around the rows
+	rows := max(m.height-8, 3)
+	switch {
+	case picker.err != nil:
+		b.WriteString(errorStyle.Render("  Cannot list projects: "+picker.err.Error()) + "\n")
+		rows--
+	case picker.projects == nil:
+		b.WriteString(hintStyle.Render("  Loading projects...") + "\n")
+		rows--
+	case len(picker.filtered) == 0:
+		b.WriteString(hintStyle.Render("  No project matches") + "\n")
+		rows--
+	}
+
+	nameWidth := 12
+	for _, i := range picker.filtered {
+		nameWidth = max(nameWidth, textutil.Width(filepath.Base(picker.projects[i].Project)))
+	}
+	nameWidth = min(nameWidth, 30)
+	start := max(0, min(picker.cursor-rows/2, len(picker.filtered)-rows))
+	end := min(start+rows, len(picker.filtered))
+	for n := start; n < end; n++ {
+		p := picker.projects[picker.filtered[n]]
+		name := textutil.Truncate(filepath.Base(p.Project), nameWidth, "...")
+		sessions := fmt.Sprintf("%d sessions", p.Sessions)
+		if p.Sessions == 1 {
+			sessions = "1 session"
+		}
+		active := ""
+		if p.Active > 0 {
+			active = fmt.Sprintf("%d active", p.Active)
+		}
+		line := fmt.Sprintf("  %s%s  %-12s  %-9s  %-8s  ", name, strings.Repeat(" ", nameWidth-textutil.Width(name)),
+			sessions, active, FormatRelativeTime(p.LastActivity))
+		line += textutil.Truncate(p.Project, max(m.width-textutil.Width(line), 10), "...")
+		if n == picker.cursor {
+			line = selectLine(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n")
+	if m.statusMsg != "" {
+		b.WriteString(hintStyle.Render(m.statusMsg))
+	}
+	b.WriteString("\n")
+	hints := []string{
+		glyphs.Up + "/" + glyphs.Down + " select",
+		"enter choose",
+		"esc close",
+	}
+	b.WriteString(statusBarStyle.Render(strings.Join(hints, "  "+glyphs.Separator+"  ")))
+	return b.String()
+}
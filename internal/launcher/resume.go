@@ -0,0 +1,427 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// resumePlan is the resume awaiting confirmation: the session and the claude
+// arguments that follow --resume <id>, which can be edited before running.
+type resumePlan struct {
+	sess    store.Session
+	args    []string
+	editing bool
+	text    string // the arguments being edited
+	// Asking where the session's missing project directory is now:
+	moving bool
+	dir    string
+}
+
+// WithResume sets how sessions are resumed: claudeBin overrides the
+// claude_bin config when not empty, and passthrough arguments override the
+// ones from the session and the config.
+func (m Model) WithResume(claudeBin, passthrough []string) Model {
+	m.claudeBin = claudeBin
+	m.passthrough = passthrough
+	return m
+}
+
+// claudeCommand returns the command that runs claude.
+func (m Model) claudeCommand() []string {
+	cfg := m.cfg
+	if len(m.claudeBin) > 0 {
+		cfg.ClaudeBin = m.claudeBin
+	}
+	return cfg.ClaudeCommand()
+}
+
+// startResume opens the confirmation screen for resuming sess.
+func (m Model) startResume(sess store.Session) Model {
+	args := config.MergeArgs(m.cfg.ResumeArgs(sess.Project, sess.ResumeArgs), m.passthrough)
+	m.resume = &resumePlan{sess: sess, args: args}
+	m.statusMsg = ""
+	return m
+}
+
+// resumeBlocked says why sess cannot be resumed from the launcher, or ""
+// if it can.
+func resumeBlocked(sess store.Session) string {
+	switch {
+	case sess.Active:
+		return "Session is active; press " + keys.Attach.Help().Key + " to jump to it"
+	case sess.Source != "":
+		return "Cannot resume a session from store " + sess.Source
+	case sess.ReadOnly:
+		return "Cannot resume an imported (read-only) session"
+	}
+	return ""
+}
+
+// latestResumable returns the most recently active session of the launcher's
+// project that can be resumed, whatever the scope, sort or search.
+func (m Model) latestResumable() (store.Session, bool) {
+	var latest store.Session
+	found := false
+	for _, sess := range m.sessions {
+		if sess.Project != m.project || sess.Active || sess.ReadOnly || sess.Headless || sess.Source != "" {
+			continue
+		}
+		if !found || sess.LastActivity > latest.LastActivity {
+			latest, found = sess, true
+		}
+	}
+	return latest, found
+}
+
+// resumeModels are the models the model key steps through on the resume
+// screen, after the one the arguments already name, if any: claude's aliases
+// for its latest models, then the models recorded for any listed session.
+func (m Model) resumeModels() []string {
+	models := []string{"", "opus", "sonnet", "haiku"}
+	for _, sess := range m.sessions {
+		if sess.Model != "" && !slices.Contains(models, sess.Model) {
+			models = append(models, sess.Model)
+		}
+	}
+	return models
+}
+
+// modelArg returns the model named by --model in args, or "".
+func modelArg(args []string) string {
+	for i := 0; i < len(args); i++ {
+		if v, ok := strings.CutPrefix(args[i], "--model="); ok {
+			return v
+		}
+		if args[i] == "--model" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// withModel returns args with any --model replaced by model, or removed if
+// model is empty.
+func withModel(args []string, model string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case strings.HasPrefix(args[i], "--model="):
+		case args[i] == "--model":
+			i++
+		default:
+			out = append(out, args[i])
+		}
+	}
+	if model != "" {
+		out = append(out, "--model", model)
+	}
+	return out
+}
+
+// nextModel returns the model after current in models, wrapping around.
+func nextModel(models []string, current string) string {
+	i := slices.Index(models, current)
+	return models[(i+1)%len(models)]
+}
+
+// handleResumeKey handles input on the resume confirmation screen.
+func (m Model) handleResumeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	plan := *m.resume
+	if plan.moving {
+		return m.handleMoveKey(msg)
+	}
+	if plan.editing {
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			plan.editing = false
+			m.statusMsg = ""
+		case "enter":
+			args, err := splitShell(plan.text)
+			if err != nil {
+				m.statusMsg = "Cannot parse arguments: " + err.Error()
+				return m, nil
+			}
+			plan.args = args
+			plan.editing = false
+			m.statusMsg = ""
+		case "backspace":
+			if r := []rune(plan.text); len(r) > 0 {
+				plan.text = string(r[:len(r)-1])
+			}
+		case "ctrl+u":
+			plan.text = ""
+		default:
+			if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+				plan.text += string(msg.Runes)
+			}
+		}
+		m.resume = &plan
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "enter", "y":
+		dir := m.cfg.ResumeDir(plan.sess.Project, plan.sess.CWD)
+		if _, err := os.Stat(dir); err != nil {
+			// Started elsewhere, claude would not find the session's
+			// project settings, nor write to its files
+			plan.moving = true
+			plan.dir = plan.sess.Project
+			m.resume = &plan
+			m.statusMsg = ""
+			return m, nil
+		}
+		m.result = &Result{SessionID: plan.sess.ID, Project: plan.sess.Project, Dir: dir, Args: plan.args}
+		return m, tea.Quit
+	case "m":
+		plan.args = withModel(plan.args, nextModel(m.resumeModels(), modelArg(plan.args)))
+		m.resume = &plan
+	case "e":
+		plan.editing = true
+		plan.text = joinShell(plan.args)
+		m.resume = &plan
+	case "esc", "q", "n":
+		m.resume = nil
+		m.statusMsg = ""
+	}
+	return m, nil
+}
+
+// handleMoveKey handles input while asking where a session's missing
+// project directory is now. The session is moved there in the store.
+func (m Model) handleMoveKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	plan := *m.resume
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		plan.moving = false
+		m.statusMsg = ""
+	case "enter":
+		dir, err := filepath.Abs(config.ExpandHome(strings.TrimSpace(plan.dir)))
+		if err == nil {
+			err = checkDir(dir)
+		}
+		if err == nil {
+			err = m.store.SetProject(plan.sess.ID, dir)
+		}
+		if err != nil {
+			m.statusMsg = "Cannot move the session: " + err.Error()
+			return m, nil
+		}
+		plan.sess.Project, plan.sess.CWD = store.ResolvePath(dir), store.ResolvePath(dir)
+		plan.moving = false
+		m.resume = &plan
+		m.statusMsg = ""
+		return m, m.loadSessions()
+	case "backspace":
+		if r := []rune(plan.dir); len(r) > 0 {
+			plan.dir = string(r[:len(r)-1])
+		}
+	case "ctrl+u":
+		plan.dir = ""
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			plan.dir += string(msg.Runes)
+		}
+	}
+	m.resume = &plan
+	return m, nil
+}
+
+// checkDir returns an error unless dir is an existing directory.
+func checkDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	return nil
+}
+
+// renderResume shows what resuming will run, so that flags from the config,
+// --dangerously-skip-permissions in particular, are never a surprise.
+func (m Model) renderResume() string {
+	plan := m.resume
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("Resume session " + textutil.ShortID(plan.sess.ID)))
+	b.WriteString("\n\n")
+
+	dir := m.cfg.ResumeDir(plan.sess.Project, plan.sess.CWD)
+	if _, err := os.Stat(dir); err != nil {
+		dir += "  " + errorStyle.Render("(missing: enter asks where it is now)")
+	} else if dir != plan.sess.Project {
+		dir += "  " + hintStyle.Render("(where the session last worked, per resume_cwd)")
+	}
+	fmt.Fprintf(&b, "  Directory    %s\n", dir)
+	if plan.sess.EndReason == store.EndReasonClear {
+		// /clear ends the session and carries on in a new one, leaving
+		// nothing behind to resume
+		fmt.Fprintf(&b, "  Context      %s\n", quietStatusStyle.Render("cleared with /clear: claude resumes an empty conversation"))
+	}
+
+	perms := permissionFlags(plan.args)
+	switch {
+	case len(perms) == 0:
+		fmt.Fprintf(&b, "  Permissions  %s\n", hintStyle.Render("default (claude asks before acting)"))
+	case slices.Contains(perms, "--dangerously-skip-permissions"):
+		fmt.Fprintf(&b, "  Permissions  %s\n", errorStyle.Render(strings.Join(perms, " ")))
+	default:
+		fmt.Fprintf(&b, "  Permissions  %s\n", strings.Join(perms, " "))
+	}
+	if m.cfg.DangerouslySkipPermissions && slices.Contains(plan.args, "--dangerously-skip-permissions") {
+		fmt.Fprintf(&b, "               %s\n", hintStyle.Render("--dangerously-skip-permissions comes from dangerously_skip_permissions in the config"))
+	}
+
+	model := hintStyle.Render("claude's default")
+	if name := modelArg(plan.args); name != "" {
+		model = modelStyle.Render(name)
+	}
+	if plan.sess.Model != "" {
+		model += hintStyle.Render("  (last used " + plan.sess.Model + ")")
+	}
+	fmt.Fprintf(&b, "  Model        %s\n", model)
+
+	b.WriteString("\n  Command\n")
+	argv := slices.Concat(m.claudeCommand(), []string{"--resume", plan.sess.ID}, plan.args)
+	width := max(m.width-4, 20)
+	b.WriteString(lipgloss.NewStyle().Width(width).PaddingLeft(4).Render(joinShell(argv)))
+	b.WriteString("\n\n")
+
+	switch {
+	case plan.moving:
+		b.WriteString("  " + errorStyle.Render(plan.sess.Project+" is missing.") + " Where is the project now? The session is moved there.\n")
+		fmt.Fprintf(&b, "  Directory: %s%s\n", plan.dir, glyphs.Cursor)
+	case plan.editing:
+		fmt.Fprintf(&b, "  Arguments: %s%s\n", plan.text, glyphs.Cursor)
+	default:
+		b.WriteString("\n")
+	}
+	if m.statusMsg != "" {
+		b.WriteString(errorStyle.Render(m.statusMsg))
+	}
+	b.WriteString("\n")
+
+	var hints []string
+	switch {
+	case plan.moving:
+		hints = []string{"enter move and review", "ctrl+u clear", "esc back"}
+	case plan.editing:
+		hints = []string{"enter apply", "ctrl+u clear", "esc discard"}
+	default:
+		hints = []string{"enter resume", "m model", "e edit arguments", "esc cancel"}
+	}
+	b.WriteString(statusBarStyle.Render(strings.Join(hints, "  "+glyphs.Separator+"  ")))
+	return b.String()
+}
+
+// permissionFlags returns the arguments in args that affect what claude may
+// do without asking, with their values.
+func permissionFlags(args []string) []string {
+	var flags []string
+	for i := 0; i < len(args); i++ {
+		name, _, hasValue := strings.Cut(args[i], "=")
+		switch name {
+		case "--dangerously-skip-permissions":
+			flags = append(flags, args[i])
+		case "--permission-mode", "--allowedTools", "--allowed-tools", "--disallowedTools", "--disallowed-tools":
+			flags = append(flags, args[i])
+			if !hasValue && i+1 < len(args) {
+				i++
+				flags = append(flags, args[i])
+			}
+		}
+	}
+	return flags
+}
+
+// joinShell renders args as a command line that a POSIX shell would split
+// back into the same arguments.
+func joinShell(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = quoteShell(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func quoteShell(s string) string {
+	if s != "" && !strings.ContainsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_=+./:,@%~", r))
+	}) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// splitShell splits a command line into arguments the way a POSIX shell
+// would, honoring single and double quotes and backslash escapes. Nothing
+// is expanded.
+func splitShell(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	switch {
+	case quote != 0:
+		return nil, errors.New("unterminated quote")
+	case escaped:
+		return nil, errors.New("trailing backslash")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
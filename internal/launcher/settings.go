@@ -0,0 +1,261 @@
+package launcher
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+// SortOrders lists the accepted session list orders; the first is the default.
+var SortOrders = config.SortOrders
+
+// Scopes lists the accepted default scopes; the first is the default.
+var Scopes = config.Scopes
+
+// setting is one row of the settings screen. step moves the value delta
+// places through its choices, wrapping around.
+type setting struct {
+	label string
+	note  string
+	value func(c config.Config) string
+	step  func(c *config.Config, delta int)
+}
+
+var settings = []setting{
+	{
+		label: "Theme",
+		value: func(c config.Config) string { return orDefault(c.Theme, Themes) },
+		step:  func(c *config.Config, d int) { c.Theme = cycle(Themes, orDefault(c.Theme, Themes), d) },
+	},
+	{
+		label: "Default scope",
+		note:  "applies on next launch",
+		value: func(c config.Config) string { return orDefault(c.DefaultScope, Scopes) },
+		step:  func(c *config.Config, d int) { c.DefaultScope = cycle(Scopes, orDefault(c.DefaultScope, Scopes), d) },
+	},
+	{
+		label: "Sort",
+		value: func(c config.Config) string { return orDefault(c.Sort, SortOrders) },
+		step:  func(c *config.Config, d int) { c.Sort = cycle(SortOrders, orDefault(c.Sort, SortOrders), d) },
+	},
+	{
+		label: "Preview",
+		value: func(c config.Config) string { return string(previewPosition(c.PreviewPosition)) },
+		step: func(c *config.Config, d int) {
+			c.PreviewPosition = string(cycle(previewCycle, previewPosition(c.PreviewPosition), d))
+		},
+	},
+	{
+		label: "Preview width",
+		value: func(c config.Config) string { return formatSetting(c.PreviewWidth, "%", "default") },
+		step: func(c *config.Config, d int) {
+			c.PreviewWidth = cycle([]int{0, 20, 30, 40, 50, 60, 70, 80}, c.PreviewWidth, d)
+		},
+	},
+	{
+		label: "Auto-refresh",
+		value: func(c config.Config) string { return formatSetting(c.RefreshSeconds, "s", "off") },
+		step: func(c *config.Config, d int) {
+			c.RefreshSeconds = cycle([]int{0, 2, 5, 10, 30, 60}, c.RefreshSeconds, d)
+		},
+	},
+	{
+		label: "Retention",
+		note:  "age used by cst cleanup",
+		value: func(c config.Config) string { return formatSetting(c.RetentionDays, " days", "30 days (default)") },
+		step: func(c *config.Config, d int) {
+			c.RetentionDays = cycle([]int{0, 7, 14, 60, 90, 180, 365}, c.RetentionDays, d)
+		},
+	},
+	{
+		label: "Idle gap",
+		note:  "longer pauses don't count as time worked",
+		value: func(c config.Config) string { return formatSetting(c.IdleGapMinutes, " min", "15 min (default)") },
+		step: func(c *config.Config, d int) {
+			c.IdleGapMinutes = cycle([]int{0, 5, 10, 30, 60, 120}, c.IdleGapMinutes, d)
+		},
+	},
+}
+
+// handleSettingsKey handles input while the settings screen is open. Every
+// change is saved immediately, so there is nothing to confirm on close.
+func (m Model) handleSettingsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "q", "esc", ",":
+		m.settingsOpen = false
+		m.statusMsg = ""
+		return m, nil
+	case "left", "h":
+		return m.changeSetting(-1)
+	case "right", "l", "enter", " ":
+		return m.changeSetting(1)
+	}
+	switch {
+	case key.Matches(msg, keys.Up):
+		m.settingsCursor = (m.settingsCursor + len(settings) - 1) % len(settings)
+	case key.Matches(msg, keys.Down):
+		m.settingsCursor = (m.settingsCursor + 1) % len(settings)
+	}
+	return m, nil
+}
+
+// changeSetting steps the selected setting and persists it. The config file
+// is re-read first so that edits made with `cst config` meanwhile survive.
+func (m Model) changeSetting(delta int) (tea.Model, tea.Cmd) {
+	cfg, err := config.Load(m.cfgPath)
+	if err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return m, nil
+	}
+	s := settings[m.settingsCursor]
+	s.step(&cfg, delta)
+	if err := config.Save(m.cfgPath, cfg); err != nil {
+		m.statusMsg = "Error saving config: " + err.Error()
+		return m, nil
+	}
+	m.statusMsg = fmt.Sprintf("Saved %s: %s", strings.ToLower(s.label), s.value(cfg))
+	return m.applyConfig(cfg)
+}
+
+// applyConfig makes the launcher reflect cfg: theme, preview layout, columns,
+// sort order and the auto-refresh timer, which is restarted if its interval changed.
+func (m Model) applyConfig(cfg config.Config) (Model, tea.Cmd) {
+	restart := cfg.RefreshSeconds != m.cfg.RefreshSeconds
+	m.cfg = cfg
+	setTheme(cfg.Theme, cfg.ThemeColors)
+	m = m.WithPreview(PreviewPosition(cfg.PreviewPosition), cfg.PreviewWidth)
+	if !m.columnsFixed {
+		// An invalid columns config shows the default rather than failing
+		m.columns, _ = ParseColumns(cfg.Columns)
+	}
+
+	selected := m.selectedID()
+	sortSessions(m.sessions, cfg.Sort)
+	m.buildFilter()
+	m.selectID(selected)
+
+	if !restart {
+		return m, nil
+	}
+	m.refreshGen++
+	return m, m.scheduleRefresh()
+}
+
+type refreshTick struct{ gen int }
+
+// scheduleRefresh starts the auto-refresh timer. Ticks from a timer started
+// before the interval last changed carry an old generation and are dropped.
+func (m Model) scheduleRefresh() tea.Cmd {
+	if m.cfg.RefreshSeconds <= 0 {
+		return nil
+	}
+	gen := m.refreshGen
+	return tea.Tick(time.Duration(m.cfg.RefreshSeconds)*time.Second, func(time.Time) tea.Msg {
+		return refreshTick{gen: gen}
+	})
+}
+
+// sortSessions orders sessions in place. The store returns them by most
+// recent activity, which also breaks ties for the other orders.
+func sortSessions(sessions []store.Session, by string) {
+	slices.SortStableFunc(sessions, func(a, b store.Session) int {
+		switch by {
+		case "started":
+			return cmpDesc(a.StartedAt, b.StartedAt)
+		case "project":
+			if c := strings.Compare(a.Project, b.Project); c != 0 {
+				return c
+			}
+		}
+		return cmpDesc(a.LastActivity, b.LastActivity)
+	})
+}
+
+func cmpDesc(a, b int64) int {
+	switch {
+	case a > b:
+		return -1
+	case a < b:
+		return 1
+	}
+	return 0
+}
+
+func (m Model) renderSettings() string {
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("Settings"))
+	b.WriteString("\n")
+
+	labelWidth := 0
+	for _, s := range settings {
+		labelWidth = max(labelWidth, len(s.label))
+	}
+	for i, s := range settings {
+		value := s.value(m.cfg)
+		line := fmt.Sprintf("  %-*s   %s %s %s", labelWidth, s.label, glyphs.ChoiceOpen, value, glyphs.ChoiceClose)
+		if i == m.settingsCursor {
+			line = selectLine(line)
+		}
+		if s.note != "" {
+			line += "   " + hintStyle.Render(s.note)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(hintStyle.Render("Changes are saved to " + m.cfgPath))
+	b.WriteString("\n")
+	if m.statusMsg != "" {
+		b.WriteString(hintStyle.Render(m.statusMsg))
+	}
+	b.WriteString("\n")
+
+	hints := []string{
+		keys.Up.Help().Key + "/" + keys.Down.Help().Key + " select",
+		glyphs.Left + "/" + glyphs.Right + " change",
+		keys.Settings.Help().Key + "/esc close",
+	}
+	b.WriteString(statusBarStyle.Render(strings.Join(hints, "  "+glyphs.Separator+"  ")))
+	return b.String()
+}
+
+// cycle returns the option delta places from cur, wrapping around. A cur
+// that is not an option counts as the first one.
+func cycle[T comparable](options []T, cur T, delta int) T {
+	i := max(slices.Index(options, cur), 0)
+	n := len(options)
+	return options[((i+delta)%n+n)%n]
+}
+
+// orDefault returns v, or the first option if v is unset or not an option.
+func orDefault(v string, options []string) string {
+	if slices.Contains(options, v) {
+		return v
+	}
+	return options[0]
+}
+
+func previewPosition(v string) PreviewPosition {
+	if pos := PreviewPosition(v); slices.Contains(previewCycle, pos) {
+		return pos
+	}
+	return PreviewRight
+}
+
+// formatSetting renders an integer setting, using zero for zero.
+func formatSetting(n int, unit, zero string) string {
+	if n == 0 {
+		return zero
+	}
+	return strconv.Itoa(n) + unit
+}
@@ -1,71 +1,243 @@
 package launcher
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+)
+
+// theme is the set of colors the TUI styles are built from.
+type theme struct {
+	active   lipgloss.TerminalColor // active sessions
+	waiting  lipgloss.TerminalColor // sessions waiting on the user
+	quiet    lipgloss.TerminalColor // running sessions without recent activity
+	inactive lipgloss.TerminalColor // inactive sessions, timestamps
+	selected lipgloss.TerminalColor // highlight background
+	header   lipgloss.TerminalColor // headers
+	prompt   lipgloss.TerminalColor // prompt text
+	model    lipgloss.TerminalColor // model names
+	err      lipgloss.TerminalColor // errors
+	hint     lipgloss.TerminalColor // hints, status bar
+	border   lipgloss.TerminalColor // preview pane border
+}
+
+// Themes lists the accepted theme names; the first is the default. "auto"
+// picks dark or light from the terminal background, and "custom" is auto
+// with the theme_colors overrides applied.
+var Themes = config.Themes
+
+// ThemeElements names the theme colors a custom theme can override.
+var ThemeElements = []string{"active", "waiting", "quiet", "inactive", "selected", "header", "prompt", "model", "error", "hint", "border"}
+
+// color returns the field of t for the named element, or nil if there is none.
+func (t *theme) color(element string) *lipgloss.TerminalColor {
+	switch element {
+	case "active":
+		return &t.active
+	case "waiting":
+		return &t.waiting
+	case "quiet":
+		return &t.quiet
+	case "inactive":
+		return &t.inactive
+	case "selected":
+		return &t.selected
+	case "header":
+		return &t.header
+	case "prompt":
+		return &t.prompt
+	case "model":
+		return &t.model
+	case "error":
+		return &t.err
+	case "hint":
+		return &t.hint
+	case "border":
+		return &t.border
+	}
+	return nil
+}
+
+var hexColorRe = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// ValidateThemeColor checks that element is one of ThemeElements and color
+// is a hex color ("#ff8800") or an ANSI color number (0-255).
+func ValidateThemeColor(element, color string) error {
+	var t theme
+	if t.color(element) == nil {
+		return fmt.Errorf("unknown theme element %q", element)
+	}
+	if hexColorRe.MatchString(color) {
+		return nil
+	}
+	if n, err := strconv.Atoi(color); err == nil && n >= 0 && n <= 255 {
+		return nil
+	}
+	return fmt.Errorf("invalid color %q, expected #rgb, #rrggbb or 0-255", color)
+}
+
+var themes = map[string]theme{
+	"dark": {
+		active:   lipgloss.Color("#00BFFF"), // Cyan
+		waiting:  lipgloss.Color("#FF9F1C"), // Orange
+		quiet:    lipgloss.Color("#E6DB74"), // Yellow
+		inactive: lipgloss.Color("#888888"), // Gray
+		selected: lipgloss.Color("#333366"),
+		header:   lipgloss.Color("#FFD700"), // Gold
+		prompt:   lipgloss.Color("#AAAAAA"), // Light gray
+		model:    lipgloss.Color("#88AAFF"),
+		err:      lipgloss.Color("#FF4444"), // Red
+		hint:     lipgloss.Color("#666666"), // Dim
+		border:   lipgloss.Color("#444444"),
+	},
+	// Darker foregrounds that stay readable on a light background.
+	"light": {
+		active:   lipgloss.Color("#0077AA"),
+		waiting:  lipgloss.Color("#C45C00"),
+		quiet:    lipgloss.Color("#8A7A00"),
+		inactive: lipgloss.Color("#666666"),
+		selected: lipgloss.Color("#D0D8F0"),
+		header:   lipgloss.Color("#8A6D00"),
+		prompt:   lipgloss.Color("#333333"),
+		model:    lipgloss.Color("#3355AA"),
+		err:      lipgloss.Color("#CC0000"),
+		hint:     lipgloss.Color("#888888"),
+		border:   lipgloss.Color("#BBBBBB"),
+	},
+	// No colors at all; selection and status rely on bold and glyphs.
+	"mono": {
+		active:   lipgloss.NoColor{},
+		waiting:  lipgloss.NoColor{},
+		quiet:    lipgloss.NoColor{},
+		inactive: lipgloss.NoColor{},
+		selected: lipgloss.NoColor{},
+		header:   lipgloss.NoColor{},
+		prompt:   lipgloss.NoColor{},
+		model:    lipgloss.NoColor{},
+		err:      lipgloss.NoColor{},
+		hint:     lipgloss.NoColor{},
+		border:   lipgloss.NoColor{},
+	},
+}
 
 var (
-	// Colors
-	activeColor   = lipgloss.Color("#00BFFF") // Cyan for active sessions
-	inactiveColor = lipgloss.Color("#888888") // Gray for inactive
-	selectedBg    = lipgloss.Color("#333366") // Highlight background
-	headerColor   = lipgloss.Color("#FFD700") // Gold for header
-	promptColor   = lipgloss.Color("#AAAAAA") // Light gray for prompts
-	errorColor    = lipgloss.Color("#FF4444") // Red for errors
-	hintColor     = lipgloss.Color("#666666") // Dim for hints
-	previewBorder = lipgloss.Color("#444444") // Border for preview pane
-
-	// Styles
+	headerStyle         lipgloss.Style
+	activeStatusStyle   lipgloss.Style
+	waitingStatusStyle  lipgloss.Style
+	inactiveStatusStyle lipgloss.Style
+	quietStatusStyle    lipgloss.Style
+	staleStatusStyle    lipgloss.Style
+	selectedStyle       lipgloss.Style
+	promptStyle         lipgloss.Style
+	timeStyle           lipgloss.Style
+	modelStyle          lipgloss.Style
+	previewStyle        lipgloss.Style
+	previewHeaderStyle  lipgloss.Style
+	previewPromptStyle  lipgloss.Style
+	previewTimeStyle    lipgloss.Style
+	hintStyle           lipgloss.Style
+	errorStyle          lipgloss.Style
+	statusBarStyle      lipgloss.Style
+)
+
+// init uses the dark theme rather than the auto-detecting default: detecting
+// the background queries the terminal, which hooks and other commands
+// without a TUI must not do.
+func init() {
+	setTheme("dark", nil)
+}
+
+// resolveTheme returns the colors for the named theme. Unknown names are
+// treated as "auto"; colors only apply to "custom", and invalid entries in
+// it are ignored.
+func resolveTheme(name string, colors map[string]string) theme {
+	if t, ok := themes[name]; ok {
+		return t
+	}
+	t := themes["dark"]
+	if !lipgloss.HasDarkBackground() {
+		t = themes["light"]
+	}
+	if name == "custom" {
+		for element, c := range colors {
+			if ValidateThemeColor(element, c) == nil {
+				*t.color(element) = lipgloss.Color(c)
+			}
+		}
+	}
+	return t
+}
+
+// setTheme rebuilds the styles from the named theme; see resolveTheme.
+func setTheme(name string, colors map[string]string) {
+	t := resolveTheme(name, colors)
+
 	headerStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(headerColor).
-			MarginBottom(1)
+		Bold(true).
+		Foreground(t.header).
+		MarginBottom(1)
 
 	activeStatusStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(activeColor)
+		Bold(true).
+		Foreground(t.active)
+
+	waitingStatusStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.waiting)
 
 	inactiveStatusStyle = lipgloss.NewStyle().
-				Foreground(inactiveColor)
+		Foreground(t.inactive)
+
+	quietStatusStyle = lipgloss.NewStyle().
+		Foreground(t.quiet)
+
+	staleStatusStyle = lipgloss.NewStyle().
+		Foreground(t.err)
 
 	selectedStyle = lipgloss.NewStyle().
-			Background(selectedBg).
-			Bold(true)
+		Background(t.selected).
+		Bold(true)
 
 	promptStyle = lipgloss.NewStyle().
-			Foreground(promptColor)
+		Foreground(t.prompt)
 
 	timeStyle = lipgloss.NewStyle().
-			Foreground(inactiveColor).
-			Width(10)
+		Foreground(t.inactive).
+		Width(10)
 
 	modelStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#88AAFF")).
-			Width(16)
+		Foreground(t.model).
+		Width(16)
 
 	previewStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(previewBorder).
-			Padding(1, 2)
+		Border(glyphs.Border).
+		BorderForeground(t.border).
+		Padding(1, 2)
 
 	previewHeaderStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(headerColor).
-				MarginBottom(1)
+		Bold(true).
+		Foreground(t.header).
+		MarginBottom(1)
 
 	previewPromptStyle = lipgloss.NewStyle().
-				Foreground(promptColor)
+		Foreground(t.prompt)
 
 	previewTimeStyle = lipgloss.NewStyle().
-				Foreground(inactiveColor).
-				Width(10)
+		Foreground(t.inactive).
+		Width(10)
 
 	hintStyle = lipgloss.NewStyle().
-			Foreground(hintColor)
+		Foreground(t.hint)
 
 	errorStyle = lipgloss.NewStyle().
-			Foreground(errorColor).
-			Bold(true)
+		Foreground(t.err).
+		Bold(true)
 
 	statusBarStyle = lipgloss.NewStyle().
-			Foreground(hintColor).
-			MarginTop(1)
-)
+		Foreground(t.hint).
+		MarginTop(1)
+}
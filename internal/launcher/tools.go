@@ -0,0 +1,43 @@
+package launcher
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// maxToolBar is the width of the bar of the most used tool, in cells.
+const maxToolBar = 8
+
+// ToolBar renders a bar of n cells' share of peak, at least one cell for any
+// use.
+func ToolBar(n, peak, width int) string {
+	if n <= 0 || peak <= 0 {
+		return ""
+	}
+	return strings.Repeat(glyphs.Bar, max(1, n*width/peak))
+}
+
+// formatToolStats renders the tools a session called, most used first, as
+// many as fit maxLen, e.g. "Bash 42 ▇▇▇▇▇▇▇▇  Edit 17 ▇▇▇  Read 9 ▇".
+func formatToolStats(tools []store.ToolCount, maxLen int) string {
+	peak := tools[0].Uses()
+	line := ""
+	for i, t := range tools {
+		item := fmt.Sprintf("%s %d %s", t.Tool, t.Uses(), ToolBar(t.Uses(), peak, maxToolBar))
+		if i > 0 {
+			item = "  " + item
+		}
+		rest := ""
+		if i < len(tools)-1 {
+			rest = "  " + glyphs.Ellipsis
+		}
+		if i > 0 && textutil.Width(line+item+rest) > maxLen {
+			return line + "  " + glyphs.Ellipsis
+		}
+		line += item
+	}
+	return line
+}
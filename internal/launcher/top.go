@@ -0,0 +1,400 @@
+package launcher
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/procutil"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// topSorts are the orders of `cst top`, stepped through with s.
+var topSorts = []string{"cpu", "rate", "activity"}
+
+// Top is the Bubbletea model for `cst top`: the running sessions one per
+// row with their process's CPU and memory, their prompt rate and their last
+// prompt, refreshed every interval. It is for watching sessions work; the
+// launcher is for resuming them.
+type Top struct {
+	store      *store.Store
+	project    string // "" for all projects
+	interval   time.Duration
+	idleGap    time.Duration
+	rows       []topRow
+	samples    map[int]cpuSample // previous CPU sample by PID
+	sortBy     int               // index into topSorts
+	cursor     int
+	confirming bool // stop confirmation
+	statusMsg  string
+	err        error
+	width      int
+	height     int
+	updated    time.Time
+}
+
+// topRow is a running session with what its process used.
+type topRow struct {
+	sess  store.Session
+	usage procutil.Usage
+	ok    bool    // usage was read: the process runs on this machine
+	cpu   float64 // percent of one CPU since the last sample, -1 if unknown
+}
+
+// cpuSample is a process's CPU time at a moment, to compute its CPU use
+// from the next sample.
+type cpuSample struct {
+	cpu time.Duration
+	at  time.Time
+}
+
+type topLoaded struct {
+	rows []topRow
+	at   time.Time
+	err  error
+}
+
+type topTick struct{}
+
+// NewTop creates a top screen of the running sessions in project, or in all
+// projects if project is "", reloaded every interval.
+func NewTop(s *store.Store, project string, interval time.Duration) Top {
+	return Top{
+		store:    s,
+		project:  project,
+		interval: interval,
+		idleGap:  store.DefaultIdleGap,
+		samples:  make(map[int]cpuSample),
+	}
+}
+
+// WithConfig applies the theme and idle gap from cfg.
+func (t Top) WithConfig(cfg config.Config) Top {
+	setTheme(cfg.Theme, cfg.ThemeColors)
+	if cfg.IdleGapMinutes > 0 {
+		t.idleGap = time.Duration(cfg.IdleGapMinutes) * time.Minute
+	}
+	return t
+}
+
+func loadTop(s *store.Store, project string) tea.Cmd {
+	return func() tea.Msg {
+		if err := s.RefreshActive(procutil.IsProcessAlive); err != nil {
+			return topLoaded{err: err}
+		}
+		sessions, err := s.ListSessions(store.ListOptions{Project: project})
+		if err != nil {
+			return topLoaded{err: err}
+		}
+		var rows []topRow
+		for _, sess := range sessions {
+			if !sess.Active {
+				continue
+			}
+			row := topRow{sess: sess, cpu: -1}
+			if sess.PID != nil && sess.IsLocal() {
+				row.usage, row.ok = procutil.ProcessUsage(*sess.PID)
+			}
+			rows = append(rows, row)
+		}
+		return topLoaded{rows: rows, at: time.Now()}
+	}
+}
+
+func (t Top) tick() tea.Cmd {
+	return tea.Tick(t.interval, func(time.Time) tea.Msg { return topTick{} })
+}
+
+// Init implements tea.Model.
+func (t Top) Init() tea.Cmd {
+	return tea.Batch(loadTop(t.store, t.project), t.tick())
+}
+
+// Update implements tea.Model.
+func (t Top) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		t.width = msg.Width
+		t.height = msg.Height
+		return t, nil
+
+	case topLoaded:
+		t.err = msg.err
+		if msg.err != nil {
+			return t, nil
+		}
+		selected := t.selectedID()
+		samples := make(map[int]cpuSample, len(msg.rows))
+		for i := range msg.rows {
+			row := &msg.rows[i]
+			if !row.ok {
+				continue
+			}
+			pid := *row.sess.PID
+			if prev, ok := t.samples[pid]; ok && msg.at.After(prev.at) && row.usage.CPU >= prev.cpu {
+				row.cpu = 100 * float64(row.usage.CPU-prev.cpu) / float64(msg.at.Sub(prev.at))
+			}
+			samples[pid] = cpuSample{cpu: row.usage.CPU, at: msg.at}
+		}
+		t.samples = samples
+		t.rows = msg.rows
+		t.sortRows()
+		t.cursor = max(slices.IndexFunc(t.rows, func(r topRow) bool { return r.sess.ID == selected }), 0)
+		t.updated = msg.at
+		return t, nil
+
+	case topTick:
+		if t.confirming {
+			// Don't move the target of a pending stop.
+			return t, t.tick()
+		}
+		return t, tea.Batch(loadTop(t.store, t.project), t.tick())
+
+	case attached:
+		if msg.err != nil {
+			t.statusMsg = "Cannot jump to session: " + msg.err.Error()
+		} else {
+			t.statusMsg = "Focused " + msg.desc
+		}
+		return t, nil
+
+	case tea.KeyMsg:
+		return t.handleKey(msg)
+	}
+	return t, nil
+}
+
+func (t Top) selectedID() string {
+	if t.cursor < len(t.rows) {
+		return t.rows[t.cursor].sess.ID
+	}
+	return ""
+}
+
+// sortRows orders the rows by the selected sort, most first, breaking ties
+// by the most recent activity.
+func (t *Top) sortRows() {
+	now := time.Now()
+	slices.SortStableFunc(t.rows, func(a, b topRow) int {
+		var c int
+		switch topSorts[t.sortBy] {
+		case "cpu":
+			c = cmp.Compare(b.cpu, a.cpu)
+		case "rate":
+			c = cmp.Compare(promptRate(b.sess, now), promptRate(a.sess, now))
+		}
+		if c != 0 {
+			return c
+		}
+		return cmp.Compare(b.sess.LastActivity, a.sess.LastActivity)
+	})
+}
+
+func (t Top) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if t.confirming {
+		t.confirming = false
+		t.statusMsg = ""
+		switch msg.String() {
+		case "y", "Y":
+			if t.cursor < len(t.rows) {
+				return t.stop(t.rows[t.cursor].sess)
+			}
+		}
+		return t, nil
+	}
+
+	t.statusMsg = ""
+	switch {
+	case key.Matches(msg, keys.Quit):
+		return t, tea.Quit
+
+	case key.Matches(msg, keys.Up):
+		if t.cursor > 0 {
+			t.cursor--
+		}
+
+	case key.Matches(msg, keys.Down):
+		if t.cursor < len(t.rows)-1 {
+			t.cursor++
+		}
+
+	case msg.String() == "s":
+		selected := t.selectedID()
+		t.sortBy = (t.sortBy + 1) % len(topSorts)
+		t.sortRows()
+		t.cursor = max(slices.IndexFunc(t.rows, func(r topRow) bool { return r.sess.ID == selected }), 0)
+		t.statusMsg = "Sorted by " + topSorts[t.sortBy]
+
+	case key.Matches(msg, keys.Attach):
+		if t.cursor < len(t.rows) {
+			sess := t.rows[t.cursor].sess
+			if !sess.IsLocal() {
+				t.statusMsg = "Session runs on " + sess.Host
+				return t, nil
+			}
+			t.statusMsg = "Looking for session " + textutil.ShortID(sess.ID) + "..."
+			return t, focusSession(sess)
+		}
+
+	case key.Matches(msg, keys.Stop):
+		if t.cursor < len(t.rows) {
+			t.statusMsg, t.confirming = stopPrompt(t.rows[t.cursor].sess)
+		}
+	}
+	return t, nil
+}
+
+// stop sends SIGTERM to a session's claude process, see stopSession, and
+// reloads.
+func (t Top) stop(sess store.Session) (tea.Model, tea.Cmd) {
+	t.statusMsg = stopSession(sess)
+	return t, loadTop(t.store, t.project)
+}
+
+// runStart is when a session's current run began: its last resume, or its
+// start if it was never resumed.
+func runStart(sess store.Session) int64 {
+	return max(sess.StartedAt, sess.LastResumeAt)
+}
+
+// promptRate returns the prompts a session has been sent per hour since it
+// started. Sessions younger than a minute are measured over a minute, so
+// a first prompt doesn't read as a flood.
+func promptRate(sess store.Session, now time.Time) float64 {
+	elapsed := max(now.Sub(time.UnixMilli(sess.StartedAt)), time.Minute)
+	return float64(sess.PromptCount) / elapsed.Hours()
+}
+
+// formatMemory formats a byte count in binary units, e.g. "512M" or "1.5G".
+func formatMemory(n int64) string {
+	const mib = 1 << 20
+	switch {
+	case n >= 10<<30:
+		return fmt.Sprintf("%dG", n>>30)
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1fG", float64(n)/(1<<30))
+	}
+	return fmt.Sprintf("%dM", (n+mib/2)/mib)
+}
+
+// View implements tea.Model.
+func (t Top) View() string {
+	if t.err != nil {
+		return errorStyle.Render("Error: " + t.err.Error())
+	}
+	if t.width == 0 {
+		return "Loading..."
+	}
+
+	var b strings.Builder
+	scope := "all projects"
+	if t.project != "" {
+		scope = t.project
+	}
+	var cpu float64
+	var rss int64
+	for _, r := range t.rows {
+		cpu += max(r.cpu, 0)
+		rss += r.usage.RSS
+	}
+	title := fmt.Sprintf("cst top  %d running, %.0f%% CPU, %s", len(t.rows), cpu, formatMemory(rss))
+	b.WriteString(headerStyle.Render(title + "  " + hintStyle.Render(fmt.Sprintf("%s %s by %s %s every %s %s updated %s",
+		scope, glyphs.Dot, topSorts[t.sortBy], glyphs.Dot, t.interval, glyphs.Dot, t.updated.Format("15:04:05")))))
+	b.WriteString("\n\n")
+
+	b.WriteString(hintStyle.Render(fmt.Sprintf("  %s %-8s %-16s %7s %5s %5s %8s %6s  %s",
+		" ", "ID", "PROJECT", "PID", "CPU%", "MEM", "UP", "P/H", "LAST PROMPT")))
+	b.WriteString("\n")
+	if len(t.rows) == 0 {
+		b.WriteString(hintStyle.Render("  No running sessions."))
+		b.WriteString("\n")
+	}
+	height := max(t.height-7, 1)
+	start := max(t.cursor-height+1, 0)
+	now := time.Now()
+	for i := start; i < min(start+height, len(t.rows)); i++ {
+		line := t.renderRow(t.rows[i], now)
+		if i == t.cursor {
+			line = selectLine(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	if t.statusMsg != "" {
+		if t.confirming {
+			b.WriteString(errorStyle.Render(t.statusMsg))
+		} else {
+			b.WriteString(hintStyle.Render(t.statusMsg))
+		}
+	}
+	b.WriteString("\n")
+	hints := []string{
+		keys.Up.Help().Key + "/" + keys.Down.Help().Key + " navigate",
+		"s sort",
+		keys.Attach.Help().Key + " jump",
+		keys.Stop.Help().Key + " stop",
+		keys.Quit.Help().Key + " quit",
+	}
+	b.WriteString(statusBarStyle.Render(strings.Join(hints, "  "+glyphs.Separator+"  ")))
+	return b.String()
+}
+
+func (t Top) renderRow(r topRow, now time.Time) string {
+	sess := r.sess
+	var status string
+	since := now.Sub(time.UnixMilli(sess.LastActivity))
+	switch {
+	case sess.AwaitingSince > 0:
+		status = waitingStatusStyle.Render(glyphs.Waiting)
+	case since < freshActivity:
+		status = activeStatusStyle.Render(glyphs.Active)
+	case since < t.idleGap:
+		status = quietStatusStyle.Render(glyphs.Active)
+	default:
+		status = staleStatusStyle.Render(glyphs.Active)
+	}
+
+	pid, cpu, mem := "-", "-", "-"
+	if sess.PID != nil {
+		pid = fmt.Sprint(*sess.PID)
+	}
+	if !sess.IsLocal() {
+		pid = "@" + sess.Host
+	}
+	if r.ok {
+		mem = formatMemory(r.usage.RSS)
+		if r.cpu >= 0 {
+			cpu = fmt.Sprintf("%.1f", r.cpu)
+		}
+	}
+	up := FormatDuration(now.UnixMilli() - runStart(sess))
+
+	promptWidth := max(t.width-2-2-8-1-16-1-7-1-5-1-5-1-8-1-6-2, 10)
+	prompt := sess.LastPrompt
+	if prompt == "" {
+		prompt = "(no prompts yet)"
+	}
+	prompt = strings.Join(strings.Fields(prompt), " ")
+	prompt = textutil.Truncate(prompt, promptWidth, "...")
+	return fmt.Sprintf("  %s %-8s %-16.16s %7.7s %5s %5s %8s %6.1f  %s",
+		status,
+		textutil.ShortID(sess.ID),
+		filepath.Base(sess.Project),
+		pid,
+		cpu,
+		mem,
+		up,
+		promptRate(sess, now),
+		promptStyle.Render(prompt),
+	)
+}
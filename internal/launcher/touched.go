@@ -0,0 +1,72 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/transcript"
+)
+
+// touchedCache holds the files touched per transcript path, so that moving
+// through the list does not re-read unchanged transcripts, which can run to
+// many megabytes.
+var touchedCache sync.Map // path -> touchedEntry
+
+type touchedEntry struct {
+	modTime time.Time
+	size    int64
+	files   []transcript.TouchedFile
+}
+
+// filesTouched returns the files sess changed, most recent first, per its
+// transcript, or nil if the transcript cannot be read. Sessions recorded
+// before their transcript path was are looked up under ~/.claude/projects,
+// unless they come from another machine.
+func filesTouched(sess store.Session) []transcript.TouchedFile {
+	path := sess.TranscriptPath
+	if path == "" && sess.Source == "" && !sess.ReadOnly {
+		path, _ = transcript.Find(transcript.DefaultDir(), sess.ID)
+	}
+	if path == "" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if v, ok := touchedCache.Load(path); ok {
+		if e := v.(touchedEntry); e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+			return e.files
+		}
+	}
+	files, err := transcript.FilesTouched(path)
+	if err != nil {
+		return nil
+	}
+	touchedCache.Store(path, touchedEntry{modTime: info.ModTime(), size: info.Size(), files: files})
+	return files
+}
+
+// formatFilesTouched renders the count of files and as many of them, relative
+// to project, as fit maxLen, e.g. "7 (src/foo.go, main.go, …)".
+func formatFilesTouched(project string, files []transcript.TouchedFile, maxLen int) string {
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		file := f.Path
+		if rel, ok := strings.CutPrefix(file, project+"/"); ok {
+			file = rel
+		}
+		names = append(names, file)
+	}
+	shown := len(names)
+	line := fmt.Sprintf("%d (%s)", len(files), strings.Join(names, ", "))
+	for shown > 1 && len([]rune(line)) > maxLen {
+		shown--
+		line = fmt.Sprintf("%d (%s, %s)", len(files), strings.Join(names[:shown], ", "), glyphs.Ellipsis)
+	}
+	return line
+}
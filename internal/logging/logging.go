@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// DefaultMaxSize is the size past which the log file is rotated.
+const DefaultMaxSize = 5 << 20
+
+// Options selects where log records go. With neither set, New returns a
+// logger that discards everything.
+type Options struct {
+	// Verbose writes debug records to stderr.
+	Verbose bool
+	// File is a log file to append debug records to; "" for none.
+	File string
+	// MaxSize rotates File to File+".1" when it has grown past this many
+	// bytes. Zero uses DefaultMaxSize.
+	MaxSize int64
+}
+
+// New returns a logger for opts. If the log file cannot be opened the
+// logger still writes to stderr when verbose, and the error is returned
+// alongside it.
+//
+// The file is rotated when it is opened rather than while writing: every
+// cst invocation, hooks included, is a short-lived process, and several may
+// append to the file at once.
+func New(opts Options) (*slog.Logger, error) {
+	handlerOpts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var handlers fanout
+	if opts.Verbose {
+		handlers = append(handlers, slog.NewTextHandler(os.Stderr, handlerOpts))
+	}
+	var err error
+	if opts.File != "" {
+		var f io.Writer
+		if f, err = openRotated(opts.File, opts.MaxSize); err == nil {
+			handlers = append(handlers, slog.NewTextHandler(f, handlerOpts))
+		}
+	}
+
+	switch len(handlers) {
+	case 0:
+		return slog.New(slog.DiscardHandler), err
+	case 1:
+		return slog.New(handlers[0]), err
+	}
+	return slog.New(handlers), err
+}
+
+// openRotated opens path for appending, first moving it to path.1 if it
+// has grown past maxSize.
+func openRotated(path string, maxSize int64) (*os.File, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() >= maxSize {
+		// Another process may have rotated it first; appending to the
+		// fresh file is fine either way.
+		if err := os.Rename(path, path+".1"); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+// fanout sends each record to every handler that accepts its level.
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanout, len(f))
+	for i, h := range f {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	out := make(fanout, len(f))
+	for i, h := range f {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
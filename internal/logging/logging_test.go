@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewWritesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "cst.log")
+	logger, err := New(Options{File: path})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	logger.Debug("hook received", "event", "SessionStart")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	if !strings.Contains(string(data), `msg="hook received" event=SessionStart`) {
+		t.Errorf("log = %q", data)
+	}
+}
+
+func TestNewRotatesLargeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cst.log")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", 100)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	logger, err := New(Options{File: path, MaxSize: 50})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	logger.Info("fresh")
+
+	old, err := os.ReadFile(path + ".1")
+	if err != nil || len(old) != 100 {
+		t.Errorf("rotated file: %d bytes, %v", len(old), err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "xxx") || !strings.Contains(string(data), "fresh") {
+		t.Errorf("new log = %q", data)
+	}
+}
+
+func TestNewDisabled(t *testing.T) {
+	logger, err := New(Options{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if logger.Handler().Enabled(t.Context(), 1<<10) {
+		t.Error("logger without outputs should discard everything")
+	}
+}
@@ -1,8 +1,12 @@
 package procutil
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
 	"syscall"
 )
@@ -29,33 +33,118 @@ func IsProcessAlive(pid int) bool {
 
 // isClaude checks whether the given PID belongs to a Claude Code process.
 func isClaude(pid int) bool {
+	isClaude, known := claudeCommand(pid)
+	if !known {
+		// Where the command line can't be read, assume alive if signal(0)
+		// passed
+		return runtime.GOOS != "linux"
+	}
+	return isClaude
+}
+
+// claudeCommand reports whether the command line of the given PID names
+// claude: from /proc/<pid>/cmdline on Linux, `ps -p <pid> -o command=` on
+// macOS. known is false when the command line can't be checked.
+func claudeCommand(pid int) (isClaude, known bool) {
+	var cmdline string
 	switch runtime.GOOS {
 	case "linux":
-		return isClaudeLinux(pid)
+		data, err := os.ReadFile(procCmdlinePath(pid))
+		if err != nil {
+			return false, true
+		}
+		cmdline = strings.ReplaceAll(string(data), "\x00", " ")
 	case "darwin":
-		return isClaudeDarwin(pid)
+		out, err := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "command=").Output()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// ps exits non-zero when there is no such process
+			return false, true
+		}
+		if err != nil {
+			return false, false
+		}
+		cmdline = string(out)
 	default:
-		// On unknown OS, assume alive if signal(0) passed
-		return true
+		return false, false
 	}
+	return strings.Contains(strings.ToLower(cmdline), "claude"), true
 }
 
-// isClaudeLinux reads /proc/<pid>/cmdline to check for "claude".
-func isClaudeLinux(pid int) bool {
-	data, err := os.ReadFile(procCmdlinePath(pid))
+// Terminate asks a Claude Code process to exit with SIGTERM. PIDs whose
+// command line doesn't name claude are refused, so a recycled PID is never
+// signalled; so are all PIDs where the command line can't be checked.
+func Terminate(pid int) error {
+	if pid <= 0 {
+		return errors.New("process is not running")
+	}
+	proc, err := os.FindProcess(pid)
 	if err != nil {
-		return false
+		return err
+	}
+	if err := proc.Signal(syscall.Signal(0)); err != nil {
+		return errors.New("process is not running")
+	}
+	isClaude, known := claudeCommand(pid)
+	if !known {
+		return fmt.Errorf("cannot check that process %d is Claude Code on %s", pid, runtime.GOOS)
+	}
+	if !isClaude {
+		return fmt.Errorf("process %d is no longer Claude Code", pid)
+	}
+	return proc.Signal(syscall.SIGTERM)
+}
+
+// ParentPID returns the parent of the given process, or 0 if it cannot be
+// determined. Only Linux is supported, via /proc/<pid>/stat.
+func ParentPID(pid int) int {
+	if runtime.GOOS != "linux" || pid <= 0 {
+		return 0
+	}
+	data, err := os.ReadFile("/proc/" + itoa(pid) + "/stat")
+	if err != nil {
+		return 0
+	}
+	return parentFromStat(string(data))
+}
+
+func parentFromStat(stat string) int {
+	fields := statFields(stat)
+	if len(fields) < 2 {
+		return 0
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0
 	}
-	cmdline := strings.ReplaceAll(string(data), "\x00", " ")
-	return strings.Contains(strings.ToLower(cmdline), "claude")
+	return ppid
 }
 
-// isClaudeDarwin is a placeholder for macOS. On macOS, /proc doesn't exist
-// but we could use `ps -p <pid> -o command=`. For now, if the process is alive, return true.
-func isClaudeDarwin(pid int) bool {
-	// On macOS, if signal(0) succeeded the process is alive.
-	// We could shell out to `ps` but that adds complexity. Accept the PID as valid.
-	return true
+// Cmdline returns the command line of the given process, or nil if it
+// cannot be read. Only Linux is supported, via /proc/<pid>/cmdline.
+func Cmdline(pid int) []string {
+	if runtime.GOOS != "linux" || pid <= 0 {
+		return nil
+	}
+	data, err := os.ReadFile(procCmdlinePath(pid))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00")
+}
+
+// IsPrintMode reports whether a claude command line runs non-interactively,
+// with -p or --print.
+func IsPrintMode(cmdline []string) bool {
+	for _, arg := range cmdline {
+		switch arg {
+		case "-p", "--print":
+			return true
+		case "--":
+			return false
+		}
+	}
+	return false
 }
 
 func procCmdlinePath(pid int) string {
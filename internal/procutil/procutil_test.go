@@ -0,0 +1,32 @@
+package procutil
+
+import (
+	"os/exec"
+	"runtime"
+	"syscall"
+	"testing"
+)
+
+func TestTerminateRefusesOtherProcesses(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("command lines are only checked on Linux and macOS")
+	}
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("start sleep: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	if err := Terminate(cmd.Process.Pid); err == nil {
+		t.Error("Terminate signalled a process that isn't claude")
+	}
+	if err := cmd.Process.Signal(syscall.Signal(0)); err != nil {
+		t.Errorf("sleep is gone after a refused Terminate: %v", err)
+	}
+	if err := Terminate(0); err == nil {
+		t.Error("Terminate(0) succeeded")
+	}
+}
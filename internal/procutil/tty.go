@@ -0,0 +1,84 @@
+package procutil
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// TTY returns the controlling terminal of the given process, e.g. "pts/3".
+// Returns "" if the process has none or it cannot be determined; only Linux
+// is supported, via /proc/<pid>/stat.
+func TTY(pid int) string {
+	if runtime.GOOS != "linux" || pid <= 0 {
+		return ""
+	}
+	data, err := os.ReadFile("/proc/" + itoa(pid) + "/stat")
+	if err != nil {
+		return ""
+	}
+	return ttyFromStat(string(data))
+}
+
+// ttyFromStat extracts and names the tty_nr field of a /proc/<pid>/stat line.
+func ttyFromStat(stat string) string {
+	fields := statFields(stat)
+	if len(fields) < 5 {
+		return ""
+	}
+	nr, err := strconv.ParseUint(fields[4], 10, 32)
+	if err != nil || nr == 0 {
+		return ""
+	}
+	major := (nr >> 8) & 0xfff
+	minor := (nr & 0xff) | ((nr >> 12) & 0xfff00)
+
+	switch {
+	case major >= 136 && major <= 143: // Unix98 pseudo-terminals
+		return "pts/" + strconv.FormatUint((major-136)*256+minor, 10)
+	case major == 4 && minor < 64:
+		return "tty" + strconv.FormatUint(minor, 10)
+	case major == 4:
+		return "ttyS" + strconv.FormatUint(minor-64, 10)
+	}
+	return ""
+}
+
+// statFields splits a /proc/<pid>/stat line after the command name, which
+// may contain spaces and parentheses, so fields are counted from the last
+// ')': state ppid pgrp session tty_nr ...
+func statFields(stat string) []string {
+	i := strings.LastIndexByte(stat, ')')
+	if i < 0 {
+		return nil
+	}
+	return strings.Fields(stat[i+1:])
+}
+
+// terminalEnv maps environment variables set by terminal emulators to their
+// names, checked in order. TERM_PROGRAM is handled separately.
+var terminalEnv = []struct{ key, name string }{
+	{"KITTY_WINDOW_ID", "kitty"},
+	{"ALACRITTY_WINDOW_ID", "alacritty"},
+	{"WEZTERM_PANE", "wezterm"},
+	{"GHOSTTY_RESOURCES_DIR", "ghostty"},
+	{"KONSOLE_VERSION", "konsole"},
+	{"GNOME_TERMINAL_SCREEN", "gnome-terminal"},
+	{"TILIX_ID", "tilix"},
+	{"WT_SESSION", "windows-terminal"},
+}
+
+// TerminalFromEnv guesses the terminal emulator from the environment
+// variables it exports, e.g. "kitty" or "iTerm.app". Returns "" if unknown.
+func TerminalFromEnv(getenv func(string) string) string {
+	if p := getenv("TERM_PROGRAM"); p != "" && p != "tmux" && p != "screen" {
+		return p
+	}
+	for _, t := range terminalEnv {
+		if getenv(t.key) != "" {
+			return t.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package procutil
+
+import "testing"
+
+func TestTTYFromStat(t *testing.T) {
+	tests := []struct {
+		stat string
+		want string
+	}{
+		{"4242 (claude) S 100 4242 100 34819 4242 4194560", "pts/3"}, // 136:3
+		{"4242 (my (odd) cmd) S 100 4242 100 34816 4242 0", "pts/0"}, // parentheses in comm
+		{"4242 (claude) S 100 4242 100 35073 4242 0", "pts/257"},     // 137:1
+		{"4242 (getty) S 1 4242 4242 1025 4242 0", "tty1"},           // 4:1
+		{"4242 (daemon) S 1 4242 4242 0 -1 0", ""},                   // no controlling terminal
+		{"garbage", ""},
+	}
+	for _, tc := range tests {
+		if got := ttyFromStat(tc.stat); got != tc.want {
+			t.Errorf("ttyFromStat(%q) = %q, want %q", tc.stat, got, tc.want)
+		}
+	}
+}
+
+func TestParentFromStat(t *testing.T) {
+	tests := []struct {
+		stat string
+		want int
+	}{
+		{"4242 (claude) S 100 4242 100 34819 4242 4194560", 100},
+		{"4242 (my (odd) cmd) S 7 4242 100 34816 4242 0", 7},
+		{"garbage", 0},
+	}
+	for _, tc := range tests {
+		if got := parentFromStat(tc.stat); got != tc.want {
+			t.Errorf("parentFromStat(%q) = %d, want %d", tc.stat, got, tc.want)
+		}
+	}
+}
+
+func TestTerminalFromEnv(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, "iTerm.app"},
+		{map[string]string{"TERM_PROGRAM": "tmux", "KITTY_WINDOW_ID": "1"}, "kitty"},
+		{map[string]string{"GNOME_TERMINAL_SCREEN": "/org/gnome/x"}, "gnome-terminal"},
+		{map[string]string{}, ""},
+	}
+	for _, tc := range tests {
+		getenv := func(k string) string { return tc.env[k] }
+		if got := TerminalFromEnv(getenv); got != tc.want {
+			t.Errorf("TerminalFromEnv(%v) = %q, want %q", tc.env, got, tc.want)
+		}
+	}
+}
+
+func TestIsPrintMode(t *testing.T) {
+	tests := []struct {
+		cmdline []string
+		want    bool
+	}{
+		{[]string{"node", "/usr/lib/claude/cli.js", "-p", "summarize"}, true},
+		{[]string{"claude", "--print", "--output-format", "json"}, true},
+		{[]string{"claude", "--resume", "abc"}, false},
+		{[]string{"claude", "--", "-p"}, false},
+		{nil, false},
+	}
+	for _, tc := range tests {
+		if got := IsPrintMode(tc.cmdline); got != tc.want {
+			t.Errorf("IsPrintMode(%q) = %v, want %v", tc.cmdline, got, tc.want)
+		}
+	}
+}
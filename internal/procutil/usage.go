@@ -0,0 +1,51 @@
+package procutil
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of CPU times in /proc/<pid>/stat, which
+// is 100 on every architecture Linux exposes to user space.
+const clockTicks = 100
+
+// Usage is the resources a process has used.
+type Usage struct {
+	CPU time.Duration // user and system time since it started
+	RSS int64         // resident memory in bytes
+}
+
+// ProcessUsage returns the CPU time and resident memory of the given
+// process. It returns false if they cannot be read; only Linux is
+// supported, via /proc/<pid>/stat.
+func ProcessUsage(pid int) (Usage, bool) {
+	if runtime.GOOS != "linux" || pid <= 0 {
+		return Usage{}, false
+	}
+	data, err := os.ReadFile("/proc/" + itoa(pid) + "/stat")
+	if err != nil {
+		return Usage{}, false
+	}
+	return usageFromStat(string(data), int64(os.Getpagesize()))
+}
+
+// usageFromStat reads the utime, stime and rss fields of a /proc/<pid>/stat
+// line, rss being in pages of pageSize bytes.
+func usageFromStat(stat string, pageSize int64) (Usage, bool) {
+	fields := statFields(stat)
+	if len(fields) < 22 {
+		return Usage{}, false
+	}
+	utime, err1 := strconv.ParseInt(fields[11], 10, 64)
+	stime, err2 := strconv.ParseInt(fields[12], 10, 64)
+	rss, err3 := strconv.ParseInt(fields[21], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return Usage{}, false
+	}
+	return Usage{
+		CPU: time.Duration(utime+stime) * time.Second / clockTicks,
+		RSS: rss * pageSize,
+	}, true
+}
@@ -0,0 +1,45 @@
+package procutil
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestUsageFromStat(t *testing.T) {
+	tests := []struct {
+		stat string
+		want Usage
+		ok   bool
+	}{
+		{"4242 (claude) R 100 4242 100 34819 4242 4194304 84 0 0 0 250 50 0 0 20 0 12 0 488703 2703360 317 18446744073709551615",
+			Usage{CPU: 3 * time.Second, RSS: 317 * 4096}, true},
+		{"4242 (my (odd) cmd) S 7 4242 100 34816 4242 0 0 0 0 0 1 0 0 0 20 0 1 0 1 1 2 0",
+			Usage{CPU: 10 * time.Millisecond, RSS: 2 * 4096}, true},
+		{"4242 (claude) S 100 4242", Usage{}, false},
+		{"garbage", Usage{}, false},
+	}
+	for _, tc := range tests {
+		got, ok := usageFromStat(tc.stat, 4096)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("usageFromStat(%q) = %+v, %v, want %+v, %v", tc.stat, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestProcessUsage(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		if _, ok := ProcessUsage(os.Getpid()); ok {
+			t.Error("ProcessUsage reported usage off Linux")
+		}
+		return
+	}
+	u, ok := ProcessUsage(os.Getpid())
+	if !ok || u.RSS <= 0 {
+		t.Errorf("ProcessUsage(self) = %+v, %v, want resident memory", u, ok)
+	}
+	if _, ok := ProcessUsage(0); ok {
+		t.Error("ProcessUsage(0) reported usage")
+	}
+}
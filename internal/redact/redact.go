@@ -0,0 +1,84 @@
+// Package redact removes secrets and personal details from session text
+// before it is shared.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Placeholder replaces each redacted secret.
+const Placeholder = "[REDACTED]"
+
+// DefaultPatterns match common secrets: private keys, cloud and API tokens,
+// passwords in assignments and URLs, bearer tokens and email addresses. In
+// patterns with a capture group, only the first group is redacted, keeping
+// what names the secret.
+var DefaultPatterns = []string{
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	`\bgh[pousr]_[A-Za-z0-9]{36,}\b`,
+	`\bgithub_pat_[A-Za-z0-9_]{22,}\b`,
+	`\bglpat-[A-Za-z0-9_-]{20,}\b`,
+	`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`,
+	`\bsk-[A-Za-z0-9_-]{20,}\b`,
+	`\bAIza[0-9A-Za-z_-]{35}\b`,
+	`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`,
+	`(?i)\bbearer\s+([A-Za-z0-9._~+/=-]{16,})`,
+	`(?i)(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|client[_-]?secret)["']?\s*[:=]\s*["']?([^\s"'&,;]{6,})`,
+	`\b[a-z][a-z0-9+.-]*://[^/\s:@]+:([^/\s@]+)@`,
+	`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`,
+}
+
+// Redactor replaces secrets with Placeholder and the home directory with ~.
+type Redactor struct {
+	home     *regexp.Regexp // nil to leave paths alone
+	patterns []*regexp.Regexp
+	count    int
+}
+
+// New returns a Redactor for DefaultPatterns and the extra patterns, which
+// follow the same capture group rule. Paths under home are shortened to ~,
+// which hides the user name; an empty home leaves paths alone.
+func New(home string, extra []string) (*Redactor, error) {
+	r := &Redactor{}
+	if home = strings.TrimSuffix(home, "/"); home != "" {
+		r.home = regexp.MustCompile(regexp.QuoteMeta(home) + `\b`)
+	}
+	for _, p := range slices.Concat(DefaultPatterns, extra) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("redact pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// String returns s with secrets and the home directory replaced.
+func (r *Redactor) String(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			r.count++
+			if re.NumSubexp() == 0 {
+				return Placeholder
+			}
+			sub := re.FindStringSubmatchIndex(match)
+			if sub[2] < 0 {
+				return Placeholder
+			}
+			return match[:sub[2]] + Placeholder + match[sub[3]:]
+		})
+	}
+	if r.home != nil {
+		s = r.home.ReplaceAllString(s, "~")
+	}
+	return s
+}
+
+// Count returns the number of secrets String has replaced so far.
+func (r *Redactor) Count() int {
+	return r.count
+}
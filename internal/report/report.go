@@ -0,0 +1,110 @@
+// Package report renders usage summaries of the session store, such as for
+// sprint retrospectives, as Markdown or HTML. Reports are built from store
+// aggregates with text or HTML templates; the built-in ones can be replaced.
+package report
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/imyousuf/claude-session-tracker/internal/launcher"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+)
+
+// Report formats.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Formats lists the accepted report formats; the first is the default.
+var Formats = []string{FormatMarkdown, FormatHTML}
+
+// maxPromptLen caps the width of a prompt in a report, in terminal cells.
+const maxPromptLen = 100
+
+//go:embed templates
+var builtin embed.FS
+
+// Data is what report templates are executed with.
+type Data struct {
+	Since     time.Time
+	Generated time.Time
+	Project   string // "" for all projects
+	store.Usage
+}
+
+// funcs are the functions available to report templates.
+var funcs = map[string]any{
+	"duration": launcher.FormatDuration,
+	"date":     func(t time.Time) string { return t.Format(time.DateOnly) },
+	"base":     filepath.Base,
+	"percent": func(n, total int) string {
+		if total == 0 {
+			return "0%"
+		}
+		return fmt.Sprintf("%.0f%%", float64(n)*100/float64(total))
+	},
+	"inc":     func(i int) int { return i + 1 },
+	"oneline": oneline,
+	"cell":    func(s string) string { return strings.ReplaceAll(oneline(s), "|", `\|`) },
+	"model": func(m string) string {
+		if m == "" {
+			return "unknown"
+		}
+		return m
+	},
+}
+
+// Render writes the report for data to w in format, with the built-in
+// template or, if templatePath is not "", the template in that file. HTML
+// templates escape what they insert; Markdown ones do not.
+func Render(w io.Writer, format, templatePath string, data Data) error {
+	var name string
+	var text []byte
+	var err error
+	switch format {
+	case FormatMarkdown:
+		name = "report.md.tmpl"
+	case FormatHTML:
+		name = "report.html.tmpl"
+	default:
+		return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+	if templatePath != "" {
+		name = filepath.Base(templatePath)
+		text, err = os.ReadFile(templatePath)
+	} else {
+		text, err = builtin.ReadFile("templates/" + name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if format == FormatHTML {
+		t, err := htmltemplate.New(name).Funcs(funcs).Parse(string(text))
+		if err != nil {
+			return err
+		}
+		return t.Execute(w, data)
+	}
+	t, err := template.New(name).Funcs(funcs).Parse(string(text))
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, data)
+}
+
+// oneline collapses whitespace in s to single spaces and cuts it to
+// maxPromptLen cells.
+func oneline(s string) string {
+	return textutil.Truncate(strings.Join(strings.Fields(s), " "), maxPromptLen, "...")
+}
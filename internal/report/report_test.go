@@ -0,0 +1,81 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+func testData() Data {
+	return Data{
+		Since:     time.Date(2026, 10, 8, 0, 0, 0, 0, time.UTC),
+		Generated: time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+		Usage: store.Usage{
+			Sessions: 4,
+			Prompts:  12,
+			WorkedMS: (2*60 + 15) * 60 * 1000,
+			Projects: []store.ProjectUsage{{Project: "/src/api", Sessions: 3, Prompts: 10}, {Project: "/src/web", Sessions: 1, Prompts: 2}},
+			Models:   []store.ModelUsage{{Model: "opus", Sessions: 3}, {Sessions: 1}},
+			TopPrompts: []store.PromptUsage{
+				{Text: "run the tests | grep FAIL", Count: 3},
+				{Text: "<script>alert(1)</script>", Count: 1},
+			},
+		},
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	var b strings.Builder
+	if err := Render(&b, FormatMarkdown, "", testData()); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"2026-10-08 to 2026-10-15",
+		"- **Time worked:** 2h 15m",
+		"| api | 3 | 10 | <1m |",
+		"| opus | 3 | 75% |",
+		"| unknown | 1 | 25% |",
+		`| 1 | run the tests \| grep FAIL | 3 |`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderHTMLEscapes(t *testing.T) {
+	var b strings.Builder
+	if err := Render(&b, FormatHTML, "", testData()); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	out := b.String()
+	if strings.Contains(out, "<script>") || !strings.Contains(out, "&lt;script&gt;") {
+		t.Errorf("prompt not escaped:\n%s", out)
+	}
+	if !strings.Contains(out, `<td title="/src/api">api</td>`) {
+		t.Errorf("project row missing:\n%s", out)
+	}
+}
+
+func TestRenderCustomTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "retro.tmpl")
+	if err := os.WriteFile(path, []byte(`{{.Sessions}} sessions, {{duration .WorkedMS}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := Render(&b, FormatMarkdown, path, testData()); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if got, want := b.String(), "4 sessions, 2h 15m"; got != want {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+
+	if err := Render(&b, "pdf", "", testData()); err == nil {
+		t.Error("Render accepted an unknown format")
+	}
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// benchStore returns a store with sessions sessions in each of projects
+// projects, each with a few prompts.
+func benchStore(b *testing.B, projects, sessions int) *Store {
+	b.Helper()
+	s := testStore(b)
+	now := time.Now().UnixMilli()
+	for p := range projects {
+		for i := range sessions {
+			id := fmt.Sprintf("p%d-s%d", p, i)
+			ts := now - int64(i)*60_000
+			if err := s.UpsertSession(Session{ID: id, Project: fmt.Sprintf("/proj/%d", p), CWD: "/proj", StartedAt: ts, LastActivity: ts}); err != nil {
+				b.Fatalf("UpsertSession: %v", err)
+			}
+			for j := range 3 {
+				if err := s.AddPrompt(id, fmt.Sprintf("prompt %d of %s", j, id), ts+int64(j)); err != nil {
+					b.Fatalf("AddPrompt: %v", err)
+				}
+			}
+		}
+	}
+	return s
+}
+
+func BenchmarkAddPrompt(b *testing.B) {
+	s := benchStore(b, 1, 1)
+	ts := time.Now().UnixMilli()
+	b.ResetTimer()
+	for i := range b.N {
+		if err := s.AddPrompt("p0-s0", fmt.Sprintf("prompt %d", i), ts+int64(i)); err != nil {
+			b.Fatalf("AddPrompt: %v", err)
+		}
+	}
+}
+
+func BenchmarkRecordPromptActivity(b *testing.B) {
+	s := benchStore(b, 1, 1)
+	ts := time.Now().UnixMilli()
+	b.ResetTimer()
+	for i := range b.N {
+		if err := s.RecordPromptActivity(PromptActivity{SessionID: "p0-s0", Prompt: fmt.Sprintf("prompt %d", i), CWD: "/proj", At: ts + int64(i)}); err != nil {
+			b.Fatalf("RecordPromptActivity: %v", err)
+		}
+	}
+}
+
+func BenchmarkListByProject(b *testing.B) {
+	s := benchStore(b, 5, 100)
+	b.ResetTimer()
+	for range b.N {
+		sessions, err := s.ListByProject("/proj/2")
+		if err != nil || len(sessions) != 100 {
+			b.Fatalf("ListByProject = %d sessions, %v", len(sessions), err)
+		}
+	}
+}
+
+// BenchmarkHookOpen is what a hook costs the store: open the database,
+// record a prompt and close it again.
+func BenchmarkHookOpen(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "hook.db")
+	s, err := Open(path)
+	if err != nil {
+		b.Fatalf("Open: %v", err)
+	}
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/proj", CWD: "/proj"}); err != nil {
+		b.Fatalf("UpsertSession: %v", err)
+	}
+	_ = s.Close()
+	ts := time.Now().UnixMilli()
+	b.ResetTimer()
+	for i := range b.N {
+		s, err := Open(path)
+		if err != nil {
+			b.Fatalf("Open: %v", err)
+		}
+		if err := s.RecordPromptActivity(PromptActivity{SessionID: "s1", Prompt: fmt.Sprintf("prompt %d", i), CWD: "/proj", At: ts + int64(i)}); err != nil {
+			b.Fatalf("RecordPromptActivity: %v", err)
+		}
+		if err := s.Close(); err != nil {
+			b.Fatalf("Close: %v", err)
+		}
+	}
+}
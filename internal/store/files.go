@@ -0,0 +1,96 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// FileTouch records that a session changed a file, per its transcript.
+type FileTouch struct {
+	SessionID string
+	Path      string
+	Edits     int   // tool calls that changed the file
+	LastAt    int64 // of the latest, 0 if unknown
+}
+
+// FileIndexState is what IndexFiles needs to tell whether a session's files
+// are out of date: its transcript and when its files were last recorded.
+type FileIndexState struct {
+	SessionID      string
+	TranscriptPath string
+	IndexedAt      int64 // 0 if never
+}
+
+// SetFiles replaces the files recorded for a session with files, noting that
+// they were read from its transcript at indexedAt. Unknown sessions are
+// ignored.
+func (s *Store) SetFiles(id string, files []FileTouch, indexedAt int64) error {
+	defer s.observe("SetFiles", "INSERT INTO session_files ...", time.Now(), int64(len(files)))
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := s.txExec(ctx, tx, `UPDATE sessions SET files_indexed_at = ? WHERE id = ?`, indexedAt, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	if _, err := s.txExec(ctx, tx, `DELETE FROM session_files WHERE session_id = ?`, id); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if _, err := s.txExec(ctx, tx, `
+			INSERT OR REPLACE INTO session_files (session_id, path, edits, last_at) VALUES (?, ?, ?, ?)
+		`, id, f.Path, f.Edits, f.LastAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// FileIndexStates returns the transcript and index time of every local
+// session, for finding those whose files need recording. Sessions imported
+// from bundles are left out.
+func (s *Store) FileIndexStates() ([]FileIndexState, error) {
+	var states []FileIndexState
+	err := s.queryRows("FileIndexStates", `
+		SELECT id, transcript_path, files_indexed_at FROM sessions WHERE read_only = 0
+	`, nil, func(rows *sql.Rows) error {
+		var st FileIndexState
+		if err := rows.Scan(&st.SessionID, &st.TranscriptPath, &st.IndexedAt); err != nil {
+			return err
+		}
+		states = append(states, st)
+		return nil
+	})
+	return states, err
+}
+
+// FilesTouching returns the recorded changes to the file at path, or to the
+// files under it if it is a directory, most recent first. Paths are compared
+// as Claude Code recorded them.
+func (s *Store) FilesTouching(path string) ([]FileTouch, error) {
+	path = strings.TrimSuffix(path, "/")
+	var touches []FileTouch
+	err := s.queryRows("FilesTouching", `
+		SELECT session_id, path, edits, last_at FROM session_files
+		WHERE path = ? OR path LIKE ? ESCAPE '\'
+		ORDER BY last_at DESC, path
+	`, []any{path, escapeLike(path) + "/%"}, func(rows *sql.Rows) error {
+		var f FileTouch
+		if err := rows.Scan(&f.SessionID, &f.Path, &f.Edits, &f.LastAt); err != nil {
+			return err
+		}
+		touches = append(touches, f)
+		return nil
+	})
+	return touches, err
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetFilesAndFilesTouching(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	for _, id := range []string{"s1", "s2"} {
+		if err := s.UpsertSession(Session{ID: id, Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	if err := s.SetFiles("s1", []FileTouch{{Path: "/proj/old.go", Edits: 1}}, now); err != nil {
+		t.Fatalf("SetFiles: %v", err)
+	}
+	// A later index replaces the earlier one
+	if err := s.SetFiles("s1", []FileTouch{{Path: "/proj/src/a.go", Edits: 2, LastAt: now - 10}, {Path: "/proj/src_b.go", Edits: 1, LastAt: now - 5}}, now+1); err != nil {
+		t.Fatalf("SetFiles: %v", err)
+	}
+	if err := s.SetFiles("s2", []FileTouch{{Path: "/proj/src/a.go", Edits: 1, LastAt: now}}, now); err != nil {
+		t.Fatalf("SetFiles: %v", err)
+	}
+	if err := s.SetFiles("missing", []FileTouch{{Path: "/proj/src/a.go"}}, now); err != nil {
+		t.Fatalf("SetFiles of unknown session: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want []FileTouch
+	}{
+		{"/proj/src/a.go", []FileTouch{{"s2", "/proj/src/a.go", 1, now}, {"s1", "/proj/src/a.go", 2, now - 10}}},
+		// A directory matches the files under it, not its prefix siblings
+		{"/proj/src/", []FileTouch{{"s2", "/proj/src/a.go", 1, now}, {"s1", "/proj/src/a.go", 2, now - 10}}},
+		{"/proj/old.go", nil},
+		{"/proj/src/a", nil},
+	}
+	for _, tt := range tests {
+		got, err := s.FilesTouching(tt.path)
+		if err != nil {
+			t.Fatalf("FilesTouching(%q): %v", tt.path, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("FilesTouching(%q) = %+v, want %+v", tt.path, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("FilesTouching(%q)[%d] = %+v, want %+v", tt.path, i, got[i], tt.want[i])
+			}
+		}
+	}
+
+	states, err := s.FileIndexStates()
+	if err != nil {
+		t.Fatalf("FileIndexStates: %v", err)
+	}
+	for _, st := range states {
+		if st.SessionID == "s1" && st.IndexedAt != now+1 {
+			t.Errorf("s1 IndexedAt = %d, want %d", st.IndexedAt, now+1)
+		}
+	}
+
+	// Files go with their session
+	if err := s.DeleteSession("s2"); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+	if got, _ := s.FilesTouching("/proj/src/a.go"); len(got) != 1 {
+		t.Errorf("after deleting s2, FilesTouching = %+v, want only s1", got)
+	}
+}
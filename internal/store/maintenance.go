@@ -0,0 +1,292 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// backuper is implemented by modernc.org/sqlite driver connections.
+type backuper interface {
+	NewBackup(dstURI string) (*sqlite.Backup, error)
+	NewRestore(srcURI string) (*sqlite.Backup, error)
+}
+
+// Vacuum rebuilds the database file, reclaiming space left by deleted rows.
+func (s *Store) Vacuum() error {
+	_, err := s.exec("Vacuum", `VACUUM`)
+	return err
+}
+
+// IntegrityCheck runs SQLite's integrity check and returns the problems found.
+// A healthy database returns an empty slice.
+func (s *Store) IntegrityCheck() ([]string, error) {
+	const query = `PRAGMA integrity_check`
+	defer s.observe("IntegrityCheck", query, time.Now(), 0)
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	rows, err := s.queryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var problems []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, err
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	return problems, rows.Err()
+}
+
+// Backup writes a consistent online copy of the database to dst using
+// SQLite's backup API. It refuses to overwrite an existing file.
+func (s *Store) Backup(dst string) error {
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("backup destination %s already exists", dst)
+	}
+	defer s.observe("Backup", "sqlite3_backup", time.Now(), 0)
+
+	return s.withBackuper(func(b backuper) error {
+		bk, err := b.NewBackup(dst)
+		if err != nil {
+			return err
+		}
+		return runBackup(bk)
+	})
+}
+
+// Restore replaces the contents of the database with the backup at src and
+// migrates it to the current schema. The backup is validated first.
+func (s *Store) Restore(src string) error {
+	if err := validateBackup(src); err != nil {
+		return err
+	}
+	start := time.Now()
+	err := s.withBackuper(func(b backuper) error {
+		bk, err := b.NewRestore(src)
+		if err != nil {
+			return err
+		}
+		return runBackup(bk)
+	})
+	s.observe("Restore", "sqlite3_backup", start, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := s.migrate(); err != nil {
+		return fmt.Errorf("migrate restored database: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) withBackuper(fn func(backuper) error) error {
+	ctx, cancel := s.opContext()
+	defer cancel()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	return conn.Raw(func(dc any) error {
+		b, ok := dc.(backuper)
+		if !ok {
+			return fmt.Errorf("sqlite driver does not support the backup API")
+		}
+		return fn(b)
+	})
+}
+
+func runBackup(bk *sqlite.Backup) error {
+	for {
+		more, err := bk.Step(-1)
+		if err != nil {
+			_ = bk.Finish()
+			return err
+		}
+		if !more {
+			break
+		}
+	}
+	return bk.Finish()
+}
+
+// validateBackup checks that path is a cst database this build can read.
+func validateBackup(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("backup %s: %w", path, err)
+	}
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	var version, tables int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("backup %s is not a valid database: %w", path, err)
+	}
+	if version > LatestSchemaVersion {
+		return fmt.Errorf("backup schema version %d is newer than supported version %d", version, LatestSchemaVersion)
+	}
+	err = db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name IN ('sessions', 'prompts')`).Scan(&tables)
+	if err != nil {
+		return fmt.Errorf("backup %s is not a valid database: %w", path, err)
+	}
+	if tables != 2 {
+		return fmt.Errorf("backup %s is not a cst database", path)
+	}
+	return nil
+}
+
+// checkpoint copies the write-ahead log into the database file and truncates
+// it, if no other connection is reading. It doesn't wait for readers, so
+// closing the store never blocks on them; a busy log is left for the next
+// checkpoint. The connection waits for locks again once it is back in the
+// pool, or is dropped if that can't be restored.
+func (s *Store) checkpoint() {
+	const query = `PRAGMA wal_checkpoint(TRUNCATE)`
+	defer s.observe("Checkpoint", query, time.Now(), 0)
+
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.ExecContext(ctx, `PRAGMA busy_timeout = 0`); err != nil {
+		return
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf(`PRAGMA busy_timeout = %d`, busyTimeout)); err != nil {
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+	_, _ = conn.ExecContext(ctx, query)
+}
+
+// freePages returns the number of unused pages in the database file, which
+// a vacuum would reclaim.
+func (s *Store) freePages() (int64, error) {
+	const query = `PRAGMA freelist_count`
+	defer s.observe("FreePages", query, time.Now(), 1)
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	var n int64
+	err := s.db.QueryRowContext(ctx, query).Scan(&n)
+	return n, err
+}
+
+// DBSize is the size on disk of a database, in bytes.
+type DBSize struct {
+	File int64 // the database file
+	WAL  int64 // its write-ahead log
+}
+
+// Total returns the size of the database file and its log together.
+func (d DBSize) Total() int64 {
+	return d.File + d.WAL
+}
+
+// Size returns the size on disk of the database.
+func (s *Store) Size() (DBSize, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return DBSize{}, err
+	}
+	size := DBSize{File: info.Size()}
+	if info, err := os.Stat(s.path + "-wal"); err == nil {
+		size.WAL = info.Size()
+	}
+	return size, nil
+}
+
+// TrimPrompts removes all but the keep newest prompts of every inactive
+// session, returning how many were removed. Sessions keep their prompt
+// count and first and last prompts.
+func (s *Store) TrimPrompts(keep int) (int64, error) {
+	res, err := s.exec("TrimPrompts", `
+		DELETE FROM prompts WHERE id IN (
+			SELECT p.id FROM prompts p JOIN sessions s ON s.id = p.session_id
+			WHERE s.active = 0 AND (
+				SELECT COUNT(*) FROM prompts n
+				WHERE n.session_id = p.session_id
+					AND (n.timestamp > p.timestamp OR n.timestamp = p.timestamp AND n.id > p.id)
+			) >= ?
+		)
+	`, keep)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ShrinkReport describes what Shrink did.
+type ShrinkReport struct {
+	Before, After  DBSize
+	PromptsRemoved int64
+	// KeptPrompts is how many prompts inactive sessions were trimmed to, or
+	// -1 if the database was small enough without trimming.
+	KeptPrompts int
+}
+
+// shrinkSteps are the prompt counts Shrink trims inactive sessions to, in
+// turn, until the database fits.
+var shrinkSteps = []int{5, 1, 0}
+
+// Shrink brings the database under maxBytes, if it is over: it checkpoints
+// the log and then trims the prompt history of inactive sessions, shorter
+// at each step, vacuuming after each that left free pages, until the
+// database fits or there is nothing left to trim. Sessions themselves are
+// never removed, so a database they alone fill over maxBytes is left as is
+// without vacuuming.
+func (s *Store) Shrink(maxBytes int64) (ShrinkReport, error) {
+	report := ShrinkReport{KeptPrompts: -1}
+	var err error
+	if report.Before, err = s.Size(); err != nil {
+		return report, err
+	}
+	report.After = report.Before
+	if report.Before.Total() <= maxBytes {
+		return report, nil
+	}
+
+	s.checkpoint()
+	for _, keep := range shrinkSteps {
+		if report.After, err = s.Size(); err != nil || report.After.Total() <= maxBytes {
+			return report, err
+		}
+		n, err := s.TrimPrompts(keep)
+		if err != nil {
+			return report, err
+		}
+		report.PromptsRemoved += n
+		report.KeptPrompts = keep
+		// Pages are also left free by a shrink stopped before its vacuum
+		if free, err := s.freePages(); err != nil || free == 0 {
+			if err != nil {
+				return report, err
+			}
+			continue
+		}
+		if err := s.Vacuum(); err != nil {
+			return report, err
+		}
+		s.checkpoint()
+	}
+	report.After, err = s.Size()
+	return report, err
+}
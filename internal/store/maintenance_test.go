@@ -0,0 +1,193 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	sess := Session{
+		ID: "s1", Project: "/proj", CWD: "/proj",
+		StartedAt: now, LastActivity: now, Model: "sonnet",
+	}
+	if err := s.UpsertSession(sess); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	if err := s.AddPrompt("s1", "hello", now); err != nil {
+		t.Fatalf("AddPrompt: %v", err)
+	}
+
+	backup := filepath.Join(t.TempDir(), "backup.db")
+	if err := s.Backup(backup); err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if err := s.Backup(backup); err == nil {
+		t.Error("expected error when backup destination exists")
+	}
+
+	if err := s.DeleteSession("s1"); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+
+	if err := s.Restore(backup); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	sessions, err := s.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].LastPrompt != "hello" {
+		t.Fatalf("unexpected sessions after restore: %+v", sessions)
+	}
+}
+
+func TestRestoreRejectsInvalidBackup(t *testing.T) {
+	s := testStore(t)
+	dir := t.TempDir()
+
+	if err := s.Restore(filepath.Join(dir, "missing.db")); err == nil {
+		t.Error("expected error for missing backup")
+	}
+
+	junk := filepath.Join(dir, "junk.db")
+	if err := os.WriteFile(junk, []byte("not a database"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := s.Restore(junk); err == nil {
+		t.Error("expected error for non-database file")
+	}
+}
+
+func TestVacuumAndIntegrityCheck(t *testing.T) {
+	s := testStore(t)
+
+	if err := s.Vacuum(); err != nil {
+		t.Fatalf("Vacuum: %v", err)
+	}
+	problems, err := s.IntegrityCheck()
+	if err != nil {
+		t.Fatalf("IntegrityCheck: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("IntegrityCheck problems = %v, want none", problems)
+	}
+}
+
+func TestTrimPromptsAndShrink(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+	for _, sess := range []Session{
+		{ID: "ended", Project: "/p", CWD: "/p", StartedAt: now, LastActivity: now},
+		{ID: "running", Project: "/p", CWD: "/p", StartedAt: now, LastActivity: now, Active: true},
+	} {
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+		for i := range DefaultMaxPrompt {
+			if err := s.AddPrompt(sess.ID, fmt.Sprintf("prompt %d", i), now+int64(i)); err != nil {
+				t.Fatalf("AddPrompt: %v", err)
+			}
+		}
+	}
+
+	n, err := s.TrimPrompts(3)
+	if err != nil {
+		t.Fatalf("TrimPrompts: %v", err)
+	}
+	if n != DefaultMaxPrompt-3 {
+		t.Errorf("TrimPrompts removed %d prompts, want %d", n, DefaultMaxPrompt-3)
+	}
+	prompts, err := s.GetPrompts("ended", DefaultMaxPrompt)
+	if err != nil {
+		t.Fatalf("GetPrompts: %v", err)
+	}
+	if len(prompts) != 3 || prompts[0].Text != fmt.Sprintf("prompt %d", DefaultMaxPrompt-1) {
+		t.Errorf("prompts after trimming = %+v, want the newest 3", prompts)
+	}
+	if prompts, _ := s.GetPrompts("running", DefaultMaxPrompt); len(prompts) != DefaultMaxPrompt {
+		t.Errorf("running session has %d prompts after trimming, want %d", len(prompts), DefaultMaxPrompt)
+	}
+
+	// Under the limit nothing changes
+	report, err := s.Shrink(1 << 40)
+	if err != nil {
+		t.Fatalf("Shrink: %v", err)
+	}
+	if report.KeptPrompts != -1 || report.PromptsRemoved != 0 {
+		t.Errorf("Shrink under the limit = %+v, want nothing done", report)
+	}
+
+	// An unreachable limit trims every step, but only inactive sessions
+	report, err = s.Shrink(1)
+	if err != nil {
+		t.Fatalf("Shrink: %v", err)
+	}
+	if report.KeptPrompts != 0 || report.PromptsRemoved != 3 || report.After.Total() == 0 {
+		t.Errorf("Shrink(1) = %+v, want 3 prompts removed and none kept", report)
+	}
+	sess, err := s.GetSession("ended")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.PromptCount != DefaultMaxPrompt || sess.FirstPrompt != "prompt 0" {
+		t.Errorf("session after shrinking: count %d, first %q", sess.PromptCount, sess.FirstPrompt)
+	}
+
+	// With nothing left to trim, shrinking again does not vacuum
+	vacuums := func() int {
+		for _, st := range s.QueryStats() {
+			if st.Op == "Vacuum" {
+				return st.Calls
+			}
+		}
+		return 0
+	}
+	before := vacuums()
+	if report, err = s.Shrink(1); err != nil || report.PromptsRemoved != 0 {
+		t.Errorf("Shrink(1) again = %+v, %v; want nothing removed", report, err)
+	}
+	if n := vacuums() - before; n != 0 {
+		t.Errorf("Shrink(1) again vacuumed %d times, want none", n)
+	}
+}
+
+func TestCloseCheckpoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	now := time.Now().UnixMilli()
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/p", CWD: "/p", StartedAt: now, LastActivity: now}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	if size, err := s.Size(); err != nil || size.File == 0 {
+		t.Fatalf("Size = %+v, %v", size, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if info, err := os.Stat(path + "-wal"); err == nil && info.Size() != 0 {
+		t.Errorf("WAL is %d bytes after Close, want it checkpointed", info.Size())
+	}
+}
+
+func TestCheckpointRestoresBusyTimeout(t *testing.T) {
+	s := testStore(t)
+	// With one connection, the checkpoint's is the one later queries get
+	s.db.SetMaxOpenConns(1)
+	s.checkpoint()
+	var timeout int
+	if err := s.db.QueryRow(`PRAGMA busy_timeout`).Scan(&timeout); err != nil {
+		t.Fatalf("busy_timeout: %v", err)
+	}
+	if timeout != busyTimeout {
+		t.Errorf("busy_timeout after checkpoint = %d, want %d", timeout, busyTimeout)
+	}
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// duplicateSlack is how far apart two sessions of a project may be and
+// still count as overlapping, covering a restart that issued a new ID.
+const duplicateSlack = time.Minute
+
+// DuplicateGroup is a set of sessions that look like one piece of work
+// split across session IDs. Keep is the most recently active, whose
+// transcript a resume would continue.
+type DuplicateGroup struct {
+	Keep       Session
+	Duplicates []Session
+}
+
+// MergeSessions folds session dupID into keepID and deletes dupID. Prompts,
+// working directory history and tags move over; the merged session keeps the
+// earliest start, the latest activity of each kind, the sum of time worked
+// and of prompts sent, and the first prompt of the session started first.
+// Fields empty on keepID, such as the branch or an outcome, are taken from
+// dupID. Returns sql.ErrNoRows if either session does not exist.
+func (s *Store) MergeSessions(keepID, dupID string) error {
+	if keepID == dupID {
+		return errors.New("cannot merge a session into itself")
+	}
+	defer s.observe("MergeSessions", "UPDATE sessions ... FROM duplicate", time.Now(), 2)
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM sessions WHERE id IN (?, ?)`, keepID, dupID).Scan(&n); err != nil {
+		return err
+	}
+	if n != 2 {
+		return sql.ErrNoRows
+	}
+
+	_, err = s.txExec(ctx, tx, `
+		UPDATE sessions AS k SET
+			started_at = MIN(k.started_at, d.started_at),
+			last_activity = MAX(k.last_activity, d.last_activity),
+			last_prompt_at = MAX(k.last_prompt_at, d.last_prompt_at),
+			last_tool_at = MAX(k.last_tool_at, d.last_tool_at),
+			last_resume_at = MAX(k.last_resume_at, d.last_resume_at),
+			worked_ms = k.worked_ms + d.worked_ms,
+			prompt_count = k.prompt_count + d.prompt_count,
+			input_tokens = k.input_tokens + d.input_tokens,
+			output_tokens = k.output_tokens + d.output_tokens,
+			cache_read_tokens = k.cache_read_tokens + d.cache_read_tokens,
+			tool_uses = k.tool_uses + d.tool_uses,
+			tool_errors = k.tool_errors + d.tool_errors,
+			first_prompt = CASE WHEN k.first_prompt = '' OR (d.first_prompt != '' AND d.started_at < k.started_at)
+				THEN d.first_prompt ELSE k.first_prompt END,
+			active = MAX(k.active, d.active),
+			pid = CASE WHEN k.active = 0 AND d.active = 1 THEN d.pid ELSE k.pid END,
+			model = CASE WHEN k.model = '' THEN d.model ELSE k.model END,
+			notes = CASE WHEN k.notes = '' THEN d.notes ELSE k.notes END,
+			transcript_path = CASE WHEN k.transcript_path = '' THEN d.transcript_path ELSE k.transcript_path END,
+			branch = CASE WHEN k.branch = '' THEN d.branch ELSE k.branch END,
+			title = CASE WHEN k.title = '' THEN d.title ELSE k.title END,
+			end_reason = CASE WHEN k.end_reason = '' THEN d.end_reason ELSE k.end_reason END,
+			concurrent_with = CASE WHEN k.concurrent_with = '' THEN d.concurrent_with ELSE k.concurrent_with END,
+			resume_args = CASE WHEN k.resume_args = '' THEN d.resume_args ELSE k.resume_args END,
+			permission_mode = CASE WHEN k.permission_mode = '' THEN d.permission_mode ELSE k.permission_mode END,
+			host = CASE WHEN k.host = '' THEN d.host ELSE k.host END,
+			user = CASE WHEN k.host = '' THEN d.user ELSE k.user END,
+			outcome = CASE WHEN k.outcome = '' THEN d.outcome ELSE k.outcome END,
+			outcome_note = CASE WHEN k.outcome = '' THEN d.outcome_note ELSE k.outcome_note END,
+			outcome_at = CASE WHEN k.outcome = '' THEN d.outcome_at ELSE k.outcome_at END,
+			outcome_requested = CASE WHEN k.outcome = '' THEN MAX(k.outcome_requested, d.outcome_requested) ELSE 0 END
+		FROM (SELECT * FROM sessions WHERE id = ?) AS d
+		WHERE k.id = ?
+	`, dupID, keepID)
+	if err != nil {
+		return fmt.Errorf("merge session: %w", err)
+	}
+	for _, q := range []string{
+		`UPDATE prompts SET session_id = ? WHERE session_id = ?`,
+		`UPDATE cwd_history SET session_id = ? WHERE session_id = ?`,
+		`INSERT OR IGNORE INTO session_tags (session_id, tag, created_at)
+			SELECT ?, tag, created_at FROM session_tags WHERE session_id = ?`,
+		`INSERT OR IGNORE INTO session_links (session_id, url, created_at)
+			SELECT ?, url, created_at FROM session_links WHERE session_id = ?`,
+		`INSERT OR IGNORE INTO session_files (session_id, path, edits, last_at)
+			SELECT ?, path, edits, last_at FROM session_files WHERE session_id = ?`,
+		`INSERT INTO tool_stats (session_id, tool, calls, completed, last_at)
+			SELECT ?, tool, calls, completed, last_at FROM tool_stats WHERE session_id = ?
+			ON CONFLICT (session_id, tool) DO UPDATE SET
+				calls = calls + excluded.calls,
+				completed = completed + excluded.completed,
+				last_at = MAX(last_at, excluded.last_at)`,
+	} {
+		if _, err := s.txExec(ctx, tx, q, keepID, dupID); err != nil {
+			return fmt.Errorf("merge session: %w", err)
+		}
+	}
+	if _, err := s.txExec(ctx, tx, `DELETE FROM sessions WHERE id = ?`, dupID); err != nil {
+		return fmt.Errorf("delete duplicate: %w", err)
+	}
+	return tx.Commit()
+}
+
+// FindDuplicates groups sessions of the same project whose active periods
+// overlap. Sessions that ran on different recorded terminals or hosts are
+// never grouped, since those are parallel sessions rather than one split in
+// two, and imported sessions are left out.
+func (s *Store) FindDuplicates() ([]DuplicateGroup, error) {
+	sessions, err := s.ListSessions(ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	sessions = slices.DeleteFunc(sessions, func(sess Session) bool { return sess.ReadOnly })
+	slices.SortFunc(sessions, func(a, b Session) int {
+		return cmp.Or(cmp.Compare(a.Project, b.Project), cmp.Compare(a.StartedAt, b.StartedAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	var groups []DuplicateGroup
+	flush := func(cluster []Session) {
+		if len(cluster) < 2 {
+			return
+		}
+		keep := slices.MaxFunc(cluster, func(a, b Session) int { return cmp.Compare(a.LastActivity, b.LastActivity) })
+		g := DuplicateGroup{Keep: keep}
+		for _, sess := range cluster {
+			if sess.ID != keep.ID {
+				g.Duplicates = append(g.Duplicates, sess)
+			}
+		}
+		groups = append(groups, g)
+	}
+
+	// compatible reports whether two recorded values, either possibly
+	// unknown, could describe the same session.
+	compatible := func(a, b string) bool { return a == "" || b == "" || a == b }
+
+	var cluster []Session
+	var end int64
+	tty, host := "", ""
+	for _, sess := range sessions {
+		overlaps := len(cluster) > 0 &&
+			sess.Project == cluster[0].Project &&
+			sess.StartedAt <= end+duplicateSlack.Milliseconds() &&
+			compatible(tty, sess.TTY) && compatible(host, sess.Host)
+		if !overlaps {
+			flush(cluster)
+			cluster, end, tty, host = nil, 0, "", ""
+		}
+		cluster = append(cluster, sess)
+		end = max(end, sess.LastActivity)
+		tty = cmp.Or(tty, sess.TTY)
+		host = cmp.Or(host, sess.Host)
+	}
+	flush(cluster)
+	return groups, nil
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestMergeSessions(t *testing.T) {
+	s := testStore(t)
+	for _, sess := range []Session{
+		{ID: "keep", Project: "/p", CWD: "/p", StartedAt: 200, LastActivity: 900, Model: "opus"},
+		{ID: "dup", Project: "/p", CWD: "/p", StartedAt: 100, LastActivity: 500, Model: "sonnet"},
+	} {
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	for _, p := range []struct {
+		id   string
+		text string
+		ts   int64
+	}{{"dup", "first", 150}, {"keep", "second", 250}, {"dup", "third", 450}} {
+		if err := s.AddPrompt(p.id, p.text, p.ts); err != nil {
+			t.Fatalf("AddPrompt: %v", err)
+		}
+	}
+	if err := s.AddTags("dup", []string{"JIRA-1"}, 100); err != nil {
+		t.Fatalf("AddTags: %v", err)
+	}
+	if err := s.AddLink("dup", "https://jira.example.com/browse/JIRA-1", 100); err != nil {
+		t.Fatalf("AddLink: %v", err)
+	}
+	if err := s.SetBranch("dup", "JIRA-1-fix"); err != nil {
+		t.Fatalf("SetBranch: %v", err)
+	}
+	if _, err := s.db.Exec(`UPDATE sessions SET worked_ms = 1000 WHERE id IN ('keep', 'dup')`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.MergeSessions("keep", "dup"); err != nil {
+		t.Fatalf("MergeSessions: %v", err)
+	}
+
+	sess, err := s.GetSession("keep")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.StartedAt != 100 || sess.LastActivity != 900 {
+		t.Errorf("span = %d..%d, want 100..900", sess.StartedAt, sess.LastActivity)
+	}
+	if sess.Model != "opus" || sess.Branch != "JIRA-1-fix" || sess.WorkedMS != 2000 {
+		t.Errorf("model %q branch %q worked %d", sess.Model, sess.Branch, sess.WorkedMS)
+	}
+	if sess.PromptCount != 3 || sess.FirstPrompt != "first" {
+		t.Errorf("PromptCount, FirstPrompt = %d, %q, want 3, %q", sess.PromptCount, sess.FirstPrompt, "first")
+	}
+	if !slices.Equal(sess.Tags, []string{"JIRA-1"}) {
+		t.Errorf("Tags = %q", sess.Tags)
+	}
+	if !slices.Equal(sess.Links, []string{"https://jira.example.com/browse/JIRA-1"}) {
+		t.Errorf("Links = %q", sess.Links)
+	}
+	prompts, err := s.GetPrompts("keep", 10)
+	if err != nil {
+		t.Fatalf("GetPrompts: %v", err)
+	}
+	var texts []string
+	for _, p := range prompts {
+		texts = append(texts, p.Text)
+	}
+	if want := []string{"third", "second", "first"}; !slices.Equal(texts, want) {
+		t.Errorf("prompts = %q, want %q", texts, want)
+	}
+	if _, err := s.GetSession("dup"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("duplicate still present: %v", err)
+	}
+
+	if err := s.MergeSessions("keep", "missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("MergeSessions(missing) = %v, want sql.ErrNoRows", err)
+	}
+	if err := s.MergeSessions("keep", "keep"); err == nil {
+		t.Error("merging a session into itself should fail")
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	s := testStore(t)
+	for _, sess := range []Session{
+		// a and b overlap; c starts 30s after b ends: one group kept as c.
+		{ID: "a", Project: "/p", CWD: "/p", StartedAt: 0, LastActivity: 100_000},
+		{ID: "b", Project: "/p", CWD: "/p", StartedAt: 50_000, LastActivity: 200_000},
+		{ID: "c", Project: "/p", CWD: "/p", StartedAt: 230_000, LastActivity: 300_000},
+		// Much later: on its own.
+		{ID: "d", Project: "/p", CWD: "/p", StartedAt: 10_000_000, LastActivity: 10_100_000},
+		// Same times in another project.
+		{ID: "e", Project: "/q", CWD: "/q", StartedAt: 0, LastActivity: 100_000},
+		// Parallel sessions on different terminals.
+		{ID: "f", Project: "/r", CWD: "/r", StartedAt: 0, LastActivity: 100_000},
+		{ID: "g", Project: "/r", CWD: "/r", StartedAt: 10_000, LastActivity: 100_000},
+	} {
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	if err := s.SetTerminal("f", "pts/1", ""); err != nil {
+		t.Fatalf("SetTerminal: %v", err)
+	}
+	if err := s.SetTerminal("g", "pts/2", ""); err != nil {
+		t.Fatalf("SetTerminal: %v", err)
+	}
+
+	groups, err := s.FindDuplicates()
+	if err != nil {
+		t.Fatalf("FindDuplicates: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("FindDuplicates = %d groups, want 1: %+v", len(groups), groups)
+	}
+	g := groups[0]
+	var dups []string
+	for _, d := range g.Duplicates {
+		dups = append(dups, d.ID)
+	}
+	if g.Keep.ID != "c" || !slices.Equal(dups, []string{"a", "b"}) {
+		t.Errorf("group = keep %s, duplicates %q; want keep c, duplicates [a b]", g.Keep.ID, dups)
+	}
+}
@@ -0,0 +1,406 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+)
+
+// migration upgrades the schema by one version. Migrations must be idempotent:
+// two hooks opening an old database at the same time may both apply one.
+type migration func(tx *sql.Tx) error
+
+// migrations are applied in order; the schema version stored in
+// PRAGMA user_version is the number of migrations applied. Append new
+// migrations to the end and never reorder or edit released ones.
+var migrations = []migration{
+	// 1: initial schema
+	func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS sessions (
+				id TEXT PRIMARY KEY,
+				project TEXT NOT NULL,
+				cwd TEXT NOT NULL,
+				started_at INTEGER NOT NULL,
+				last_activity INTEGER NOT NULL,
+				pid INTEGER,
+				active INTEGER DEFAULT 0,
+				model TEXT DEFAULT ''
+			);
+
+			CREATE TABLE IF NOT EXISTS prompts (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+				prompt TEXT NOT NULL,
+				timestamp INTEGER NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_sessions_project ON sessions(project);
+			CREATE INDEX IF NOT EXISTS idx_sessions_active ON sessions(active);
+			CREATE INDEX IF NOT EXISTS idx_sessions_last_activity ON sessions(last_activity DESC);
+			CREATE INDEX IF NOT EXISTS idx_prompts_session ON prompts(session_id, timestamp DESC);
+		`)
+		return err
+	},
+	// 2: per-source activity timestamps
+	func(tx *sql.Tx) error {
+		for _, col := range []string{"last_prompt_at", "last_tool_at", "last_resume_at"} {
+			if err := addColumn(tx, "sessions", col, "INTEGER DEFAULT 0"); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	// 3: working directory history
+	func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS cwd_history (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+				cwd TEXT NOT NULL,
+				timestamp INTEGER NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_cwd_history_session ON cwd_history(session_id, timestamp DESC);
+		`)
+		return err
+	},
+	// 4: imported (read-only) sessions with notes and a transcript copy
+	func(tx *sql.Tx) error {
+		for _, col := range []struct{ name, def string }{
+			{"read_only", "INTEGER DEFAULT 0"},
+			{"notes", "TEXT DEFAULT ''"},
+			{"transcript_path", "TEXT DEFAULT ''"},
+		} {
+			if err := addColumn(tx, "sessions", col.name, col.def); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	// 5: counters for rejected or repaired hook events
+	func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS hook_anomalies (
+				event TEXT NOT NULL,
+				reason TEXT NOT NULL,
+				count INTEGER NOT NULL DEFAULT 0,
+				last_seen INTEGER NOT NULL,
+				PRIMARY KEY (event, reason)
+			);
+		`)
+		return err
+	},
+	// 6: sessions waiting on the user (Notification hook)
+	func(tx *sql.Tx) error {
+		for _, col := range []struct{ name, def string }{
+			{"awaiting_since", "INTEGER DEFAULT 0"},
+			{"awaiting_message", "TEXT DEFAULT ''"},
+		} {
+			if err := addColumn(tx, "sessions", col.name, col.def); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	// 7: controlling terminal captured at session start
+	func(tx *sql.Tx) error {
+		for _, col := range []string{"tty", "terminal"} {
+			if err := addColumn(tx, "sessions", col, "TEXT DEFAULT ''"); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	// 8: time actually worked, excluding idle gaps
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "sessions", "worked_ms", "INTEGER DEFAULT 0")
+	},
+	// 9: git branch and tags such as ticket IDs extracted from it
+	func(tx *sql.Tx) error {
+		if err := addColumn(tx, "sessions", "branch", "TEXT DEFAULT ''"); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS session_tags (
+				session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+				tag TEXT NOT NULL COLLATE NOCASE,
+				created_at INTEGER NOT NULL,
+				PRIMARY KEY (session_id, tag)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_session_tags_tag ON session_tags(tag);
+		`)
+		return err
+	},
+	// 10: user-given session outcome labels
+	func(tx *sql.Tx) error {
+		for _, col := range []struct{ name, def string }{
+			{"outcome", "TEXT DEFAULT ''"},
+			{"outcome_note", "TEXT DEFAULT ''"},
+			{"outcome_at", "INTEGER DEFAULT 0"},
+			{"outcome_requested", "INTEGER DEFAULT 0"},
+		} {
+			if err := addColumn(tx, "sessions", col.name, col.def); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	// 11: machine and user a session runs on, for shared databases
+	func(tx *sql.Tx) error {
+		for _, col := range []string{"host", "user"} {
+			if err := addColumn(tx, "sessions", col, "TEXT DEFAULT ''"); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	// 12: permission mode (plan, acceptEdits, ...) at the last hook event
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "sessions", "permission_mode", "TEXT DEFAULT ''")
+	},
+	// 13: prompts sent and the first of them, which the prompt cap evicts
+	func(tx *sql.Tx) error {
+		if err := addColumn(tx, "sessions", "prompt_count", "INTEGER DEFAULT 0"); err != nil {
+			return err
+		}
+		if err := addColumn(tx, "sessions", "first_prompt", "TEXT DEFAULT ''"); err != nil {
+			return err
+		}
+		// Earlier prompts are gone; the best guess is what is still stored
+		_, err := tx.Exec(`
+			UPDATE sessions SET
+				prompt_count = (SELECT COUNT(*) FROM prompts WHERE session_id = sessions.id),
+				first_prompt = COALESCE((SELECT prompt FROM prompts WHERE session_id = sessions.id
+					ORDER BY timestamp, id LIMIT 1), '')
+		`)
+		return err
+	},
+	// 14: short title from the transcript summary or generated with claude -p
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "sessions", "title", "TEXT DEFAULT ''")
+	},
+	// 15: subagent and non-interactive (claude -p) sessions
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "sessions", "headless", "INTEGER DEFAULT 0")
+	},
+	// 16: claude arguments of the session's own, as a JSON array
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "sessions", "resume_args", "TEXT DEFAULT ''")
+	},
+	// 17: when RefreshActive first found the session's PID dead, 0 if alive
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "sessions", "last_pid_check", "INTEGER DEFAULT 0")
+	},
+	// 18: links to issues, tickets and pull requests
+	func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS session_links (
+				session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+				url TEXT NOT NULL,
+				created_at INTEGER NOT NULL,
+				PRIMARY KEY (session_id, url)
+			);
+		`)
+		return err
+	},
+	// 19: times a prompt was sent in a row, e.g. retried after an error
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "prompts", "occurrences", "INTEGER NOT NULL DEFAULT 1")
+	},
+	// 20: files sessions changed, read from their transcripts, for cst which
+	func(tx *sql.Tx) error {
+		if err := addColumn(tx, "sessions", "files_indexed_at", "INTEGER DEFAULT 0"); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS session_files (
+				session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+				path TEXT NOT NULL,
+				edits INTEGER NOT NULL DEFAULT 1,
+				last_at INTEGER NOT NULL DEFAULT 0,
+				PRIMARY KEY (session_id, path)
+			);
+			CREATE INDEX IF NOT EXISTS idx_session_files_path ON session_files(path);
+		`)
+		return err
+	},
+	// 21: tokens and tool calls read from transcripts by cst daemon, and how
+	// far it has read
+	func(tx *sql.Tx) error {
+		for _, col := range []string{"input_tokens", "output_tokens", "cache_read_tokens", "tool_uses", "tail_offset"} {
+			if err := addColumn(tx, "sessions", col, "INTEGER DEFAULT 0"); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	// 22: why the session ended, from SessionEnd, e.g. "clear"
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "sessions", "end_reason", "TEXT DEFAULT ''")
+	},
+	// 23: sessions already running in the project when the session started
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "sessions", "concurrent_with", "TEXT DEFAULT ''")
+	},
+	// 24: the whole text of long prompts, compressed, see storePrompt
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "prompts", "full_text", "BLOB")
+	},
+	// 25: the whole text of long prompts, as it is, see storePrompt
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "prompts", "prompt_full", "TEXT")
+	},
+	// 26: the subagent a prompt was sent to, from the hook's agent_type
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "prompts", "agent", "TEXT NOT NULL DEFAULT ''")
+	},
+	// 27: tool calls per session, from the PreToolUse and PostToolUse hooks
+	// when tool_stats is on
+	func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS tool_stats (
+				session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+				tool TEXT NOT NULL,
+				calls INTEGER NOT NULL DEFAULT 0,
+				completed INTEGER NOT NULL DEFAULT 0,
+				last_at INTEGER NOT NULL DEFAULT 0,
+				PRIMARY KEY (session_id, tool)
+			);
+		`)
+		return err
+	},
+	// 28: tool calls that failed, from PostToolUse and PostToolUseFailure
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "sessions", "tool_errors", "INTEGER DEFAULT 0")
+	},
+}
+
+// LatestSchemaVersion is the schema version this build migrates databases to.
+var LatestSchemaVersion = len(migrations)
+
+// SchemaVersion returns the schema version of the open database.
+func (s *Store) SchemaVersion() (int, error) {
+	ctx, cancel := s.opContext()
+	defer cancel()
+	var version int
+	err := s.db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&version)
+	return version, err
+}
+
+// ReadSchemaVersion returns the schema version of the database at dbPath
+// without creating or migrating it. Returns 0 if the database does not exist.
+func ReadSchemaVersion(dbPath string) (int, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?mode=ro&_pragma=busy_timeout(%d)", dbPath, busyTimeout))
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = db.Close() }()
+
+	var version int
+	err = db.QueryRow(`PRAGMA user_version`).Scan(&version)
+	return version, err
+}
+
+// MigratedFrom returns the schema version the database had before Open
+// applied pending migrations.
+func (s *Store) MigratedFrom() int {
+	return s.migratedFrom
+}
+
+// migrate applies all pending migrations, each in its own transaction, and
+// returns the schema version found before migrating. Transactions begin
+// IMMEDIATE (see Open) and re-read the version inside, so that when several
+// processes open the database after an upgrade, each migration runs once:
+// the others wait for the lock, then skip what it applied.
+func (s *Store) migrate() (int, error) {
+	from, err := s.SchemaVersion()
+	if err != nil {
+		return 0, err
+	}
+	if from > LatestSchemaVersion {
+		return from, fmt.Errorf("database schema version %d is newer than supported version %d; upgrade cst", from, LatestSchemaVersion)
+	}
+
+	for from < LatestSchemaVersion {
+		done, err := s.migrateStep()
+		if err != nil {
+			return from, err
+		}
+		if done {
+			break
+		}
+	}
+	return from, nil
+}
+
+// migrateStep applies the migration after the current schema version, and
+// reports whether there was none left to apply.
+func (s *Store) migrateStep() (done bool, err error) {
+	ctx, cancel := s.opContext()
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil || done {
+			_ = tx.Rollback()
+		}
+	}()
+	var v int
+	if err := tx.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&v); err != nil {
+		return false, err
+	}
+	if v >= LatestSchemaVersion {
+		return true, nil
+	}
+	if err := migrations[v](tx); err != nil {
+		return false, fmt.Errorf("migration %d: %w", v+1, err)
+	}
+	// PRAGMA does not accept bound parameters
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`PRAGMA user_version = %d`, v+1)); err != nil {
+		return false, fmt.Errorf("migration %d: set version: %w", v+1, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("migration %d: commit: %w", v+1, err)
+	}
+	return false, nil
+}
+
+// addColumn adds a column to table unless it already exists.
+func addColumn(tx *sql.Tx, table, column, def string) error {
+	rows, err := tx.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	exists := false
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		if name == column {
+			exists = true
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	_, err = tx.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + def)
+	if err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestOpenMigratesToLatest(t *testing.T) {
+	s := testStore(t)
+
+	version, err := s.SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion: %v", err)
+	}
+	if version != LatestSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", version, LatestSchemaVersion)
+	}
+	if s.MigratedFrom() != 0 {
+		t.Errorf("MigratedFrom = %d, want 0", s.MigratedFrom())
+	}
+}
+
+func TestOpenUpgradesUnversionedSchema(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "old.db")
+
+	// Databases created before migrations existed have user_version 0
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE sessions (
+		id TEXT PRIMARY KEY, project TEXT NOT NULL, cwd TEXT NOT NULL,
+		started_at INTEGER NOT NULL, last_activity INTEGER NOT NULL,
+		pid INTEGER, active INTEGER DEFAULT 0, model TEXT DEFAULT ''
+	); INSERT INTO sessions (id, project, cwd, started_at, last_activity) VALUES ('old', '/proj', '/proj', 1, 1)`)
+	_ = db.Close()
+	if err != nil {
+		t.Fatalf("create old schema: %v", err)
+	}
+
+	s, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	sessions, err := s.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != "old" {
+		t.Fatalf("unexpected sessions after upgrade: %+v", sessions)
+	}
+	if err := s.RecordActivity("old", "/proj", ActivityTool, 2); err != nil {
+		t.Fatalf("RecordActivity on upgraded schema: %v", err)
+	}
+}
+
+func TestOpenIsIdempotent(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	for i := 0; i < 2; i++ {
+		s, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open #%d: %v", i+1, err)
+		}
+		if i == 1 && s.MigratedFrom() != LatestSchemaVersion {
+			t.Errorf("MigratedFrom = %d, want %d", s.MigratedFrom(), LatestSchemaVersion)
+		}
+		_ = s.Close()
+	}
+}
+
+func TestOpenRejectsNewerSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "future.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	_, err = db.Exec(`PRAGMA user_version = 9999`)
+	_ = db.Close()
+	if err != nil {
+		t.Fatalf("set user_version: %v", err)
+	}
+
+	if s, err := Open(dbPath); err == nil {
+		_ = s.Close()
+		t.Fatal("expected error opening a database with a newer schema")
+	}
+}
+
+func TestReadSchemaVersion(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.db")
+
+	version, err := ReadSchemaVersion(dbPath)
+	if err != nil {
+		t.Fatalf("ReadSchemaVersion missing: %v", err)
+	}
+	if version != 0 {
+		t.Errorf("version of missing db = %d, want 0", version)
+	}
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Fatal("ReadSchemaVersion should not create the database")
+	}
+
+	s, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	_ = s.Close()
+
+	version, err = ReadSchemaVersion(dbPath)
+	if err != nil {
+		t.Fatalf("ReadSchemaVersion: %v", err)
+	}
+	if version != LatestSchemaVersion {
+		t.Errorf("version = %d, want %d", version, LatestSchemaVersion)
+	}
+}
+
+func TestOpenConcurrently(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	// Hooks of several sessions open the database at once after an
+	// upgrade; each migration must run exactly once
+	const opens = 8
+	errs := make(chan error, opens)
+	var wg sync.WaitGroup
+	for range opens {
+		wg.Go(func() {
+			s, err := Open(dbPath)
+			if err == nil {
+				err = s.Close()
+			}
+			errs <- err
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Open: %v", err)
+		}
+	}
+
+	version, err := ReadSchemaVersion(dbPath)
+	if err != nil {
+		t.Fatalf("ReadSchemaVersion: %v", err)
+	}
+	if version != LatestSchemaVersion {
+		t.Errorf("schema version = %d, want %d", version, LatestSchemaVersion)
+	}
+}
+
+func TestOpenContextCanceled(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if s, err := OpenContext(ctx, dbPath); !errors.Is(err, context.Canceled) {
+		if err == nil {
+			_ = s.Close()
+		}
+		t.Fatalf("OpenContext with a canceled context = %v, want context.Canceled", err)
+	}
+	if version, err := ReadSchemaVersion(dbPath); err != nil || version != 0 {
+		t.Errorf("schema version = %d, %v; want no migrations", version, err)
+	}
+}
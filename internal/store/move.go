@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+)
+
+// MoveProject rewrites every recorded path that is from or inside it to the
+// same path under to, for a repository or home directory that moved: the
+// project and working directory of sessions, and their working directory
+// history. It returns how many sessions had their project moved.
+func (s *Store) MoveProject(from, to string) (moved int64, err error) {
+	from, to = filepath.Clean(from), filepath.Clean(to)
+	defer func(start time.Time) { s.observe("MoveProject", "UPDATE sessions SET project ...", start, moved) }(time.Now())
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if moved, err = movePaths(ctx, tx, "sessions", "project", from, to); err != nil {
+		return 0, err
+	}
+	if _, err := movePaths(ctx, tx, "sessions", "cwd", from, to); err != nil {
+		return 0, err
+	}
+	if _, err := movePaths(ctx, tx, "cwd_history", "cwd", from, to); err != nil {
+		return 0, err
+	}
+	return moved, tx.Commit()
+}
+
+// movePaths rewrites the paths in column of table that are from or inside
+// it to the same paths under to. SQLite's substr counts characters, not
+// bytes.
+func movePaths(ctx context.Context, tx *sql.Tx, table, column, from, to string) (int64, error) {
+	prefix := from + string(filepath.Separator)
+	query := fmt.Sprintf(`UPDATE %[1]s SET %[2]s = ? || substr(%[2]s, ?)
+		WHERE %[2]s = ? OR substr(%[2]s, 1, ?) = ?`, table, column)
+	n := utf8.RuneCountInString(from)
+	res, err := tx.ExecContext(ctx, query, to, n+1, from, n+1, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("move %s.%s: %w", table, column, err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,53 @@
+package store
+
+import "testing"
+
+func TestMoveProject(t *testing.T) {
+	s := testStore(t)
+	for _, sess := range []Session{
+		{ID: "root", Project: "/src/api", CWD: "/src/api/pkg", StartedAt: 100, LastActivity: 100},
+		{ID: "sub", Project: "/src/api/tools", CWD: "/src/api/tools", StartedAt: 100, LastActivity: 100},
+		{ID: "sibling", Project: "/src/api-v2", CWD: "/src/api-v2", StartedAt: 100, LastActivity: 100},
+		{ID: "ünï", Project: "/src/ünï", CWD: "/src/ünï/x", StartedAt: 100, LastActivity: 100},
+	} {
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	if err := s.AddCWD("root", "/src/api/pkg", 100); err != nil {
+		t.Fatalf("AddCWD: %v", err)
+	}
+
+	moved, err := s.MoveProject("/src/api/", "/code/api")
+	if err != nil {
+		t.Fatalf("MoveProject: %v", err)
+	}
+	if moved != 2 {
+		t.Errorf("moved = %d, want 2", moved)
+	}
+	if _, err := s.MoveProject("/src/ünï", "/src/uni"); err != nil {
+		t.Fatalf("MoveProject: %v", err)
+	}
+
+	for id, want := range map[string][2]string{
+		"root":    {"/code/api", "/code/api/pkg"},
+		"sub":     {"/code/api/tools", "/code/api/tools"},
+		"sibling": {"/src/api-v2", "/src/api-v2"},
+		"ünï":     {"/src/uni", "/src/uni/x"},
+	} {
+		got, err := s.GetSession(id)
+		if err != nil {
+			t.Fatalf("GetSession(%s): %v", id, err)
+		}
+		if got.Project != want[0] || got.CWD != want[1] {
+			t.Errorf("%s: project, cwd = %q, %q, want %q, %q", id, got.Project, got.CWD, want[0], want[1])
+		}
+	}
+	history, err := s.GetCWDHistory("root")
+	if err != nil {
+		t.Fatalf("GetCWDHistory: %v", err)
+	}
+	if len(history) != 1 || history[0].CWD != "/code/api/pkg" {
+		t.Errorf("cwd history = %+v, want /code/api/pkg", history)
+	}
+}
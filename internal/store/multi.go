@@ -0,0 +1,104 @@
+package store
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// Secondary is a read-only store whose sessions are listed alongside those
+// of the local store, e.g. a database copied from another machine.
+type Secondary struct {
+	Name  string
+	Store *Store
+}
+
+// ListMerged lists the sessions matching opts from primary and every
+// secondary, ordered and paged as ListSessions does. Secondary sessions have
+// Source set and are marked ReadOnly; a session ID already listed from an
+// earlier store is skipped, so the local copy of a session wins.
+func ListMerged(primary *Store, secondaries []Secondary, opts ListOptions) ([]Session, error) {
+	if len(secondaries) == 0 {
+		return primary.ListSessions(opts)
+	}
+
+	// Each store can contribute at most a full page, wherever it lands.
+	perStore := opts
+	perStore.Offset = 0
+	if opts.Limit > 0 {
+		perStore.Limit = opts.Limit + max(opts.Offset, 0)
+	}
+
+	sessions, err := primary.ListSessions(perStore)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(sessions))
+	for _, sess := range sessions {
+		seen[sess.ID] = true
+	}
+	for _, sec := range secondaries {
+		more, err := sec.Store.ListSessions(perStore)
+		if err != nil {
+			return nil, fmt.Errorf("store %s: %w", sec.Name, err)
+		}
+		for _, sess := range more {
+			if seen[sess.ID] {
+				continue
+			}
+			seen[sess.ID] = true
+			sess.Source = sec.Name
+			sess.ReadOnly = true
+			sessions = append(sessions, sess)
+		}
+	}
+
+	slices.SortFunc(sessions, func(a, b Session) int {
+		if c := cmp.Compare(b.LastActivity, a.LastActivity); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	sessions = sessions[min(max(opts.Offset, 0), len(sessions)):]
+	if opts.Limit > 0 && len(sessions) > opts.Limit {
+		sessions = sessions[:opts.Limit]
+	}
+	return sessions, nil
+}
+
+// ProjectsMerged lists the projects of primary and every secondary as
+// Projects does, adding up the sessions of a project found in several.
+func ProjectsMerged(primary *Store, secondaries []Secondary, excludeHeadless bool) ([]ProjectSummary, error) {
+	projects, err := primary.Projects(excludeHeadless)
+	if err != nil || len(secondaries) == 0 {
+		return projects, err
+	}
+	index := make(map[string]int, len(projects))
+	for i, p := range projects {
+		index[p.Project] = i
+	}
+	for _, sec := range secondaries {
+		more, err := sec.Store.Projects(excludeHeadless)
+		if err != nil {
+			return nil, fmt.Errorf("store %s: %w", sec.Name, err)
+		}
+		for _, p := range more {
+			i, ok := index[p.Project]
+			if !ok {
+				index[p.Project] = len(projects)
+				projects = append(projects, p)
+				continue
+			}
+			projects[i].Sessions += p.Sessions
+			projects[i].Active += p.Active
+			projects[i].LastActivity = max(projects[i].LastActivity, p.LastActivity)
+		}
+	}
+	slices.SortFunc(projects, func(a, b ProjectSummary) int {
+		if c := cmp.Compare(b.LastActivity, a.LastActivity); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Project, b.Project)
+	})
+	return projects, nil
+}
@@ -0,0 +1,173 @@
+package store
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestListMerged(t *testing.T) {
+	primary := testStore(t)
+
+	remotePath := filepath.Join(t.TempDir(), "remote.db")
+	remote, err := Open(remotePath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for _, tc := range []struct {
+		s  *Store
+		id string
+		ts int64
+	}{
+		{primary, "local-1", 1000},
+		{primary, "local-2", 3000},
+		{primary, "shared", 5000},
+		{remote, "remote-1", 2000},
+		{remote, "remote-2", 4000},
+		{remote, "shared", 6000},
+	} {
+		sess := Session{ID: tc.id, Project: "/p", CWD: "/p", StartedAt: tc.ts, LastActivity: tc.ts}
+		if err := tc.s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession %s: %v", tc.id, err)
+		}
+	}
+	if err := remote.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	ro, err := OpenReadOnly(remotePath)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	t.Cleanup(func() { _ = ro.Close() })
+	if err := ro.DeleteSession("remote-1"); err == nil {
+		t.Error("DeleteSession on a read-only store succeeded")
+	}
+
+	secondaries := []Secondary{{Name: "laptop", Store: ro}}
+	sessions, err := ListMerged(primary, secondaries, ListOptions{})
+	if err != nil {
+		t.Fatalf("ListMerged: %v", err)
+	}
+	var got []string
+	for _, sess := range sessions {
+		got = append(got, sess.ID+"@"+sess.Source)
+		if (sess.Source != "") != sess.ReadOnly {
+			t.Errorf("%s: Source = %q but ReadOnly = %v", sess.ID, sess.Source, sess.ReadOnly)
+		}
+	}
+	// The local copy of "shared" wins even though the remote one is newer.
+	want := []string{"shared@", "remote-2@laptop", "local-2@", "remote-1@laptop", "local-1@"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ListMerged = %v, want %v", got, want)
+	}
+
+	page, err := ListMerged(primary, secondaries, ListOptions{Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatalf("ListMerged page: %v", err)
+	}
+	if len(page) != 2 || page[0].ID != "remote-2" || page[1].ID != "local-2" {
+		t.Errorf("page = %v, want remote-2, local-2", page)
+	}
+}
+
+func TestOpenReadOnlyRejectsMissingAndOldSchema(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := OpenReadOnly(filepath.Join(dir, "missing.db")); err == nil {
+		t.Error("expected error for a missing database")
+	}
+
+	path := filepath.Join(dir, "old.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := s.db.Exec(`PRAGMA user_version = 1`); err != nil {
+		t.Fatalf("set user_version: %v", err)
+	}
+	_ = s.Close()
+	if _, err := OpenReadOnly(path); err == nil {
+		t.Error("expected error for an older schema")
+	}
+}
+
+func TestReadOnlyStoreSkipsRefreshActive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "copy.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	pid := 4242
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/p", CWD: "/p", PID: &pid, Active: true}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	_ = s.Close()
+
+	ro, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	t.Cleanup(func() { _ = ro.Close() })
+	if !ro.ReadOnly() {
+		t.Error("ReadOnly() = false for a store opened with OpenReadOnly")
+	}
+	checked := false
+	if err := ro.RefreshActive(func(int) bool { checked = true; return false }); err != nil {
+		t.Fatalf("RefreshActive: %v", err)
+	}
+	if checked {
+		t.Error("RefreshActive checked PIDs of a read-only store")
+	}
+	sess, err := ro.GetSession("s1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if !sess.Active {
+		t.Error("session was deactivated in a read-only store")
+	}
+}
+
+func TestProjectsMerged(t *testing.T) {
+	primary := testStore(t)
+	remote := testStore(t)
+	for _, tc := range []struct {
+		s        *Store
+		id, proj string
+		ts       int64
+		active   bool
+		headless bool
+	}{
+		{s: primary, id: "a1", proj: "/a", ts: 1000, active: true},
+		{s: primary, id: "a2", proj: "/a", ts: 3000},
+		{s: primary, id: "b1", proj: "/b", ts: 2000},
+		{s: primary, id: "h1", proj: "/h", ts: 9000, headless: true},
+		{s: remote, id: "b2", proj: "/b", ts: 5000, active: true},
+		{s: remote, id: "c1", proj: "/c", ts: 4000},
+	} {
+		sess := Session{ID: tc.id, Project: tc.proj, CWD: tc.proj, StartedAt: tc.ts, LastActivity: tc.ts, Active: tc.active}
+		if err := tc.s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession %s: %v", tc.id, err)
+		}
+		if err := tc.s.SetHeadless(tc.id, tc.headless); err != nil {
+			t.Fatalf("SetHeadless: %v", err)
+		}
+	}
+
+	projects, err := primary.Projects(false)
+	if err != nil {
+		t.Fatalf("Projects: %v", err)
+	}
+	want := []ProjectSummary{{"/h", 1, 0, 9000}, {"/a", 2, 1, 3000}, {"/b", 1, 0, 2000}}
+	if !slices.Equal(projects, want) {
+		t.Errorf("Projects = %v, want %v", projects, want)
+	}
+
+	projects, err = ProjectsMerged(primary, []Secondary{{Name: "laptop", Store: remote}}, true)
+	if err != nil {
+		t.Fatalf("ProjectsMerged: %v", err)
+	}
+	want = []ProjectSummary{{"/b", 2, 1, 5000}, {"/c", 1, 0, 4000}, {"/a", 2, 1, 3000}}
+	if !slices.Equal(projects, want) {
+		t.Errorf("ProjectsMerged = %v, want %v", projects, want)
+	}
+}
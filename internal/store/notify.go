@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchChanges reports writes to the database, such as those of hooks in
+// other processes, until ctx is done. It watches the database file and its
+// write-ahead log, which every commit appends to; reads only touch the
+// shared-memory index and go unreported. Writes arriving while an earlier
+// one is still unreceived are reported once, so a receiver reloading on
+// each report never falls behind.
+func (s *Store) WatchChanges(ctx context.Context) (<-chan struct{}, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// The directory is watched, since the log is removed when the last
+	// connection closes and created again by the next
+	if err := w.Add(filepath.Dir(s.path)); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	names := map[string]bool{filepath.Clean(s.path): true, filepath.Clean(s.path) + "-wal": true}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer func() { _ = w.Close() }()
+		defer close(changes)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if !names[filepath.Clean(ev.Name)] || !ev.Has(fsnotify.Write|fsnotify.Create) {
+					continue
+				}
+				select {
+				case changes <- struct{}{}:
+				default: // a report is pending already
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				slog.Warn("watching the database failed", "err", err)
+			}
+		}
+	}()
+	return changes, nil
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatchChanges(t *testing.T) {
+	s := testStore(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	changes, err := s.WatchChanges(ctx)
+	if err != nil {
+		t.Fatalf("WatchChanges: %v", err)
+	}
+
+	// A write from another connection, as a hook's would be, is reported
+	other, err := Open(s.path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = other.Close() }()
+	now := time.Now().UnixMilli()
+	if err := other.UpsertSession(Session{ID: "s1", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("write not reported")
+	}
+
+	// Reads are not, once the write's own events are drained
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case <-changes:
+	default:
+	}
+	if _, err := s.ListAll(); err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	select {
+	case <-changes:
+		t.Error("read reported as a change")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Error("change reported after cancel")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("changes not closed after cancel")
+	}
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"bytes"
+	"compress/flate"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultPromptLen is the length in bytes prompts are cut to for display,
+// and stored at unless the prompt storage keeps them whole.
+const DefaultPromptLen = 200
+
+// PromptStorage is how a store keeps prompts longer than its prompt length.
+type PromptStorage string
+
+const (
+	// PromptsTruncated cuts them to the prompt length, the default.
+	PromptsTruncated PromptStorage = "truncate"
+	// PromptsFull keeps them whole in prompt_full, where search reads them.
+	PromptsFull PromptStorage = "full"
+	// PromptsCompressed keeps them whole in full_text, compressed with
+	// DEFLATE; search only sees the cut text. DEFLATE is in the standard
+	// library, where zstd or snappy would add a dependency for little gain
+	// on text as short as prompts.
+	PromptsCompressed PromptStorage = "compressed"
+)
+
+// SetPromptStorage sets how prompts added from now on are stored, and the
+// length in bytes they are cut to for display and listings. Unknown modes
+// truncate, and non-positive lengths restore DefaultPromptLen.
+func (s *Store) SetPromptStorage(mode PromptStorage, length int) {
+	s.promptStorage = mode
+	if length <= 0 {
+		length = DefaultPromptLen
+	}
+	s.promptLen = length
+}
+
+// storedPrompt is the columns a prompt is stored in: text, cut to the
+// prompt length for listings, and for longer prompts, depending on the
+// prompt storage, the whole text in full or compressed.
+type storedPrompt struct {
+	text       string
+	full       any // string or NULL
+	compressed any // []byte or NULL
+}
+
+// storePrompt returns the columns a prompt is stored in.
+func (s *Store) storePrompt(text string) (storedPrompt, error) {
+	length := s.promptLen
+	if length <= 0 {
+		length = DefaultPromptLen
+	}
+	if len(text) <= length {
+		return storedPrompt{text: text}, nil
+	}
+	// A cut through a multi-byte character leaves invalid bytes, dropped here
+	sp := storedPrompt{text: strings.ToValidUTF8(text[:max(length-3, 0)], "") + "..."}
+	switch s.promptStorage {
+	case PromptsFull:
+		sp.full = text
+	case PromptsCompressed:
+		var b bytes.Buffer
+		w, err := flate.NewWriter(&b, flate.BestCompression)
+		if err != nil {
+			return sp, err
+		}
+		if _, err := io.WriteString(w, text); err != nil {
+			return sp, err
+		}
+		if err := w.Close(); err != nil {
+			return sp, fmt.Errorf("compress prompt: %w", err)
+		}
+		sp.compressed = b.Bytes()
+	}
+	return sp, nil
+}
+
+// promptText returns the whole text of a prompt from its stored columns.
+func promptText(text string, full sql.NullString, compressed []byte) (string, error) {
+	switch {
+	case full.Valid:
+		return full.String, nil
+	case compressed != nil:
+		data, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+		if err != nil {
+			return "", fmt.Errorf("decompress prompt: %w", err)
+		}
+		return string(data), nil
+	}
+	return text, nil
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStorePrompt(t *testing.T) {
+	s := testStore(t)
+	short := strings.Repeat("a", DefaultPromptLen)
+	if sp, err := s.storePrompt(short); err != nil || sp.text != short || sp.full != nil || sp.compressed != nil {
+		t.Errorf("storePrompt(short) = %+v, %v, want it as it is", sp, err)
+	}
+
+	// A cut through a multi-byte character drops what is left of it
+	long := strings.Repeat("a", DefaultPromptLen-4) + strings.Repeat("é", 500)
+	want := strings.Repeat("a", DefaultPromptLen-4) + "..."
+	for _, mode := range []PromptStorage{PromptsTruncated, PromptsFull, PromptsCompressed} {
+		s.SetPromptStorage(mode, 0)
+		sp, err := s.storePrompt(long)
+		if err != nil {
+			t.Fatalf("%s: storePrompt: %v", mode, err)
+		}
+		if sp.text != want {
+			t.Errorf("%s: text = %q, want %q", mode, sp.text, want)
+		}
+		full, _ := sp.full.(string)
+		compressed, _ := sp.compressed.([]byte)
+		text, err := promptText(sp.text, sql.NullString{String: full, Valid: sp.full != nil}, compressed)
+		if err != nil {
+			t.Fatalf("%s: promptText: %v", mode, err)
+		}
+		if whole := mode != PromptsTruncated; (text == long) != whole {
+			t.Errorf("%s: promptText = %d bytes, whole = %v", mode, len(text), whole)
+		}
+		if mode == PromptsCompressed && len(compressed) >= len(long) {
+			t.Errorf("compressed to %d bytes from %d", len(compressed), len(long))
+		}
+	}
+
+	s.SetPromptStorage(PromptsTruncated, 20)
+	if sp, _ := s.storePrompt(long); sp.text != strings.Repeat("a", 17)+"..." {
+		t.Errorf("text cut to 20 = %q", sp.text)
+	}
+}
+
+func TestAddPromptCompressed(t *testing.T) {
+	s := testStore(t)
+	s.SetPromptStorage(PromptsCompressed, 0)
+	now := time.Now().UnixMilli()
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+
+	long := strings.Repeat("explain the retry loop ", 100)
+	for i, text := range []string{long, long, long + "again"} {
+		if err := s.AddPrompt("s1", text, now+int64(i)); err != nil {
+			t.Fatalf("AddPrompt: %v", err)
+		}
+	}
+
+	// GetPrompts reads them whole; a resend of the same one is counted
+	prompts, err := s.GetPrompts("s1", 10)
+	if err != nil {
+		t.Fatalf("GetPrompts: %v", err)
+	}
+	if len(prompts) != 2 || prompts[0].Text != long+"again" || prompts[1].Text != long || prompts[1].Occurrences != 2 {
+		t.Fatalf("GetPrompts = %d prompts, want the two, the first sent twice", len(prompts))
+	}
+
+	// Listings see the cut text
+	sessions, err := s.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	if got := sessions[0]; len(got.LastPrompt) != DefaultPromptLen || len(got.FirstPrompt) != DefaultPromptLen {
+		t.Errorf("last and first prompts are %d and %d bytes, want %d", len(got.LastPrompt), len(got.FirstPrompt), DefaultPromptLen)
+	}
+}
+
+func TestAddPromptFull(t *testing.T) {
+	s := testStore(t)
+	s.SetPromptStorage(PromptsFull, 50)
+	now := time.Now().UnixMilli()
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	long := strings.Repeat("explain the retry loop ", 10) + "in the uploader"
+	if err := s.AddPrompt("s1", long, now); err != nil {
+		t.Fatalf("AddPrompt: %v", err)
+	}
+
+	sessions, err := s.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	if got := sessions[0].LastPrompt; len(got) != 50 {
+		t.Errorf("last prompt is %d bytes, want 50", len(got))
+	}
+
+	// Search and history see the whole prompt
+	found, err := s.QuerySessions([]Filter{{"prompt", "~", "uploader"}}, "", 0)
+	if err != nil || len(found) != 1 {
+		t.Errorf("QuerySessions(prompt ~ uploader) = %d sessions, %v, want the one", len(found), err)
+	}
+	history, err := s.PromptHistory([]string{"s1"}, 1)
+	if err != nil || len(history["s1"]) != 1 || history["s1"][0] != long {
+		t.Errorf("PromptHistory = %v, %v, want the whole prompt", history, err)
+	}
+}
@@ -0,0 +1,249 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldKind describes how a query field's values are interpreted.
+type FieldKind int
+
+const (
+	FieldText FieldKind = iota
+	FieldTime           // milliseconds since the epoch
+	FieldBool
+	FieldInt
+)
+
+// QueryField is a session attribute that can be used in a Filter.
+type QueryField struct {
+	Name string
+	Kind FieldKind
+	Doc  string
+	expr string
+}
+
+// QueryFields lists the fields accepted by QuerySessions, in display order.
+// Only these names ever reach SQL, so filters cannot reference arbitrary columns.
+var QueryFields = []QueryField{
+	{"id", FieldText, "session ID", "s.id"},
+	{"project", FieldText, "project directory", "s.project"},
+	{"cwd", FieldText, "last working directory", "s.cwd"},
+	{"model", FieldText, "model name", "s.model"},
+	{"title", FieldText, "session title, empty if none", "s.title"},
+	{"notes", FieldText, "notes attached to imported sessions", "s.notes"},
+	{"tty", FieldText, "controlling terminal, e.g. pts/3", "s.tty"},
+	{"terminal", FieldText, "terminal emulator, e.g. kitty", "s.terminal"},
+	{"branch", FieldText, "git branch at the last prompt", "s.branch"},
+	{"host", FieldText, "machine the session last started on", "s.host"},
+	{"user", FieldText, "user the session last started as", "s.user"},
+	{"permission_mode", FieldText, "permission mode, e.g. plan or acceptEdits", "s.permission_mode"},
+	{"prompt", FieldText, "text of any recorded prompt", ""},
+	{"tag", FieldText, "any tag, e.g. a ticket ID from the branch", ""},
+	{"ticket", FieldText, "any ticket ID taken from the branch (ticket_patterns)", ""},
+	{"outcome", FieldText, "outcome label, empty if none", "s.outcome"},
+	{"active", FieldBool, "session is running", "s.active"},
+	{"read_only", FieldBool, "session was imported from a bundle", "s.read_only"},
+	{"headless", FieldBool, "subagent or non-interactive (claude -p) session", "s.headless"},
+	{"pid", FieldInt, "claude process ID", "s.pid"},
+	{"prompt_count", FieldInt, "prompts sent, including those no longer stored", "s.prompt_count"},
+	{"first_prompt", FieldText, "first prompt sent, kept after it is evicted", "s.first_prompt"},
+	{"worked_ms", FieldInt, "time worked in milliseconds, idle gaps excluded", "s.worked_ms"},
+	{"input_tokens", FieldInt, "input tokens, read by cst daemon", "s.input_tokens"},
+	{"output_tokens", FieldInt, "output tokens, read by cst daemon", "s.output_tokens"},
+	{"tool_uses", FieldInt, "tool calls, read by cst daemon", "s.tool_uses"},
+	{"tool_errors", FieldInt, "tool calls that failed, e.g. commands that exited non-zero", "s.tool_errors"},
+	{"end_reason", FieldText, "why the session last ended: clear, logout, prompt_input_exit or other", "s.end_reason"},
+	{"started_at", FieldTime, "first seen", "s.started_at"},
+	{"last_activity", FieldTime, "last hook event of any kind", "s.last_activity"},
+	{"last_prompt_at", FieldTime, "last prompt", "s.last_prompt_at"},
+	{"last_tool_at", FieldTime, "last tool use", "s.last_tool_at"},
+	{"last_resume_at", FieldTime, "last resume", "s.last_resume_at"},
+	{"awaiting_since", FieldTime, "waiting on the user since (0 if not waiting)", "s.awaiting_since"},
+}
+
+// childValues holds, for fields stored in a child table, a subquery selecting
+// the session's values as v. Filters on them match if any value matches.
+var childValues = map[string]string{
+	"prompt": "SELECT COALESCE(prompt_full, prompt) AS v FROM prompts WHERE session_id = s.id",
+	"tag":    "SELECT tag AS v FROM session_tags WHERE session_id = s.id",
+	"ticket": "SELECT tag AS v FROM session_tags WHERE session_id = s.id",
+}
+
+// Filter operators. "~" is a case-insensitive substring match.
+var filterOps = []string{"!=", "<=", ">=", "!~", "=", "<", ">", "~"}
+
+// Filter is a single "field op value" condition. Value is a string, int64 or bool.
+type Filter struct {
+	Field string
+	Op    string
+	Value any
+}
+
+// ParseFilter parses an expression such as "project ~ api" or
+// "last_activity > 7d". Values of time fields may be a relative age
+// ("90m", "7d", meaning that long ago), a date ("2006-01-02"), an RFC 3339
+// timestamp or milliseconds since the epoch.
+func ParseFilter(expr string, now time.Time) (Filter, error) {
+	pos, op := -1, ""
+	for _, candidate := range filterOps {
+		if i := strings.Index(expr, candidate); i >= 0 && (pos < 0 || i < pos) {
+			pos, op = i, candidate
+		}
+	}
+	if pos < 0 {
+		return Filter{}, fmt.Errorf("invalid filter %q: expected <field> <op> <value> with op one of %s",
+			expr, strings.Join(filterOps, " "))
+	}
+	name := strings.TrimSpace(expr[:pos])
+	raw := strings.TrimSpace(expr[pos+len(op):])
+
+	field, ok := lookupField(name)
+	if !ok {
+		return Filter{}, fmt.Errorf("unknown field %q", name)
+	}
+
+	f := Filter{Field: name, Op: op}
+	var err error
+	switch field.Kind {
+	case FieldText:
+		if op != "=" && op != "!=" && op != "~" && op != "!~" {
+			return Filter{}, fmt.Errorf("operator %s not supported for text field %s", op, name)
+		}
+		f.Value = raw
+	case FieldBool:
+		if op != "=" && op != "!=" {
+			return Filter{}, fmt.Errorf("operator %s not supported for boolean field %s", op, name)
+		}
+		f.Value, err = strconv.ParseBool(raw)
+	case FieldInt:
+		f.Value, err = strconv.ParseInt(raw, 10, 64)
+	case FieldTime:
+		f.Value, err = ParseTime(raw, now)
+	}
+	if err != nil {
+		return Filter{}, fmt.Errorf("invalid value %q for %s", raw, name)
+	}
+	if (op == "~" || op == "!~") && field.Kind != FieldText {
+		return Filter{}, fmt.Errorf("operator %s not supported for field %s", op, name)
+	}
+	return f, nil
+}
+
+// ParseTime parses a time value as accepted by ParseFilter into
+// milliseconds since the epoch.
+func ParseTime(raw string, now time.Time) (int64, error) {
+	if ms, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return ms, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", raw, time.Local); err == nil {
+		return t.UnixMilli(), nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UnixMilli(), nil
+	}
+	if n, ok := strings.CutSuffix(raw, "d"); ok {
+		days, err := strconv.Atoi(n)
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid age %q", raw)
+		}
+		return now.AddDate(0, 0, -days).UnixMilli(), nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid time %q", raw)
+	}
+	return now.Add(-d).UnixMilli(), nil
+}
+
+func lookupField(name string) (QueryField, bool) {
+	i := slices.IndexFunc(QueryFields, func(f QueryField) bool { return f.Name == name })
+	if i < 0 {
+		return QueryField{}, false
+	}
+	return QueryFields[i], true
+}
+
+// QuerySessions returns the sessions matching all filters, ordered by the
+// given field (prefix "-" for descending; empty means newest activity first).
+// A limit of zero or less returns every match.
+func (s *Store) QuerySessions(filters []Filter, order string, limit int) ([]Session, error) {
+	var conds []string
+	var args []any
+	for _, f := range filters {
+		cond, arg, err := filterSQL(f)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+		args = append(args, arg)
+	}
+
+	query := s.sessionListQuery()
+	if len(conds) > 0 {
+		query += "\tWHERE " + strings.Join(conds, " AND ") + "\n"
+	}
+
+	if order == "" {
+		order = "-last_activity"
+	}
+	dir := "ASC"
+	if name, ok := strings.CutPrefix(order, "-"); ok {
+		order, dir = name, "DESC"
+	}
+	field, ok := lookupField(order)
+	if !ok || field.expr == "" {
+		return nil, fmt.Errorf("cannot order by %q", order)
+	}
+	query += fmt.Sprintf("\tORDER BY %s %s, s.id\n", field.expr, dir)
+
+	if limit > 0 {
+		query += "\tLIMIT ?\n"
+		args = append(args, limit)
+	}
+	return s.listSessions("QuerySessions", query, args...)
+}
+
+// filterSQL renders a filter as a WHERE condition with a single placeholder.
+func filterSQL(f Filter) (string, any, error) {
+	field, ok := lookupField(f.Field)
+	if !ok {
+		return "", nil, fmt.Errorf("unknown field %q", f.Field)
+	}
+	if !slices.Contains(filterOps, f.Op) {
+		return "", nil, fmt.Errorf("unknown operator %q", f.Op)
+	}
+
+	value := f.Value
+	if b, ok := value.(bool); ok {
+		value = 0
+		if b {
+			value = 1
+		}
+	}
+
+	op := f.Op
+	if op == "~" || op == "!~" {
+		value = "%" + escapeLike(fmt.Sprint(value)) + "%"
+		op = "LIKE"
+		if f.Op == "!~" {
+			op = "NOT LIKE"
+		}
+		op += ` ? ESCAPE '\'`
+	} else {
+		op += " ?"
+	}
+
+	if values, ok := childValues[field.Name]; ok {
+		exists := "EXISTS"
+		if f.Op == "!=" || f.Op == "!~" {
+			exists = "NOT EXISTS"
+			op = strings.Replace(strings.Replace(op, "NOT LIKE", "LIKE", 1), "!=", "=", 1)
+		}
+		return exists + " (SELECT 1 FROM (" + values + ") WHERE v " + op + ")", value, nil
+	}
+	return field.expr + " " + op, value, nil
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseFilter(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want Filter
+	}{
+		{"project ~ api", Filter{"project", "~", "api"}},
+		{"model!=opus", Filter{"model", "!=", "opus"}},
+		{"active = true", Filter{"active", "=", true}},
+		{"pid >= 42", Filter{"pid", ">=", int64(42)}},
+		{"last_activity > 7d", Filter{"last_activity", ">", now.AddDate(0, 0, -7).UnixMilli()}},
+		{"started_at <= 90m", Filter{"started_at", "<=", now.Add(-90 * time.Minute).UnixMilli()}},
+		{"started_at < 1700000000000", Filter{"started_at", "<", int64(1700000000000)}},
+		{"notes ~ a<b", Filter{"notes", "~", "a<b"}},
+	}
+	for _, tc := range tests {
+		got, err := ParseFilter(tc.expr, now)
+		if err != nil {
+			t.Errorf("ParseFilter(%q): %v", tc.expr, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseFilter(%q) = %+v, want %+v", tc.expr, got, tc.want)
+		}
+	}
+
+	for _, expr := range []string{
+		"project",                   // no operator
+		"secret = 1",                // unknown field
+		"project > a",               // ordering on text
+		"active ~ t",                // substring on bool
+		"last_activity > yesterday", // bad time
+		"1=1; DROP TABLE sessions",  // not a field
+	} {
+		if _, err := ParseFilter(expr, now); err == nil {
+			t.Errorf("ParseFilter(%q): expected error", expr)
+		}
+	}
+}
+
+func TestQuerySessions(t *testing.T) {
+	for _, windowFuncs := range []bool{true, false} {
+		s := testStore(t)
+		s.windowFuncs = windowFuncs
+
+		for i, sess := range []Session{
+			{ID: "s1", Project: "/work/api", LastActivity: 3000, Model: "opus", Active: true},
+			{ID: "s2", Project: "/work/web", LastActivity: 2000, Model: "sonnet"},
+			{ID: "s3", Project: "/home/API-docs", LastActivity: 1000, Model: "sonnet"},
+		} {
+			sess.CWD, sess.StartedAt = sess.Project, int64(i)
+			if err := s.UpsertSession(sess); err != nil {
+				t.Fatalf("UpsertSession: %v", err)
+			}
+		}
+		if err := s.AddPrompt("s2", "Fix the 100% CPU loop", 2000); err != nil {
+			t.Fatalf("AddPrompt: %v", err)
+		}
+
+		tests := []struct {
+			name    string
+			filters []Filter
+			order   string
+			limit   int
+			want    []string
+		}{
+			{"all", nil, "", 0, []string{"s1", "s2", "s3"}},
+			{"contains is case-insensitive", []Filter{{"project", "~", "api"}}, "", 0, []string{"s1", "s3"}},
+			{"and", []Filter{{"project", "~", "api"}, {"last_activity", ">", int64(1500)}}, "", 0, []string{"s1"}},
+			{"bool", []Filter{{"active", "=", false}}, "", 0, []string{"s2", "s3"}},
+			{"prompt escapes like", []Filter{{"prompt", "~", "100%"}}, "", 0, []string{"s2"}},
+			{"prompt negated", []Filter{{"prompt", "!~", "cpu"}}, "", 0, []string{"s1", "s3"}},
+			{"order asc and limit", nil, "last_activity", 2, []string{"s3", "s2"}},
+		}
+		for _, tc := range tests {
+			sessions, err := s.QuerySessions(tc.filters, tc.order, tc.limit)
+			if err != nil {
+				t.Fatalf("%s: QuerySessions: %v", tc.name, err)
+			}
+			var got []string
+			for _, sess := range sessions {
+				got = append(got, sess.ID)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("windowFuncs=%v %s: got %v, want %v", windowFuncs, tc.name, got, tc.want)
+			}
+		}
+
+		if _, err := s.QuerySessions(nil, "prompt", 0); err == nil {
+			t.Error("expected error ordering by prompt")
+		}
+		if _, err := s.QuerySessions([]Filter{{"project) OR (1", "=", "x"}}, "", 0); err == nil {
+			t.Error("expected error for unknown field")
+		}
+	}
+}
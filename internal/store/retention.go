@@ -0,0 +1,124 @@
+package store
+
+import (
+	"time"
+)
+
+// RetentionPolicy returns how long the inactive sessions of a project are
+// kept, in days, and how many sessions the project keeps at most. Zero
+// means no limit.
+type RetentionPolicy func(project string) (days, maxSessions int)
+
+// EndReasonClear is the EndReason of sessions ended by /clear, whose
+// conversation cannot be resumed.
+const EndReasonClear = "clear"
+
+// Cleanup removes the inactive sessions that policy no longer keeps: those
+// older than their project's retention and the oldest beyond its session
+// cap. Sessions ended by /clear expire after clearedDays instead, if that
+// is positive and shorter; projects kept regardless of age keep them too.
+// A non-empty project limits it to that project. Active sessions are never
+// removed but count towards the cap. Returns the removed sessions.
+func (s *Store) Cleanup(policy RetentionPolicy, clearedDays int, project string) ([]Session, error) {
+	expired, err := s.ExpiredSessions(policy, clearedDays, project)
+	if err != nil {
+		return nil, err
+	}
+	return expired, s.deleteSessions("Cleanup", expired)
+}
+
+// ExpiredSessions returns the sessions Cleanup would remove, most recently
+// active first, without removing them.
+func (s *Store) ExpiredSessions(policy RetentionPolicy, clearedDays int, project string) ([]Session, error) {
+	return s.expiredSessions(policy, clearedDays, project, true)
+}
+
+// EnforceCap removes the oldest inactive sessions if the total count exceeds
+// maxSessions, then those beyond the per-project caps of policy, if any.
+// Unlike Cleanup it leaves old sessions alone.
+func (s *Store) EnforceCap(maxSessions int, policy RetentionPolicy) error {
+	_, err := s.exec("EnforceCap", `
+		DELETE FROM sessions WHERE id IN (
+			SELECT id FROM sessions
+			WHERE active = 0
+			ORDER BY last_activity ASC
+			LIMIT MAX(0, (SELECT COUNT(*) FROM sessions) - ?)
+		)
+	`, maxSessions)
+	if err != nil || policy == nil {
+		return err
+	}
+	expired, err := s.expiredSessions(policy, 0, "", false)
+	if err != nil {
+		return err
+	}
+	return s.deleteSessions("EnforceCap", expired)
+}
+
+// expiredSessions returns the inactive sessions of project (all projects if
+// empty) that policy and clearedDays no longer keep, ignoring ages unless
+// withAge.
+func (s *Store) expiredSessions(policy RetentionPolicy, clearedDays int, project string, withAge bool) ([]Session, error) {
+	sessions, err := s.ListSessions(ListOptions{Project: project})
+	if err != nil {
+		return nil, err
+	}
+
+	type limits struct {
+		cutoff        int64 // last activity before which sessions expire; 0 for none
+		clearedCutoff int64 // the same for sessions ended by /clear
+		max           int   // sessions kept at most; 0 for no cap
+		kept          int
+	}
+	now := time.Now()
+	byProject := make(map[string]*limits)
+	var expired []Session
+	// Newest first, so the sessions beyond a cap are the oldest
+	for _, sess := range sessions {
+		l := byProject[sess.Project]
+		if l == nil {
+			days, maxSessions := policy(sess.Project)
+			l = &limits{max: maxSessions}
+			if withAge && days > 0 {
+				l.cutoff = now.AddDate(0, 0, -days).UnixMilli()
+				l.clearedCutoff = l.cutoff
+				if clearedDays > 0 && clearedDays < days {
+					l.clearedCutoff = now.AddDate(0, 0, -clearedDays).UnixMilli()
+				}
+			}
+			byProject[sess.Project] = l
+		}
+		cutoff := l.cutoff
+		if sess.EndReason == EndReasonClear {
+			cutoff = l.clearedCutoff
+		}
+		if !sess.Active && (sess.LastActivity < cutoff || (l.max > 0 && l.kept >= l.max)) {
+			expired = append(expired, sess)
+			continue
+		}
+		l.kept++
+	}
+	return expired, nil
+}
+
+// deleteSessions removes sessions, with their prompts, in one transaction.
+func (s *Store) deleteSessions(op string, sessions []Session) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	defer s.observe(op, "DELETE FROM sessions WHERE id = ?", time.Now(), int64(len(sessions)))
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	for _, sess := range sessions {
+		if _, err := s.txExec(ctx, tx, `DELETE FROM sessions WHERE id = ?`, sess.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
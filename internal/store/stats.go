@@ -0,0 +1,230 @@
+package store
+
+import (
+	"database/sql"
+	"slices"
+	"time"
+)
+
+// Summary holds aggregate counts across all tracked sessions.
+type Summary struct {
+	Sessions int
+	Active   int
+	Projects int
+	Prompts  int
+	WorkedMS int64
+}
+
+// Summary returns aggregate session and prompt counts.
+func (s *Store) Summary() (sum Summary, err error) {
+	const query = `
+		SELECT COUNT(*), COALESCE(SUM(active), 0), COUNT(DISTINCT project),
+			(SELECT COALESCE(SUM(occurrences), 0) FROM prompts), COALESCE(SUM(worked_ms), 0)
+		FROM sessions
+	`
+	defer func(start time.Time) { s.observe("Summary", query, start, 1) }(time.Now())
+	ctx, cancel := s.opContext()
+	defer cancel()
+	err = s.queryRowContext(ctx, query).Scan(&sum.Sessions, &sum.Active, &sum.Projects, &sum.Prompts, &sum.WorkedMS)
+	return sum, err
+}
+
+// ProjectSummary counts the sessions of a project.
+type ProjectSummary struct {
+	Project      string
+	Sessions     int
+	Active       int
+	LastActivity int64
+}
+
+// Projects lists every project with sessions, most recently active first.
+// Subagent and non-interactive sessions are left out with excludeHeadless.
+func (s *Store) Projects(excludeHeadless bool) (projects []ProjectSummary, err error) {
+	query := `
+		SELECT project, COUNT(*), COALESCE(SUM(active), 0), MAX(last_activity)
+		FROM sessions`
+	if excludeHeadless {
+		query += ` WHERE NOT headless`
+	}
+	query += ` GROUP BY project ORDER BY MAX(last_activity) DESC, project`
+	err = s.queryRows("Projects", query, nil, func(rows *sql.Rows) error {
+		var p ProjectSummary
+		if err := rows.Scan(&p.Project, &p.Sessions, &p.Active, &p.LastActivity); err != nil {
+			return err
+		}
+		projects = append(projects, p)
+		return nil
+	})
+	return projects, err
+}
+
+// AgentUsage counts the prompts sent to a subagent.
+type AgentUsage struct {
+	Agent    string
+	Prompts  int
+	Sessions int
+}
+
+// AgentPrompts counts the stored prompts sent to each subagent, most
+// prompted first. Prompts to the main agent are left out.
+func (s *Store) AgentPrompts() (agents []AgentUsage, err error) {
+	const query = `
+		SELECT agent, SUM(occurrences), COUNT(DISTINCT session_id)
+		FROM prompts WHERE agent != ''
+		GROUP BY agent ORDER BY SUM(occurrences) DESC, agent`
+	err = s.queryRows("AgentPrompts", query, nil, func(rows *sql.Rows) error {
+		var a AgentUsage
+		if err := rows.Scan(&a.Agent, &a.Prompts, &a.Sessions); err != nil {
+			return err
+		}
+		agents = append(agents, a)
+		return nil
+	})
+	return agents, err
+}
+
+// PromptsPerDay counts recorded prompts per local calendar day, keyed by
+// "2006-01-02", for prompts at or after since. Days without prompts are absent.
+// A prompt sent several times in a row counts on the day it was last sent.
+// Bucketing happens in Go so days follow the local time zone, including DST.
+func (s *Store) PromptsPerDay(since time.Time) (days map[string]int, err error) {
+	const query = `SELECT timestamp, occurrences FROM prompts WHERE timestamp >= ?`
+	var n int64
+	defer func(start time.Time) { s.observe("PromptsPerDay", query, start, n) }(time.Now())
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	rows, err := s.queryContext(ctx, query, since.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	days = make(map[string]int)
+	for rows.Next() {
+		var ts int64
+		var occurrences int
+		if err := rows.Scan(&ts, &occurrences); err != nil {
+			return nil, err
+		}
+		days[time.UnixMilli(ts).Format(time.DateOnly)] += occurrences
+		n++
+	}
+	return days, rows.Err()
+}
+
+// Usage holds aggregates over the sessions active in a time window.
+type Usage struct {
+	Sessions   int
+	Prompts    int   // sent in those sessions, including ones no longer stored
+	WorkedMS   int64 // over the sessions' whole lives, idle gaps excluded
+	Projects   []ProjectUsage
+	Models     []ModelUsage
+	TopPrompts []PromptUsage
+}
+
+// ProjectUsage holds the Usage totals of one project.
+type ProjectUsage struct {
+	Project  string
+	Sessions int
+	Prompts  int
+	WorkedMS int64
+}
+
+// ModelUsage counts the sessions that last ran a model; Model is "" when
+// none was recorded.
+type ModelUsage struct {
+	Model    string
+	Sessions int
+}
+
+// PromptUsage counts how often a prompt was sent.
+type PromptUsage struct {
+	Text  string
+	Count int
+}
+
+// Usage aggregates the sessions last active at or after since, of project
+// if not "": totals per project, by most sessions, the model mix, and the
+// topPrompts prompts sent most often in the window. Prompts are counted
+// among those still stored, which are the last few of each session.
+func (s *Store) Usage(since time.Time, project string, topPrompts int) (u Usage, err error) {
+	cond, args := "s.last_activity >= ?", []any{since.UnixMilli()}
+	if project != "" {
+		cond += " AND s.project = ?"
+		args = append(args, ResolvePath(project))
+	}
+
+	projectsQuery := `
+		SELECT s.project, COUNT(*), COALESCE(SUM(s.prompt_count), 0), COALESCE(SUM(s.worked_ms), 0)
+		FROM sessions s WHERE ` + cond + `
+		GROUP BY s.project ORDER BY COUNT(*) DESC, s.project`
+	err = s.queryRows("Usage", projectsQuery, args, func(rows *sql.Rows) error {
+		var p ProjectUsage
+		if err := rows.Scan(&p.Project, &p.Sessions, &p.Prompts, &p.WorkedMS); err != nil {
+			return err
+		}
+		u.Projects = append(u.Projects, p)
+		u.Sessions += p.Sessions
+		u.Prompts += p.Prompts
+		u.WorkedMS += p.WorkedMS
+		return nil
+	})
+	if err != nil {
+		return u, err
+	}
+
+	modelsQuery := `
+		SELECT COALESCE(s.model, ''), COUNT(*)
+		FROM sessions s WHERE ` + cond + `
+		GROUP BY 1 ORDER BY COUNT(*) DESC, 1`
+	err = s.queryRows("Usage", modelsQuery, args, func(rows *sql.Rows) error {
+		var m ModelUsage
+		if err := rows.Scan(&m.Model, &m.Sessions); err != nil {
+			return err
+		}
+		u.Models = append(u.Models, m)
+		return nil
+	})
+	if err != nil || topPrompts <= 0 {
+		return u, err
+	}
+
+	promptsQuery := `
+		SELECT p.prompt, SUM(p.occurrences)
+		FROM prompts p JOIN sessions s ON s.id = p.session_id
+		WHERE p.timestamp >= ? AND ` + cond + `
+		GROUP BY p.prompt ORDER BY SUM(p.occurrences) DESC, MAX(p.timestamp) DESC
+		LIMIT ?`
+	promptArgs := slices.Concat([]any{since.UnixMilli()}, args, []any{topPrompts})
+	err = s.queryRows("Usage", promptsQuery, promptArgs, func(rows *sql.Rows) error {
+		var p PromptUsage
+		if err := rows.Scan(&p.Text, &p.Count); err != nil {
+			return err
+		}
+		u.TopPrompts = append(u.TopPrompts, p)
+		return nil
+	})
+	return u, err
+}
+
+// queryRows runs query and calls scan for each row, timing it as op.
+func (s *Store) queryRows(op, query string, args []any, scan func(*sql.Rows) error) (err error) {
+	var n int64
+	defer func(start time.Time) { s.observe(op, query, start, n) }(time.Now())
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	rows, err := s.queryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+		n++
+	}
+	return rows.Err()
+}
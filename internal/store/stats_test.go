@@ -0,0 +1,152 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSummaryAndPromptsPerDay(t *testing.T) {
+	s := testStore(t)
+	day := time.Date(2026, 3, 10, 9, 0, 0, 0, time.Local)
+
+	for _, sess := range []Session{
+		{ID: "s1", Project: "/a", Active: true},
+		{ID: "s2", Project: "/a"},
+		{ID: "s3", Project: "/b"},
+	} {
+		sess.CWD = sess.Project
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	for i, ts := range []time.Time{
+		day.AddDate(0, 0, -30), // before the window
+		day.AddDate(0, 0, -1),
+		day,
+		day.Add(10 * time.Hour),
+	} {
+		if err := s.AddPrompt("s1", fmt.Sprintf("p%d", i), ts.UnixMilli()); err != nil {
+			t.Fatalf("AddPrompt: %v", err)
+		}
+	}
+
+	sum, err := s.Summary()
+	if err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+	if want := (Summary{Sessions: 3, Active: 1, Projects: 2, Prompts: 4}); sum != want {
+		t.Errorf("Summary = %+v, want %+v", sum, want)
+	}
+
+	days, err := s.PromptsPerDay(day.AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("PromptsPerDay: %v", err)
+	}
+	if len(days) != 2 || days["2026-03-09"] != 1 || days["2026-03-10"] != 2 {
+		t.Errorf("PromptsPerDay = %v, want 2026-03-09:1 2026-03-10:2", days)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	s := testStore(t)
+	now := time.Now()
+	week := now.AddDate(0, 0, -7)
+
+	for _, sess := range []Session{
+		{ID: "s1", Project: "/a", Model: "opus", LastActivity: now.UnixMilli()},
+		{ID: "s2", Project: "/a", Model: "sonnet", LastActivity: now.Add(-time.Hour).UnixMilli()},
+		{ID: "s3", Project: "/b", Model: "opus", LastActivity: now.Add(-time.Minute).UnixMilli()},
+		{ID: "old", Project: "/b", Model: "haiku", LastActivity: now.AddDate(0, 0, -30).UnixMilli()},
+	} {
+		sess.CWD = sess.Project
+		sess.StartedAt = sess.LastActivity
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	for _, p := range []struct {
+		id, text string
+		ts       time.Time
+	}{
+		{"s1", "run the tests", now},
+		{"s2", "run the tests", now},
+		{"s3", "fix the build", now},
+		{"s3", "run the tests", now.AddDate(0, 0, -8)}, // before the window
+		{"old", "fix the build", now.AddDate(0, 0, -30)},
+	} {
+		if err := s.AddPrompt(p.id, p.text, p.ts.UnixMilli()); err != nil {
+			t.Fatalf("AddPrompt: %v", err)
+		}
+	}
+
+	u, err := s.Usage(week, "", 5)
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	if u.Sessions != 3 || u.Prompts != 4 {
+		t.Errorf("Sessions, Prompts = %d, %d, want 3, 4", u.Sessions, u.Prompts)
+	}
+	wantProjects := []ProjectUsage{{Project: "/a", Sessions: 2, Prompts: 2}, {Project: "/b", Sessions: 1, Prompts: 2}}
+	if !slices.Equal(u.Projects, wantProjects) {
+		t.Errorf("Projects = %+v, want %+v", u.Projects, wantProjects)
+	}
+	wantModels := []ModelUsage{{"opus", 2}, {"sonnet", 1}}
+	if !slices.Equal(u.Models, wantModels) {
+		t.Errorf("Models = %+v, want %+v", u.Models, wantModels)
+	}
+	wantPrompts := []PromptUsage{{"run the tests", 2}, {"fix the build", 1}}
+	if !slices.Equal(u.TopPrompts, wantPrompts) {
+		t.Errorf("TopPrompts = %+v, want %+v", u.TopPrompts, wantPrompts)
+	}
+
+	u, err = s.Usage(week, "/b", 0)
+	if err != nil {
+		t.Fatalf("Usage of a project: %v", err)
+	}
+	if u.Sessions != 1 || len(u.Projects) != 1 || u.TopPrompts != nil {
+		t.Errorf("Usage of /b = %+v, want one session and no prompts", u)
+	}
+}
+
+func TestAgentPrompts(t *testing.T) {
+	s := testStore(t)
+	for _, id := range []string{"s1", "s2"} {
+		if err := s.UpsertSession(Session{ID: id, Project: "/p", StartedAt: 100, LastActivity: 100}); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	for i, p := range []struct{ id, text, agent string }{
+		{"s1", "main", ""},
+		{"s1", "look around", "Explore"},
+		{"s1", "look around", "Explore"}, // sent again
+		{"s1", "look around", "reviewer"},
+		{"s2", "find the bug", "Explore"},
+	} {
+		if err := s.RecordPromptActivity(PromptActivity{SessionID: p.id, Prompt: p.text, Agent: p.agent, CWD: "/p", At: int64(200 + i)}); err != nil {
+			t.Fatalf("RecordPromptActivity: %v", err)
+		}
+	}
+
+	agents, err := s.AgentPrompts()
+	if err != nil {
+		t.Fatalf("AgentPrompts: %v", err)
+	}
+	want := []AgentUsage{{"Explore", 3, 2}, {"reviewer", 1, 1}}
+	if !slices.Equal(agents, want) {
+		t.Errorf("AgentPrompts = %+v, want %+v", agents, want)
+	}
+
+	prompts, err := s.GetPrompts("s1", 10)
+	if err != nil {
+		t.Fatalf("GetPrompts: %v", err)
+	}
+	var got []string
+	for _, p := range prompts {
+		got = append(got, fmt.Sprintf("%s/%s/%d", p.Text, p.Agent, p.Occurrences))
+	}
+	if want := []string{"look around/reviewer/1", "look around/Explore/2", "main//1"}; !slices.Equal(got, want) {
+		t.Errorf("prompts = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// maxCachedStmts bounds the prepared statements a store keeps. Queries
+// built per call, such as ListSessions' for each set of filters, run
+// unprepared once it is reached.
+const maxCachedStmts = 64
+
+// stmtCache keeps prepared statements by their SQL.
+type stmtCache struct {
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+	seen  map[string]bool // run once, unprepared
+}
+
+// stmt returns query prepared, or nil on its first use, when the cache is
+// full or when it cannot be prepared; the caller then runs it as is, which
+// reports any error in the query.
+//
+// Statements are prepared on their second use: a hook runs each one once,
+// and preparing inside a transaction would open a second connection.
+func (s *Store) stmt(ctx context.Context, query string) *sql.Stmt {
+	s.stmts.mu.Lock()
+	defer s.stmts.mu.Unlock()
+	if st, ok := s.stmts.stmts[query]; ok {
+		return st
+	}
+	if len(s.stmts.stmts) >= maxCachedStmts {
+		return nil
+	}
+	if !s.stmts.seen[query] {
+		if s.stmts.seen == nil {
+			s.stmts.seen = make(map[string]bool)
+		}
+		if len(s.stmts.seen) < 4*maxCachedStmts {
+			s.stmts.seen[query] = true
+		}
+		return nil
+	}
+	st, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil
+	}
+	if s.stmts.stmts == nil {
+		s.stmts.stmts = make(map[string]*sql.Stmt)
+	}
+	s.stmts.stmts[query] = st
+	return st
+}
+
+// execContext runs a statement, prepared once it is run again.
+func (s *Store) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if st := s.stmt(ctx, query); st != nil {
+		return st.ExecContext(ctx, args...)
+	}
+	return s.db.ExecContext(ctx, query, args...)
+}
+
+// queryContext runs a query, prepared once it is run again.
+func (s *Store) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if st := s.stmt(ctx, query); st != nil {
+		return st.QueryContext(ctx, args...)
+	}
+	return s.db.QueryContext(ctx, query, args...)
+}
+
+// queryRowContext runs a query for a single row, prepared once it is run
+// again.
+func (s *Store) queryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if st := s.stmt(ctx, query); st != nil {
+		return st.QueryRowContext(ctx, args...)
+	}
+	return s.db.QueryRowContext(ctx, query, args...)
+}
+
+// txExec runs a statement in tx, with the store's prepared statement once
+// there is one.
+func (s *Store) txExec(ctx context.Context, tx *sql.Tx, query string, args ...any) (sql.Result, error) {
+	if st := s.stmt(ctx, query); st != nil {
+		return tx.StmtContext(ctx, st).ExecContext(ctx, args...)
+	}
+	return tx.ExecContext(ctx, query, args...)
+}
+
+// closeStmts closes the prepared statements, before the database.
+func (s *Store) closeStmts() {
+	s.stmts.mu.Lock()
+	defer s.stmts.mu.Unlock()
+	for _, st := range s.stmts.stmts {
+		_ = st.Close()
+	}
+	s.stmts.stmts, s.stmts.seen = nil, nil
+}
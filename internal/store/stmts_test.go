@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestStmtCache(t *testing.T) {
+	s := testStore(t)
+	cached := func(query string) bool {
+		s.stmts.mu.Lock()
+		defer s.stmts.mu.Unlock()
+		return s.stmts.stmts[query] != nil
+	}
+
+	// Prepared on the second use, and shared with copies
+	const query = `SELECT COUNT(*) FROM sessions WHERE project = ?`
+	ctx := context.Background()
+	for i := range 3 {
+		var n int
+		if err := s.WithContext(ctx).queryRowContext(ctx, query, "/proj").Scan(&n); err != nil {
+			t.Fatalf("query %d: %v", i, err)
+		}
+		if want := i > 0; cached(query) != want {
+			t.Errorf("after %d runs, cached = %v, want %v", i+1, !want, want)
+		}
+	}
+
+	// Past the cap, queries still run, unprepared
+	for i := range maxCachedStmts + 5 {
+		q := fmt.Sprintf("SELECT %d", i)
+		for range 2 {
+			var n int
+			if err := s.queryRowContext(ctx, q).Scan(&n); err != nil || n != i {
+				t.Fatalf("%s = %d, %v", q, n, err)
+			}
+		}
+	}
+	if n := len(s.stmts.stmts); n != maxCachedStmts {
+		t.Errorf("%d statements cached, want %d", n, maxCachedStmts)
+	}
+
+	// A query that cannot be prepared reports its error when run
+	if _, err := s.execContext(ctx, `UPDATE nowhere SET x = 1`); err == nil {
+		t.Error("bad statement ran without an error")
+	}
+	if _, err := s.execContext(ctx, `UPDATE nowhere SET x = 1`); err == nil {
+		t.Error("bad statement ran without an error the second time")
+	}
+}
@@ -1,22 +1,41 @@
 package store
 
 import (
+	"cmp"
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
 const (
-	DefaultDBDir     = ".cst"
 	DefaultDBName    = "sessions.db"
 	DefaultMaxCap    = 500
 	DefaultMaxPrompt = 10
+	DefaultMaxCWDs   = 50
+
+	// DefaultIdleGap is the longest pause between hook events still counted
+	// as time worked in a session.
+	DefaultIdleGap = 15 * time.Minute
+
+	// DefaultPIDGrace is how long a session's process may be gone before
+	// RefreshActive marks the session inactive, so that claude restarting,
+	// as it does to update itself, doesn't end the session.
+	DefaultPIDGrace = 10 * time.Second
 )
 
+// busyTimeout is how long, in milliseconds, a statement waits for another
+// connection's lock before failing with SQLITE_BUSY.
+const busyTimeout = 5000
+
 // Session represents a tracked Claude Code session.
 type Session struct {
 	ID           string
@@ -27,22 +46,168 @@ type Session struct {
 	PID          *int
 	Active       bool
 	Model        string
+	// Per-source activity timestamps (0 if never seen):
+	LastPromptAt int64
+	LastToolAt   int64
+	LastResumeAt int64
+	// Set for sessions imported from a bundle, which cannot be resumed:
+	ReadOnly       bool
+	Notes          string
+	TranscriptPath string
+	// Set while Claude is waiting on the user (0 if not waiting):
+	AwaitingSince   int64
+	AwaitingMessage string
+	// Controlling terminal of the claude process at its last start,
+	// e.g. "pts/3" and "kitty"; empty if unknown:
+	TTY      string
+	Terminal string
+	// WorkedMS sums the gaps between consecutive hook events of a session
+	// run, leaving out pauses longer than the store's idle gap:
+	WorkedMS int64
+	// Git branch checked out in the session's working directory, and tags
+	// such as ticket IDs extracted from it:
+	Branch string
+	Tags   []string
+	// URLs of issues, tickets or pull requests the session is about, in the
+	// order they were added, see AddLink:
+	Links []string
+	// User-given label for how the session went, e.g. "shipped", with an
+	// optional note. OutcomeRequested is set at session end when the outcome
+	// survey is enabled and stays set until a label is given:
+	Outcome          string
+	OutcomeNote      string
+	OutcomeAt        int64
+	OutcomeRequested bool
+	// Machine and user the session last started on; empty for sessions
+	// recorded before hosts were tracked:
+	Host string
+	User string
+	// Claude Code permission mode at the last prompt or tool use, e.g.
+	// "plan" or "acceptEdits"; empty if never reported:
+	PermissionMode string
+	// Number of prompts sent in the session, including those evicted by the
+	// prompt cap, and the first of them, kept as a stable title:
+	PromptCount int
+	FirstPrompt string
+	// Short description of the session, see SetTitle; empty if none:
+	Title string
+	// Set for subagent and non-interactive (claude -p) sessions, which
+	// listings hide unless asked for them:
+	Headless bool
+	// Claude arguments the session is always resumed with, overriding the
+	// config's, see SetResumeArgs:
+	ResumeArgs []string
+	// Why the session last ended, as SessionEnd reported it: "clear",
+	// "logout", "prompt_input_exit" or "other"; empty while running or if
+	// unknown:
+	EndReason string
+	// IDs of the other sessions that were running in the same project when
+	// the session last started, see SetConcurrentWith; nil if none or not
+	// checked:
+	ConcurrentWith []string
+	// Tokens and tool calls read from the transcript while cst daemon runs;
+	// zero if it never followed the session. InputTokens includes those
+	// written to the prompt cache:
+	InputTokens     int64
+	OutputTokens    int64
+	CacheReadTokens int64
+	ToolUses        int
+	// Tool calls that failed, per the tool hooks, see CountToolError:
+	ToolErrors int
+	// Name of the secondary store the session was read from; empty for the
+	// local store. Set by ListMerged, never stored:
+	Source string
 	// Populated by joined queries for display:
 	LastPrompt   string
 	LastPromptTS *int64
 }
 
+// ActivitySource identifies which hook event drove a session's activity.
+type ActivitySource string
+
+const (
+	ActivityPrompt ActivitySource = "prompt"
+	ActivityTool   ActivitySource = "tool"
+	ActivityResume ActivitySource = "resume"
+)
+
+// activityColumns maps each activity source to its per-source timestamp column.
+var activityColumns = map[ActivitySource]string{
+	ActivityPrompt: "last_prompt_at",
+	ActivityTool:   "last_tool_at",
+	ActivityResume: "last_resume_at",
+}
+
 // Prompt represents a user prompt within a session.
 type Prompt struct {
 	ID        int64
 	SessionID string
 	Text      string
+	Timestamp int64 // of the latest occurrence
+	// Occurrences counts the times the prompt was sent in a row
+	Occurrences int
+	// Agent is the subagent the prompt was sent to, such as a custom
+	// subagent type; empty for the main agent.
+	Agent string
+}
+
+// CWDEntry records a working directory a session moved into.
+type CWDEntry struct {
+	SessionID string
+	CWD       string
 	Timestamp int64
 }
 
+// HookAnomaly counts hook events that were rejected or repaired, grouped by reason.
+type HookAnomaly struct {
+	Event    string
+	Reason   string
+	Count    int
+	LastSeen int64
+}
+
 // Store wraps the SQLite database for session tracking.
 type Store struct {
 	db *sql.DB
+	// windowFuncs reports whether the SQLite build supports window functions.
+	// When false, list queries fall back to correlated subqueries.
+	windowFuncs bool
+	// migratedFrom is the schema version found on disk before Open migrated it.
+	migratedFrom int
+	timing       *timing // shared by the copies WithContext makes
+	stmts        *stmtCache
+	idleGap      time.Duration
+	pidGrace     time.Duration
+	// How prompts longer than promptLen are kept, see SetPromptStorage:
+	promptStorage PromptStorage
+	promptLen     int
+	// ctx bounds every operation, see OpenContext and WithContext, and
+	// timeout each one on its own, see SetTimeout.
+	ctx     context.Context
+	timeout time.Duration
+	// host is this machine's name; RefreshActive leaves sessions started on
+	// other hosts alone, since their PIDs mean nothing here.
+	host string
+	// readOnly is set for stores opened with OpenReadOnly.
+	readOnly bool
+	// path is the database file.
+	path string
+}
+
+// LocalHost returns the name of this machine, or "" if it is unknown.
+func LocalHost() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// IsLocal reports whether the session last ran on this machine, judged by
+// the host recorded at its start. Sessions without a recorded host are
+// assumed to be local.
+func (sess Session) IsLocal() bool {
+	return sess.Host == "" || sess.Host == LocalHost()
 }
 
 // ResolvePath resolves symlinks to get the canonical path.
@@ -55,72 +220,97 @@ func ResolvePath(p string) string {
 	return resolved
 }
 
-// DefaultDBPath returns the default database path (~/.cst/sessions.db).
-func DefaultDBPath() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		home = "."
-	}
-	return filepath.Join(home, DefaultDBDir, DefaultDBName)
+// Open opens or creates the session tracking database at the given path,
+// applying any pending schema migrations.
+func Open(dbPath string) (*Store, error) {
+	return OpenContext(context.Background(), dbPath)
 }
 
-// Open opens or creates the session tracking database at the given path.
-func Open(dbPath string) (*Store, error) {
+// OpenContext is Open, with opening and migrating the database, and every
+// operation of the store it returns, canceled when ctx is done, as for
+// WithContext.
+func OpenContext(ctx context.Context, dbPath string) (*Store, error) {
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("create db directory: %w", err)
 	}
 
-	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(ON)", dbPath)
+	// Transactions take the write lock when they begin, as they all write:
+	// one that took it only on its first write could find another writer
+	// there first and fail at once instead of waiting out busy_timeout
+	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(%d)&_pragma=foreign_keys(ON)&_txlock=immediate", dbPath, busyTimeout)
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
 
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(ctx); err != nil {
 		_ = db.Close()
 		return nil, fmt.Errorf("ping database: %w", err)
 	}
 
-	s := &Store{db: db}
-	if err := s.createTables(); err != nil {
+	s := &Store{db: db, timing: &timing{}, stmts: &stmtCache{}, idleGap: DefaultIdleGap, pidGrace: DefaultPIDGrace, host: LocalHost(), path: dbPath, ctx: ctx}
+	from, err := s.migrate()
+	if err != nil {
 		_ = db.Close()
-		return nil, fmt.Errorf("create tables: %w", err)
+		return nil, fmt.Errorf("migrate schema: %w", err)
 	}
+	s.migratedFrom = from
+	s.windowFuncs = s.supportsWindowFunctions()
 
 	return s, nil
 }
 
-func (s *Store) createTables() error {
-	_, err := s.db.Exec(`
-		CREATE TABLE IF NOT EXISTS sessions (
-			id TEXT PRIMARY KEY,
-			project TEXT NOT NULL,
-			cwd TEXT NOT NULL,
-			started_at INTEGER NOT NULL,
-			last_activity INTEGER NOT NULL,
-			pid INTEGER,
-			active INTEGER DEFAULT 0,
-			model TEXT DEFAULT ''
-		);
-
-		CREATE TABLE IF NOT EXISTS prompts (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
-			prompt TEXT NOT NULL,
-			timestamp INTEGER NOT NULL
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_sessions_project ON sessions(project);
-		CREATE INDEX IF NOT EXISTS idx_sessions_active ON sessions(active);
-		CREATE INDEX IF NOT EXISTS idx_sessions_last_activity ON sessions(last_activity DESC);
-		CREATE INDEX IF NOT EXISTS idx_prompts_session ON prompts(session_id, timestamp DESC);
-	`)
-	return err
+// OpenReadOnly opens an existing database, such as a copy synced from
+// another machine, without creating, migrating or writing to it. The schema
+// must be at the latest version, since older ones lack columns that session
+// queries select.
+func OpenReadOnly(dbPath string) (*Store, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, err
+	}
+	dsn := fmt.Sprintf("file:%s?mode=ro&_pragma=busy_timeout(%d)&_pragma=query_only(1)", dbPath, busyTimeout)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("read schema version: %w", err)
+	}
+	if version != LatestSchemaVersion {
+		_ = db.Close()
+		return nil, fmt.Errorf("database schema version %d does not match supported version %d; use the same cst version on both machines", version, LatestSchemaVersion)
+	}
+
+	s := &Store{db: db, timing: &timing{}, stmts: &stmtCache{}, idleGap: DefaultIdleGap, pidGrace: DefaultPIDGrace, host: LocalHost(), migratedFrom: version, readOnly: true, path: dbPath}
+	s.windowFuncs = s.supportsWindowFunctions()
+	return s, nil
+}
+
+// ReadOnly reports whether the store was opened with OpenReadOnly.
+func (s *Store) ReadOnly() bool {
+	return s.readOnly
 }
 
-// Close closes the database connection.
+// supportsWindowFunctions probes for window function support (SQLite 3.25+).
+func (s *Store) supportsWindowFunctions() bool {
+	ctx, cancel := s.opContext()
+	defer cancel()
+	var n int
+	return s.db.QueryRowContext(ctx, `SELECT ROW_NUMBER() OVER ()`).Scan(&n) == nil
+}
+
+// Close checkpoints the write-ahead log into the database file, so that it
+// doesn't grow with every short-lived hook process, and closes the database
+// connection.
 func (s *Store) Close() error {
+	if !s.readOnly {
+		s.checkpoint()
+	}
+	s.closeStmts()
 	return s.db.Close()
 }
 
@@ -133,7 +323,7 @@ func (s *Store) UpsertSession(sess Session) error {
 	}
 	project := ResolvePath(sess.Project)
 	cwd := ResolvePath(sess.CWD)
-	_, err := s.db.Exec(`
+	_, err := s.exec("UpsertSession", `
 		INSERT INTO sessions (id, project, cwd, started_at, last_activity, pid, active, model)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
@@ -141,7 +331,8 @@ func (s *Store) UpsertSession(sess Session) error {
 			last_activity = excluded.last_activity,
 			pid = excluded.pid,
 			active = excluded.active,
-			model = excluded.model
+			model = excluded.model,
+			last_pid_check = 0
 	`, sess.ID, project, cwd, sess.StartedAt, sess.LastActivity, sess.PID, active, sess.Model)
 	return err
 }
@@ -150,8 +341,9 @@ func (s *Store) UpsertSession(sess Session) error {
 func (s *Store) Activate(id string, pid int, model, cwd string) error {
 	now := time.Now().UnixMilli()
 	resolvedCWD := ResolvePath(cwd)
-	result, err := s.db.Exec(`
-		UPDATE sessions SET active = 1, pid = ?, model = ?, cwd = ?, last_activity = ?
+	result, err := s.exec("Activate", `
+		UPDATE sessions SET active = 1, pid = ?, model = ?, cwd = ?, last_activity = ?,
+			awaiting_since = 0, awaiting_message = '', last_pid_check = 0, end_reason = '', concurrent_with = ''
 		WHERE id = ?
 	`, pid, model, resolvedCWD, now, id)
 	if err != nil {
@@ -169,8 +361,10 @@ func (s *Store) Activate(id string, pid int, model, cwd string) error {
 
 // Deactivate marks a session as inactive and clears its PID.
 func (s *Store) Deactivate(id string) error {
-	_, err := s.db.Exec(`
-		UPDATE sessions SET active = 0, pid = NULL WHERE id = ?
+	_, err := s.exec("Deactivate", `
+		UPDATE sessions SET active = 0, pid = NULL, awaiting_since = 0, awaiting_message = '',
+			last_pid_check = 0
+		WHERE id = ?
 	`, id)
 	return err
 }
@@ -178,29 +372,510 @@ func (s *Store) Deactivate(id string) error {
 // UpdateActivity updates the last_activity timestamp and cwd for a session.
 func (s *Store) UpdateActivity(id, cwd string, ts int64) error {
 	resolvedCWD := ResolvePath(cwd)
-	_, err := s.db.Exec(`
+	_, err := s.exec("UpdateActivity", `
 		UPDATE sessions SET last_activity = ?, cwd = ? WHERE id = ?
 	`, ts, resolvedCWD, id)
 	return err
 }
 
+// RecordActivity updates last_activity and cwd along with the per-source
+// timestamp for the hook event that produced the activity. Any activity means
+// the session is no longer waiting on the user.
+func (s *Store) RecordActivity(id, cwd string, source ActivitySource, ts int64) error {
+	query, args, err := s.activityUpdate(id, cwd, source, ts)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec("RecordActivity", query, args...)
+	return err
+}
+
+// activityUpdate returns the statement that RecordActivity runs, and its
+// arguments.
+func (s *Store) activityUpdate(id, cwd string, source ActivitySource, ts int64) (string, []any, error) {
+	column, ok := activityColumns[source]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown activity source %q", source)
+	}
+	query := `
+		UPDATE sessions SET ` + workedSQL + `, last_activity = ?, cwd = ?, ` + column + ` = ?,
+			awaiting_since = 0, awaiting_message = ''
+		WHERE id = ?
+	`
+	return query, []any{ts, s.idleGap.Milliseconds(), ts, ts, ResolvePath(cwd), ts, id}, nil
+}
+
+// workedSQL adds the time since the previous event to worked_ms unless it
+// exceeds the idle gap. Its arguments are the event time, the idle gap in
+// milliseconds and the event time again. SQLite evaluates every SET
+// expression against the old row, so it sees the previous last_activity.
+const workedSQL = `worked_ms = worked_ms + CASE
+			WHEN ? - last_activity BETWEEN 0 AND ? THEN ? - last_activity ELSE 0 END`
+
+// SetIdleGap sets the longest pause between hook events that still counts
+// as time worked. Non-positive values restore DefaultIdleGap.
+func (s *Store) SetIdleGap(d time.Duration) {
+	if d <= 0 {
+		d = DefaultIdleGap
+	}
+	s.idleGap = d
+}
+
+// SetPIDGrace sets how long a session's process must have been gone, over
+// two consecutive RefreshActive checks, before the session is marked
+// inactive. Zero only requires the two checks; negative values mark it
+// inactive at the first failed check.
+func (s *Store) SetPIDGrace(d time.Duration) {
+	s.pidGrace = d
+}
+
+// WithContext returns a copy of the store whose operations are canceled
+// when ctx is done. The copy shares the database connection with s, so only
+// one of them is to be closed.
+//
+// Cancellation interrupts a running statement, but not a wait for another
+// connection's lock, which lasts up to the 5s busy timeout; callers that
+// must not block that long, such as hooks, should stop waiting on the
+// operation itself at their deadline.
+func (s *Store) WithContext(ctx context.Context) *Store {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
+// SetTimeout bounds each operation, or transaction, to d. Zero, the
+// default, leaves them to the store's context alone.
+func (s *Store) SetTimeout(d time.Duration) {
+	s.timeout = d
+}
+
+// opContext returns the context an operation runs in: the store's, with
+// the operation's timeout if there is one.
+func (s *Store) opContext() (context.Context, context.CancelFunc) {
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if s.timeout > 0 {
+		return context.WithTimeout(ctx, s.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// EndSession marks a session inactive at ts for reason, counting the time
+// since its last event as worked unless it exceeds the idle gap.
+func (s *Store) EndSession(id, reason string, ts int64) error {
+	_, err := s.exec("EndSession", `
+		UPDATE sessions SET `+workedSQL+`, last_activity = ?, active = 0, pid = NULL,
+			awaiting_since = 0, awaiting_message = '', last_pid_check = 0, end_reason = ?
+		WHERE id = ?
+	`, ts, s.idleGap.Milliseconds(), ts, ts, reason, id)
+	return err
+}
+
+// SetTerminal records the controlling terminal a session runs in.
+func (s *Store) SetTerminal(id, tty, terminal string) error {
+	_, err := s.exec("SetTerminal", `
+		UPDATE sessions SET tty = ?, terminal = ? WHERE id = ?
+	`, tty, terminal, id)
+	return err
+}
+
+// SetHost records the machine and user a session is running on.
+func (s *Store) SetHost(id, host, user string) error {
+	_, err := s.exec("SetHost", `
+		UPDATE sessions SET host = ?, user = ? WHERE id = ?
+	`, host, user, id)
+	return err
+}
+
+// SetTranscriptPath records where Claude Code writes a session's transcript.
+// An empty path keeps the recorded one.
+func (s *Store) SetTranscriptPath(id, path string) error {
+	if path == "" {
+		return nil
+	}
+	_, err := s.exec("SetTranscriptPath", `
+		UPDATE sessions SET transcript_path = ? WHERE id = ?
+	`, path, id)
+	return err
+}
+
+// SetPermissionMode records the permission mode a session is running in.
+// An empty mode, from a Claude Code version that does not report it, keeps
+// the recorded one.
+func (s *Store) SetPermissionMode(id, mode string) error {
+	if mode == "" {
+		return nil
+	}
+	_, err := s.exec("SetPermissionMode", `
+		UPDATE sessions SET permission_mode = ? WHERE id = ?
+	`, mode, id)
+	return err
+}
+
+// SetConcurrentWith records the other sessions running in the same project
+// as a session when it started. Activate clears them.
+func (s *Store) SetConcurrentWith(id string, others []string) error {
+	_, err := s.exec("SetConcurrentWith", `
+		UPDATE sessions SET concurrent_with = ? WHERE id = ?
+	`, strings.Join(others, ","), id)
+	return err
+}
+
+// SetBranch records the git branch a session is working on.
+func (s *Store) SetBranch(id, branch string) error {
+	_, err := s.exec("SetBranch", `
+		UPDATE sessions SET branch = ? WHERE id = ?
+	`, branch, id)
+	return err
+}
+
+// SetTitle stores a short title for a session. An empty title clears it.
+// Returns sql.ErrNoRows if no session has the given ID.
+func (s *Store) SetTitle(id, title string) error {
+	res, err := s.exec("SetTitle", `UPDATE sessions SET title = ? WHERE id = ?`, title, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetProject moves a session to project, such as the directory its project
+// was renamed to, which becomes its working directory as well. Returns
+// sql.ErrNoRows if no session has the given ID.
+func (s *Store) SetProject(id, project string) error {
+	project = ResolvePath(project)
+	res, err := s.exec("SetProject", `UPDATE sessions SET project = ?, cwd = ? WHERE id = ?`, project, project, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetHeadless marks a session as a subagent or non-interactive run, or as an
+// interactive one.
+func (s *Store) SetHeadless(id string, headless bool) error {
+	_, err := s.exec("SetHeadless", `UPDATE sessions SET headless = ? WHERE id = ?`, headless, id)
+	return err
+}
+
+// SetResumeArgs stores the claude arguments a session is resumed with, on
+// top of the config's. No arguments clear them. Returns sql.ErrNoRows if no
+// session has the given ID.
+func (s *Store) SetResumeArgs(id string, args []string) error {
+	var encoded string
+	if len(args) > 0 {
+		b, err := json.Marshal(args)
+		if err != nil {
+			return err
+		}
+		encoded = string(b)
+	}
+	res, err := s.exec("SetResumeArgs", `UPDATE sessions SET resume_args = ? WHERE id = ?`, encoded, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListUntitled returns the inactive sessions without a title, most recently
+// active first.
+func (s *Store) ListUntitled() ([]Session, error) {
+	return s.listSessions("ListUntitled", s.sessionListQuery()+`
+		WHERE s.active = 0 AND s.title = ''
+		ORDER BY s.last_activity DESC
+	`)
+}
+
+// RequestOutcome marks a session as waiting for an outcome label, unless it
+// already has one.
+func (s *Store) RequestOutcome(id string) error {
+	_, err := s.exec("RequestOutcome", `
+		UPDATE sessions SET outcome_requested = 1 WHERE id = ? AND outcome = ''
+	`, id)
+	return err
+}
+
+// SetOutcome labels how a session went. An empty outcome clears the label.
+// Returns sql.ErrNoRows if no session has the given ID.
+func (s *Store) SetOutcome(id, outcome, note string, ts int64) error {
+	if outcome == "" {
+		note, ts = "", 0
+	}
+	res, err := s.exec("SetOutcome", `
+		UPDATE sessions SET outcome = ?, outcome_note = ?, outcome_at = ?, outcome_requested = 0
+		WHERE id = ?
+	`, outcome, note, ts, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListUnlabeled returns the sessions waiting for an outcome label, most
+// recently active first.
+func (s *Store) ListUnlabeled() ([]Session, error) {
+	return s.listSessions("ListUnlabeled", s.sessionListQuery()+`
+		WHERE s.outcome_requested = 1 AND s.outcome = ''
+		ORDER BY s.last_activity DESC
+	`)
+}
+
+// AddTags attaches tags to a session. Tags are case-insensitive; ones the
+// session already has are left unchanged.
+func (s *Store) AddTags(id string, tags []string, ts int64) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	defer s.observe("AddTags", "INSERT OR IGNORE INTO session_tags ...", time.Now(), int64(len(tags)))
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := s.addTags(ctx, tx, id, tags, ts); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addTags attaches tags to a session in tx, see AddTags.
+func (s *Store) addTags(ctx context.Context, tx *sql.Tx, id string, tags []string, ts int64) error {
+	for _, tag := range tags {
+		if _, err := s.txExec(ctx, tx, `
+			INSERT OR IGNORE INTO session_tags (session_id, tag, created_at) VALUES (?, ?, ?)
+		`, id, tag, ts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddLink attaches the URL of an issue, ticket or pull request to a session.
+// A URL the session already has is left unchanged. Returns sql.ErrNoRows if
+// there is no such session.
+func (s *Store) AddLink(id, url string, ts int64) error {
+	result, err := s.exec("AddLink", `
+		INSERT OR IGNORE INTO session_links (session_id, url, created_at)
+		SELECT id, ?, ? FROM sessions WHERE id = ?
+	`, url, ts, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		if exists, err := s.SessionExists(id); err != nil {
+			return err
+		} else if !exists {
+			return sql.ErrNoRows
+		}
+	}
+	return nil
+}
+
+// RemoveLink detaches a URL from a session. Returns sql.ErrNoRows if the
+// session has no such link.
+func (s *Store) RemoveLink(id, url string) error {
+	result, err := s.exec("RemoveLink", `DELETE FROM session_links WHERE session_id = ? AND url = ?`, id, url)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetAwaiting marks a session as waiting on the user. The earliest time is
+// kept across repeated notifications so waits are measured from the start;
+// the message is always replaced with the latest. Unknown sessions are ignored.
+func (s *Store) SetAwaiting(id, message string, ts int64) error {
+	_, err := s.exec("SetAwaiting", `
+		UPDATE sessions SET
+			awaiting_since = CASE WHEN awaiting_since > 0 THEN awaiting_since ELSE ? END,
+			awaiting_message = ?
+		WHERE id = ?
+	`, ts, message, id)
+	return err
+}
+
+// ListAwaiting returns active sessions waiting on the user, longest wait first.
+func (s *Store) ListAwaiting() ([]Session, error) {
+	return s.listSessions("ListAwaiting", s.sessionListQuery()+`
+		WHERE s.active = 1 AND s.awaiting_since > 0
+		ORDER BY s.awaiting_since ASC
+	`)
+}
+
+// ListRunning returns this host's active sessions, leaving out headless
+// ones, most recently active first. Only ID, Project, PID, Active,
+// LastActivity and AwaitingSince are set: it reads the sessions table
+// alone, so that status bars polling it stay cheap.
+func (s *Store) ListRunning() (sessions []Session, err error) {
+	const query = `
+		SELECT id, project, pid, last_activity, awaiting_since FROM sessions
+		WHERE active = 1 AND headless = 0 AND (host = '' OR host = ?)
+		ORDER BY last_activity DESC`
+	err = s.queryRows("ListRunning", query, []any{s.host}, func(rows *sql.Rows) error {
+		sess := Session{Active: true}
+		var pid sql.NullInt64
+		if err := rows.Scan(&sess.ID, &sess.Project, &pid, &sess.LastActivity, &sess.AwaitingSince); err != nil {
+			return err
+		}
+		if pid.Valid {
+			p := int(pid.Int64)
+			sess.PID = &p
+		}
+		sessions = append(sessions, sess)
+		return nil
+	})
+	return sessions, err
+}
+
 // AddPrompt inserts a prompt and evicts the oldest if the session exceeds the prompt cap.
+// A prompt identical to the session's latest, such as a retry after an error,
+// instead moves the latest to ts and counts another occurrence of it; the
+// session's prompt count still goes up.
 func (s *Store) AddPrompt(sessionID, prompt string, ts int64) error {
-	tx, err := s.db.Begin()
+	defer s.observe("AddPrompt", "INSERT INTO prompts ...", time.Now(), 1)
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = tx.Rollback() }()
+	if err := s.addPrompt(ctx, tx, sessionID, prompt, "", ts); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
-	_, err = tx.Exec(`
-		INSERT INTO prompts (session_id, prompt, timestamp) VALUES (?, ?, ?)
-	`, sessionID, prompt, ts)
+// PromptActivity is a prompt as RecordPromptActivity records it.
+type PromptActivity struct {
+	SessionID string
+	Prompt    string // empty to record only the activity, as for ignored prompts
+	Agent     string // empty for the main agent
+	CWD       string
+	// PermissionMode, Branch and Tags are left as they were when empty.
+	PermissionMode string
+	Branch         string
+	Tags           []string
+	At             int64
+}
+
+// RecordPromptActivity adds a prompt, as AddPrompt does, and records it as
+// the session's activity, as RecordActivity does, along with its permission
+// mode, working directory, branch and tags, in one transaction: a prompt
+// costs a single commit and is never stored half-recorded.
+func (s *Store) RecordPromptActivity(a PromptActivity) error {
+	defer s.observe("RecordPromptActivity", "INSERT INTO prompts ...; UPDATE sessions ...", time.Now(), 1)
+
+	query, args, err := s.activityUpdate(a.SessionID, a.CWD, ActivityPrompt, a.At)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := s.opContext()
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if a.Prompt != "" {
+		if err := s.addPrompt(ctx, tx, a.SessionID, a.Prompt, a.Agent, a.At); err != nil {
+			return err
+		}
+	}
+	if _, err := s.txExec(ctx, tx, query, args...); err != nil {
+		return err
+	}
+	if a.PermissionMode != "" {
+		if _, err := s.txExec(ctx, tx, `UPDATE sessions SET permission_mode = ? WHERE id = ?`, a.PermissionMode, a.SessionID); err != nil {
+			return err
+		}
+	}
+	if err := s.addCWD(ctx, tx, a.SessionID, a.CWD, a.At); err != nil {
+		return err
+	}
+	if a.Branch != "" {
+		if _, err := s.txExec(ctx, tx, `UPDATE sessions SET branch = ? WHERE id = ?`, a.Branch, a.SessionID); err != nil {
+			return err
+		}
+	}
+	if err := s.addTags(ctx, tx, a.SessionID, a.Tags, a.At); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addPrompt adds a prompt in tx, see AddPrompt.
+func (s *Store) addPrompt(ctx context.Context, tx *sql.Tx, sessionID, prompt, agent string, ts int64) error {
+	sp, err := s.storePrompt(prompt)
+	if err != nil {
+		return err
+	}
+	// A prompt sent again, e.g. retried after an error, bumps the latest one
+	result, err := s.txExec(ctx, tx, `
+		UPDATE prompts SET timestamp = MAX(timestamp, ?), occurrences = occurrences + 1
+		WHERE id = (
+			SELECT id FROM prompts WHERE session_id = ?
+			ORDER BY timestamp DESC, id DESC LIMIT 1
+		) AND prompt = ? AND prompt_full IS ? AND full_text IS ? AND agent = ?
+	`, ts, sessionID, sp.text, sp.full, sp.compressed, agent)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		_, err = s.txExec(ctx, tx, `
+			INSERT INTO prompts (session_id, prompt, prompt_full, full_text, timestamp, agent) VALUES (?, ?, ?, ?, ?, ?)
+		`, sessionID, sp.text, sp.full, sp.compressed, ts, agent)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = s.txExec(ctx, tx, `
+		UPDATE sessions SET
+			prompt_count = prompt_count + 1,
+			first_prompt = CASE WHEN first_prompt = '' THEN ? ELSE first_prompt END
+		WHERE id = ?
+	`, sp.text, sessionID)
 	if err != nil {
 		return err
 	}
 
 	// Evict oldest prompts if over the cap
-	_, err = tx.Exec(`
+	_, err = s.txExec(ctx, tx, `
 		DELETE FROM prompts WHERE id IN (
 			SELECT id FROM prompts
 			WHERE session_id = ?
@@ -212,23 +887,162 @@ func (s *Store) AddPrompt(sessionID, prompt string, ts int64) error {
 		return err
 	}
 
+	return nil
+}
+
+// AddCWD appends cwd to the session's working directory history unless it is
+// the same as the most recent entry, evicting the oldest entries over the cap.
+// Unknown sessions are ignored.
+func (s *Store) AddCWD(sessionID, cwd string, ts int64) error {
+	defer s.observe("AddCWD", "INSERT INTO cwd_history ...", time.Now(), 1)
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := s.addCWD(ctx, tx, sessionID, cwd, ts); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RecordToolActivity records a tool call as the session's activity, as
+// RecordActivity does, along with its permission mode, see
+// SetPermissionMode, and working directory, see AddCWD, in one transaction.
+func (s *Store) RecordToolActivity(sessionID, cwd, mode string, ts int64) error {
+	defer s.observe("RecordToolActivity", "UPDATE sessions ...; INSERT INTO cwd_history ...", time.Now(), 1)
+
+	query, args, err := s.activityUpdate(sessionID, cwd, ActivityTool, ts)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := s.opContext()
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := s.txExec(ctx, tx, query, args...); err != nil {
+		return err
+	}
+	if mode != "" {
+		if _, err := s.txExec(ctx, tx, `UPDATE sessions SET permission_mode = ? WHERE id = ?`, mode, sessionID); err != nil {
+			return err
+		}
+	}
+	if err := s.addCWD(ctx, tx, sessionID, cwd, ts); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
 
+// addCWD adds cwd to the session's history in tx, see AddCWD.
+func (s *Store) addCWD(ctx context.Context, tx *sql.Tx, sessionID, cwd string, ts int64) error {
+	resolved := ResolvePath(cwd)
+	result, err := s.txExec(ctx, tx, `
+		INSERT INTO cwd_history (session_id, cwd, timestamp)
+		SELECT ?, ?, ?
+		WHERE EXISTS (SELECT 1 FROM sessions WHERE id = ?)
+			AND COALESCE((
+				SELECT cwd FROM cwd_history WHERE session_id = ?
+				ORDER BY timestamp DESC, id DESC LIMIT 1
+			), '') != ?
+	`, sessionID, resolved, ts, sessionID, sessionID, resolved)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	_, err = s.txExec(ctx, tx, `
+		DELETE FROM cwd_history WHERE id IN (
+			SELECT id FROM cwd_history
+			WHERE session_id = ?
+			ORDER BY timestamp DESC, id DESC
+			LIMIT -1 OFFSET ?
+		)
+	`, sessionID, DefaultMaxCWDs)
+	return err
+}
+
+// GetCWDHistory returns the working directories a session moved through, oldest first.
+func (s *Store) GetCWDHistory(sessionID string) (entries []CWDEntry, err error) {
+	const query = `
+		SELECT session_id, cwd, timestamp
+		FROM cwd_history
+		WHERE session_id = ?
+		ORDER BY timestamp ASC, id ASC
+	`
+	defer func(start time.Time) { s.observe("GetCWDHistory", query, start, int64(len(entries))) }(time.Now())
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	rows, err := s.queryContext(ctx, query, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var e CWDEntry
+		if err := rows.Scan(&e.SessionID, &e.CWD, &e.Timestamp); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// sessionListQuery returns a query selecting sessions joined with their most
+// recent prompt. Callers append a WHERE clause (optional) and ORDER BY.
+func (s *Store) sessionListQuery() string {
+	if !s.windowFuncs {
+		return `
+	SELECT s.id, s.project, s.cwd, s.started_at, s.last_activity, s.pid, s.active, s.model,
+		s.last_prompt_at, s.last_tool_at, s.last_resume_at,
+		s.read_only, s.notes, s.transcript_path, s.awaiting_since, s.awaiting_message,
+		s.tty, s.terminal, s.worked_ms, s.branch,
+		s.outcome, s.outcome_note, s.outcome_at, s.outcome_requested, s.host, s.user, s.permission_mode,
+		s.prompt_count, s.first_prompt, s.title, s.headless, s.resume_args,
+		s.input_tokens, s.output_tokens, s.cache_read_tokens, s.tool_uses, s.tool_errors, s.end_reason, s.concurrent_with,
+		(SELECT group_concat(tag, ',') FROM (SELECT tag FROM session_tags WHERE session_id = s.id ORDER BY created_at, tag)),
+		(SELECT group_concat(url, char(10)) FROM (SELECT url FROM session_links WHERE session_id = s.id ORDER BY created_at, url)),
+		COALESCE((SELECT prompt FROM prompts WHERE session_id = s.id ORDER BY timestamp DESC, id DESC LIMIT 1), ''),
+		(SELECT MAX(timestamp) FROM prompts WHERE session_id = s.id)
+	FROM sessions s
+`
+	}
+	return `
+	SELECT s.id, s.project, s.cwd, s.started_at, s.last_activity, s.pid, s.active, s.model,
+		s.last_prompt_at, s.last_tool_at, s.last_resume_at,
+		s.read_only, s.notes, s.transcript_path, s.awaiting_since, s.awaiting_message,
+		s.tty, s.terminal, s.worked_ms, s.branch,
+		s.outcome, s.outcome_note, s.outcome_at, s.outcome_requested, s.host, s.user, s.permission_mode,
+		s.prompt_count, s.first_prompt, s.title, s.headless, s.resume_args,
+		s.input_tokens, s.output_tokens, s.cache_read_tokens, s.tool_uses, s.tool_errors, s.end_reason, s.concurrent_with,
+		(SELECT group_concat(tag, ',') FROM (SELECT tag FROM session_tags WHERE session_id = s.id ORDER BY created_at, tag)),
+		(SELECT group_concat(url, char(10)) FROM (SELECT url FROM session_links WHERE session_id = s.id ORDER BY created_at, url)),
+		COALESCE(p.prompt, ''), p.timestamp
+	FROM sessions s
+	LEFT JOIN (
+		SELECT session_id, prompt, timestamp,
+			ROW_NUMBER() OVER (PARTITION BY session_id ORDER BY timestamp DESC) as rn
+		FROM prompts
+	) p ON p.session_id = s.id AND p.rn = 1
+`
+}
+
 // ListByProject returns sessions for a given project, ordered by last_activity DESC.
 // Each session includes the most recent prompt text and timestamp.
 // The project path is resolved to its canonical form to handle symlinks.
 func (s *Store) ListByProject(project string) ([]Session, error) {
 	resolved := ResolvePath(project)
-	return s.listSessions(`
-		SELECT s.id, s.project, s.cwd, s.started_at, s.last_activity, s.pid, s.active, s.model,
-			COALESCE(p.prompt, ''), p.timestamp
-		FROM sessions s
-		LEFT JOIN (
-			SELECT session_id, prompt, timestamp,
-				ROW_NUMBER() OVER (PARTITION BY session_id ORDER BY timestamp DESC) as rn
-			FROM prompts
-		) p ON p.session_id = s.id AND p.rn = 1
+	return s.listSessions("ListByProject", s.sessionListQuery()+`
 		WHERE s.project = ?
 		ORDER BY s.last_activity DESC
 	`, resolved)
@@ -236,40 +1050,290 @@ func (s *Store) ListByProject(project string) ([]Session, error) {
 
 // ListAll returns all sessions, ordered by last_activity DESC.
 func (s *Store) ListAll() ([]Session, error) {
-	return s.listSessions(`
-		SELECT s.id, s.project, s.cwd, s.started_at, s.last_activity, s.pid, s.active, s.model,
-			COALESCE(p.prompt, ''), p.timestamp
-		FROM sessions s
-		LEFT JOIN (
-			SELECT session_id, prompt, timestamp,
-				ROW_NUMBER() OVER (PARTITION BY session_id ORDER BY timestamp DESC) as rn
-			FROM prompts
-		) p ON p.session_id = s.id AND p.rn = 1
+	return s.listSessions("ListAll", s.sessionListQuery()+`
 		ORDER BY s.last_activity DESC
 	`)
 }
 
-func (s *Store) listSessions(query string, args ...any) ([]Session, error) {
-	rows, err := s.db.Query(query, args...)
+// ListOptions narrows and pages the result of ListSessions. Zero values
+// mean no restriction.
+type ListOptions struct {
+	Project  string   // only sessions of this project
+	Projects []string // only sessions of one of these projects, e.g. a workspace's
+	Since    int64    // last activity at or after this time (ms)
+	Until    int64    // last activity before this time (ms)
+	Limit    int      // at most this many sessions
+	Offset   int      // skip this many sessions first
+	Tag      string   // only sessions with this tag (case-insensitive)
+	Host     string   // only sessions last started on this host
+	Model    string   // only sessions whose model contains this (case-insensitive)
+	// Search keeps the sessions whose title, prompts, project, branch,
+	// model, tags or links contain each of its words (case-insensitive)
+	Search string
+	// ExcludeHeadless leaves out subagent and non-interactive sessions
+	ExcludeHeadless bool
+}
+
+// ListSessions returns the sessions matching opts, ordered by last_activity
+// DESC. Ties are broken by ID so that consecutive pages don't overlap.
+func (s *Store) ListSessions(opts ListOptions) ([]Session, error) {
+	var conds []string
+	var args []any
+	if opts.Project != "" {
+		conds = append(conds, "s.project = ?")
+		args = append(args, ResolvePath(opts.Project))
+	}
+	if len(opts.Projects) > 0 {
+		conds = append(conds, "s.project IN (?"+strings.Repeat(", ?", len(opts.Projects)-1)+")")
+		for _, p := range opts.Projects {
+			args = append(args, ResolvePath(p))
+		}
+	}
+	if opts.Since > 0 {
+		conds = append(conds, "s.last_activity >= ?")
+		args = append(args, opts.Since)
+	}
+	if opts.Until > 0 {
+		conds = append(conds, "s.last_activity < ?")
+		args = append(args, opts.Until)
+	}
+	if opts.Tag != "" {
+		conds = append(conds, "EXISTS (SELECT 1 FROM session_tags WHERE session_id = s.id AND tag = ?)")
+		args = append(args, opts.Tag)
+	}
+	if opts.Host != "" {
+		conds = append(conds, "s.host = ?")
+		args = append(args, opts.Host)
+	}
+	if opts.Model != "" {
+		conds = append(conds, `s.model LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(opts.Model)+"%")
+	}
+	if opts.ExcludeHeadless {
+		conds = append(conds, "s.headless = 0")
+	}
+	for _, word := range strings.Fields(opts.Search) {
+		conds = append(conds, searchCond)
+		args = append(args, slices.Repeat([]any{"%" + escapeLike(word) + "%"}, strings.Count(searchCond, "?"))...)
+	}
+
+	query := s.sessionListQuery()
+	if len(conds) > 0 {
+		query += "\tWHERE " + strings.Join(conds, " AND ") + "\n"
+	}
+	query += "\tORDER BY s.last_activity DESC, s.id\n"
+	if opts.Limit > 0 || opts.Offset > 0 {
+		limit := opts.Limit
+		if limit <= 0 {
+			limit = -1 // SQLite: no limit
+		}
+		query += "\tLIMIT ? OFFSET ?\n"
+		args = append(args, limit, max(opts.Offset, 0))
+	}
+	return s.listSessions("ListSessions", query, args...)
+}
+
+// searchCond matches the sessions with a word, bound to each ?, in one
+// of the fields the launcher searches.
+const searchCond = `(s.title LIKE ? ESCAPE '\' OR s.first_prompt LIKE ? ESCAPE '\'
+		OR s.project LIKE ? ESCAPE '\' OR s.branch LIKE ? ESCAPE '\' OR s.model LIKE ? ESCAPE '\'
+		OR EXISTS (SELECT 1 FROM prompts WHERE session_id = s.id AND COALESCE(prompt_full, prompt) LIKE ? ESCAPE '\')
+		OR EXISTS (SELECT 1 FROM session_tags WHERE session_id = s.id AND tag LIKE ? ESCAPE '\')
+		OR EXISTS (SELECT 1 FROM session_links WHERE session_id = s.id AND url LIKE ? ESCAPE '\'))`
+
+// ListInactiveBefore returns inactive sessions whose last activity is older than
+// the given cutoff (milliseconds), ordered oldest first.
+func (s *Store) ListInactiveBefore(cutoff int64) ([]Session, error) {
+	return s.listSessions("ListInactiveBefore", s.sessionListQuery()+`
+		WHERE s.active = 0 AND s.last_activity < ?
+		ORDER BY s.last_activity ASC
+	`, cutoff)
+}
+
+// ErrAmbiguousID is returned by GetSession when an ID prefix matches more than one session.
+var ErrAmbiguousID = errors.New("ambiguous session ID prefix")
+
+// GetSession returns the session whose ID equals or uniquely starts with id.
+// Returns sql.ErrNoRows if no session matches and ErrAmbiguousID if several do.
+func (s *Store) GetSession(id string) (Session, error) {
+	sessions, err := s.listSessions("GetSession", s.sessionListQuery()+`
+		WHERE s.id = ? OR s.id LIKE ? ESCAPE '\'
+		ORDER BY s.id = ? DESC, s.last_activity DESC
+		LIMIT 2
+	`, id, escapeLike(id)+"%", id)
+	if err != nil {
+		return Session{}, err
+	}
+	switch {
+	case len(sessions) == 0:
+		return Session{}, sql.ErrNoRows
+	case sessions[0].ID == id || len(sessions) == 1:
+		return sessions[0], nil
+	default:
+		return Session{}, fmt.Errorf("%w: %q", ErrAmbiguousID, id)
+	}
+}
+
+// escapeLike escapes LIKE wildcards so s matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ImportSession inserts a read-only session together with its prompts and
+// working directory history. It fails if a session with the same ID exists.
+func (s *Store) ImportSession(sess Session, prompts []Prompt, cwds []CWDEntry) error {
+	defer s.observe("ImportSession", "INSERT INTO sessions ...", time.Now(), int64(1+len(prompts)+len(cwds)))
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	count, first := sess.PromptCount, sess.FirstPrompt
+	if count < len(prompts) {
+		count = len(prompts)
+	}
+	if first == "" && len(prompts) > 0 {
+		sp, err := s.storePrompt(slices.MinFunc(prompts, func(a, b Prompt) int { return cmp.Compare(a.Timestamp, b.Timestamp) }).Text)
+		if err != nil {
+			return err
+		}
+		first = sp.text
+	}
+	_, err = s.txExec(ctx, tx, `
+		INSERT INTO sessions (id, project, cwd, started_at, last_activity, active, model,
+			last_prompt_at, last_tool_at, last_resume_at, read_only, notes, transcript_path,
+			prompt_count, first_prompt, title)
+		VALUES (?, ?, ?, ?, ?, 0, ?, ?, ?, ?, 1, ?, ?, ?, ?, ?)
+	`, sess.ID, sess.Project, sess.CWD, sess.StartedAt, sess.LastActivity, sess.Model,
+		sess.LastPromptAt, sess.LastToolAt, sess.LastResumeAt, sess.Notes, sess.TranscriptPath,
+		count, first, sess.Title)
+	if err != nil {
+		return fmt.Errorf("insert session: %w", err)
+	}
+	for _, p := range prompts {
+		sp, err := s.storePrompt(p.Text)
+		if err != nil {
+			return err
+		}
+		if _, err := s.txExec(ctx, tx, `
+			INSERT INTO prompts (session_id, prompt, prompt_full, full_text, timestamp, occurrences, agent) VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, sess.ID, sp.text, sp.full, sp.compressed, p.Timestamp, max(p.Occurrences, 1), p.Agent); err != nil {
+			return fmt.Errorf("insert prompt: %w", err)
+		}
+	}
+	for _, c := range cwds {
+		if _, err := s.txExec(ctx, tx, `
+			INSERT INTO cwd_history (session_id, cwd, timestamp) VALUES (?, ?, ?)
+		`, sess.ID, c.CWD, c.Timestamp); err != nil {
+			return fmt.Errorf("insert cwd history: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// AdoptSession inserts an inactive session that cst never saw, such as one
+// read back from its transcript, with its prompts oldest first. Only the
+// newest DefaultMaxPrompt prompts are stored, all of them and their
+// repeats counted. It
+// returns false, changing nothing, if the session exists.
+func (s *Store) AdoptSession(sess Session, prompts []Prompt) (bool, error) {
+	defer s.observe("AdoptSession", "INSERT INTO sessions ...", time.Now(), int64(1+len(prompts)))
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	first, count := "", 0
+	if len(prompts) > 0 {
+		sp, err := s.storePrompt(prompts[0].Text)
+		if err != nil {
+			return false, err
+		}
+		first = sp.text
+	}
+	for _, p := range prompts {
+		count += max(p.Occurrences, 1)
+	}
+	result, err := s.txExec(ctx, tx, `
+		INSERT INTO sessions (id, project, cwd, started_at, last_activity, active, model,
+			last_prompt_at, transcript_path, branch, host, user, headless, prompt_count, first_prompt)
+		VALUES (?, ?, ?, ?, ?, 0, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO NOTHING
+	`, sess.ID, ResolvePath(sess.Project), ResolvePath(sess.CWD), sess.StartedAt, sess.LastActivity, sess.Model,
+		sess.LastPromptAt, sess.TranscriptPath, sess.Branch, sess.Host, sess.User, sess.Headless, count, first)
+	if err != nil {
+		return false, fmt.Errorf("insert session: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	for _, p := range prompts[max(len(prompts)-DefaultMaxPrompt, 0):] {
+		sp, err := s.storePrompt(p.Text)
+		if err != nil {
+			return false, err
+		}
+		if _, err := s.txExec(ctx, tx, `
+			INSERT INTO prompts (session_id, prompt, prompt_full, full_text, timestamp, occurrences) VALUES (?, ?, ?, ?, ?, ?)
+		`, sess.ID, sp.text, sp.full, sp.compressed, p.Timestamp, max(p.Occurrences, 1)); err != nil {
+			return false, fmt.Errorf("insert prompt: %w", err)
+		}
+	}
+	if _, err := s.txExec(ctx, tx, `
+		INSERT INTO cwd_history (session_id, cwd, timestamp) VALUES (?, ?, ?)
+	`, sess.ID, ResolvePath(sess.CWD), sess.StartedAt); err != nil {
+		return false, fmt.Errorf("insert cwd history: %w", err)
+	}
+	return true, tx.Commit()
+}
+
+func (s *Store) listSessions(op, query string, args ...any) (sessions []Session, err error) {
+	defer func(start time.Time) { s.observe(op, query, start, int64(len(sessions))) }(time.Now())
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	rows, err := s.queryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = rows.Close() }()
 
-	var sessions []Session
 	for rows.Next() {
 		var sess Session
-		var active int
+		var active, readOnly, outcomeRequested, headless int
 		var pid sql.NullInt64
 		var promptTS sql.NullInt64
+		var tags, links, resumeArgs, concurrentWith sql.NullString
 		err := rows.Scan(
 			&sess.ID, &sess.Project, &sess.CWD, &sess.StartedAt, &sess.LastActivity,
-			&pid, &active, &sess.Model, &sess.LastPrompt, &promptTS,
+			&pid, &active, &sess.Model,
+			&sess.LastPromptAt, &sess.LastToolAt, &sess.LastResumeAt,
+			&readOnly, &sess.Notes, &sess.TranscriptPath, &sess.AwaitingSince, &sess.AwaitingMessage,
+			&sess.TTY, &sess.Terminal, &sess.WorkedMS, &sess.Branch,
+			&sess.Outcome, &sess.OutcomeNote, &sess.OutcomeAt, &outcomeRequested, &sess.Host, &sess.User, &sess.PermissionMode,
+			&sess.PromptCount, &sess.FirstPrompt, &sess.Title, &headless, &resumeArgs,
+			&sess.InputTokens, &sess.OutputTokens, &sess.CacheReadTokens, &sess.ToolUses, &sess.ToolErrors, &sess.EndReason, &concurrentWith, &tags, &links,
+			&sess.LastPrompt, &promptTS,
 		)
 		if err != nil {
 			return nil, err
 		}
 		sess.Active = active != 0
+		sess.ReadOnly = readOnly != 0
+		sess.OutcomeRequested = outcomeRequested != 0
+		sess.Headless = headless != 0
+		if resumeArgs.String != "" {
+			if err := json.Unmarshal([]byte(resumeArgs.String), &sess.ResumeArgs); err != nil {
+				return nil, fmt.Errorf("session %s: resume args: %w", sess.ID, err)
+			}
+		}
 		if pid.Valid {
 			p := int(pid.Int64)
 			sess.PID = &p
@@ -278,85 +1342,231 @@ func (s *Store) listSessions(query string, args ...any) ([]Session, error) {
 			ts := promptTS.Int64
 			sess.LastPromptTS = &ts
 		}
+		if tags.String != "" {
+			sess.Tags = strings.Split(tags.String, ",")
+		}
+		if concurrentWith.String != "" {
+			sess.ConcurrentWith = strings.Split(concurrentWith.String, ",")
+		}
+		if links.String != "" {
+			sess.Links = strings.Split(links.String, "\n")
+		}
 		sessions = append(sessions, sess)
 	}
 	return sessions, rows.Err()
 }
 
 // GetPrompts returns the last N prompts for a session, ordered newest first.
-func (s *Store) GetPrompts(sessionID string, limit int) ([]Prompt, error) {
-	rows, err := s.db.Query(`
-		SELECT id, session_id, prompt, timestamp
+func (s *Store) GetPrompts(sessionID string, limit int) (prompts []Prompt, err error) {
+	const query = `
+		SELECT id, session_id, prompt, prompt_full, full_text, timestamp, occurrences, agent
 		FROM prompts
 		WHERE session_id = ?
-		ORDER BY timestamp DESC
+		ORDER BY timestamp DESC, id DESC
 		LIMIT ?
-	`, sessionID, limit)
+	`
+	defer func(start time.Time) { s.observe("GetPrompts", query, start, int64(len(prompts))) }(time.Now())
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	rows, err := s.queryContext(ctx, query, sessionID, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = rows.Close() }()
 
-	var prompts []Prompt
 	for rows.Next() {
 		var p Prompt
-		if err := rows.Scan(&p.ID, &p.SessionID, &p.Text, &p.Timestamp); err != nil {
+		var full sql.NullString
+		var compressed []byte
+		if err := rows.Scan(&p.ID, &p.SessionID, &p.Text, &full, &compressed, &p.Timestamp, &p.Occurrences, &p.Agent); err != nil {
 			return nil, err
 		}
+		if p.Text, err = promptText(p.Text, full, compressed); err != nil {
+			return nil, fmt.Errorf("prompt %d: %w", p.ID, err)
+		}
 		prompts = append(prompts, p)
 	}
 	return prompts, rows.Err()
 }
 
-// DeleteSession removes a session and its prompts (cascade).
-func (s *Store) DeleteSession(id string) error {
-	_, err := s.db.Exec(`DELETE FROM sessions WHERE id = ?`, id)
+// PromptHistory returns up to perSession of the most recent prompt texts of
+// each of the given sessions, newest first, keyed by session ID. Sessions
+// without prompts are left out.
+func (s *Store) PromptHistory(sessionIDs []string, perSession int) (history map[string][]string, err error) {
+	history = make(map[string][]string)
+	if len(sessionIDs) == 0 {
+		return history, nil
+	}
+	in := `(?` + strings.Repeat(", ?", len(sessionIDs)-1) + `)`
+	query := `
+		SELECT session_id, COALESCE(prompt_full, prompt) FROM (
+			SELECT session_id, prompt, prompt_full, timestamp, id,
+				ROW_NUMBER() OVER (PARTITION BY session_id ORDER BY timestamp DESC, id DESC) AS n
+			FROM prompts
+			WHERE session_id IN ` + in + `
+		)
+		WHERE n <= ?
+		ORDER BY session_id, timestamp DESC, id DESC
+	`
+	if !s.windowFuncs {
+		query = `
+		SELECT p.session_id, COALESCE(p.prompt_full, p.prompt) FROM prompts p
+		WHERE p.session_id IN ` + in + `
+			AND p.id IN (SELECT q.id FROM prompts q WHERE q.session_id = p.session_id
+				ORDER BY q.timestamp DESC, q.id DESC LIMIT ?)
+		ORDER BY p.session_id, p.timestamp DESC, p.id DESC
+	`
+	}
+	defer func(start time.Time) { s.observe("PromptHistory", query, start, int64(len(history))) }(time.Now())
+
+	args := make([]any, 0, len(sessionIDs)+1)
+	for _, id := range sessionIDs {
+		args = append(args, id)
+	}
+	args = append(args, perSession)
+	ctx, cancel := s.opContext()
+	defer cancel()
+	rows, err := s.queryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var id, text string
+		if err := rows.Scan(&id, &text); err != nil {
+			return nil, err
+		}
+		history[id] = append(history[id], text)
+	}
+	return history, rows.Err()
+}
+
+// SessionExists reports whether a session with exactly this ID is tracked.
+func (s *Store) SessionExists(id string) (exists bool, err error) {
+	const query = `SELECT EXISTS (SELECT 1 FROM sessions WHERE id = ?)`
+	defer func(start time.Time) { s.observe("SessionExists", query, start, 1) }(time.Now())
+	ctx, cancel := s.opContext()
+	defer cancel()
+	err = s.queryRowContext(ctx, query, id).Scan(&exists)
+	return exists, err
+}
+
+// RecordHookAnomaly increments the counter for a rejected or repaired hook event.
+func (s *Store) RecordHookAnomaly(event, reason string, ts int64) error {
+	_, err := s.exec("RecordHookAnomaly", `
+		INSERT INTO hook_anomalies (event, reason, count, last_seen) VALUES (?, ?, 1, ?)
+		ON CONFLICT(event, reason) DO UPDATE SET
+			count = count + 1,
+			last_seen = excluded.last_seen
+	`, event, reason, ts)
 	return err
 }
 
-// Cleanup removes inactive sessions older than the specified number of days.
-func (s *Store) Cleanup(olderThanDays int) (int, error) {
-	cutoff := time.Now().Add(-time.Duration(olderThanDays) * 24 * time.Hour).UnixMilli()
-	result, err := s.db.Exec(`
-		DELETE FROM sessions WHERE active = 0 AND last_activity < ?
-	`, cutoff)
+// HookAnomalies returns all hook anomaly counters, most recent first.
+func (s *Store) HookAnomalies() (anomalies []HookAnomaly, err error) {
+	const query = `SELECT event, reason, count, last_seen FROM hook_anomalies ORDER BY last_seen DESC`
+	defer func(start time.Time) { s.observe("HookAnomalies", query, start, int64(len(anomalies))) }(time.Now())
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	rows, err := s.queryContext(ctx, query)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	rows, err := result.RowsAffected()
-	return int(rows), err
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var a HookAnomaly
+		if err := rows.Scan(&a.Event, &a.Reason, &a.Count, &a.LastSeen); err != nil {
+			return nil, err
+		}
+		anomalies = append(anomalies, a)
+	}
+	return anomalies, rows.Err()
 }
 
-// EnforceCap removes the oldest inactive sessions if the total count exceeds maxSessions.
-func (s *Store) EnforceCap(maxSessions int) error {
-	_, err := s.db.Exec(`
-		DELETE FROM sessions WHERE id IN (
-			SELECT id FROM sessions
-			WHERE active = 0
-			ORDER BY last_activity ASC
-			LIMIT MAX(0, (SELECT COUNT(*) FROM sessions) - ?)
-		)
-	`, maxSessions)
+// CountSessions returns the total number of tracked sessions across all projects.
+func (s *Store) CountSessions() (n int, err error) {
+	const query = `SELECT COUNT(*) FROM sessions`
+	defer func(start time.Time) { s.observe("CountSessions", query, start, 1) }(time.Now())
+	ctx, cancel := s.opContext()
+	defer cancel()
+	err = s.queryRowContext(ctx, query).Scan(&n)
+	return n, err
+}
+
+// DeleteSession removes a session and its prompts (cascade).
+func (s *Store) DeleteSession(id string) error {
+	_, err := s.exec("DeleteSession", `DELETE FROM sessions WHERE id = ?`, id)
 	return err
 }
 
+// DeletePrompt removes a single prompt from a session's history, and the
+// session's first prompt if it is the same text. The prompt count is kept.
+// Returns sql.ErrNoRows if no prompt has the given ID.
+func (s *Store) DeletePrompt(id int64) error {
+	_, err := s.exec("DeletePrompt", `
+		UPDATE sessions SET first_prompt = ''
+		FROM (SELECT session_id, prompt FROM prompts WHERE id = ?) AS p
+		WHERE sessions.id = p.session_id AND sessions.first_prompt = p.prompt
+	`, id)
+	if err != nil {
+		return err
+	}
+	result, err := s.exec("DeletePrompt", `DELETE FROM prompts WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // RefreshActive checks all active sessions and deactivates those whose PID is no longer alive.
+// A session is only deactivated at the second consecutive failed check, at
+// least the PID grace period (see SetPIDGrace) after the first, which is
+// recorded in last_pid_check; a hook activating it again in between clears
+// that. Sessions started on another host, e.g. in a database shared over a
+// network mount, are skipped: their PIDs cannot be checked from here. A
+// read-only store is left as it is.
 func (s *Store) RefreshActive(isAlive func(pid int) bool) error {
-	rows, err := s.db.Query(`SELECT id, pid FROM sessions WHERE active = 1`)
+	if s.readOnly {
+		return nil
+	}
+	const query = `SELECT id, pid, last_pid_check FROM sessions WHERE active = 1 AND (host = '' OR host = ?)`
+	defer s.observe("RefreshActive", query, time.Now(), 0)
+
+	ctx, cancel := s.opContext()
+	defer cancel()
+	rows, err := s.queryContext(ctx, query, s.host)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = rows.Close() }()
 
-	var toDeactivate []string
+	now := time.Now().UnixMilli()
+	var toDeactivate, failed, alive []string
 	for rows.Next() {
 		var id string
 		var pid sql.NullInt64
-		if err := rows.Scan(&id, &pid); err != nil {
+		var lastCheck int64
+		if err := rows.Scan(&id, &pid, &lastCheck); err != nil {
 			return err
 		}
-		if !pid.Valid || !isAlive(int(pid.Int64)) {
+		switch {
+		case pid.Valid && isAlive(int(pid.Int64)):
+			if lastCheck != 0 {
+				alive = append(alive, id)
+			}
+		case s.pidGrace < 0 || lastCheck != 0 && now-lastCheck >= s.pidGrace.Milliseconds():
 			toDeactivate = append(toDeactivate, id)
+		case lastCheck == 0:
+			failed = append(failed, id)
 		}
 	}
 	if err := rows.Err(); err != nil {
@@ -368,5 +1578,15 @@ func (s *Store) RefreshActive(isAlive func(pid int) bool) error {
 			return err
 		}
 	}
+	for _, id := range failed {
+		if _, err := s.exec("RefreshActive", `UPDATE sessions SET last_pid_check = ? WHERE id = ?`, now, id); err != nil {
+			return err
+		}
+	}
+	for _, id := range alive {
+		if _, err := s.exec("RefreshActive", `UPDATE sessions SET last_pid_check = 0 WHERE id = ?`, id); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -1,13 +1,18 @@
 package store
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 )
 
-func testStore(t *testing.T) *Store {
+func testStore(t testing.TB) *Store {
 	t.Helper()
 	dir := t.TempDir()
 	dbPath := filepath.Join(dir, "test.db")
@@ -203,6 +208,176 @@ func TestAddPromptAndGetPrompts(t *testing.T) {
 	}
 }
 
+func TestAddPromptRepeated(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	for i, text := range []string{"fix the build", "fix the build", "fix the build", "thanks", "fix the build"} {
+		if err := s.AddPrompt("s1", text, now+int64(i)); err != nil {
+			t.Fatalf("AddPrompt: %v", err)
+		}
+	}
+
+	prompts, err := s.GetPrompts("s1", 10)
+	if err != nil {
+		t.Fatalf("GetPrompts: %v", err)
+	}
+	// Only repeats in a row are folded
+	want := []Prompt{{Text: "fix the build", Timestamp: now + 4, Occurrences: 1}, {Text: "thanks", Timestamp: now + 3, Occurrences: 1}, {Text: "fix the build", Timestamp: now + 2, Occurrences: 3}}
+	if len(prompts) != len(want) {
+		t.Fatalf("got %d prompts, want %d", len(prompts), len(want))
+	}
+	for i, p := range prompts {
+		if p.Text != want[i].Text || p.Timestamp != want[i].Timestamp || p.Occurrences != want[i].Occurrences {
+			t.Errorf("prompt %d = %q at %d (×%d), want %q at %d (×%d)", i, p.Text, p.Timestamp, p.Occurrences, want[i].Text, want[i].Timestamp, want[i].Occurrences)
+		}
+	}
+
+	got, err := s.GetSession("s1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if got.PromptCount != 5 {
+		t.Errorf("PromptCount = %d, want 5", got.PromptCount)
+	}
+}
+
+func TestPromptCountAndFirstPrompt(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	for i := 0; i < DefaultMaxPrompt+4; i++ {
+		if err := s.AddPrompt("s1", fmt.Sprintf("prompt %d", i), now+int64(i)); err != nil {
+			t.Fatalf("AddPrompt: %v", err)
+		}
+	}
+
+	// The count and first prompt outlive the prompt cap
+	got, err := s.GetSession("s1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if got.PromptCount != DefaultMaxPrompt+4 || got.FirstPrompt != "prompt 0" {
+		t.Errorf("PromptCount, FirstPrompt = %d, %q, want %d, %q", got.PromptCount, got.FirstPrompt, DefaultMaxPrompt+4, "prompt 0")
+	}
+
+	// Deleting the first prompt drops it as the title too
+	if err := s.UpsertSession(Session{ID: "s2", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	for i, text := range []string{"my password is hunter2", "second"} {
+		if err := s.AddPrompt("s2", text, now+int64(i)); err != nil {
+			t.Fatalf("AddPrompt: %v", err)
+		}
+	}
+	prompts, err := s.GetPrompts("s2", 10)
+	if err != nil {
+		t.Fatalf("GetPrompts: %v", err)
+	}
+	if err := s.DeletePrompt(prompts[1].ID); err != nil {
+		t.Fatalf("DeletePrompt: %v", err)
+	}
+	got, err = s.GetSession("s2")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if got.PromptCount != 2 || got.FirstPrompt != "" {
+		t.Errorf("after delete PromptCount, FirstPrompt = %d, %q, want 2, empty", got.PromptCount, got.FirstPrompt)
+	}
+}
+
+func TestSetResumeArgs(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+
+	want := []string{"--model", "opus", "--append-system-prompt", "be terse, please"}
+	if err := s.SetResumeArgs("s1", want); err != nil {
+		t.Fatalf("SetResumeArgs: %v", err)
+	}
+	if err := s.SetResumeArgs("missing", want); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("SetResumeArgs(missing) = %v, want sql.ErrNoRows", err)
+	}
+	got, err := s.GetSession("s1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if !slices.Equal(got.ResumeArgs, want) {
+		t.Errorf("ResumeArgs = %q, want %q", got.ResumeArgs, want)
+	}
+
+	if err := s.SetResumeArgs("s1", nil); err != nil {
+		t.Fatalf("SetResumeArgs(nil): %v", err)
+	}
+	if got, _ = s.GetSession("s1"); got.ResumeArgs != nil {
+		t.Errorf("ResumeArgs after clearing = %q", got.ResumeArgs)
+	}
+}
+
+func TestSetTitle(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	for _, sess := range []Session{
+		{ID: "s1", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now},
+		{ID: "s2", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now + 1},
+	} {
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	if err := s.SetTitle("s1", "Fix retry loop"); err != nil {
+		t.Fatalf("SetTitle: %v", err)
+	}
+	if err := s.SetTitle("missing", "x"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("SetTitle(missing) = %v, want sql.ErrNoRows", err)
+	}
+
+	got, err := s.GetSession("s1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if got.Title != "Fix retry loop" {
+		t.Errorf("Title = %q", got.Title)
+	}
+	untitled, err := s.ListUntitled()
+	if err != nil {
+		t.Fatalf("ListUntitled: %v", err)
+	}
+	if len(untitled) != 1 || untitled[0].ID != "s2" {
+		t.Errorf("ListUntitled = %v, want [s2]", untitled)
+	}
+}
+
+func TestSetProject(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/old", CWD: "/old/pkg", StartedAt: now, LastActivity: now}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	if err := s.SetProject("s1", "/new"); err != nil {
+		t.Fatalf("SetProject: %v", err)
+	}
+	if err := s.SetProject("missing", "/new"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("SetProject(missing) = %v, want sql.ErrNoRows", err)
+	}
+	got, err := s.GetSession("s1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if got.Project != "/new" || got.CWD != "/new" {
+		t.Errorf("Project, CWD = %q, %q, want /new for both", got.Project, got.CWD)
+	}
+}
+
 func TestListIncludesLatestPrompt(t *testing.T) {
 	s := testStore(t)
 	now := time.Now().UnixMilli()
@@ -271,6 +446,76 @@ func TestDeleteSession(t *testing.T) {
 	}
 }
 
+func TestPromptHistory(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	for _, id := range []string{"s1", "s2", "s3"} {
+		if err := s.UpsertSession(Session{ID: id, Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	for i, text := range []string{"one", "two", "three"} {
+		if err := s.AddPrompt("s1", text, now+int64(i)); err != nil {
+			t.Fatalf("AddPrompt: %v", err)
+		}
+	}
+	if err := s.AddPrompt("s2", "other", now); err != nil {
+		t.Fatalf("AddPrompt: %v", err)
+	}
+
+	for _, windowFuncs := range []bool{true, false} {
+		s.windowFuncs = windowFuncs
+		history, err := s.PromptHistory([]string{"s1", "s3"}, 2)
+		if err != nil {
+			t.Fatalf("windowFuncs=%v: PromptHistory: %v", windowFuncs, err)
+		}
+		if len(history) != 1 {
+			t.Fatalf("windowFuncs=%v: expected only s1 in history, got %v", windowFuncs, history)
+		}
+		if got := history["s1"]; len(got) != 2 || got[0] != "three" || got[1] != "two" {
+			t.Errorf("windowFuncs=%v: expected the two newest prompts of s1, got %v", windowFuncs, got)
+		}
+	}
+
+	if history, err := s.PromptHistory(nil, 2); err != nil || len(history) != 0 {
+		t.Errorf("expected empty history for no sessions, got %v, %v", history, err)
+	}
+}
+
+func TestDeletePrompt(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	for i, text := range []string{"first", "my password is hunter2", "third"} {
+		if err := s.AddPrompt("s1", text, now+int64(i)); err != nil {
+			t.Fatalf("AddPrompt: %v", err)
+		}
+	}
+	prompts, err := s.GetPrompts("s1", 10)
+	if err != nil {
+		t.Fatalf("GetPrompts: %v", err)
+	}
+
+	if err := s.DeletePrompt(prompts[1].ID); err != nil {
+		t.Fatalf("DeletePrompt: %v", err)
+	}
+	if err := s.DeletePrompt(prompts[1].ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("DeletePrompt twice = %v, want sql.ErrNoRows", err)
+	}
+
+	prompts, err = s.GetPrompts("s1", 10)
+	if err != nil {
+		t.Fatalf("GetPrompts: %v", err)
+	}
+	if len(prompts) != 2 || prompts[0].Text != "third" || prompts[1].Text != "first" {
+		t.Errorf("prompts after delete = %+v, want third, first", prompts)
+	}
+}
+
 func TestCleanup(t *testing.T) {
 	s := testStore(t)
 	now := time.Now().UnixMilli()
@@ -300,12 +545,21 @@ func TestCleanup(t *testing.T) {
 		}
 	}
 
-	removed, err := s.Cleanup(30)
+	policy := func(string) (int, int) { return 30, 0 }
+	expired, err := s.ExpiredSessions(policy, 0, "")
+	if err != nil {
+		t.Fatalf("ExpiredSessions: %v", err)
+	}
+	if len(expired) != 1 || expired[0].ID != "old-inactive" {
+		t.Errorf("expired = %v, want old-inactive only", expired)
+	}
+
+	removed, err := s.Cleanup(policy, 0, "")
 	if err != nil {
 		t.Fatalf("Cleanup: %v", err)
 	}
-	if removed != 1 {
-		t.Errorf("removed = %d, want 1", removed)
+	if len(removed) != 1 || removed[0].ID != "old-inactive" {
+		t.Errorf("removed = %v, want old-inactive only", removed)
 	}
 
 	sessions, err := s.ListAll()
@@ -333,7 +587,7 @@ func TestEnforceCap(t *testing.T) {
 		}
 	}
 
-	if err := s.EnforceCap(3); err != nil {
+	if err := s.EnforceCap(3, nil); err != nil {
 		t.Fatalf("EnforceCap: %v", err)
 	}
 
@@ -351,6 +605,147 @@ func TestEnforceCap(t *testing.T) {
 	}
 }
 
+func TestCleanupPerProjectPolicy(t *testing.T) {
+	s := testStore(t)
+	now := time.Now()
+	daysAgo := func(d int) int64 { return now.AddDate(0, 0, -d).UnixMilli() }
+
+	for _, tc := range []struct {
+		id, project string
+		ts          int64
+		active      bool
+	}{
+		{"main-old", "/main", daysAgo(400), false},
+		{"scratch-1", "/scratch", daysAgo(1), true},
+		{"scratch-2", "/scratch", daysAgo(2), false},
+		{"scratch-3", "/scratch", daysAgo(3), false},
+		{"scratch-4", "/scratch", daysAgo(4), false},
+		{"other-old", "/other", daysAgo(40), false},
+		{"other-new", "/other", daysAgo(5), false},
+	} {
+		sess := Session{ID: tc.id, Project: tc.project, CWD: tc.project, StartedAt: tc.ts, LastActivity: tc.ts, Active: tc.active}
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession %s: %v", tc.id, err)
+		}
+	}
+	policy := func(project string) (int, int) {
+		switch project {
+		case "/main":
+			return 0, 0 // unlimited
+		case "/scratch":
+			return 30, 2
+		}
+		return 30, 0
+	}
+
+	// Scoped to one project, only its sessions go
+	removed, err := s.Cleanup(policy, 0, "/scratch")
+	if err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("removed %d sessions, want 2 (scratch sessions beyond the cap)", len(removed))
+	}
+
+	removed, err = s.Cleanup(policy, 0, "")
+	if err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+	if len(removed) != 1 {
+		t.Errorf("removed %d sessions, want 1 (other-old)", len(removed))
+	}
+
+	sessions, err := s.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	var ids []string
+	for _, sess := range sessions {
+		ids = append(ids, sess.ID)
+	}
+	slices.Sort(ids)
+	if want := []string{"main-old", "other-new", "scratch-1", "scratch-2"}; !slices.Equal(ids, want) {
+		t.Errorf("remaining = %v, want %v", ids, want)
+	}
+}
+
+func TestCleanupCleared(t *testing.T) {
+	s := testStore(t)
+	daysAgo := func(n int) int64 { return time.Now().AddDate(0, 0, -n).UnixMilli() }
+	for _, tc := range []struct {
+		id, project, reason string
+		ts                  int64
+	}{
+		{"cleared-old", "/p", "clear", daysAgo(10)},
+		{"cleared-new", "/p", "clear", daysAgo(2)},
+		{"exited-old", "/p", "prompt_input_exit", daysAgo(10)},
+		{"cleared-kept", "/main", "clear", daysAgo(10)},
+	} {
+		sess := Session{ID: tc.id, Project: tc.project, CWD: tc.project, StartedAt: tc.ts, LastActivity: tc.ts, Active: true}
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession %s: %v", tc.id, err)
+		}
+		if err := s.EndSession(tc.id, tc.reason, tc.ts); err != nil {
+			t.Fatalf("EndSession %s: %v", tc.id, err)
+		}
+	}
+	policy := func(project string) (int, int) {
+		if project == "/main" {
+			return 0, 0 // kept regardless of age
+		}
+		return 30, 0
+	}
+
+	// Cleared sessions go after clearedDays; the rest keep the project's age
+	expired, err := s.ExpiredSessions(policy, 7, "")
+	if err != nil {
+		t.Fatalf("ExpiredSessions: %v", err)
+	}
+	if len(expired) != 1 || expired[0].ID != "cleared-old" {
+		t.Errorf("expired = %+v, want cleared-old only", expired)
+	}
+	// A clearedDays longer than the project's retention changes nothing
+	if expired, err = s.ExpiredSessions(func(string) (int, int) { return 5, 0 }, 7, "/p"); err != nil || len(expired) != 2 {
+		t.Errorf("expired with 5-day retention = %+v, %v, want the two 10-day-old sessions", expired, err)
+	}
+}
+
+func TestEnforceCapPerProject(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	for i, id := range []string{"a1", "a2", "a3", "b1", "b2", "b3"} {
+		project := "/" + id[:1]
+		sess := Session{ID: id, Project: project, CWD: project, StartedAt: now + int64(i), LastActivity: now + int64(i)}
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	// Only /a is capped; ages are left to Cleanup
+	policy := func(project string) (int, int) {
+		if project == "/a" {
+			return 1, 1
+		}
+		return 1, 0
+	}
+	if err := s.EnforceCap(100, policy); err != nil {
+		t.Fatalf("EnforceCap: %v", err)
+	}
+
+	sessions, err := s.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	var ids []string
+	for _, sess := range sessions {
+		ids = append(ids, sess.ID)
+	}
+	slices.Sort(ids)
+	if want := []string{"a3", "b1", "b2", "b3"}; !slices.Equal(ids, want) {
+		t.Errorf("remaining = %v, want %v", ids, want)
+	}
+}
+
 func TestRefreshActive(t *testing.T) {
 	s := testStore(t)
 	now := time.Now().UnixMilli()
@@ -365,16 +760,49 @@ func TestRefreshActive(t *testing.T) {
 		t.Fatalf("UpsertSession: %v", err)
 	}
 
-	// Simulate dead process
-	if err := s.RefreshActive(func(pid int) bool { return false }); err != nil {
-		t.Fatalf("RefreshActive: %v", err)
+	dead := func(pid int) bool { return false }
+	alive := func(pid int) bool { return true }
+	active := func() bool {
+		t.Helper()
+		got, err := s.GetSession("s1")
+		if err != nil {
+			t.Fatalf("GetSession: %v", err)
+		}
+		return got.Active
+	}
+	refresh := func(isAlive func(int) bool) {
+		t.Helper()
+		if err := s.RefreshActive(isAlive); err != nil {
+			t.Fatalf("RefreshActive: %v", err)
+		}
 	}
 
-	sessions, err := s.ListAll()
-	if err != nil {
-		t.Fatalf("ListAll: %v", err)
+	// Within the grace period, as when claude restarts, the session stays active
+	refresh(dead)
+	refresh(dead)
+	if !active() {
+		t.Error("session should stay active within the PID grace period")
 	}
-	if sessions[0].Active {
+
+	// A live PID in between starts the count again
+	s.SetPIDGrace(0)
+	refresh(alive)
+	refresh(dead)
+	if !active() {
+		t.Error("session should stay active after one failed check")
+	}
+	refresh(dead)
+	if active() {
+		t.Error("session should be inactive after two failed checks")
+	}
+
+	// Without a grace period, the first failed check deactivates
+	if err := s.Activate("s1", pid, "sonnet", "/proj"); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	s.SetPIDGrace(-1)
+	refresh(dead)
+	if active() {
 		t.Error("session should be inactive after RefreshActive with dead PID")
 	}
 }
@@ -407,3 +835,982 @@ func TestUpdateActivity(t *testing.T) {
 		t.Errorf("CWD = %q, want %q", sessions[0].CWD, "/proj/sub")
 	}
 }
+
+func TestListInactiveBefore(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+	old := now - 61*24*60*60*1000 // 61 days ago
+
+	for _, tc := range []struct {
+		id     string
+		active bool
+		ts     int64
+	}{
+		{"old-inactive", false, old},
+		{"old-active", true, old},
+		{"new-inactive", false, now},
+	} {
+		sess := Session{
+			ID: tc.id, Project: "/proj", CWD: "/proj",
+			StartedAt: tc.ts, LastActivity: tc.ts,
+			Active: tc.active, Model: "sonnet",
+		}
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession %s: %v", tc.id, err)
+		}
+	}
+
+	sessions, err := s.ListInactiveBefore(now - 60*24*60*60*1000)
+	if err != nil {
+		t.Fatalf("ListInactiveBefore: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(sessions))
+	}
+	if sessions[0].ID != "old-inactive" {
+		t.Errorf("ID = %q, want %q", sessions[0].ID, "old-inactive")
+	}
+}
+
+func TestListSessionsOptions(t *testing.T) {
+	s := testStore(t)
+	for i, id := range []string{"s1", "s2", "s3", "s4", "s5"} {
+		project, model := "/a", "claude-opus-4-1"
+		if i%2 == 1 {
+			project, model = "/b", "claude-sonnet-4-5"
+		}
+		ts := int64(1000 * (i + 1))
+		sess := Session{ID: id, Project: project, CWD: project, StartedAt: ts, LastActivity: ts, Model: model}
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession %s: %v", id, err)
+		}
+	}
+
+	ids := func(opts ListOptions) []string {
+		t.Helper()
+		sessions, err := s.ListSessions(opts)
+		if err != nil {
+			t.Fatalf("ListSessions(%+v): %v", opts, err)
+		}
+		var got []string
+		for _, sess := range sessions {
+			got = append(got, sess.ID)
+		}
+		return got
+	}
+
+	for _, tc := range []struct {
+		opts ListOptions
+		want []string
+	}{
+		{ListOptions{}, []string{"s5", "s4", "s3", "s2", "s1"}},
+		{ListOptions{Limit: 2}, []string{"s5", "s4"}},
+		{ListOptions{Limit: 2, Offset: 2}, []string{"s3", "s2"}},
+		{ListOptions{Offset: 3}, []string{"s2", "s1"}},
+		{ListOptions{Project: "/a"}, []string{"s5", "s3", "s1"}},
+		{ListOptions{Since: 2000, Until: 4000}, []string{"s3", "s2"}},
+		{ListOptions{Project: "/b", Since: 3000}, []string{"s4"}},
+		{ListOptions{Projects: []string{"/a", "/b"}, Limit: 3}, []string{"s5", "s4", "s3"}},
+		{ListOptions{Projects: []string{"/b", "/c"}}, []string{"s4", "s2"}},
+		{ListOptions{Model: "Sonnet"}, []string{"s4", "s2"}},
+		{ListOptions{Model: "opus", Since: 2000}, []string{"s5", "s3"}},
+		{ListOptions{Model: "%"}, nil},
+		{ListOptions{Offset: 10}, nil},
+	} {
+		if got := ids(tc.opts); !slices.Equal(got, tc.want) {
+			t.Errorf("ListSessions(%+v) = %v, want %v", tc.opts, got, tc.want)
+		}
+	}
+}
+
+func TestListSessionsSearch(t *testing.T) {
+	s := testStore(t)
+	for i, id := range []string{"s1", "s2", "s3", "s4"} {
+		ts := int64(1000 * (i + 1))
+		if err := s.UpsertSession(Session{ID: id, Project: "/work/" + id, CWD: "/proj", StartedAt: ts, LastActivity: ts}); err != nil {
+			t.Fatalf("UpsertSession %s: %v", id, err)
+		}
+	}
+	for _, err := range []error{
+		s.AddPrompt("s1", "Fix the RETRY loop in the uploader", 1500),
+		s.AddPrompt("s1", "now the tests", 1600),
+		s.AddTags("s2", []string{"retry"}, 2500),
+		s.AddLink("s3", "https://example.com/uploader/42", 3500),
+		s.SetTitle("s4", "100% done"),
+	} {
+		if err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		opts ListOptions
+		want []string
+	}{
+		{ListOptions{Search: "retry"}, []string{"s2", "s1"}},
+		{ListOptions{Search: "uploader"}, []string{"s3", "s1"}},
+		{ListOptions{Search: "retry uploader"}, []string{"s1"}},
+		{ListOptions{Search: "work/s4"}, []string{"s4"}},
+		{ListOptions{Search: "100%"}, []string{"s4"}},
+		{ListOptions{Search: "1%"}, nil},
+		{ListOptions{Search: "uploader", Limit: 1, Offset: 1}, []string{"s1"}},
+	} {
+		sessions, err := s.ListSessions(tc.opts)
+		if err != nil {
+			t.Fatalf("ListSessions(%+v): %v", tc.opts, err)
+		}
+		var got []string
+		for _, sess := range sessions {
+			got = append(got, sess.ID)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("ListSessions(%+v) = %v, want %v", tc.opts, got, tc.want)
+		}
+	}
+}
+
+func TestRecordPromptActivity(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now, Active: true}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	if err := s.SetAwaiting("s1", "permission needed", now); err != nil {
+		t.Fatalf("SetAwaiting: %v", err)
+	}
+
+	prompt := PromptActivity{SessionID: "s1", Prompt: "fix the tests", CWD: "/proj/sub",
+		PermissionMode: "plan", Branch: "gh-42-fix", Tags: []string{"gh-42"}, At: now + 1000}
+	if err := s.RecordPromptActivity(prompt); err != nil {
+		t.Fatalf("RecordPromptActivity: %v", err)
+	}
+	sessions, err := s.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	got := sessions[0]
+	if got.LastPrompt != "fix the tests" || got.PromptCount != 1 || got.FirstPrompt != "fix the tests" {
+		t.Errorf("prompt: last %q, count %d, first %q", got.LastPrompt, got.PromptCount, got.FirstPrompt)
+	}
+	if got.LastPromptAt != now+1000 || got.LastActivity != now+1000 || got.CWD != "/proj/sub" || got.AwaitingSince != 0 {
+		t.Errorf("activity: last_prompt_at %d, last_activity %d, cwd %q, awaiting_since %d", got.LastPromptAt, got.LastActivity, got.CWD, got.AwaitingSince)
+	}
+	if got.PermissionMode != "plan" || got.Branch != "gh-42-fix" || !slices.Equal(got.Tags, []string{"gh-42"}) {
+		t.Errorf("mode %q, branch %q, tags %v", got.PermissionMode, got.Branch, got.Tags)
+	}
+	history, err := s.GetCWDHistory("s1")
+	if err != nil || len(history) != 1 || history[0].CWD != "/proj/sub" {
+		t.Errorf("GetCWDHistory = %v, %v", history, err)
+	}
+
+	// An ignored prompt is activity only, keeping the mode and branch
+	if err := s.RecordPromptActivity(PromptActivity{SessionID: "s1", CWD: "/proj/sub", At: now + 2000}); err != nil {
+		t.Fatalf("RecordPromptActivity without a prompt: %v", err)
+	}
+	if got, err := s.GetSession("s1"); err != nil || got.PromptCount != 1 || got.LastPromptAt != now+2000 || got.Branch != "gh-42-fix" || got.PermissionMode != "plan" {
+		t.Errorf("after an ignored prompt: %+v, %v", got, err)
+	}
+
+	// The prompts of unknown sessions are refused by their foreign key
+	if err := s.RecordPromptActivity(PromptActivity{SessionID: "nope", Prompt: "lost", CWD: "/proj", At: now}); err == nil {
+		t.Error("RecordPromptActivity for an unknown session succeeded")
+	}
+}
+
+func TestRecordActivity(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	sess := Session{
+		ID: "s1", Project: "/proj", CWD: "/proj",
+		StartedAt: now, LastActivity: now, Model: "sonnet",
+	}
+	if err := s.UpsertSession(sess); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+
+	if err := s.RecordActivity("s1", "/proj", ActivityPrompt, now+1000); err != nil {
+		t.Fatalf("RecordActivity prompt: %v", err)
+	}
+	if err := s.RecordActivity("s1", "/proj/sub", ActivityTool, now+2000); err != nil {
+		t.Fatalf("RecordActivity tool: %v", err)
+	}
+	if err := s.RecordActivity("s1", "/proj", "bogus", now); err == nil {
+		t.Error("expected error for unknown activity source")
+	}
+
+	sessions, err := s.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	got := sessions[0]
+	if got.LastPromptAt != now+1000 {
+		t.Errorf("LastPromptAt = %d, want %d", got.LastPromptAt, now+1000)
+	}
+	if got.LastToolAt != now+2000 {
+		t.Errorf("LastToolAt = %d, want %d", got.LastToolAt, now+2000)
+	}
+	if got.LastResumeAt != 0 {
+		t.Errorf("LastResumeAt = %d, want 0", got.LastResumeAt)
+	}
+	if got.LastActivity != now+2000 {
+		t.Errorf("LastActivity = %d, want %d", got.LastActivity, now+2000)
+	}
+	if got.CWD != "/proj/sub" {
+		t.Errorf("CWD = %q, want %q", got.CWD, "/proj/sub")
+	}
+}
+
+func TestListWithoutWindowFunctions(t *testing.T) {
+	s := testStore(t)
+	if !s.windowFuncs {
+		t.Fatal("expected window function support to be detected")
+	}
+	s.windowFuncs = false
+	now := time.Now().UnixMilli()
+
+	for _, id := range []string{"s1", "s2"} {
+		sess := Session{
+			ID: id, Project: "/proj", CWD: "/proj",
+			StartedAt: now, LastActivity: now, Model: "sonnet",
+		}
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	if err := s.AddPrompt("s1", "first prompt", now); err != nil {
+		t.Fatalf("AddPrompt: %v", err)
+	}
+	if err := s.AddPrompt("s1", "second prompt", now+1000); err != nil {
+		t.Fatalf("AddPrompt: %v", err)
+	}
+
+	sessions, err := s.ListByProject("/proj")
+	if err != nil {
+		t.Fatalf("ListByProject: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+	for _, sess := range sessions {
+		switch sess.ID {
+		case "s1":
+			if sess.LastPrompt != "second prompt" {
+				t.Errorf("LastPrompt = %q, want %q", sess.LastPrompt, "second prompt")
+			}
+			if sess.LastPromptTS == nil || *sess.LastPromptTS != now+1000 {
+				t.Errorf("LastPromptTS = %v, want %d", sess.LastPromptTS, now+1000)
+			}
+		case "s2":
+			if sess.LastPrompt != "" || sess.LastPromptTS != nil {
+				t.Errorf("s2 should have no prompt, got %q / %v", sess.LastPrompt, sess.LastPromptTS)
+			}
+		}
+	}
+}
+
+func TestCountSessions(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	n, err := s.CountSessions()
+	if err != nil {
+		t.Fatalf("CountSessions: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("CountSessions on empty store = %d, want 0", n)
+	}
+
+	for _, tc := range []struct{ id, project string }{{"s1", "/a"}, {"s2", "/b"}} {
+		sess := Session{
+			ID: tc.id, Project: tc.project, CWD: tc.project,
+			StartedAt: now, LastActivity: now, Model: "sonnet",
+		}
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	n, err = s.CountSessions()
+	if err != nil {
+		t.Fatalf("CountSessions: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("CountSessions = %d, want 2", n)
+	}
+}
+
+func TestRecordToolActivity(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now, Active: true}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+
+	if err := s.RecordToolActivity("s1", "/proj/api", "plan", now+1000); err != nil {
+		t.Fatalf("RecordToolActivity: %v", err)
+	}
+	// A hook without a permission mode keeps the recorded one
+	if err := s.RecordToolActivity("s1", "/proj/api", "", now+2000); err != nil {
+		t.Fatalf("RecordToolActivity: %v", err)
+	}
+	got, err := s.GetSession("s1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if got.LastToolAt != now+2000 || got.LastActivity != now+2000 || got.CWD != "/proj/api" || got.PermissionMode != "plan" {
+		t.Errorf("activity: last_tool_at %d, last_activity %d, cwd %q, mode %q", got.LastToolAt, got.LastActivity, got.CWD, got.PermissionMode)
+	}
+	history, err := s.GetCWDHistory("s1")
+	if err != nil {
+		t.Fatalf("GetCWDHistory: %v", err)
+	}
+	if len(history) != 1 || history[0].CWD != "/proj/api" {
+		t.Errorf("cwd history = %+v, want /proj/api once", history)
+	}
+
+	// Unknown sessions are ignored
+	if err := s.RecordToolActivity("nope", "/proj", "plan", now); err != nil {
+		t.Errorf("RecordToolActivity for an unknown session: %v", err)
+	}
+}
+
+func TestAddCWDAndHistory(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	sess := Session{
+		ID: "s1", Project: "/proj", CWD: "/proj",
+		StartedAt: now, LastActivity: now, Model: "sonnet",
+	}
+	if err := s.UpsertSession(sess); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+
+	for i, cwd := range []string{"/proj", "/proj", "/proj/api", "/proj/api", "/proj"} {
+		if err := s.AddCWD("s1", cwd, now+int64(i)); err != nil {
+			t.Fatalf("AddCWD %d: %v", i, err)
+		}
+	}
+	// Unknown sessions are ignored rather than violating the foreign key
+	if err := s.AddCWD("missing", "/proj", now); err != nil {
+		t.Fatalf("AddCWD unknown session: %v", err)
+	}
+
+	history, err := s.GetCWDHistory("s1")
+	if err != nil {
+		t.Fatalf("GetCWDHistory: %v", err)
+	}
+	want := []string{"/proj", "/proj/api", "/proj"}
+	if len(history) != len(want) {
+		t.Fatalf("history len = %d, want %d: %+v", len(history), len(want), history)
+	}
+	for i, e := range history {
+		if e.CWD != want[i] {
+			t.Errorf("history[%d] = %q, want %q", i, e.CWD, want[i])
+		}
+	}
+}
+
+func TestAddCWDCap(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	sess := Session{
+		ID: "s1", Project: "/proj", CWD: "/proj",
+		StartedAt: now, LastActivity: now, Model: "sonnet",
+	}
+	if err := s.UpsertSession(sess); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	for i := 0; i < DefaultMaxCWDs+5; i++ {
+		cwd := "/proj/a"
+		if i%2 == 1 {
+			cwd = "/proj/b"
+		}
+		if err := s.AddCWD("s1", cwd, now+int64(i)); err != nil {
+			t.Fatalf("AddCWD %d: %v", i, err)
+		}
+	}
+
+	history, err := s.GetCWDHistory("s1")
+	if err != nil {
+		t.Fatalf("GetCWDHistory: %v", err)
+	}
+	if len(history) != DefaultMaxCWDs {
+		t.Errorf("history len = %d, want %d", len(history), DefaultMaxCWDs)
+	}
+}
+
+func TestGetSession(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	for _, id := range []string{"abc-111", "abc-222", "def-333"} {
+		sess := Session{
+			ID: id, Project: "/proj", CWD: "/proj",
+			StartedAt: now, LastActivity: now, Model: "sonnet",
+		}
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+
+	got, err := s.GetSession("def")
+	if err != nil {
+		t.Fatalf("GetSession prefix: %v", err)
+	}
+	if got.ID != "def-333" {
+		t.Errorf("GetSession prefix ID = %q, want %q", got.ID, "def-333")
+	}
+
+	got, err = s.GetSession("abc-222")
+	if err != nil {
+		t.Fatalf("GetSession exact: %v", err)
+	}
+	if got.ID != "abc-222" {
+		t.Errorf("GetSession exact ID = %q, want %q", got.ID, "abc-222")
+	}
+
+	if _, err := s.GetSession("abc"); !errors.Is(err, ErrAmbiguousID) {
+		t.Errorf("GetSession ambiguous error = %v, want ErrAmbiguousID", err)
+	}
+	if _, err := s.GetSession("zzz"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetSession missing error = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.GetSession("%"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetSession wildcard error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestImportSession(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	sess := Session{
+		ID: "imported-1", Project: "/other/proj", CWD: "/other/proj/api",
+		StartedAt: now, LastActivity: now, Model: "opus",
+		Notes: "see prompt 3", TranscriptPath: "/tmp/imported-1.jsonl",
+	}
+	prompts := []Prompt{{Text: "first", Timestamp: now}, {Text: "second", Timestamp: now + 1}}
+	cwds := []CWDEntry{{CWD: "/other/proj", Timestamp: now}, {CWD: "/other/proj/api", Timestamp: now + 1}}
+	if err := s.ImportSession(sess, prompts, cwds); err != nil {
+		t.Fatalf("ImportSession: %v", err)
+	}
+	if err := s.ImportSession(sess, nil, nil); err == nil {
+		t.Error("expected error importing a duplicate session")
+	}
+
+	got, err := s.GetSession("imported-1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if !got.ReadOnly || got.Active {
+		t.Errorf("ReadOnly = %v, Active = %v; want true, false", got.ReadOnly, got.Active)
+	}
+	if got.Notes != "see prompt 3" || got.TranscriptPath != "/tmp/imported-1.jsonl" {
+		t.Errorf("Notes/TranscriptPath = %q/%q", got.Notes, got.TranscriptPath)
+	}
+	if got.LastPrompt != "second" {
+		t.Errorf("LastPrompt = %q, want %q", got.LastPrompt, "second")
+	}
+	history, err := s.GetCWDHistory("imported-1")
+	if err != nil {
+		t.Fatalf("GetCWDHistory: %v", err)
+	}
+	if len(history) != 2 {
+		t.Errorf("history len = %d, want 2", len(history))
+	}
+}
+
+func TestAdoptSession(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	sess := Session{
+		ID: "adopted-1", Project: "/proj", CWD: "/proj", StartedAt: now - 5000, LastActivity: now,
+		Model: "claude-opus-4-1", LastPromptAt: now - 1000, Branch: "main", TranscriptPath: "/tmp/adopted-1.jsonl",
+	}
+	var prompts []Prompt
+	for i := range DefaultMaxPrompt + 2 {
+		prompts = append(prompts, Prompt{Text: fmt.Sprintf("p%d", i), Timestamp: now - 5000 + int64(i)})
+	}
+	if ok, err := s.AdoptSession(sess, prompts); err != nil || !ok {
+		t.Fatalf("AdoptSession = %v, %v, want true", ok, err)
+	}
+	if ok, err := s.AdoptSession(Session{ID: "adopted-1", Project: "/elsewhere"}, nil); err != nil || ok {
+		t.Errorf("AdoptSession of a known session = %v, %v, want false", ok, err)
+	}
+
+	got, err := s.GetSession("adopted-1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if got.ReadOnly || got.Active || got.Project != "/proj" || got.Branch != "main" || got.Model != "claude-opus-4-1" {
+		t.Errorf("adopted session = %+v", got)
+	}
+	if got.PromptCount != DefaultMaxPrompt+2 || got.FirstPrompt != "p0" || got.LastPrompt != fmt.Sprintf("p%d", DefaultMaxPrompt+1) {
+		t.Errorf("PromptCount/FirstPrompt/LastPrompt = %d/%q/%q", got.PromptCount, got.FirstPrompt, got.LastPrompt)
+	}
+	stored, err := s.GetPrompts("adopted-1", DefaultMaxPrompt+2)
+	if err != nil {
+		t.Fatalf("GetPrompts: %v", err)
+	}
+	if len(stored) != DefaultMaxPrompt {
+		t.Errorf("stored %d prompts, want %d", len(stored), DefaultMaxPrompt)
+	}
+}
+
+func TestHookAnomalies(t *testing.T) {
+	s := testStore(t)
+
+	for i := 0; i < 3; i++ {
+		if err := s.RecordHookAnomaly("UserPromptSubmit", "invalid session ID", int64(i)); err != nil {
+			t.Fatalf("RecordHookAnomaly: %v", err)
+		}
+	}
+	if err := s.RecordHookAnomaly("SessionStart", "missing session ID", 10); err != nil {
+		t.Fatalf("RecordHookAnomaly: %v", err)
+	}
+
+	anomalies, err := s.HookAnomalies()
+	if err != nil {
+		t.Fatalf("HookAnomalies: %v", err)
+	}
+	if len(anomalies) != 2 {
+		t.Fatalf("expected 2 anomalies, got %d", len(anomalies))
+	}
+	if anomalies[0].Event != "SessionStart" {
+		t.Errorf("most recent anomaly = %q, want SessionStart", anomalies[0].Event)
+	}
+	if anomalies[1].Count != 3 || anomalies[1].LastSeen != 2 {
+		t.Errorf("counter = %+v, want count 3, last_seen 2", anomalies[1])
+	}
+}
+
+func TestSessionExists(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	sess := Session{
+		ID: "abc-123", Project: "/proj", CWD: "/proj",
+		StartedAt: now, LastActivity: now, Model: "sonnet",
+	}
+	if err := s.UpsertSession(sess); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+
+	for id, want := range map[string]bool{"abc-123": true, "abc": false, "zzz": false} {
+		got, err := s.SessionExists(id)
+		if err != nil {
+			t.Fatalf("SessionExists(%q): %v", id, err)
+		}
+		if got != want {
+			t.Errorf("SessionExists(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	canceled := s.WithContext(ctx)
+	if _, err := canceled.ListAll(); !errors.Is(err, context.Canceled) {
+		t.Errorf("ListAll with a canceled context: err = %v", err)
+	}
+	if err := canceled.AddTags("s1", []string{"x"}, now); !errors.Is(err, context.Canceled) {
+		t.Errorf("AddTags with a canceled context: err = %v", err)
+	}
+	if _, err := canceled.SessionExists("s1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("SessionExists with a canceled context: err = %v", err)
+	}
+
+	// The store it was made from is unaffected
+	if sessions, err := s.ListAll(); err != nil || len(sessions) != 1 || len(sessions[0].Tags) != 0 {
+		t.Errorf("ListAll = %+v, %v, want the untagged session", sessions, err)
+	}
+
+	s.SetTimeout(time.Nanosecond)
+	if err := s.UpdateActivity("s1", "/proj", now); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("UpdateActivity past its timeout: err = %v", err)
+	}
+	s.SetTimeout(0)
+	if err := s.UpdateActivity("s1", "/proj", now); err != nil {
+		t.Errorf("UpdateActivity without a timeout: %v", err)
+	}
+}
+
+func TestSetAwaiting(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	for _, id := range []string{"s1", "s2", "s3"} {
+		if err := s.UpsertSession(Session{ID: id, Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now, Active: true}); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	if err := s.SetAwaiting("s2", "needs permission", now+100); err != nil {
+		t.Fatalf("SetAwaiting: %v", err)
+	}
+	if err := s.SetAwaiting("s1", "waiting for input", now+200); err != nil {
+		t.Fatalf("SetAwaiting: %v", err)
+	}
+	// A repeated notification keeps the original start time.
+	if err := s.SetAwaiting("s2", "still waiting", now+300); err != nil {
+		t.Fatalf("SetAwaiting: %v", err)
+	}
+	if err := s.SetAwaiting("missing", "x", now); err != nil {
+		t.Fatalf("SetAwaiting unknown session: %v", err)
+	}
+
+	waiting, err := s.ListAwaiting()
+	if err != nil {
+		t.Fatalf("ListAwaiting: %v", err)
+	}
+	if len(waiting) != 2 || waiting[0].ID != "s2" || waiting[1].ID != "s1" {
+		t.Fatalf("ListAwaiting = %+v, want s2 then s1", waiting)
+	}
+	if waiting[0].AwaitingSince != now+100 || waiting[0].AwaitingMessage != "still waiting" {
+		t.Errorf("s2 awaiting = %d %q, want %d %q", waiting[0].AwaitingSince, waiting[0].AwaitingMessage, now+100, "still waiting")
+	}
+
+	// Activity and ending the session both clear the wait.
+	if err := s.RecordActivity("s2", "/proj", ActivityPrompt, now+400); err != nil {
+		t.Fatalf("RecordActivity: %v", err)
+	}
+	if err := s.Deactivate("s1"); err != nil {
+		t.Fatalf("Deactivate: %v", err)
+	}
+	waiting, err = s.ListAwaiting()
+	if err != nil {
+		t.Fatalf("ListAwaiting: %v", err)
+	}
+	if len(waiting) != 0 {
+		t.Errorf("ListAwaiting after activity = %+v, want none", waiting)
+	}
+}
+
+func TestSetTerminal(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	if err := s.SetTerminal("s1", "pts/3", "kitty"); err != nil {
+		t.Fatalf("SetTerminal: %v", err)
+	}
+	sess, err := s.GetSession("s1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.TTY != "pts/3" || sess.Terminal != "kitty" {
+		t.Errorf("terminal = %q %q, want pts/3 kitty", sess.TTY, sess.Terminal)
+	}
+}
+
+func TestSetTranscriptPath(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	for _, path := range []string{"/home/u/.claude/projects/-proj/s1.jsonl", ""} {
+		if err := s.SetTranscriptPath("s1", path); err != nil {
+			t.Fatalf("SetTranscriptPath(%q): %v", path, err)
+		}
+	}
+	sess, err := s.GetSession("s1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if want := "/home/u/.claude/projects/-proj/s1.jsonl"; sess.TranscriptPath != want {
+		t.Errorf("TranscriptPath = %q, want %q kept over an empty path", sess.TranscriptPath, want)
+	}
+}
+
+func TestWorkedTimeExcludesIdleGaps(t *testing.T) {
+	s := testStore(t)
+	start := time.Now().UnixMilli()
+	at := func(d time.Duration) int64 { return start + d.Milliseconds() }
+
+	if err := s.UpsertSession(Session{ID: "s1", Project: "/proj", CWD: "/proj", StartedAt: start, LastActivity: start, Active: true}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	for _, ev := range []struct {
+		source ActivitySource
+		ts     int64
+	}{
+		{ActivityPrompt, at(5 * time.Minute)},
+		{ActivityTool, at(10 * time.Minute)},
+		{ActivityPrompt, at(60 * time.Minute)}, // 50m idle gap, not counted
+	} {
+		if err := s.RecordActivity("s1", "/proj", ev.source, ev.ts); err != nil {
+			t.Fatalf("RecordActivity: %v", err)
+		}
+	}
+	if err := s.EndSession("s1", "prompt_input_exit", at(62*time.Minute)); err != nil {
+		t.Fatalf("EndSession: %v", err)
+	}
+
+	sess, err := s.GetSession("s1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if want := (12 * time.Minute).Milliseconds(); sess.WorkedMS != want {
+		t.Errorf("WorkedMS = %v, want %v", time.Duration(sess.WorkedMS)*time.Millisecond, 12*time.Minute)
+	}
+	if sess.Active || sess.LastActivity != at(62*time.Minute) || sess.EndReason != "prompt_input_exit" {
+		t.Errorf("after EndSession: active=%v last_activity=%d end_reason=%q", sess.Active, sess.LastActivity, sess.EndReason)
+	}
+
+	// With a longer idle gap the pause counts too.
+	s.SetIdleGap(time.Hour)
+	if err := s.Activate("s1", 1, "", "/proj"); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	sess, _ = s.GetSession("s1")
+	if sess.EndReason != "" {
+		t.Errorf("EndReason after Activate = %q, want it cleared", sess.EndReason)
+	}
+	if err := s.RecordActivity("s1", "/proj", ActivityPrompt, sess.LastActivity+(50*time.Minute).Milliseconds()); err != nil {
+		t.Fatalf("RecordActivity: %v", err)
+	}
+	sess, _ = s.GetSession("s1")
+	if want := (62 * time.Minute).Milliseconds(); sess.WorkedMS != want {
+		t.Errorf("WorkedMS with 1h idle gap = %v, want %v", time.Duration(sess.WorkedMS)*time.Millisecond, 62*time.Minute)
+	}
+}
+
+func TestBranchAndTags(t *testing.T) {
+	s := testStore(t)
+	for _, id := range []string{"s1", "s2"} {
+		if err := s.UpsertSession(Session{ID: id, Project: "/p", CWD: "/p", StartedAt: 1, LastActivity: 1}); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	if err := s.SetBranch("s1", "feature/JIRA-123-login"); err != nil {
+		t.Fatalf("SetBranch: %v", err)
+	}
+	if err := s.AddTags("s1", []string{"JIRA-123", "1234"}, 10); err != nil {
+		t.Fatalf("AddTags: %v", err)
+	}
+	if err := s.AddTags("s1", []string{"jira-123"}, 20); err != nil {
+		t.Fatalf("AddTags duplicate: %v", err)
+	}
+
+	sess, err := s.GetSession("s1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.Branch != "feature/JIRA-123-login" {
+		t.Errorf("Branch = %q", sess.Branch)
+	}
+	if want := []string{"1234", "JIRA-123"}; !slices.Equal(sess.Tags, want) {
+		t.Errorf("Tags = %q, want %q", sess.Tags, want)
+	}
+
+	tagged, err := s.ListSessions(ListOptions{Tag: "jira-123"})
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(tagged) != 1 || tagged[0].ID != "s1" {
+		t.Errorf("ListSessions(Tag) = %v, want [s1]", tagged)
+	}
+
+	for _, expr := range []string{"tag = jira-123", "ticket = JIRA-123"} {
+		f, err := ParseFilter(expr, time.Now())
+		if err != nil {
+			t.Fatalf("ParseFilter: %v", err)
+		}
+		queried, err := s.QuerySessions([]Filter{f}, "", 0)
+		if err != nil {
+			t.Fatalf("QuerySessions: %v", err)
+		}
+		if len(queried) != 1 || queried[0].ID != "s1" {
+			t.Errorf("QuerySessions(%s) = %v, want [s1]", expr, queried)
+		}
+	}
+
+	if err := s.DeleteSession("s1"); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM session_tags`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("tags after delete = %d, %v; want 0", n, err)
+	}
+}
+
+func TestOutcomes(t *testing.T) {
+	s := testStore(t)
+	for _, id := range []string{"s1", "s2", "s3"} {
+		if err := s.UpsertSession(Session{ID: id, Project: "/p", CWD: "/p", StartedAt: 1, LastActivity: 1}); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	for _, id := range []string{"s1", "s2"} {
+		if err := s.RequestOutcome(id); err != nil {
+			t.Fatalf("RequestOutcome: %v", err)
+		}
+	}
+
+	pending, err := s.ListUnlabeled()
+	if err != nil {
+		t.Fatalf("ListUnlabeled: %v", err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("ListUnlabeled = %d sessions, want 2", len(pending))
+	}
+
+	if err := s.SetOutcome("s1", "shipped", "merged the fix", 50); err != nil {
+		t.Fatalf("SetOutcome: %v", err)
+	}
+	sess, err := s.GetSession("s1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.Outcome != "shipped" || sess.OutcomeNote != "merged the fix" || sess.OutcomeAt != 50 || sess.OutcomeRequested {
+		t.Errorf("outcome = %q %q %d requested=%v", sess.Outcome, sess.OutcomeNote, sess.OutcomeAt, sess.OutcomeRequested)
+	}
+
+	// A labelled session is not asked about again when it ends another time.
+	if err := s.RequestOutcome("s1"); err != nil {
+		t.Fatalf("RequestOutcome: %v", err)
+	}
+	pending, err = s.ListUnlabeled()
+	if err != nil {
+		t.Fatalf("ListUnlabeled: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != "s2" {
+		t.Errorf("ListUnlabeled = %v, want [s2]", pending)
+	}
+
+	if err := s.SetOutcome("missing", "shipped", "", 50); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("SetOutcome(missing) = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestHostsAndRefreshActive(t *testing.T) {
+	s := testStore(t)
+	s.host = "laptop"
+	pid := 4242
+	for _, id := range []string{"local", "remote", "legacy"} {
+		if err := s.UpsertSession(Session{ID: id, Project: "/p", CWD: "/p", StartedAt: 1, LastActivity: 1, PID: &pid, Active: true}); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	if err := s.SetHost("local", "laptop", "me"); err != nil {
+		t.Fatalf("SetHost: %v", err)
+	}
+	if err := s.SetHost("remote", "desktop", "me"); err != nil {
+		t.Fatalf("SetHost: %v", err)
+	}
+
+	// No PID is alive here: only sessions of this host, or without a
+	// recorded host, can be judged by that.
+	s.SetPIDGrace(-1)
+	if err := s.RefreshActive(func(int) bool { return false }); err != nil {
+		t.Fatalf("RefreshActive: %v", err)
+	}
+	for id, want := range map[string]bool{"local": false, "remote": true, "legacy": false} {
+		sess, err := s.GetSession(id)
+		if err != nil {
+			t.Fatalf("GetSession: %v", err)
+		}
+		if sess.Active != want {
+			t.Errorf("%s: Active = %v, want %v", id, sess.Active, want)
+		}
+	}
+
+	remote, err := s.ListSessions(ListOptions{Host: "desktop"})
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(remote) != 1 || remote[0].ID != "remote" || remote[0].User != "me" {
+		t.Errorf("ListSessions(Host) = %+v, want [remote]", remote)
+	}
+}
+
+func TestListRunning(t *testing.T) {
+	s := testStore(t)
+	s.host = "laptop"
+	pid := 4242
+	for i, id := range []string{"older", "newer", "remote", "subagent", "ended"} {
+		sess := Session{ID: id, Project: "/p/" + id, CWD: "/p", StartedAt: 1, LastActivity: int64(i + 1), PID: &pid, Active: id != "ended"}
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	if err := s.SetHost("remote", "desktop", "me"); err != nil {
+		t.Fatalf("SetHost: %v", err)
+	}
+	if err := s.SetHeadless("subagent", true); err != nil {
+		t.Fatalf("SetHeadless: %v", err)
+	}
+	if err := s.SetAwaiting("older", "needs permission", 10); err != nil {
+		t.Fatalf("SetAwaiting: %v", err)
+	}
+
+	running, err := s.ListRunning()
+	if err != nil {
+		t.Fatalf("ListRunning: %v", err)
+	}
+	var ids []string
+	for _, sess := range running {
+		ids = append(ids, sess.ID)
+	}
+	if want := []string{"newer", "older"}; !slices.Equal(ids, want) {
+		t.Fatalf("ListRunning = %v, want %v", ids, want)
+	}
+	if older := running[1]; older.Project != "/p/older" || older.PID == nil || *older.PID != pid || older.AwaitingSince != 10 {
+		t.Errorf("older = %+v", older)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	s := testStore(t)
+	if err := s.UpsertSession(Session{ID: "a", Project: "/p", CWD: "/p", StartedAt: 1, LastActivity: 1}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	const issue, pr = "https://github.com/acme/api/issues/42", "https://github.com/acme/api/pull/7"
+	for i, link := range []string{issue, pr, issue} {
+		if err := s.AddLink("a", link, int64(i+1)); err != nil {
+			t.Fatalf("AddLink(%s): %v", link, err)
+		}
+	}
+	if err := s.AddLink("missing", issue, 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("AddLink to a missing session: %v, want sql.ErrNoRows", err)
+	}
+
+	sess, err := s.GetSession("a")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if want := []string{issue, pr}; !slices.Equal(sess.Links, want) {
+		t.Errorf("Links = %v, want %v", sess.Links, want)
+	}
+
+	if err := s.RemoveLink("a", issue); err != nil {
+		t.Fatalf("RemoveLink: %v", err)
+	}
+	if err := s.RemoveLink("a", issue); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("RemoveLink twice: %v, want sql.ErrNoRows", err)
+	}
+	if sess, _ = s.GetSession("a"); !slices.Equal(sess.Links, []string{pr}) {
+		t.Errorf("Links after RemoveLink = %v, want [%s]", sess.Links, pr)
+	}
+
+	if err := s.DeleteSession("a"); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM session_links`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("links left after DeleteSession: %d (%v)", n, err)
+	}
+}
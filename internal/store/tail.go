@@ -0,0 +1,53 @@
+package store
+
+import (
+	"database/sql"
+)
+
+// TailState is how far cst daemon has read an active session's transcript.
+type TailState struct {
+	SessionID      string
+	TranscriptPath string // empty if not recorded yet
+	Offset         int64  // bytes read
+}
+
+// TokenUsage is what cst daemon read from the end of a session's transcript.
+type TokenUsage struct {
+	InputTokens     int64
+	OutputTokens    int64
+	CacheReadTokens int64
+	ToolUses        int
+	At              int64 // time of the latest entry read, 0 if unknown
+}
+
+// TailStates returns the running local sessions with how far their
+// transcripts have been read.
+func (s *Store) TailStates() ([]TailState, error) {
+	var states []TailState
+	err := s.queryRows("TailStates", `
+		SELECT id, transcript_path, tail_offset FROM sessions WHERE active = 1 AND read_only = 0
+	`, nil, func(rows *sql.Rows) error {
+		var st TailState
+		if err := rows.Scan(&st.SessionID, &st.TranscriptPath, &st.Offset); err != nil {
+			return err
+		}
+		states = append(states, st)
+		return nil
+	})
+	return states, err
+}
+
+// RecordTail adds usage read from a session's transcript and notes that it
+// has been read up to offset. An entry later than the session's last
+// activity counts as activity, so sessions working on their own for a long
+// time between prompts still look busy and accrue time worked.
+func (s *Store) RecordTail(id string, u TokenUsage, offset int64) error {
+	_, err := s.exec("RecordTail", `
+		UPDATE sessions SET `+workedSQL+`, last_activity = MAX(last_activity, ?),
+			input_tokens = input_tokens + ?, output_tokens = output_tokens + ?,
+			cache_read_tokens = cache_read_tokens + ?, tool_uses = tool_uses + ?, tail_offset = ?
+		WHERE id = ?
+	`, u.At, s.idleGap.Milliseconds(), u.At, u.At,
+		u.InputTokens, u.OutputTokens, u.CacheReadTokens, u.ToolUses, offset, id)
+	return err
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTailStatesAndRecordTail(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	if err := s.UpsertSession(Session{ID: "running", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now, Active: true}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	if err := s.UpsertSession(Session{ID: "ended", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+	if err := s.SetTranscriptPath("running", "/t/running.jsonl"); err != nil {
+		t.Fatalf("SetTranscriptPath: %v", err)
+	}
+
+	states, err := s.TailStates()
+	if err != nil {
+		t.Fatalf("TailStates: %v", err)
+	}
+	if len(states) != 1 || states[0] != (TailState{SessionID: "running", TranscriptPath: "/t/running.jsonl"}) {
+		t.Fatalf("TailStates = %+v, want the running session unread", states)
+	}
+
+	// Usage adds up; only a later entry moves last activity and counts as worked
+	at := now + 60_000
+	if err := s.RecordTail("running", TokenUsage{InputTokens: 100, OutputTokens: 20, CacheReadTokens: 1000, ToolUses: 2, At: at}, 512); err != nil {
+		t.Fatalf("RecordTail: %v", err)
+	}
+	if err := s.RecordTail("running", TokenUsage{InputTokens: 5, OutputTokens: 1, ToolUses: 1, At: now}, 600); err != nil {
+		t.Fatalf("RecordTail: %v", err)
+	}
+	sess, err := s.GetSession("running")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.InputTokens != 105 || sess.OutputTokens != 21 || sess.CacheReadTokens != 1000 || sess.ToolUses != 3 {
+		t.Errorf("usage = %d/%d/%d/%d, want 105/21/1000/3", sess.InputTokens, sess.OutputTokens, sess.CacheReadTokens, sess.ToolUses)
+	}
+	if sess.LastActivity != at || sess.WorkedMS != 60_000 {
+		t.Errorf("LastActivity/WorkedMS = %d/%d, want %d/60000", sess.LastActivity, sess.WorkedMS, at)
+	}
+	if states, err = s.TailStates(); err != nil || len(states) != 1 || states[0].Offset != 600 {
+		t.Errorf("TailStates = %+v, %v, want offset 600", states, err)
+	}
+}
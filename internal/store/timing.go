@@ -0,0 +1,96 @@
+package store
+
+import (
+	"database/sql"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSlowQueryThreshold is the duration above which queries are logged.
+const DefaultSlowQueryThreshold = 100 * time.Millisecond
+
+// QueryStat aggregates execution timing for one store operation.
+type QueryStat struct {
+	Op    string
+	Calls int
+	Total time.Duration
+	Max   time.Duration
+	Rows  int64
+}
+
+// timing records per-operation query statistics and logs slow queries.
+type timing struct {
+	mu        sync.Mutex
+	logger    *slog.Logger
+	threshold time.Duration
+	stats     map[string]*QueryStat
+}
+
+// SetQueryLogger enables slow-query logging: queries taking longer than
+// threshold are logged with their SQL and row count. A nil logger disables it.
+func (s *Store) SetQueryLogger(logger *slog.Logger, threshold time.Duration) {
+	s.timing.mu.Lock()
+	defer s.timing.mu.Unlock()
+	s.timing.logger = logger
+	s.timing.threshold = threshold
+}
+
+// QueryStats returns timing statistics for every operation executed so far,
+// ordered by total time spent, slowest first.
+func (s *Store) QueryStats() []QueryStat {
+	s.timing.mu.Lock()
+	defer s.timing.mu.Unlock()
+	stats := make([]QueryStat, 0, len(s.timing.stats))
+	for _, st := range s.timing.stats {
+		stats = append(stats, *st)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Total > stats[j].Total })
+	return stats
+}
+
+// observe records the execution of op, started at start and affecting or
+// returning rows rows.
+func (s *Store) observe(op, query string, start time.Time, rows int64) {
+	elapsed := time.Since(start)
+
+	s.timing.mu.Lock()
+	defer s.timing.mu.Unlock()
+	if s.timing.stats == nil {
+		s.timing.stats = make(map[string]*QueryStat)
+	}
+	st, ok := s.timing.stats[op]
+	if !ok {
+		st = &QueryStat{Op: op}
+		s.timing.stats[op] = st
+	}
+	st.Calls++
+	st.Total += elapsed
+	st.Rows += rows
+	st.Max = max(st.Max, elapsed)
+
+	if s.timing.logger != nil && elapsed >= s.timing.threshold {
+		s.timing.logger.Debug("slow query",
+			"op", op,
+			"duration", elapsed,
+			"rows", rows,
+			"sql", strings.Join(strings.Fields(query), " "),
+		)
+	}
+}
+
+// exec runs a statement and records its timing under op.
+func (s *Store) exec(op, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	ctx, cancel := s.opContext()
+	defer cancel()
+	result, err := s.execContext(ctx, query, args...)
+	var rows int64
+	if err == nil {
+		rows, _ = result.RowsAffected()
+	}
+	s.observe(op, query, start, rows)
+	return result, err
+}
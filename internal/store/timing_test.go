@@ -0,0 +1,65 @@
+package store
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryStats(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	for _, id := range []string{"s1", "s2"} {
+		sess := Session{
+			ID: id, Project: "/proj", CWD: "/proj",
+			StartedAt: now, LastActivity: now, Model: "sonnet",
+		}
+		if err := s.UpsertSession(sess); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	if _, err := s.ListAll(); err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+
+	stats := make(map[string]QueryStat)
+	for _, st := range s.QueryStats() {
+		stats[st.Op] = st
+	}
+	if got := stats["UpsertSession"]; got.Calls != 2 || got.Rows != 2 {
+		t.Errorf("UpsertSession stat = %+v, want 2 calls and 2 rows", got)
+	}
+	if got := stats["ListAll"]; got.Calls != 1 || got.Rows != 2 {
+		t.Errorf("ListAll stat = %+v, want 1 call and 2 rows", got)
+	}
+}
+
+func TestSlowQueryLogging(t *testing.T) {
+	s := testStore(t)
+	var buf bytes.Buffer
+	s.SetQueryLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})), 0)
+
+	if _, err := s.ListAll(); err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "slow query") || !strings.Contains(out, "op=ListAll") {
+		t.Errorf("expected slow query log for ListAll, got %q", out)
+	}
+	if !strings.Contains(out, "FROM sessions s") {
+		t.Errorf("expected SQL in log, got %q", out)
+	}
+
+	buf.Reset()
+	s.SetQueryLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})), time.Hour)
+	if _, err := s.ListAll(); err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no log below threshold, got %q", buf.String())
+	}
+}
@@ -0,0 +1,76 @@
+package store
+
+import "database/sql"
+
+// ToolCount tallies the calls of a tool, recorded by the PreToolUse and
+// PostToolUse hooks when tool_stats is on.
+type ToolCount struct {
+	Tool      string
+	Calls     int // calls Claude made, from PreToolUse
+	Completed int // calls that succeeded, from PostToolUse
+	Sessions  int // sessions that called it, in ToolUsage only
+}
+
+// Uses returns the tool's calls, or the calls that succeeded when only
+// PostToolUse is hooked.
+func (c ToolCount) Uses() int {
+	return max(c.Calls, c.Completed)
+}
+
+// CountToolUse counts a call of tool by a session at ts: a call Claude made,
+// or with completed, one that succeeded. Unknown sessions are ignored.
+func (s *Store) CountToolUse(sessionID, tool string, completed bool, ts int64) error {
+	column := "calls"
+	if completed {
+		column = "completed"
+	}
+	_, err := s.exec("CountToolUse", `
+		INSERT INTO tool_stats (session_id, tool, `+column+`, last_at)
+		SELECT ?, ?, 1, ? WHERE EXISTS (SELECT 1 FROM sessions WHERE id = ?)
+		ON CONFLICT (session_id, tool) DO UPDATE SET
+			`+column+` = `+column+` + 1,
+			last_at = MAX(last_at, excluded.last_at)
+	`, sessionID, tool, ts, sessionID)
+	return err
+}
+
+// SessionTools returns the tools a session called, most used first.
+func (s *Store) SessionTools(sessionID string) ([]ToolCount, error) {
+	var tools []ToolCount
+	err := s.queryRows("SessionTools", `
+		SELECT tool, calls, completed FROM tool_stats WHERE session_id = ?
+		ORDER BY MAX(calls, completed) DESC, tool
+	`, []any{sessionID}, func(rows *sql.Rows) error {
+		c := ToolCount{Sessions: 1}
+		if err := rows.Scan(&c.Tool, &c.Calls, &c.Completed); err != nil {
+			return err
+		}
+		tools = append(tools, c)
+		return nil
+	})
+	return tools, err
+}
+
+// ToolUsage tallies the calls of each tool across sessions, most used first.
+func (s *Store) ToolUsage() ([]ToolCount, error) {
+	var tools []ToolCount
+	err := s.queryRows("ToolUsage", `
+		SELECT tool, SUM(calls), SUM(completed), COUNT(*) FROM tool_stats
+		GROUP BY tool ORDER BY MAX(SUM(calls), SUM(completed)) DESC, tool
+	`, nil, func(rows *sql.Rows) error {
+		var c ToolCount
+		if err := rows.Scan(&c.Tool, &c.Calls, &c.Completed, &c.Sessions); err != nil {
+			return err
+		}
+		tools = append(tools, c)
+		return nil
+	})
+	return tools, err
+}
+
+// CountToolError counts a failed tool call of a session. Unknown sessions
+// are ignored.
+func (s *Store) CountToolError(sessionID string) error {
+	_, err := s.exec("CountToolError", `UPDATE sessions SET tool_errors = tool_errors + 1 WHERE id = ?`, sessionID)
+	return err
+}
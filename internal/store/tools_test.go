@@ -0,0 +1,81 @@
+package store
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCountToolUse(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+
+	for _, id := range []string{"s1", "s2", "s3"} {
+		if err := s.UpsertSession(Session{ID: id, Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	count := func(id, tool string, completed bool) {
+		t.Helper()
+		if err := s.CountToolUse(id, tool, completed, now); err != nil {
+			t.Fatalf("CountToolUse: %v", err)
+		}
+	}
+	count("s1", "Read", false)
+	count("s1", "Read", true)
+	count("s1", "Bash", false)
+	count("s1", "Bash", false)
+	count("s2", "Bash", true) // only PostToolUse hooked
+	count("s3", "WebSearch", false)
+	count("missing", "Bash", false)
+
+	tools, err := s.SessionTools("s1")
+	if err != nil {
+		t.Fatalf("SessionTools: %v", err)
+	}
+	want := []ToolCount{{"Bash", 2, 0, 1}, {"Read", 1, 1, 1}}
+	if !slices.Equal(tools, want) {
+		t.Errorf("SessionTools = %+v, want %+v", tools, want)
+	}
+
+	// A merged duplicate's calls add to the kept session's
+	if err := s.MergeSessions("s1", "s3"); err != nil {
+		t.Fatalf("MergeSessions: %v", err)
+	}
+	usage, err := s.ToolUsage()
+	if err != nil {
+		t.Fatalf("ToolUsage: %v", err)
+	}
+	want = []ToolCount{{"Bash", 2, 1, 2}, {"Read", 1, 1, 1}, {"WebSearch", 1, 0, 1}}
+	if !slices.Equal(usage, want) {
+		t.Errorf("ToolUsage = %+v, want %+v", usage, want)
+	}
+	if uses := usage[0].Uses(); uses != 2 {
+		t.Errorf("Uses = %d, want 2", uses)
+	}
+}
+
+func TestCountToolError(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UnixMilli()
+	for _, id := range []string{"s1", "s2"} {
+		if err := s.UpsertSession(Session{ID: id, Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now}); err != nil {
+			t.Fatalf("UpsertSession: %v", err)
+		}
+	}
+	for _, id := range []string{"s1", "s1", "s2", "missing"} {
+		if err := s.CountToolError(id); err != nil {
+			t.Fatalf("CountToolError(%s): %v", id, err)
+		}
+	}
+	if err := s.MergeSessions("s1", "s2"); err != nil {
+		t.Fatalf("MergeSessions: %v", err)
+	}
+	sess, err := s.GetSession("s1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.ToolErrors != 3 {
+		t.Errorf("ToolErrors = %d, want 3", sess.ToolErrors)
+	}
+}
@@ -0,0 +1,179 @@
+// Package tailer follows the transcripts of running sessions as Claude Code
+// writes them, recording tokens, tool calls and activity between the hook
+// events, which a long autonomous run can go without for a long time.
+package tailer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/transcript"
+)
+
+// Tailer follows the transcripts of the store's running sessions.
+type Tailer struct {
+	s       *store.Store
+	dir     string // where transcripts are looked up, see transcript.Find
+	watcher *fsnotify.Watcher
+	tails   map[string]*tail // by transcript path
+	dirs    map[string]int   // watched directories to the tails in them
+	// alive reports whether a session's process runs; nil leaves active
+	// state to the hooks
+	alive func(pid int) bool
+}
+
+// tail is a running session's transcript being followed.
+type tail struct {
+	sessionID string
+	transcript.Tail
+}
+
+// New returns a Tailer for the running sessions of s whose transcripts are
+// under dir. With alive set, the running sessions are refreshed with
+// Store.RefreshActive before each look for new ones.
+func New(s *store.Store, dir string, alive func(pid int) bool) (*Tailer, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return &Tailer{
+		s:       s,
+		dir:     dir,
+		watcher: w,
+		tails:   make(map[string]*tail),
+		dirs:    make(map[string]int),
+		alive:   alive,
+	}, nil
+}
+
+// Run follows transcripts until ctx is done, reading each as it is written.
+// Every interval it picks up sessions that started, lets go of those that
+// ended and reads every transcript, in case a change went unnoticed.
+func (t *Tailer) Run(ctx context.Context, interval time.Duration) error {
+	defer func() { _ = t.watcher.Close() }()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := t.Sync(); err != nil {
+			slog.Warn("tailer sync failed", "err", err)
+		}
+		for waiting := true; waiting; {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				waiting = false
+			case ev, ok := <-t.watcher.Events:
+				if !ok {
+					return errors.New("watcher closed")
+				}
+				if tl := t.tails[ev.Name]; tl != nil && ev.Has(fsnotify.Write) {
+					t.read(tl)
+				}
+			case err, ok := <-t.watcher.Errors:
+				if !ok {
+					return errors.New("watcher closed")
+				}
+				slog.Warn("watching transcripts failed", "err", err)
+			}
+		}
+	}
+}
+
+// Sync starts following the transcripts of sessions that started running,
+// stops following those of sessions that ended after a last read, and reads
+// the rest.
+func (t *Tailer) Sync() error {
+	if t.alive != nil {
+		if err := t.s.RefreshActive(t.alive); err != nil {
+			return err
+		}
+	}
+	states, err := t.s.TailStates()
+	if err != nil {
+		return err
+	}
+
+	running := make(map[string]bool, len(states))
+	for _, st := range states {
+		path := st.TranscriptPath
+		if path == "" {
+			if path, err = transcript.Find(t.dir, st.SessionID); err != nil {
+				continue // not written yet
+			}
+			if err := t.s.SetTranscriptPath(st.SessionID, path); err != nil {
+				return err
+			}
+		}
+		running[path] = true
+		if t.tails[path] == nil {
+			t.follow(&tail{sessionID: st.SessionID, Tail: transcript.Tail{Path: path, Offset: st.Offset}})
+		}
+	}
+	for path, tl := range t.tails {
+		t.read(tl)
+		if !running[path] {
+			t.unfollow(tl)
+		}
+	}
+	return nil
+}
+
+// follow starts following tl. Directories are watched rather than files,
+// which keeps working when a transcript is replaced.
+func (t *Tailer) follow(tl *tail) {
+	dir := filepath.Dir(tl.Path)
+	if t.dirs[dir] == 0 {
+		if err := t.watcher.Add(dir); err != nil {
+			slog.Warn("watching transcripts failed", "dir", dir, "err", err)
+		}
+	}
+	t.dirs[dir]++
+	t.tails[tl.Path] = tl
+	slog.Debug("following transcript", "session", tl.sessionID, "transcript", tl.Path, "offset", tl.Offset)
+}
+
+func (t *Tailer) unfollow(tl *tail) {
+	delete(t.tails, tl.Path)
+	dir := filepath.Dir(tl.Path)
+	if t.dirs[dir]--; t.dirs[dir] <= 0 {
+		delete(t.dirs, dir)
+		_ = t.watcher.Remove(dir)
+	}
+	slog.Debug("stopped following transcript", "session", tl.sessionID, "transcript", tl.Path)
+}
+
+// read records what was appended to tl's transcript since it was last read.
+func (t *Tailer) read(tl *tail) {
+	offset := tl.Offset
+	u, err := tl.Read()
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("reading transcript failed", "session", tl.sessionID, "transcript", tl.Path, "err", err)
+		}
+		return
+	}
+	if u.IsZero() && tl.Offset == offset {
+		return
+	}
+	usage := store.TokenUsage{
+		InputTokens:     u.InputTokens,
+		OutputTokens:    u.OutputTokens,
+		CacheReadTokens: u.CacheReadTokens,
+		ToolUses:        u.ToolUses,
+	}
+	if !u.Last.IsZero() {
+		usage.At = u.Last.UnixMilli()
+	}
+	if err := t.s.RecordTail(tl.sessionID, usage, tl.Offset); err != nil {
+		slog.Warn("recording transcript usage failed", "session", tl.sessionID, "err", err)
+	}
+}
@@ -0,0 +1,113 @@
+package tailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+func testStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+const assistantLine = `{"type":"assistant","timestamp":"%s","message":{"id":"%s","usage":{"input_tokens":10,"output_tokens":4},"content":[{"type":"tool_use","name":"Bash","input":{}}]}}` + "\n"
+
+func appendLine(t *testing.T, path, id string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := fmt.Fprintf(f, assistantLine, time.Now().UTC().Format(time.RFC3339Nano), id); err != nil {
+		t.Fatalf("Fprintf: %v", err)
+	}
+}
+
+func TestTailer(t *testing.T) {
+	s := testStore(t)
+	dir := t.TempDir()
+	projDir := filepath.Join(dir, "-proj")
+	if err := os.Mkdir(projDir, 0755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	path := filepath.Join(projDir, "sess-1.jsonl")
+	appendLine(t, path, "m1")
+
+	now := time.Now().UnixMilli()
+	if err := s.UpsertSession(store.Session{ID: "sess-1", Project: "/proj", CWD: "/proj", StartedAt: now, LastActivity: now, Active: true}); err != nil {
+		t.Fatalf("UpsertSession: %v", err)
+	}
+
+	tl, err := New(s, dir, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := tl.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	sess, err := s.GetSession("sess-1")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.TranscriptPath != path || sess.InputTokens != 10 || sess.OutputTokens != 4 || sess.ToolUses != 1 {
+		t.Errorf("after Sync = %q %d/%d/%d, want the transcript found and read", sess.TranscriptPath, sess.InputTokens, sess.OutputTokens, sess.ToolUses)
+	}
+
+	// While running, appended entries are read as they are written
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- tl.Run(ctx, time.Hour) }()
+	appendLine(t, path, "m2")
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if sess, err = s.GetSession("sess-1"); err != nil {
+			t.Fatalf("GetSession: %v", err)
+		}
+		if sess.InputTokens == 20 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run: %v", err)
+	}
+	if sess.InputTokens != 20 || sess.ToolUses != 2 {
+		t.Errorf("after write = %d/%d, want 20 input tokens and 2 tool uses", sess.InputTokens, sess.ToolUses)
+	}
+
+	// A new Tailer resumes where the last stopped; an ended session is read
+	// a last time, then let go
+	tl, err = New(s, dir, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = tl.watcher.Close() })
+	if err := tl.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	appendLine(t, path, "m3")
+	if err := s.Deactivate("sess-1"); err != nil {
+		t.Fatalf("Deactivate: %v", err)
+	}
+	if err := tl.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if sess, err = s.GetSession("sess-1"); err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if sess.InputTokens != 30 || len(tl.tails) != 0 || len(tl.dirs) != 0 {
+		t.Errorf("after end = %d input tokens, following %d, want 30 and none", sess.InputTokens, len(tl.tails))
+	}
+}
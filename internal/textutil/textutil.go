@@ -0,0 +1,43 @@
+// Package textutil measures and cuts text by how wide it is drawn in a
+// terminal, so prompts in any script stay whole characters when shortened.
+package textutil
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// Width returns the number of terminal cells s takes: two for wide
+// characters such as CJK and most emoji, none for combining marks.
+func Width(s string) int {
+	return uniseg.StringWidth(s)
+}
+
+// Truncate cuts s to width terminal cells, tail included when it is cut.
+// It cuts between grapheme clusters, so a character, with its accents or
+// emoji modifiers, is never split and the result is always valid UTF-8.
+func Truncate(s string, width int, tail string) string {
+	if Width(s) <= width {
+		return s
+	}
+	limit := max(width-Width(tail), 0)
+	var b strings.Builder
+	g := uniseg.NewGraphemes(s)
+	for w := 0; g.Next(); {
+		if w += g.Width(); w > limit {
+			break
+		}
+		b.WriteString(g.Str())
+	}
+	return b.String() + tail
+}
+
+// ShortID returns the first 8 characters of a session ID, as it is shown
+// to people, or the whole ID when it is shorter.
+func ShortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
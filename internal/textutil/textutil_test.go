@@ -0,0 +1,73 @@
+package textutil
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		tail  string
+		want  string
+	}{
+		{"fix the bug", 20, "...", "fix the bug"},
+		{"fix the bug", 11, "...", "fix the bug"},
+		{"fix the bug", 10, "...", "fix the..."},
+		{"sonnet-4-6-20250101", 14, "", "sonnet-4-6-202"},
+		// Wide characters take two cells and are not split
+		{"日本語のプロンプト", 10, "...", "日本語..."},
+		{"日本語のプロンプト", 9, "...", "日本語..."},
+		{"日本語のプロンプト", 8, "...", "日本..."},
+		{"ok 🎉🎉🎉", 7, "…", "ok 🎉…"},
+		// Combining marks and joined emoji stay with their base
+		{"cafe\u0301 cafe\u0301", 7, "...", "cafe\u0301..."},
+		{"👩‍💻👩‍💻 done", 5, "...", "👩‍💻..."},
+		{"anything", 2, "...", "..."},
+	}
+	for _, tc := range tests {
+		got := Truncate(tc.s, tc.width, tc.tail)
+		if got != tc.want {
+			t.Errorf("Truncate(%q, %d, %q) = %q, want %q", tc.s, tc.width, tc.tail, got, tc.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("Truncate(%q, %d, %q) is not valid UTF-8", tc.s, tc.width, tc.tail)
+		}
+	}
+}
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"日本", 4},
+		{"🎉", 2},
+		{"cafe\u0301", 4},
+	}
+	for _, tc := range tests {
+		if got := Width(tc.s); got != tc.want {
+			t.Errorf("Width(%q) = %d, want %d", tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestShortID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"4f9c2a1e-7b3d-4c8a-9e2f-1a2b3c4d5e6f", "4f9c2a1e"},
+		{"4f9c2a1e", "4f9c2a1e"},
+		{"abc", "abc"},
+		{"", ""},
+	}
+	for _, tc := range tests {
+		if got := ShortID(tc.id); got != tc.want {
+			t.Errorf("ShortID(%q) = %q, want %q", tc.id, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package title gives sessions short titles, taken from the summary Claude
+// Code writes into a transcript or generated by running claude -p on the
+// session's prompts.
+package title
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/imyousuf/claude-session-tracker/internal/config"
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+	"github.com/imyousuf/claude-session-tracker/internal/textutil"
+	"github.com/imyousuf/claude-session-tracker/internal/transcript"
+)
+
+// Values of the auto_title config: titles are taken from transcript
+// summaries only, or also generated with claude -p.
+const (
+	ModeOff     = "off"
+	ModeSummary = "summary"
+	ModeClaude  = "claude"
+)
+
+// Modes lists the accepted auto_title values.
+var Modes = config.AutoTitleModes
+
+// MaxLen caps the width of a title, in terminal cells.
+const MaxLen = 80
+
+// Timeout bounds a claude -p run.
+const Timeout = 2 * time.Minute
+
+// HeadlessEnv is set in the environment of claude -p runs. cst hooks ignore
+// the events of such runs, so titling never records sessions of its own.
+const HeadlessEnv = "CST_HEADLESS"
+
+// runClaude runs argv, a claude command followed by its arguments, and
+// returns its output. Replaced in tests.
+var runClaude = func(ctx context.Context, argv []string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = append(os.Environ(), HeadlessEnv+"=1")
+	return cmd.Output()
+}
+
+// FromTranscript returns the summary in the transcript at path as a title,
+// or "" if it has none.
+func FromTranscript(path string) (string, error) {
+	summary, err := transcript.Summary(path)
+	if err != nil {
+		return "", err
+	}
+	return Clean(summary), nil
+}
+
+// FromPrompts asks claude -p for a title summarizing prompts, given in the
+// order they were sent. claude is the command that runs claude, as returned
+// by config.ClaudeCommand.
+func FromPrompts(ctx context.Context, claude []string, prompts []string) (string, error) {
+	if len(prompts) == 0 {
+		return "", errors.New("no prompts to summarize")
+	}
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	out, err := runClaude(ctx, slices.Concat(claude, []string{"-p", request(prompts)}))
+	if err != nil {
+		return "", fmt.Errorf("claude -p: %w", err)
+	}
+	title := Clean(string(out))
+	if title == "" {
+		return "", errors.New("claude -p returned no title")
+	}
+	return title, nil
+}
+
+// Prompts returns the prompts of sess to summarize, oldest first: its first
+// prompt followed by the stored ones, given newest first as GetPrompts
+// returns them.
+func Prompts(sess store.Session, stored []store.Prompt) []string {
+	var prompts []string
+	if sess.FirstPrompt != "" {
+		prompts = append(prompts, sess.FirstPrompt)
+	}
+	for _, p := range slices.Backward(stored) {
+		if len(prompts) == 0 || p.Text != prompts[len(prompts)-1] {
+			prompts = append(prompts, p.Text)
+		}
+	}
+	return prompts
+}
+
+func request(prompts []string) string {
+	var b strings.Builder
+	b.WriteString("Write a title of at most eight words for a coding session that began with the prompts below. ")
+	b.WriteString("Reply with the title only, without quotes or punctuation at the end.\n")
+	for _, p := range prompts {
+		b.WriteString("\n- ")
+		b.WriteString(strings.Join(strings.Fields(p), " "))
+	}
+	return b.String()
+}
+
+// Clean reduces text to a one-line title: its first non-empty line without
+// Markdown heading marks or surrounding quotes, cut to MaxLen cells.
+func Clean(text string) string {
+	var line string
+	for l := range strings.Lines(text) {
+		if line = strings.TrimSpace(l); line != "" {
+			break
+		}
+	}
+	line = strings.TrimSpace(strings.TrimLeft(line, "#"))
+	line = strings.Trim(line, "\"'`*")
+	line = strings.Join(strings.Fields(line), " ")
+	if textutil.Width(line) > MaxLen {
+		line = strings.TrimSpace(textutil.Truncate(line, MaxLen-3, "")) + "..."
+	}
+	return line
+}
@@ -0,0 +1,69 @@
+package title
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/imyousuf/claude-session-tracker/internal/store"
+)
+
+func TestClean(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{"Fix retry loop", "Fix retry loop"},
+		{"\n\n  \"Fix retry loop\"  \nmore text", "Fix retry loop"},
+		{"# **Migrate   to Postgres**", "Migrate to Postgres"},
+		{"", ""},
+		{strings.Repeat("a", 100), strings.Repeat("a", MaxLen-3) + "..."},
+	} {
+		if got := Clean(tc.in); got != tc.want {
+			t.Errorf("Clean(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestPrompts(t *testing.T) {
+	sess := store.Session{FirstPrompt: "why does the client retry forever?"}
+	stored := []store.Prompt{{Text: "add a test"}, {Text: "cap it at 5"}, {Text: "why does the client retry forever?"}}
+	got := Prompts(sess, stored)
+	want := []string{"why does the client retry forever?", "cap it at 5", "add a test"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Prompts = %q, want %q", got, want)
+	}
+}
+
+func TestFromPrompts(t *testing.T) {
+	orig := runClaude
+	t.Cleanup(func() { runClaude = orig })
+
+	var argv []string
+	runClaude = func(_ context.Context, a []string) ([]byte, error) {
+		argv = a
+		return []byte("\"Cap HTTP client retries\"\n"), nil
+	}
+	got, err := FromPrompts(context.Background(), []string{"ssh", "devbox", "claude"}, []string{"why does the client\nretry forever?"})
+	if err != nil {
+		t.Fatalf("FromPrompts: %v", err)
+	}
+	if got != "Cap HTTP client retries" {
+		t.Errorf("FromPrompts = %q", got)
+	}
+	if len(argv) != 5 || !slices.Equal(argv[:4], []string{"ssh", "devbox", "claude", "-p"}) {
+		t.Fatalf("argv = %q, want the claude command, -p and the request", argv)
+	}
+	if !strings.Contains(argv[4], "- why does the client retry forever?") {
+		t.Errorf("request does not list the prompt on one line:\n%s", argv[4])
+	}
+
+	runClaude = func(context.Context, []string) ([]byte, error) { return nil, errors.New("not found") }
+	if _, err := FromPrompts(context.Background(), []string{"claude"}, []string{"x"}); err == nil {
+		t.Error("FromPrompts should fail when claude fails")
+	}
+	if _, err := FromPrompts(context.Background(), []string{"claude"}, nil); err == nil {
+		t.Error("FromPrompts should fail without prompts")
+	}
+}
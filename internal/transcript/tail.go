@@ -0,0 +1,117 @@
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// Usage is what transcript entries record about a session's model use.
+type Usage struct {
+	InputTokens     int64 // including those written to the prompt cache
+	OutputTokens    int64
+	CacheReadTokens int64
+	ToolUses        int
+	Last            time.Time // of the latest entry, zero if none had a time
+}
+
+// IsZero reports whether u records nothing.
+func (u Usage) IsZero() bool {
+	return u == Usage{}
+}
+
+// Tail reads the entries appended to a transcript since it last read it.
+type Tail struct {
+	Path   string
+	Offset int64 // bytes read so far
+	// Claude Code writes each content block of an assistant message as its
+	// own entry, repeating the message's usage; it is counted once
+	lastMessage string
+}
+
+// Read returns the usage recorded by the entries appended since the last
+// Read, or since Offset, and moves Offset past them. A last line still being
+// written is left for the next Read. If the file shrank, as when it was
+// replaced, reading resumes at its new end.
+func (t *Tail) Read() (Usage, error) {
+	var u Usage
+	f, err := os.Open(t.Path)
+	if err != nil {
+		return u, err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return u, err
+	}
+	if info.Size() < t.Offset {
+		t.Offset = info.Size()
+	}
+	if _, err := f.Seek(t.Offset, io.SeekStart); err != nil {
+		return u, err
+	}
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return u, nil // an incomplete line, if any, is read again next time
+		}
+		if err != nil {
+			return u, err
+		}
+		t.Offset += int64(len(line))
+		t.add(&u, line)
+	}
+}
+
+// add adds what the transcript entry in line records to u.
+func (t *Tail) add(u *Usage, line []byte) {
+	var entry struct {
+		Type      string    `json:"type"`
+		Timestamp time.Time `json:"timestamp"`
+		Message   struct {
+			ID    string `json:"id"`
+			Usage struct {
+				InputTokens         int64 `json:"input_tokens"`
+				CacheCreationTokens int64 `json:"cache_creation_input_tokens"`
+				CacheReadTokens     int64 `json:"cache_read_input_tokens"`
+				OutputTokens        int64 `json:"output_tokens"`
+			} `json:"usage"`
+			Content json.RawMessage `json:"content"`
+		} `json:"message"`
+	}
+	if json.Unmarshal(line, &entry) != nil {
+		return
+	}
+	if entry.Timestamp.After(u.Last) {
+		u.Last = entry.Timestamp
+	}
+	if entry.Type != "assistant" {
+		return
+	}
+	if id := entry.Message.ID; id == "" || id != t.lastMessage {
+		usage := entry.Message.Usage
+		u.InputTokens += usage.InputTokens + usage.CacheCreationTokens
+		u.CacheReadTokens += usage.CacheReadTokens
+		u.OutputTokens += usage.OutputTokens
+		t.lastMessage = id
+	}
+	if bytes.Contains(entry.Message.Content, []byte(`"tool_use"`)) {
+		var content []struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(entry.Message.Content, &content) == nil {
+			for _, c := range content {
+				if c.Type == "tool_use" {
+					u.ToolUses++
+				}
+			}
+		}
+	}
+}
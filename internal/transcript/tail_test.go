@@ -0,0 +1,65 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sess-1.jsonl")
+	write := func(s string) {
+		t.Helper()
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("OpenFile: %v", err)
+		}
+		if _, err := f.WriteString(s); err != nil {
+			t.Fatalf("WriteString: %v", err)
+		}
+		_ = f.Close()
+	}
+
+	write(`{"type":"user","timestamp":"2026-05-01T10:00:00Z","message":{"content":"fix it"}}
+{"type":"assistant","timestamp":"2026-05-01T10:00:05Z","message":{"id":"m1","usage":{"input_tokens":10,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000,"output_tokens":5},"content":[{"type":"text","text":"looking"}]}}
+{"type":"assistant","timestamp":"2026-05-01T10:00:06Z","message":{"id":"m1","usage":{"input_tokens":10,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000,"output_tokens":5},"content":[{"type":"tool_use","name":"Bash","input":{}}]}}
+{"type":"assistant","timestamp":"2026-05-01T10:00:09Z","message":{"id":"m2","usage":{"input_tokens":1,"output_tokens":7},"content":[{"type":"tool_use","name":"Edit","input":{}},{"type":"tool_use","name":"Edit","input":{}}]}}
+{"type":"assistant","timestamp":"2026-05-01T10:00:10Z","message":{"id":"m3","usa`)
+
+	tail := &Tail{Path: path}
+	u, err := tail.Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	want := Usage{InputTokens: 111, OutputTokens: 12, CacheReadTokens: 1000, ToolUses: 3,
+		Last: time.Date(2026, 5, 1, 10, 0, 9, 0, time.UTC)}
+	if u != want {
+		t.Errorf("Read = %+v, want %+v", u, want)
+	}
+
+	// The incomplete line is read once it is finished
+	write(`ge":{"input_tokens":2,"output_tokens":3},"content":[]}}` + "\n")
+	if u, err = tail.Read(); err != nil || u.InputTokens != 2 || u.OutputTokens != 3 || u.ToolUses != 0 {
+		t.Errorf("Read after append = %+v, %v, want the finished line's usage", u, err)
+	}
+	if u, err = tail.Read(); err != nil || !u.IsZero() {
+		t.Errorf("Read with nothing new = %+v, %v, want zero", u, err)
+	}
+
+	// Offset ends up at the end of the file, where a new Tail would resume
+	info, _ := os.Stat(path)
+	if tail.Offset != info.Size() {
+		t.Errorf("Offset = %d, want %d", tail.Offset, info.Size())
+	}
+	if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if u, err = tail.Read(); err != nil || !u.IsZero() || tail.Offset != 3 {
+		t.Errorf("Read of a shrunk file = %+v, %v, offset %d, want zero at offset 3", u, err, tail.Offset)
+	}
+
+	if _, err := (&Tail{Path: filepath.Join(t.TempDir(), "missing.jsonl")}).Read(); !os.IsNotExist(err) {
+		t.Errorf("Read of a missing file = %v, want not exist", err)
+	}
+}
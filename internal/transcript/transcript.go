@@ -0,0 +1,346 @@
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultClaudeDir   = ".claude"
+	DefaultProjectsDir = "projects"
+)
+
+// DefaultDir returns the directory where Claude Code stores session
+// transcripts (~/.claude/projects).
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, DefaultClaudeDir, DefaultProjectsDir)
+}
+
+// Find locates the transcript file for a session under dir.
+// Claude Code stores transcripts as <dir>/<encoded-project>/<session-id>.jsonl;
+// rather than reproduce the project path encoding, every project directory is
+// searched. Returns os.ErrNotExist if no transcript is found.
+func Find(dir, sessionID string) (string, error) {
+	if sessionID == "" {
+		return "", os.ErrNotExist
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*", sessionID+".jsonl"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", os.ErrNotExist
+	}
+	return matches[0], nil
+}
+
+// All returns the transcript path of every session under dir, keyed by
+// session ID, for looking up many sessions at once.
+func All(dir string) (map[string]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*", "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]string, len(matches))
+	for _, path := range matches {
+		paths[strings.TrimSuffix(filepath.Base(path), ".jsonl")] = path
+	}
+	return paths, nil
+}
+
+// Summary returns the text of the first summary entry in the transcript at
+// path, which Claude Code writes to describe a conversation, or "" if there
+// is none.
+func Summary(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		// Only decode the lines that can be summaries; the rest can be large
+		if bytes.Contains(line, []byte(`"summary"`)) {
+			var entry struct {
+				Type    string `json:"type"`
+				Summary string `json:"summary"`
+			}
+			if json.Unmarshal(line, &entry) == nil && entry.Type == "summary" && entry.Summary != "" {
+				return entry.Summary, nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// editTools are the tools that change files, with the input field naming
+// the file.
+var editTools = map[string]string{
+	"Edit":         "file_path",
+	"MultiEdit":    "file_path",
+	"Write":        "file_path",
+	"NotebookEdit": "notebook_path",
+}
+
+// TouchedFile is a file that edit tool calls in a transcript changed.
+type TouchedFile struct {
+	Path  string
+	Edits int       // tool calls that changed it
+	Last  time.Time // of the latest, zero if the transcript has no times
+}
+
+// FilesTouched returns the files that Edit, MultiEdit, Write and
+// NotebookEdit tool calls in the transcript at path changed, most recently
+// changed first and each once.
+func FilesTouched(path string) ([]TouchedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	byPath := make(map[string]*TouchedFile)
+	order := make(map[string]int) // file to the index of its latest change
+	n := 0
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		// Only decode the lines that can hold tool calls
+		if bytes.Contains(line, []byte(`"tool_use"`)) {
+			var entry struct {
+				Timestamp time.Time `json:"timestamp"`
+				Message   struct {
+					Content json.RawMessage `json:"content"`
+				} `json:"message"`
+			}
+			var content []struct {
+				Type  string                     `json:"type"`
+				Name  string                     `json:"name"`
+				Input map[string]json.RawMessage `json:"input"`
+			}
+			if json.Unmarshal(line, &entry) == nil && json.Unmarshal(entry.Message.Content, &content) == nil {
+				for _, c := range content {
+					field, ok := editTools[c.Name]
+					if c.Type != "tool_use" || !ok {
+						continue
+					}
+					var file string
+					if json.Unmarshal(c.Input[field], &file) != nil || file == "" {
+						continue
+					}
+					tf := byPath[file]
+					if tf == nil {
+						tf = &TouchedFile{Path: file}
+						byPath[file] = tf
+					}
+					tf.Edits++
+					if entry.Timestamp.After(tf.Last) {
+						tf.Last = entry.Timestamp
+					}
+					order[file] = n
+					n++
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	files := make([]TouchedFile, 0, len(byPath))
+	for _, tf := range byPath {
+		files = append(files, *tf)
+	}
+	slices.SortFunc(files, func(a, b TouchedFile) int { return order[b.Path] - order[a.Path] })
+	return files, nil
+}
+
+// Message is a user prompt or assistant reply in a transcript.
+type Message struct {
+	Role string // "user" or "assistant"
+	Text string
+	Time time.Time // zero if the transcript has no times
+}
+
+// Messages returns the last n messages of the transcript at path, oldest
+// first, or all of them if n is not positive. Only text counts: tool calls,
+// tool results, thinking and Claude Code's own meta entries are left out.
+func Messages(path string, n int) ([]Message, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var messages []Message
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		var entry struct {
+			Type      string    `json:"type"`
+			IsMeta    bool      `json:"isMeta"`
+			Timestamp time.Time `json:"timestamp"`
+			Message   struct {
+				Content json.RawMessage `json:"content"`
+			} `json:"message"`
+		}
+		if (bytes.Contains(line, []byte(`"user"`)) || bytes.Contains(line, []byte(`"assistant"`))) &&
+			json.Unmarshal(line, &entry) == nil && !entry.IsMeta && (entry.Type == "user" || entry.Type == "assistant") {
+			if text := messageText(entry.Message.Content); text != "" {
+				messages = append(messages, Message{Role: entry.Type, Text: text, Time: entry.Timestamp})
+				if n > 0 && len(messages) > n {
+					messages = messages[1:]
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return messages, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// messageText returns the text of a message's content, which is either a
+// string or a list of blocks of which only text blocks are kept.
+func messageText(content json.RawMessage) string {
+	var text string
+	if json.Unmarshal(content, &text) == nil {
+		return strings.TrimSpace(text)
+	}
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(content, &blocks) != nil {
+		return ""
+	}
+	var parts []string
+	for _, b := range blocks {
+		if b.Type == "text" && strings.TrimSpace(b.Text) != "" {
+			parts = append(parts, strings.TrimSpace(b.Text))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Session is what a transcript records about its session, for adopting
+// sessions that ran before cst's hooks were installed.
+type Session struct {
+	ID         string // from the file name
+	CWD        string // of the first entry that has one
+	Branch     string // git branch at the latest entry that has one
+	Model      string // of the latest assistant reply
+	Start, End time.Time
+	Prompts    []Message // the user's prompts, oldest first
+	Sidechain  bool      // a subagent's transcript
+}
+
+// ReadSession reads the session of the transcript at path. Slash commands
+// become prompts such as "/review 42"; command output and interruptions
+// are left out, as they are not prompts.
+func ReadSession(path string) (Session, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Session{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	sess := Session{ID: strings.TrimSuffix(filepath.Base(path), ".jsonl"), Sidechain: true}
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		var entry struct {
+			Type        string    `json:"type"`
+			IsMeta      bool      `json:"isMeta"`
+			IsSidechain bool      `json:"isSidechain"`
+			CWD         string    `json:"cwd"`
+			GitBranch   string    `json:"gitBranch"`
+			Timestamp   time.Time `json:"timestamp"`
+			Message     struct {
+				Model   string          `json:"model"`
+				Content json.RawMessage `json:"content"`
+			} `json:"message"`
+		}
+		if len(bytes.TrimSpace(line)) > 0 && json.Unmarshal(line, &entry) == nil && (entry.Type == "user" || entry.Type == "assistant") {
+			sess.Sidechain = sess.Sidechain && entry.IsSidechain
+			if sess.CWD == "" {
+				sess.CWD = entry.CWD
+			}
+			if entry.GitBranch != "" {
+				sess.Branch = entry.GitBranch
+			}
+			if !entry.Timestamp.IsZero() {
+				if sess.Start.IsZero() || entry.Timestamp.Before(sess.Start) {
+					sess.Start = entry.Timestamp
+				}
+				if entry.Timestamp.After(sess.End) {
+					sess.End = entry.Timestamp
+				}
+			}
+			switch {
+			case entry.Type == "assistant" && entry.Message.Model != "" && !strings.HasPrefix(entry.Message.Model, "<"):
+				sess.Model = entry.Message.Model
+			case entry.Type == "user" && !entry.IsMeta:
+				if text := promptText(messageText(entry.Message.Content)); text != "" {
+					sess.Prompts = append(sess.Prompts, Message{Role: "user", Text: text, Time: entry.Timestamp})
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return sess, nil
+		}
+		if err != nil {
+			return Session{}, err
+		}
+	}
+}
+
+var (
+	commandNameRe = regexp.MustCompile(`<command-name>([^<]*)</command-name>`)
+	commandArgsRe = regexp.MustCompile(`<command-args>([^<]*)</command-args>`)
+)
+
+// promptText returns the prompt a user message holds: slash commands, which
+// Claude Code records as tags, as typed, and "" for command output and
+// interruptions.
+func promptText(text string) string {
+	switch {
+	case strings.HasPrefix(text, "<local-command-"), strings.HasPrefix(text, "[Request interrupted"):
+		return ""
+	case strings.Contains(text, "<command-name>"):
+		m := commandNameRe.FindStringSubmatch(text)
+		if m == nil {
+			return ""
+		}
+		name := "/" + strings.TrimPrefix(strings.TrimSpace(m[1]), "/")
+		if a := commandArgsRe.FindStringSubmatch(text); a != nil && strings.TrimSpace(a[1]) != "" {
+			return name + " " + strings.TrimSpace(a[1])
+		}
+		return name
+	}
+	return text
+}
@@ -0,0 +1,180 @@
+package transcript
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	projDir := filepath.Join(dir, "-home-user-project")
+	if err := os.MkdirAll(projDir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	want := filepath.Join(projDir, "sess-1.jsonl")
+	if err := os.WriteFile(want, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	got, err := Find(dir, "sess-1")
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if got != want {
+		t.Errorf("Find = %q, want %q", got, want)
+	}
+}
+
+func TestFindMissing(t *testing.T) {
+	dir := t.TempDir()
+	for _, id := range []string{"missing", ""} {
+		if _, err := Find(dir, id); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Find(%q) error = %v, want os.ErrNotExist", id, err)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sess-1.jsonl")
+	lines := `{"type":"user","message":{"content":"the word \"summary\" in a prompt"}}
+{"type":"summary","summary":"Fix retry loop in HTTP client","leafUuid":"abc"}
+{"type":"summary","summary":"Later summary","leafUuid":"def"}`
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	got, err := Summary(path)
+	if err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+	if got != "Fix retry loop in HTTP client" {
+		t.Errorf("Summary = %q, want the first summary entry", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"type":"user"}`+"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if got, err := Summary(path); err != nil || got != "" {
+		t.Errorf("Summary without summary entry = %q, %v, want empty", got, err)
+	}
+	if _, err := Summary(filepath.Join(t.TempDir(), "missing.jsonl")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Summary of missing file error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestFilesTouched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sess-1.jsonl")
+	lines := `{"type":"user","message":{"content":"edit src/a.go"}}
+{"type":"assistant","timestamp":"2026-05-01T10:00:00Z","message":{"content":[{"type":"text","text":"ok"},{"type":"tool_use","name":"Edit","input":{"file_path":"/p/src/a.go","old_string":"x","new_string":"y"}}]}}
+{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Read","input":{"file_path":"/p/README.md"}}]}}
+{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Write","input":{"file_path":"/p/b.go","content":"package b"}},{"type":"tool_use","name":"NotebookEdit","input":{"notebook_path":"/p/n.ipynb"}}]}}
+{"type":"user","message":{"content":[{"type":"tool_result","content":"done"}]}}
+{"type":"assistant","timestamp":"2026-05-01T10:03:00Z","message":{"content":[{"type":"tool_use","name":"MultiEdit","input":{"file_path":"/p/src/a.go","edits":[]}}]}}`
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	got, err := FilesTouched(path)
+	if err != nil {
+		t.Fatalf("FilesTouched: %v", err)
+	}
+	var paths []string
+	for _, f := range got {
+		paths = append(paths, f.Path)
+	}
+	if want := []string{"/p/src/a.go", "/p/n.ipynb", "/p/b.go"}; !slices.Equal(paths, want) {
+		t.Errorf("FilesTouched = %v, want %v", paths, want)
+	}
+	if got[0].Edits != 2 || !got[0].Last.Equal(time.Date(2026, 5, 1, 10, 3, 0, 0, time.UTC)) {
+		t.Errorf("a.go: %d edits, last %v, want 2 at 10:03", got[0].Edits, got[0].Last)
+	}
+	if got[2].Edits != 1 || !got[2].Last.IsZero() {
+		t.Errorf("b.go: %d edits, last %v, want 1 without a time", got[2].Edits, got[2].Last)
+	}
+	if _, err := FilesTouched(filepath.Join(t.TempDir(), "missing.jsonl")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("FilesTouched of missing file error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sess-1.jsonl")
+	lines := `{"type":"summary","summary":"Login fix"}
+{"type":"user","isMeta":true,"message":{"content":"Caveat: local command output"}}
+{"type":"user","timestamp":"2026-05-01T10:00:00Z","message":{"content":"fix the login bug"}}
+{"type":"assistant","message":{"content":[{"type":"thinking","thinking":"hmm"},{"type":"text","text":"Looking at auth.go."},{"type":"tool_use","name":"Read","input":{"file_path":"/p/auth.go"}}]}}
+{"type":"user","message":{"content":[{"type":"tool_result","content":"package auth"}]}}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Fixed the expiry check."}]}}`
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	all, err := Messages(path, 0)
+	if err != nil {
+		t.Fatalf("Messages: %v", err)
+	}
+	want := []Message{
+		{Role: "user", Text: "fix the login bug", Time: time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{Role: "assistant", Text: "Looking at auth.go."},
+		{Role: "assistant", Text: "Fixed the expiry check."},
+	}
+	if !slices.EqualFunc(all, want, func(a, b Message) bool { return a.Role == b.Role && a.Text == b.Text && a.Time.Equal(b.Time) }) {
+		t.Errorf("Messages(0) = %+v, want %+v", all, want)
+	}
+
+	last, err := Messages(path, 2)
+	if err != nil {
+		t.Fatalf("Messages: %v", err)
+	}
+	if len(last) != 2 || last[0].Text != "Looking at auth.go." || last[1].Text != "Fixed the expiry check." {
+		t.Errorf("Messages(2) = %+v, want the last two", last)
+	}
+	if _, err := Messages(filepath.Join(t.TempDir(), "missing.jsonl"), 1); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Messages of missing file error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestReadSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sess-1.jsonl")
+	lines := `{"type":"summary","summary":"Login fix"}
+{"type":"user","cwd":"/p","gitBranch":"main","timestamp":"2026-05-01T10:00:00Z","message":{"content":"fix the login bug"}}
+{"type":"assistant","timestamp":"2026-05-01T10:01:00Z","message":{"model":"claude-opus-4-1","content":[{"type":"text","text":"Done."}]}}
+{"type":"user","isMeta":true,"timestamp":"2026-05-01T10:02:00Z","message":{"content":"Caveat: local command output"}}
+{"type":"user","timestamp":"2026-05-01T10:02:00Z","message":{"content":"<command-message>review</command-message>\n<command-name>/review</command-name>\n<command-args>42</command-args>"}}
+{"type":"user","timestamp":"2026-05-01T10:02:01Z","message":{"content":"<local-command-stdout>ok</local-command-stdout>"}}
+{"type":"user","cwd":"/p/sub","gitBranch":"fix/JIRA-1","timestamp":"2026-05-01T10:05:00Z","message":{"content":[{"type":"tool_result","content":"x"}]}}
+{"type":"user","timestamp":"2026-05-01T10:06:00Z","message":{"content":[{"type":"text","text":"[Request interrupted by user]"}]}}
+{"type":"assistant","timestamp":"2026-05-01T10:07:00Z","message":{"model":"<synthetic>","content":[{"type":"text","text":"API error"}]}}`
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	sess, err := ReadSession(path)
+	if err != nil {
+		t.Fatalf("ReadSession: %v", err)
+	}
+	if sess.ID != "sess-1" || sess.CWD != "/p" || sess.Branch != "fix/JIRA-1" || sess.Model != "claude-opus-4-1" || sess.Sidechain {
+		t.Errorf("ReadSession = %+v", sess)
+	}
+	if want := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC); !sess.Start.Equal(want) {
+		t.Errorf("Start = %v, want %v", sess.Start, want)
+	}
+	if want := time.Date(2026, 5, 1, 10, 7, 0, 0, time.UTC); !sess.End.Equal(want) {
+		t.Errorf("End = %v, want %v", sess.End, want)
+	}
+	var prompts []string
+	for _, p := range sess.Prompts {
+		prompts = append(prompts, p.Text)
+	}
+	if want := []string{"fix the login bug", "/review 42"}; !slices.Equal(prompts, want) {
+		t.Errorf("Prompts = %q, want %q", prompts, want)
+	}
+
+	agent := filepath.Join(t.TempDir(), "agent-1.jsonl")
+	if err := os.WriteFile(agent, []byte(`{"type":"user","isSidechain":true,"cwd":"/p","message":{"content":"search"}}`), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if sess, err := ReadSession(agent); err != nil || !sess.Sidechain {
+		t.Errorf("ReadSession of a subagent = %+v, %v, want Sidechain", sess, err)
+	}
+}